@@ -666,6 +666,75 @@ const docTemplate = `{
                 }
             }
         },
+        "/datasource/dbml/{dataName}": {
+            "get": {
+                "description": "\"导出数据库类型数据源的dbml\"",
+                "tags": [
+                    "datasource"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "model名称",
+                        "name": "dataName",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "dbml文本",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/i18n.CustomError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "\"导入dbml，转换成prisma模型追加到数据源的prisma文本\"",
+                "tags": [
+                    "datasource"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "model名称",
+                        "name": "dataName",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "dbml文本",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "导入结果",
+                        "schema": {
+                            "$ref": "#/definitions/datasource.DbmlImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/i18n.CustomError"
+                        }
+                    }
+                }
+            }
+        },
         "/datasource/diff/{dataName}": {
             "post": {
                 "description": "\"生成增量迁移脚本\"",
@@ -743,6 +812,37 @@ const docTemplate = `{
                 }
             }
         },
+        "/datasource/erDiagram/{dataName}": {
+            "get": {
+                "description": "\"获取数据库类型数据源的ER图\"",
+                "tags": [
+                    "datasource"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "model名称",
+                        "name": "dataName",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ER图",
+                        "schema": {
+                            "$ref": "#/definitions/datasource.ErDiagram"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/i18n.CustomError"
+                        }
+                    }
+                }
+            }
+        },
         "/datasource/graphql/{dataName}": {
             "get": {
                 "description": "\"获取graphql文本\"",
@@ -811,9 +911,76 @@ const docTemplate = `{
                 }
             }
         },
+        "/datasource/importRest": {
+            "post": {
+                "description": "\"预览Postman集合或HAR文件转换的OAS文档\"",
+                "tags": [
+                    "datasource"
+                ],
+                "parameters": [
+                    {
+                        "description": "Postman v2.1集合或HAR文件内容",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OAS文档"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/i18n.CustomError"
+                        }
+                    }
+                }
+            }
+        },
+        "/datasource/importRest/{dataName}": {
+            "post": {
+                "description": "\"将Postman集合或HAR文件转换成OAS文档并保存为rest数据源的OAS文件\"",
+                "tags": [
+                    "datasource"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "dataName",
+                        "name": "dataName",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Postman v2.1集合或HAR文件内容",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OAS文档"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/i18n.CustomError"
+                        }
+                    }
+                }
+            }
+        },
         "/datasource/migrate/{dataName}": {
             "post": {
-                "description": "\"迁移\"",
+                "description": "\"迁移，存在破坏性变更时需要携带确认令牌\"",
                 "tags": [
                     "datasource"
                 ],
@@ -825,6 +992,12 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "破坏性变更报告中的确认令牌",
+                        "name": "confirmToken",
+                        "in": "query"
+                    },
                     {
                         "description": "迁移数据",
                         "name": "data",
@@ -839,6 +1012,46 @@ const docTemplate = `{
                     "200": {
                         "description": "OK"
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/i18n.CustomError"
+                        }
+                    },
+                    "428": {
+                        "description": "破坏性变更报告",
+                        "schema": {
+                            "$ref": "#/definitions/datasource.SchemaPushReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/datasource/migrations/{dataName}": {
+            "get": {
+                "description": "\"迁移历史，包含迁移sql及应用状态\"",
+                "tags": [
+                    "datasource"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "model名称",
+                        "name": "dataName",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "迁移列表",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/datasource.PrismaMigration"
+                            }
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
@@ -914,6 +1127,163 @@ const docTemplate = `{
                 }
             }
         },
+        "/datasource/rollbackMigration/{dataName}": {
+            "post": {
+                "description": "\"将失败的迁移标记为已回滚\"",
+                "tags": [
+                    "datasource"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "model名称",
+                        "name": "dataName",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "迁移名称",
+                        "name": "migrationName",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/i18n.CustomError"
+                        }
+                    }
+                }
+            }
+        },
+        "/datasource/schemaDrift/{dataName}": {
+            "get": {
+                "description": "\"获取graphql数据源最近一次轮询发现的上游schema差异\"",
+                "tags": [
+                    "datasource"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "model名称",
+                        "name": "dataName",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "schema差异，无差异时为null",
+                        "schema": {
+                            "$ref": "#/definitions/datasource.SchemaDrift"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/i18n.CustomError"
+                        }
+                    }
+                }
+            }
+        },
+        "/datasource/seed/{dataName}": {
+            "post": {
+                "description": "\"写入store/seed/{dataName}下的种子数据\"",
+                "tags": [
+                    "datasource"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "model名称",
+                        "name": "dataName",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/datasource.SeedModelResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/i18n.CustomError"
+                        }
+                    }
+                }
+            }
+        },
+        "/engine/asyncapi": {
+            "get": {
+                "description": "\"引擎asyncapi.json\"",
+                "tags": [
+                    "engine"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "成功"
+                    }
+                }
+            }
+        },
+        "/engine/cache": {
+            "get": {
+                "description": "\"响应缓存统计和缓存列表\"",
+                "tags": [
+                    "engine"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.ResponseCacheSnapshot"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "\"清除响应缓存，不携带参数时清空所有缓存\"",
+                "tags": [
+                    "engine"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "接口路径",
+                        "name": "operation",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "缓存标签，逗号分隔",
+                        "name": "tags",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "清除的数量",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    }
+                }
+            }
+        },
         "/engine/restart": {
             "get": {
                 "description": "\"引擎重启\"",
@@ -929,13 +1299,39 @@ const docTemplate = `{
         },
         "/engine/swagger": {
             "get": {
-                "description": "\"引擎swagger.json\"",
+                "description": "\"引擎swagger.json，携带过滤参数时返回按角色/标签/公开过滤后的文档\"",
                 "tags": [
                     "engine"
                 ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "角色",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "标签或目录，逗号分隔",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "仅公开接口",
+                        "name": "publicOnly",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "成功"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/i18n.CustomError"
+                        }
                     }
                 }
             }
@@ -1031,6 +1427,28 @@ const docTemplate = `{
                 }
             }
         },
+        "/home/hookStatistics": {
+            "get": {
+                "description": "\"钩子调用统计\"",
+                "tags": [
+                    "home"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/websocket.HookTraceStatistics"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/i18n.CustomError"
+                        }
+                    }
+                }
+            }
+        },
         "/operation/bindRoles": {
             "post": {
                 "description": "\"BindRoles\"",
@@ -1054,7 +1472,32 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "string"
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/i18n.CustomError"
+                        }
+                    }
+                }
+            }
+        },
+        "/operation/deprecation": {
+            "get": {
+                "description": "\"getDeprecationReport\"",
+                "tags": [
+                    "operation"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/server.DeprecatedOperationReport"
                             }
                         }
                     },
@@ -1414,6 +1857,36 @@ const docTemplate = `{
                 }
             }
         },
+        "/sdk/verifyHooks": {
+            "post": {
+                "description": "\"按照hook.swagger向钩子服务发送模拟请求，校验开启的钩子是否缺失、异常或过慢\"",
+                "tags": [
+                    "sdk"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "慢钩子阈值(毫秒)",
+                        "name": "slowThreshold",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/sdk.HookVerifyReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/i18n.CustomError"
+                        }
+                    }
+                }
+            }
+        },
         "/storage/hookOptions/{dataName}": {
             "get": {
                 "description": "\"getHookOptions\"",
@@ -2185,6 +2658,12 @@ const docTemplate = `{
                 "operation": {
                     "$ref": "#/definitions/api.operationStatistics"
                 },
+                "slowestHooks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/websocket.HookStatistic"
+                    }
+                },
                 "storage": {
                     "$ref": "#/definitions/api.storageStatistics"
                 }
@@ -2207,73 +2686,430 @@ const docTemplate = `{
                 }
             }
         },
-        "api.paramBindRole": {
+        "api.paramBindRole": {
+            "type": "object",
+            "properties": {
+                "operationPaths": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "rbacType": {
+                    "type": "string"
+                },
+                "roleCodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.paramQueryRole": {
+            "type": "object",
+            "properties": {
+                "rbacType": {
+                    "type": "string"
+                },
+                "roleCode": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.storageStatistics": {
+            "type": "object",
+            "properties": {
+                "memoryTotal": {
+                    "type": "integer"
+                },
+                "memoryUsed": {
+                    "type": "integer"
+                },
+                "storageTotal": {
+                    "type": "integer"
+                }
+            }
+        },
+        "datasource.DbmlImportResult": {
+            "type": "object",
+            "properties": {
+                "enums": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "models": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "prismaSchema": {
+                    "type": "string"
+                },
+                "skipped": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "datasource.DiffCmdOption": {
+            "type": "object",
+            "properties": {
+                "databaseUrl": {
+                    "$ref": "#/definitions/wgpb.ConfigurationVariable"
+                },
+                "type": {
+                    "$ref": "#/definitions/datasource.DiffCmdOptionType"
+                }
+            }
+        },
+        "datasource.DiffCmdOptionType": {
+            "type": "string",
+            "enum": [
+                "migrations_to_dev",
+                "dev_to_prod",
+                "migrations_to_database",
+                "database_to_schema"
+            ],
+            "x-enum-comments": {
+                "DiffCmdOptionDatabaseToSchema": "数据源实际数据库与prisma文件的差异，用于推送前检查破坏性变更",
+                "DiffCmdOptionMigrationsToDatabase": "迁移历史与数据源实际数据库的差异(漂移)，databaseUrl为影子数据库"
+            },
+            "x-enum-varnames": [
+                "DiffCmdOptionMigrationsToDev",
+                "DiffCmdOptionDevToProd",
+                "DiffCmdOptionMigrationsToDatabase",
+                "DiffCmdOptionDatabaseToSchema"
+            ]
+        },
+        "datasource.ErColumn": {
+            "type": "object",
+            "properties": {
+                "dbName": {
+                    "type": "string"
+                },
+                "default": {
+                    "type": "string"
+                },
+                "enum": {
+                    "type": "boolean"
+                },
+                "increment": {
+                    "type": "boolean"
+                },
+                "list": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "primaryKey": {
+                    "type": "boolean"
+                },
+                "required": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "unique": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "datasource.ErDiagram": {
+            "type": "object",
+            "properties": {
+                "enums": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datasource.ErEnum"
+                    }
+                },
+                "relations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datasource.ErRelation"
+                    }
+                },
+                "tables": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datasource.ErTable"
+                    }
+                }
+            }
+        },
+        "datasource.ErEnum": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "values": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "datasource.ErRelation": {
+            "type": "object",
+            "properties": {
+                "cardinality": {
+                    "$ref": "#/definitions/datasource.ErRelationCardinality"
+                },
+                "from": {
+                    "$ref": "#/definitions/datasource.ErRelationEnd"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "onDelete": {
+                    "type": "string"
+                },
+                "to": {
+                    "$ref": "#/definitions/datasource.ErRelationEnd"
+                }
+            }
+        },
+        "datasource.ErRelationCardinality": {
+            "type": "string",
+            "enum": [
+                "one-to-one",
+                "many-to-one",
+                "many-to-many"
+            ],
+            "x-enum-varnames": [
+                "ErRelationOneToOne",
+                "ErRelationManyToOne",
+                "ErRelationManyToMany"
+            ]
+        },
+        "datasource.ErRelationEnd": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "table": {
+                    "type": "string"
+                }
+            }
+        },
+        "datasource.ErTable": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datasource.ErColumn"
+                    }
+                },
+                "dbName": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "primaryKey": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "uniques": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "datasource.PrismaMigration": {
+            "type": "object",
+            "properties": {
+                "appliedStepsCount": {
+                    "type": "integer"
+                },
+                "checksum": {
+                    "type": "string"
+                },
+                "finishedAt": {
+                    "type": "string"
+                },
+                "logs": {
+                    "type": "string"
+                },
+                "missing": {
+                    "description": "数据库中存在记录但本地迁移目录缺失",
+                    "type": "boolean"
+                },
+                "modified": {
+                    "description": "本地迁移文件与已应用的checksum不一致",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rolledBackAt": {
+                    "type": "string"
+                },
+                "sql": {
+                    "type": "string"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/datasource.PrismaMigrationStatus"
+                }
+            }
+        },
+        "datasource.PrismaMigrationStatus": {
+            "type": "string",
+            "enum": [
+                "applied",
+                "pending",
+                "failed",
+                "rolledBack"
+            ],
+            "x-enum-varnames": [
+                "PrismaMigrationApplied",
+                "PrismaMigrationPending",
+                "PrismaMigrationFailed",
+                "PrismaMigrationRolledBack"
+            ]
+        },
+        "datasource.SchemaDrift": {
             "type": "object",
             "properties": {
-                "operationPaths": {
+                "breaking": {
+                    "type": "boolean"
+                },
+                "brokenOperations": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "rbacType": {
-                    "type": "string"
-                },
-                "roleCodes": {
+                "changes": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/datasource.SchemaDriftChange"
                     }
-                }
-            }
-        },
-        "api.paramQueryRole": {
-            "type": "object",
-            "properties": {
-                "rbacType": {
+                },
+                "checkTime": {
                     "type": "string"
                 },
-                "roleCode": {
+                "datasource": {
                     "type": "string"
                 }
             }
         },
-        "api.storageStatistics": {
+        "datasource.SchemaDriftChange": {
             "type": "object",
             "properties": {
-                "memoryTotal": {
-                    "type": "integer"
+                "breaking": {
+                    "type": "boolean"
                 },
-                "memoryUsed": {
-                    "type": "integer"
+                "coordinate": {
+                    "type": "string"
                 },
-                "storageTotal": {
-                    "type": "integer"
+                "detail": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
                 }
             }
         },
-        "datasource.DiffCmdOption": {
+        "datasource.SchemaPushChange": {
             "type": "object",
             "properties": {
-                "databaseUrl": {
-                    "$ref": "#/definitions/wgpb.ConfigurationVariable"
+                "column": {
+                    "type": "string"
                 },
-                "type": {
-                    "$ref": "#/definitions/datasource.DiffCmdOptionType"
+                "kind": {
+                    "$ref": "#/definitions/datasource.SchemaPushChangeKind"
+                },
+                "statement": {
+                    "type": "string"
+                },
+                "table": {
+                    "type": "string"
                 }
             }
         },
-        "datasource.DiffCmdOptionType": {
+        "datasource.SchemaPushChangeKind": {
             "type": "string",
             "enum": [
-                "migrations_to_dev",
-                "dev_to_prod"
+                "dropTable",
+                "dropColumn",
+                "alterColumnType",
+                "addRequiredColumn",
+                "redefineTable"
             ],
             "x-enum-varnames": [
-                "DiffCmdOptionMigrationsToDev",
-                "DiffCmdOptionDevToProd"
+                "SchemaPushDropTable",
+                "SchemaPushDropColumn",
+                "SchemaPushAlterColumnType",
+                "SchemaPushAddRequiredColumn",
+                "SchemaPushRedefineTable"
             ]
         },
+        "datasource.SchemaPushReport": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/datasource.SchemaPushChange"
+                    }
+                },
+                "confirmToken": {
+                    "type": "string"
+                },
+                "destructive": {
+                    "type": "boolean"
+                },
+                "script": {
+                    "type": "string"
+                }
+            }
+        },
+        "datasource.SeedModelResult": {
+            "type": "object",
+            "properties": {
+                "file": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "records": {
+                    "type": "integer"
+                }
+            }
+        },
         "fileloader.DataBatchResult": {
             "type": "object",
             "properties": {
@@ -2698,6 +3534,167 @@ const docTemplate = `{
                 "SdkServer"
             ]
         },
+        "sdk.HookVerifyReport": {
+            "type": "object",
+            "properties": {
+                "passed": {
+                    "type": "boolean"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/sdk.HookVerifyResult"
+                    }
+                },
+                "serverUrl": {
+                    "type": "string"
+                }
+            }
+        },
+        "sdk.HookVerifyResult": {
+            "type": "object",
+            "properties": {
+                "endpoint": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "hook": {
+                    "type": "string"
+                },
+                "latency": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/sdk.HookVerifyStatus"
+                },
+                "statusCode": {
+                    "type": "integer"
+                },
+                "target": {
+                    "type": "string"
+                }
+            }
+        },
+        "sdk.HookVerifyStatus": {
+            "type": "string",
+            "enum": [
+                "passed",
+                "slow",
+                "missing",
+                "misbehaving",
+                "unreachable"
+            ],
+            "x-enum-varnames": [
+                "HookVerifyPassed",
+                "HookVerifySlow",
+                "HookVerifyMissing",
+                "HookVerifyMisbehaving",
+                "HookVerifyUnreachable"
+            ]
+        },
+        "server.DeprecatedOperationReport": {
+            "type": "object",
+            "properties": {
+                "calls": {
+                    "type": "integer"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "lastCalledAt": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "replacement": {
+                    "type": "string"
+                },
+                "sunsetDate": {
+                    "type": "string"
+                },
+                "sunsetPassed": {
+                    "type": "boolean"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.ResponseCacheEntry": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "operation": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "server.ResponseCacheSnapshot": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/server.ResponseCacheEntry"
+                    }
+                },
+                "statistics": {
+                    "$ref": "#/definitions/server.ResponseCacheStatistics"
+                }
+            }
+        },
+        "server.ResponseCacheStatistics": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "integer"
+                },
+                "evictions": {
+                    "type": "integer"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "maxSize": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
         "vscode.FileStat": {
             "type": "object",
             "properties": {
@@ -2731,6 +3728,105 @@ const docTemplate = `{
                 "SymbolicLink"
             ]
         },
+        "websocket.HookInvocation": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "hook": {
+                    "type": "string"
+                },
+                "latency": {
+                    "type": "integer"
+                },
+                "operation": {
+                    "type": "string"
+                },
+                "requestSize": {
+                    "type": "integer"
+                },
+                "responseSize": {
+                    "type": "integer"
+                },
+                "statusCode": {
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "websocket.HookStatistic": {
+            "type": "object",
+            "properties": {
+                "avgLatency": {
+                    "type": "number"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "errorCount": {
+                    "type": "integer"
+                },
+                "histogram": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "hook": {
+                    "type": "string"
+                },
+                "maxLatency": {
+                    "type": "integer"
+                },
+                "operation": {
+                    "type": "string"
+                },
+                "p50Latency": {
+                    "type": "integer"
+                },
+                "p95Latency": {
+                    "type": "integer"
+                },
+                "p99Latency": {
+                    "type": "integer"
+                },
+                "requestBytes": {
+                    "type": "integer"
+                },
+                "responseBytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "websocket.HookTraceStatistics": {
+            "type": "object",
+            "properties": {
+                "latencyBounds": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "recent": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/websocket.HookInvocation"
+                    }
+                },
+                "statistics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/websocket.HookStatistic"
+                    }
+                },
+                "window": {
+                    "type": "integer"
+                }
+            }
+        },
         "wgpb.ConfigurationVariable": {
             "type": "object",
             "properties": {
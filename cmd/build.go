@@ -39,5 +39,6 @@ func init() {
 	buildCmd.Flags().String(consts.ActiveMode, consts.DefaultProdActive, "Mode active to run in different environment")
 	buildCmd.Flags().String(consts.Workdir, "", "Working directory to build the application")
 	buildCmd.Flags().Bool(consts.IgnoreMergeEnvironment, false, "Whether Ignore merge environment")
+	buildCmd.Flags().String(consts.SunsetOperationAction, consts.SunsetOperationWarn, "Action on operations past sunset date when building, one of [warn, fail, disable]")
 	rootCmd.AddCommand(buildCmd)
 }
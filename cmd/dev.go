@@ -40,5 +40,9 @@ func init() {
 
 	devCmd.Flags().Bool(consts.EnableAuth, false, "Whether enable auth key on dev mode")
 	devCmd.Flags().Bool(consts.EnableHookReport, true, "Whether enable hook report on dev mode")
+	devCmd.Flags().Bool(consts.EnableHookStub, false, "Whether serve built-in stub hook server when server url not configured on dev mode")
+	devCmd.Flags().Bool(consts.EnableHookTrace, true, "Whether record hook invocations and latency statistics on dev mode")
+	devCmd.Flags().String(consts.SunsetOperationAction, consts.SunsetOperationWarn, "Action on operations past sunset date when building, one of [warn, fail, disable]")
+	devCmd.Flags().Int32(consts.ResponseCacheMaxSize, 64, "Max size in megabytes of in-memory response cache declared by @cache")
 	rootCmd.AddCommand(devCmd)
 }
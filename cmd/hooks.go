@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/utils"
+	engineSdk "fireboom-server/pkg/engine/sdk"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	hooksVerifySlowThreshold = "slow-threshold"
+	hooksVerifyTimeout       = "timeout"
+)
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Manage hook server",
+	Long:  `Check the running hook server against the hook contract described by generated hook.swagger`,
+}
+
+var hooksVerifyCmd = &cobra.Command{
+	Use:     "verify",
+	Short:   "Verify hook server conforms to hook.swagger",
+	Long:    `Send synthetic requests to every enabled hook endpoint, validate status codes and response bodies, report missing, misbehaving or slow hooks, exit with code 1 if any hook not passed`,
+	Example: `./fireboom hooks verify --slow-threshold 500`,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = viper.BindPFlags(cmd.Flags())
+		utils.ExecuteInitMethods()
+		slowThreshold, _ := cmd.Flags().GetInt64(hooksVerifySlowThreshold)
+		timeout, _ := cmd.Flags().GetInt64(hooksVerifyTimeout)
+		report, err := engineSdk.VerifyHooks(context.Background(), &engineSdk.HookVerifyOption{
+			SlowThreshold: time.Duration(slowThreshold) * time.Millisecond,
+			Timeout:       time.Duration(timeout) * time.Second,
+		})
+		if err != nil {
+			zap.L().Error("verify hooks failed", zap.Error(err))
+			os.Exit(1)
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(writer, "ENDPOINT\tTARGET\tSTATUS\tCODE\tLATENCY\tERRORS")
+		for _, item := range report.Results {
+			_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%d\t%dms\t%s\n", item.Endpoint, item.Target, item.Status, item.StatusCode, item.Latency, strings.Join(item.Errors, "; "))
+		}
+		_ = writer.Flush()
+		if !report.Passed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	hooksCmd.PersistentFlags().String(consts.ActiveMode, consts.DefaultProdActive, "Mode active to run in different environment")
+	hooksCmd.PersistentFlags().String(consts.Workdir, "", "Working directory to run the verification")
+	hooksCmd.PersistentFlags().Bool(consts.IgnoreMergeEnvironment, false, "Whether Ignore merge environment")
+	hooksVerifyCmd.Flags().Int64(hooksVerifySlowThreshold, 1000, "Latency in milliseconds above which a hook is reported as slow")
+	hooksVerifyCmd.Flags().Int64(hooksVerifyTimeout, 10, "Timeout in seconds of each hook request")
+	hooksCmd.AddCommand(hooksVerifyCmd)
+	rootCmd.AddCommand(hooksCmd)
+}
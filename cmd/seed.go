@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"context"
+	"fireboom-server/pkg/common/consts"
+	engineDatasource "fireboom-server/pkg/engine/datasource"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var seedCmd = &cobra.Command{
+	Use:     "seed [datasource]",
+	Short:   "Apply seed data of database datasource",
+	Long:    `Apply json/csv seed files in store/seed/<datasource> named by prisma model, records with unique key are upserted`,
+	Example: `./fireboom seed mysql`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ds, ok := initMigrateDatasource(cmd, args[0])
+		if !ok {
+			return
+		}
+
+		results, err := engineDatasource.ApplySeeds(context.Background(), ds)
+		for _, item := range results {
+			zap.L().Info("seed model success", zap.String("model", item.Model), zap.String("file", item.File), zap.Int("records", item.Records))
+		}
+		if err != nil {
+			zap.L().Error("apply seeds failed", zap.Error(err))
+		}
+	},
+}
+
+func init() {
+	seedCmd.Flags().String(consts.ActiveMode, consts.DefaultProdActive, "Mode active to run in different environment")
+	seedCmd.Flags().String(consts.Workdir, "", "Working directory to run the seed")
+	seedCmd.Flags().Bool(consts.IgnoreMergeEnvironment, false, "Whether Ignore merge environment")
+	rootCmd.AddCommand(seedCmd)
+}
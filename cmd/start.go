@@ -48,5 +48,10 @@ func init() {
 	startCmd.Flags().Bool(consts.EnableWebConsole, true, "Whether enable web console page in production")
 	startCmd.Flags().Bool(consts.EnableDebugPprof, false, "Whether enable /debug/pprof router in production")
 	startCmd.Flags().Bool(consts.RegenerateKey, false, "Whether to renew authentication key in production")
+	startCmd.Flags().Bool(consts.EnableDestructivePush, false, "Whether allow destructive prisma schema push in production")
+	startCmd.Flags().Bool(consts.EnableHookSupervisor, false, "Whether start and supervise hook server by sdk supervisor config in production")
+	startCmd.Flags().Bool(consts.EnableHookTrace, false, "Whether record hook invocations and latency statistics in production")
+	startCmd.Flags().String(consts.SunsetOperationAction, consts.SunsetOperationWarn, "Action on operations past sunset date when building, one of [warn, fail, disable]")
+	startCmd.Flags().Int32(consts.ResponseCacheMaxSize, 64, "Max size in megabytes of in-memory response cache declared by @cache")
 	rootCmd.AddCommand(startCmd)
 }
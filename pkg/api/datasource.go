@@ -6,6 +6,7 @@
 package api
 
 import (
+	"context"
 	"fireboom-server/pkg/api/base"
 	"fireboom-server/pkg/common/consts"
 	"fireboom-server/pkg/common/models"
@@ -18,8 +19,11 @@ import (
 	engineClient "github.com/prisma/prisma-client-go/engine"
 	"github.com/prisma/prisma-client-go/generator/ast/dmmf"
 	"github.com/wundergraph/wundergraph/pkg/datasources/database"
+	"github.com/wundergraph/wundergraph/pkg/eventbus"
 	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"go.uber.org/zap"
 	"net/http"
+	"strings"
 )
 
 func DatasourceExtraRouter(_, datasourceRouter *echo.Group, baseHandler *base.Handler[models.Datasource], modelRoot *fileloader.Model[models.Datasource]) {
@@ -40,6 +44,15 @@ func DatasourceExtraRouter(_, datasourceRouter *echo.Group, baseHandler *base.Ha
 	datasourceRouter.POST("/createMigration"+base.DataNamePath, handler.createMigration)
 	datasourceRouter.POST("/applyMigration"+base.DataNamePath, handler.applyMigration)
 	datasourceRouter.POST("/diff"+base.DataNamePath, handler.diff)
+	datasourceRouter.GET("/migrations"+base.DataNamePath, handler.listMigrations)
+	datasourceRouter.POST("/rollbackMigration"+base.DataNamePath, handler.rollbackMigration)
+	datasourceRouter.POST("/seed"+base.DataNamePath, handler.applySeeds)
+	datasourceRouter.POST("/importRest", handler.previewImportRest)
+	datasourceRouter.POST("/importRest"+base.DataNamePath, handler.importRest)
+	datasourceRouter.GET("/schemaDrift"+base.DataNamePath, handler.getSchemaDrift)
+	datasourceRouter.GET("/dbml"+base.DataNamePath, handler.getDbml)
+	datasourceRouter.POST("/dbml"+base.DataNamePath, handler.importDbml)
+	datasourceRouter.GET("/erDiagram"+base.DataNamePath, handler.getErDiagram)
 }
 
 type (
@@ -80,10 +93,12 @@ func (d *datasource) checkConnection(c echo.Context) error {
 }
 
 // @Tags datasource
-// @Description "迁移"
+// @Description "迁移，存在破坏性变更时需要携带确认令牌"
 // @Param dataName path string true "model名称"
+// @Param confirmToken query string false "破坏性变更报告中的确认令牌"
 // @Param data body string true "迁移数据"
 // @Success 200 "OK"
+// @Success 428 {object} datasource.SchemaPushReport "破坏性变更报告"
 // @Failure 400 {object} i18n.CustomError
 // @Router /datasource/migrate/{dataName} [post]
 func (d *datasource) migrate(c echo.Context) error {
@@ -102,6 +117,19 @@ func (d *datasource) migrate(c echo.Context) error {
 		PrismaSchemaFilepath: engineDatasource.CachePrismaSchemaText.GetPath(data.Name),
 		EnvironmentRequired:  data.Kind == wgpb.DataSourceKind_PRISMA,
 	}
+	pushReport, err := engineDatasource.CheckSchemaPush(c.Request().Context(), engineInput, data)
+	if err != nil {
+		return i18n.NewCustomErrorWithMode(d.modelName, err, i18n.PrismaDiffError)
+	}
+	if pushReport != nil && pushReport.Destructive {
+		if !utils.GetBoolWithLockViper(consts.DevMode) && !utils.GetBoolWithLockViper(consts.EnableDestructivePush) {
+			return i18n.NewCustomErrorWithMode(d.modelName, nil, i18n.PrismaDestructivePushForbiddenError)
+		}
+		if c.QueryParam(consts.QueryParamConfirmToken) != pushReport.ConfirmToken {
+			return c.JSON(http.StatusPreconditionRequired, pushReport)
+		}
+	}
+
 	err = engineDatasource.SchemaPush(c.Request().Context(), engineInput, data.Name)
 	if err != nil {
 		return i18n.NewCustomErrorWithMode(d.modelName, err, i18n.PrismaMigrateError)
@@ -111,9 +139,8 @@ func (d *datasource) migrate(c echo.Context) error {
 		return i18n.NewCustomErrorWithMode(d.modelName, err, i18n.PrismaQueryError)
 	}
 
-	if utils.GetBoolWithLockViper(consts.DevMode) {
-		go utils.BuildAndStart()
-	}
+	go d.reloadDatasource(data)
+	go d.seedAfterMigrate(data)
 	return c.NoContent(http.StatusOK)
 }
 
@@ -175,6 +202,70 @@ func (d *datasource) applyMigration(c echo.Context) error {
 	return c.JSON(http.StatusOK, appliedMigrationNames)
 }
 
+// @Tags datasource
+// @Description "迁移历史，包含迁移sql及应用状态"
+// @Param dataName path string true "model名称"
+// @Success 200 {array} datasource.PrismaMigration "迁移列表"
+// @Failure 400 {object} i18n.CustomError
+// @Router /datasource/migrations/{dataName} [get]
+func (d *datasource) listMigrations(c echo.Context) error {
+	data, err := d.baseHandler.GetOneByDataName(c)
+	if err != nil {
+		return err
+	}
+
+	migrations, err := engineDatasource.ListMigrations(c.Request().Context(), data)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, migrations)
+}
+
+// @Tags datasource
+// @Description "将失败的迁移标记为已回滚"
+// @Param dataName path string true "model名称"
+// @Param migrationName query string true "迁移名称"
+// @Success 200 "OK"
+// @Failure 400 {object} i18n.CustomError
+// @Router /datasource/rollbackMigration/{dataName} [post]
+func (d *datasource) rollbackMigration(c echo.Context) error {
+	data, err := d.baseHandler.GetOneByDataName(c)
+	if err != nil {
+		return err
+	}
+	migrationName, err := d.baseHandler.GetQueryParam(c, consts.QueryParamMigrationName)
+	if err != nil {
+		return err
+	}
+
+	if err = engineDatasource.MarkMigrationRolledBack(c.Request().Context(), data, migrationName); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// @Tags datasource
+// @Description "写入store/seed/{dataName}下的种子数据"
+// @Param dataName path string true "model名称"
+// @Success 200 {array} datasource.SeedModelResult "OK"
+// @Failure 400 {object} i18n.CustomError
+// @Router /datasource/seed/{dataName} [post]
+func (d *datasource) applySeeds(c echo.Context) error {
+	data, err := d.baseHandler.GetOneByDataName(c)
+	if err != nil {
+		return err
+	}
+
+	results, err := engineDatasource.ApplySeeds(c.Request().Context(), data)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
 // @Tags datasource
 // @Description "生成增量迁移脚本"
 // @Param dataName path string true "model名称"
@@ -307,13 +398,102 @@ func (d *datasource) updatePrismaText(c echo.Context) (err error) {
 		return
 	}
 
+	if err = d.writePrismaText(dataName, user, body); err != nil {
+		return
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (d *datasource) writePrismaText(dataName, user string, body []byte) (err error) {
 	if err = models.DatasourceUploadPrisma.Write(dataName, user, body); err != nil {
 		prismaFilepath := models.DatasourceUploadPrisma.GetPath(dataName)
 		err = i18n.NewCustomErrorWithMode(d.modelName, err, i18n.FileWriteError, prismaFilepath)
+	}
+	return
+}
+
+// @Tags datasource
+// @Description "导出数据库类型数据源的dbml"
+// @Param dataName path string true "model名称"
+// @Success 200 {string} string "dbml文本"
+// @Failure 400 {object} i18n.CustomError
+// @Router /datasource/dbml/{dataName} [get]
+func (d *datasource) getDbml(c echo.Context) error {
+	diagram, err := d.buildErDiagram(c)
+	if err != nil {
+		return err
+	}
+
+	return c.String(http.StatusOK, engineDatasource.BuildDbml(diagram))
+}
+
+// @Tags datasource
+// @Description "获取数据库类型数据源的ER图"
+// @Param dataName path string true "model名称"
+// @Success 200 {object} datasource.ErDiagram "ER图"
+// @Failure 400 {object} i18n.CustomError
+// @Router /datasource/erDiagram/{dataName} [get]
+func (d *datasource) getErDiagram(c echo.Context) error {
+	diagram, err := d.buildErDiagram(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, diagram)
+}
+
+func (d *datasource) buildErDiagram(c echo.Context) (diagram *engineDatasource.ErDiagram, err error) {
+	data, err := d.baseHandler.GetOneByDataName(c)
+	if err != nil {
 		return
 	}
 
-	return c.NoContent(http.StatusOK)
+	document, err := engineDatasource.LoadDmmfDocument(data)
+	if err != nil {
+		err = i18n.NewCustomErrorWithMode(d.modelName, err, i18n.PrismaQueryError)
+		return
+	}
+
+	diagram = engineDatasource.BuildErDiagram(document)
+	return
+}
+
+// @Tags datasource
+// @Description "导入dbml，转换成prisma模型追加到数据源的prisma文本"
+// @Param dataName path string true "model名称"
+// @Param data body string true "dbml文本"
+// @Success 200 {object} datasource.DbmlImportResult "导入结果"
+// @Failure 400 {object} i18n.CustomError
+// @Router /datasource/dbml/{dataName} [post]
+func (d *datasource) importDbml(c echo.Context) error {
+	data, err := d.baseHandler.GetOneByDataName(c)
+	if err != nil {
+		return err
+	}
+
+	body, user, err := d.baseHandler.GetUserAndBody(c)
+	if err != nil {
+		return err
+	}
+
+	prismaSchema, _ := models.DatasourceUploadPrisma.Read(data.Name)
+	if prismaSchema == "" {
+		prismaSchema, _ = engineDatasource.CachePrismaSchemaText.Read(data.Name)
+	}
+	result, err := engineDatasource.ConvertDbmlToPrisma(string(body), prismaSchema)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Models) > 0 || len(result.Enums) > 0 {
+		prismaSchema = strings.TrimRight(prismaSchema, "\n") + "\n\n" + result.PrismaSchema
+		if err = d.writePrismaText(data.Name, user, []byte(prismaSchema)); err != nil {
+			return err
+		}
+	}
+
+	return c.JSON(http.StatusOK, result)
 }
 
 // @Tags datasource
@@ -373,6 +553,122 @@ func (d *datasource) graphqlQuery(c echo.Context) (err error) {
 	return c.JSON(http.StatusOK, graphqlResult)
 }
 
+// @Tags datasource
+// @Description "预览Postman集合或HAR文件转换的OAS文档"
+// @Param origin query string false "HAR文件仅导入该源的请求，为空时使用请求数最多的源"
+// @Param data body string true "Postman v2.1集合或HAR文件内容"
+// @Success 200 "OAS文档"
+// @Failure 400 {object} i18n.CustomError
+// @Router /datasource/importRest [post]
+func (d *datasource) previewImportRest(c echo.Context) error {
+	body, _, err := d.baseHandler.GetUserAndBody(c)
+	if err != nil {
+		return err
+	}
+
+	doc, err := engineDatasource.ConvertToOpenapi(body, c.QueryParam(consts.QueryParamOrigin))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, doc)
+}
+
+// @Tags datasource
+// @Description "将Postman集合或HAR文件转换成OAS文档并保存为rest数据源的OAS文件"
+// @Param dataName path string true "dataName"
+// @Param origin query string false "HAR文件仅导入该源的请求，为空时使用请求数最多的源"
+// @Param data body string true "Postman v2.1集合或HAR文件内容"
+// @Success 200 "OAS文档"
+// @Failure 400 {object} i18n.CustomError
+// @Router /datasource/importRest/{dataName} [post]
+func (d *datasource) importRest(c echo.Context) error {
+	data, err := d.baseHandler.GetOneByDataName(c)
+	if err != nil {
+		return err
+	}
+
+	if data.Kind != wgpb.DataSourceKind_REST || data.CustomSoap != nil {
+		return i18n.NewCustomErrorWithMode(d.modelName, nil, i18n.DatasourceKindNotSupportedError, data.Kind)
+	}
+
+	body, user, err := d.baseHandler.GetUserAndBody(c)
+	if err != nil {
+		return err
+	}
+
+	doc, err := engineDatasource.ConvertToOpenapi(body, c.QueryParam(consts.QueryParamOrigin))
+	if err != nil {
+		return err
+	}
+
+	docBytes, err := json.Marshal(doc)
+	if err != nil {
+		return i18n.NewCustomErrorWithMode(d.modelName, err, i18n.DatasourceImportConvertError)
+	}
+
+	// 未上传过OAS文件时使用数据源名称作为文件名
+	// 以系统用户修改避免在OAS文件写入前触发编译
+	if data.CustomRest == nil || data.CustomRest.OasFilepath == "" {
+		modifyBytes, _ := json.Marshal(map[string]any{
+			"name":       data.Name,
+			"customRest": map[string]any{"oasFilepath": data.Name + string(fileloader.ExtJson)},
+		})
+		if data, err = d.modelRoot.UpdateByDataName(modifyBytes, fileloader.SystemUser); err != nil {
+			return i18n.NewCustomErrorWithMode(d.modelName, err, i18n.DataUpdateError)
+		}
+	}
+
+	if err = models.DatasourceUploadOas.Write(data.Name, user, docBytes); err != nil {
+		return i18n.NewCustomErrorWithMode(d.modelName, err, i18n.FileWriteError, models.DatasourceUploadOas.GetPath(data.Name))
+	}
+
+	go d.reloadDatasource(data)
+	return c.JSONBlob(http.StatusOK, docBytes)
+}
+
+// @Tags datasource
+// @Description "获取graphql数据源最近一次轮询发现的上游schema差异"
+// @Param dataName path string true "model名称"
+// @Success 200 {object} datasource.SchemaDrift "schema差异，无差异时为null"
+// @Failure 400 {object} i18n.CustomError
+// @Router /datasource/schemaDrift/{dataName} [get]
+func (d *datasource) getSchemaDrift(c echo.Context) error {
+	data, err := d.baseHandler.GetOneByDataName(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, engineDatasource.GetSchemaDrift(data.Name))
+}
+
+// 数据源依赖的文件或数据库结构变更后优先增量编译，增量编译失败时全量编译
+func (d *datasource) reloadDatasource(data *models.Datasource) {
+	if !utils.GetBoolWithLockViper(consts.DevMode) {
+		return
+	}
+
+	if !eventbus.Publish(eventbus.ChannelDatasource, eventbus.EventUpdate, data) {
+		utils.BuildAndStart()
+	}
+}
+
+// 开发模式下数据源开启seedAfterMigrate时，迁移成功后写入种子数据
+func (d *datasource) seedAfterMigrate(data *models.Datasource) {
+	if !utils.GetBoolWithLockViper(consts.DevMode) || data.CustomDatabase == nil || !data.CustomDatabase.SeedAfterMigrate {
+		return
+	}
+
+	results, err := engineDatasource.ApplySeeds(context.Background(), data)
+	if err != nil {
+		zap.L().Error("seed after migrate failed", zap.Error(err), zap.String("datasource", data.Name))
+		return
+	}
+	for _, item := range results {
+		zap.L().Info("seed after migrate success", zap.String("model", item.Model), zap.Int("records", item.Records))
+	}
+}
+
 func (d *datasource) getPrismaFilepath(c echo.Context, data *models.Datasource) (prismaFilepath string, cacheUsed bool, err error) {
 	cacheUsed = c.QueryParam(consts.QueryParamCrud) == "" || data.Kind != wgpb.DataSourceKind_PRISMA
 	if cacheUsed {
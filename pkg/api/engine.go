@@ -1,37 +1,75 @@
 // Package api
 /*
- 注册引擎相关的路由，包括重启引擎及swagger文档
+ 注册引擎相关的路由，包括重启引擎及swagger/asyncapi文档
  飞布提供了两份swagger文档，这里是引擎即9991端口的文档
+ @cache声明的响应缓存支持查看统计和按接口/标签清除
 */
 package api
 
 import (
 	"fireboom-server/pkg/api/base"
+	"fireboom-server/pkg/common/configs"
 	"fireboom-server/pkg/common/consts"
 	"fireboom-server/pkg/common/utils"
 	"fireboom-server/pkg/engine/build"
+	"fireboom-server/pkg/engine/server"
+	"fireboom-server/pkg/engine/swagger"
 	"github.com/labstack/echo/v4"
+	"github.com/spf13/cast"
 	"net/http"
+	"strings"
 )
 
 func EngineRouter(contextRouter *echo.Group) {
 	handler := &engine{}
 	engineRouter := contextRouter.Group("/engine")
 	engineRouter.GET("/restart", handler.restart)
+	engineRouter.GET("/cache", handler.getResponseCache)
+	engineRouter.DELETE("/cache", handler.purgeResponseCache)
 	if utils.GetBoolWithLockViper(consts.EnableSwagger) {
 		engineRouter.GET("/swagger", handler.getSwaggerJsonFile)
+		engineRouter.GET("/asyncapi", handler.getAsyncapiJsonFile)
 	}
 }
 
 type engine struct{}
 
 // @Tags engine
-// @Description "引擎swagger.json"
+// @Description "引擎swagger.json，携带过滤参数时返回按角色/标签/公开过滤后的文档"
+// @Param role query string false "角色"
+// @Param tags query string false "标签或目录，逗号分隔"
+// @Param publicOnly query bool false "仅公开接口"
 // @Success 200 "成功"
+// @Failure 400 {object} i18n.CustomError
 // @Router /engine/swagger [get]
 func (s *engine) getSwaggerJsonFile(c echo.Context) error {
 	base.SetHeaderCacheControlNoCache(c)
-	return c.File(build.GeneratedSwaggerText.GetPath(build.GeneratedSwaggerText.Title))
+	filter := &configs.SwaggerFilter{
+		Role:       c.QueryParam(consts.QueryParamRole),
+		PublicOnly: cast.ToBool(c.QueryParam(consts.QueryParamPublicOnly)),
+	}
+	if tags := c.QueryParam(consts.QueryParamTags); tags != "" {
+		filter.Tags = strings.Split(tags, utils.StringComma)
+	}
+	if filter.Role == "" && len(filter.Tags) == 0 && !filter.PublicOnly {
+		return c.File(build.GeneratedSwaggerText.GetPath(build.GeneratedSwaggerText.Title))
+	}
+
+	docBytes, err := swagger.GenerateFiltered(filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSONBlob(http.StatusOK, docBytes)
+}
+
+// @Tags engine
+// @Description "引擎asyncapi.json"
+// @Success 200 "成功"
+// @Router /engine/asyncapi [get]
+func (s *engine) getAsyncapiJsonFile(c echo.Context) error {
+	base.SetHeaderCacheControlNoCache(c)
+	return c.File(build.GeneratedAsyncapiText.GetPath(build.GeneratedAsyncapiText.Title))
 }
 
 // @Tags engine
@@ -44,3 +82,25 @@ func (s *engine) restart(c echo.Context) error {
 	}
 	return c.NoContent(http.StatusOK)
 }
+
+// @Tags engine
+// @Description "响应缓存统计和缓存列表"
+// @Success 200 {object} server.ResponseCacheSnapshot "OK"
+// @Router /engine/cache [get]
+func (s *engine) getResponseCache(c echo.Context) error {
+	return c.JSON(http.StatusOK, server.ResponseCacheStore.Snapshot())
+}
+
+// @Tags engine
+// @Description "清除响应缓存，不携带参数时清空所有缓存"
+// @Param operation query string false "接口路径"
+// @Param tags query string false "缓存标签，逗号分隔"
+// @Success 200 {integer} integer "清除的数量"
+// @Router /engine/cache [delete]
+func (s *engine) purgeResponseCache(c echo.Context) error {
+	var tags []string
+	if tagsValue := c.QueryParam(consts.QueryParamTags); tagsValue != "" {
+		tags = strings.Split(tagsValue, utils.StringComma)
+	}
+	return c.JSON(http.StatusOK, server.ResponseCacheStore.Purge(c.QueryParam(consts.QueryParamOperation), tags))
+}
@@ -2,11 +2,13 @@
 /*
  注册首页统计的路由
  统计数据源，接口，认证，存储等信息
+ 钩子调用统计展示最近窗口内的调用次数、耗时分布和最慢的钩子
 */
 package api
 
 import (
 	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/websocket"
 	"github.com/labstack/echo/v4"
 	"github.com/wundergraph/wundergraph/pkg/wgpb"
 	"net/http"
@@ -17,16 +19,20 @@ func HomeRouter(contextRouter *echo.Group) {
 	homeRouter := contextRouter.Group("/home")
 	homeRouter.GET("", handler.getHomeData)
 	homeRouter.GET("/bulletin", handler.getBulletin)
+	homeRouter.GET("/hookStatistics", handler.getHookStatistics)
 }
 
+const homeSlowestHookLimit = 5
+
 type home struct{}
 
 type (
 	homeStatistics struct {
-		DataSource     *datasourceStatistics     `json:"dataSource"`
-		Operation      *operationStatistics      `json:"operation"`
-		Authentication *authenticationStatistics `json:"authentication"`
-		Storage        *storageStatistics        `json:"storage"`
+		DataSource     *datasourceStatistics      `json:"dataSource"`
+		Operation      *operationStatistics       `json:"operation"`
+		Authentication *authenticationStatistics  `json:"authentication"`
+		Storage        *storageStatistics         `json:"storage"`
+		SlowestHooks   []*websocket.HookStatistic `json:"slowestHooks"`
 	}
 	datasourceStatistics struct {
 		RestTotal      int `json:"restTotal"`
@@ -97,9 +103,19 @@ func (h *home) getHomeData(c echo.Context) error {
 		Operation:      homeOperation,
 		Authentication: homeAuthentication,
 		Storage:        homeStorage,
+		SlowestHooks:   websocket.GetSlowestHooks(homeSlowestHookLimit),
 	})
 }
 
+// @Tags home
+// @Description "钩子调用统计"
+// @Success 200 {object} websocket.HookTraceStatistics "OK"
+// @Failure 400 {object} i18n.CustomError
+// @Router /home/hookStatistics [get]
+func (h *home) getHookStatistics(c echo.Context) error {
+	return c.JSON(http.StatusOK, websocket.GetHookTraceStatistics())
+}
+
 // TODO: system notify
 func (h *home) getBulletin(c echo.Context) error {
 	return c.JSON(http.StatusOK, []map[string]any{{
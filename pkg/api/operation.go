@@ -14,6 +14,7 @@ import (
 	"fireboom-server/pkg/common/utils"
 	"fireboom-server/pkg/engine/build"
 	"fireboom-server/pkg/engine/directives"
+	"fireboom-server/pkg/engine/server"
 	"fireboom-server/pkg/plugins/fileloader"
 	"fireboom-server/pkg/plugins/i18n"
 	"fmt"
@@ -49,6 +50,7 @@ func OperationExtraRouter(_, operationRouter *echo.Group, baseHandler *base.Hand
 	operationRouter.POST("/function"+base.DataNamePath, handler.updateFunctionText)
 	operationRouter.POST("/proxy"+base.DataNamePath, handler.updateProxyText)
 	operationRouter.GET("/hookOptions"+base.DataNamePath, handler.getHookOptions)
+	operationRouter.GET("/deprecation", handler.getDeprecationReport)
 
 	operationRouter.POST("/bindRoles", handler.bindRoles)
 	base.AddRouterMetas(modelRoot,
@@ -454,6 +456,15 @@ func (o *operation) getHookOptions(c echo.Context) error {
 	return c.JSON(http.StatusOK, models.GetOperationHookOptions(dataName))
 }
 
+// @Tags operation
+// @Description "getDeprecationReport"
+// @Success 200 {object} []server.DeprecatedOperationReport "OK"
+// @Failure 400 {object} i18n.CustomError
+// @Router /operation/deprecation [get]
+func (o *operation) getDeprecationReport(c echo.Context) error {
+	return c.JSON(http.StatusOK, server.GetDeprecatedOperationReports())
+}
+
 func (o *operation) updateOperationExtensionText(c echo.Context, text *fileloader.ModelText[models.Operation]) (err error) {
 	dataName, err := o.baseHandler.GetPathParamDataName(c)
 	if err != nil {
@@ -1,24 +1,29 @@
 // Package api
 /*
  在基础路由上进行扩展
- 注册服务端钩子查询，打包下载钩子生成目录路由
+ 注册服务端钩子查询，打包下载钩子生成目录，钩子约定校验路由
 */
 package api
 
 import (
 	"fireboom-server/pkg/api/base"
+	"fireboom-server/pkg/common/consts"
 	"fireboom-server/pkg/common/models"
 	"fireboom-server/pkg/common/utils"
+	engineSdk "fireboom-server/pkg/engine/sdk"
 	"fireboom-server/pkg/plugins/fileloader"
 	"fireboom-server/pkg/plugins/i18n"
 	"github.com/labstack/echo/v4"
+	"github.com/spf13/cast"
 	"net/http"
+	"time"
 )
 
 func SdkRouter(_, sdkRouter *echo.Group, baseHandler *base.Handler[models.Sdk], modelRoot *fileloader.Model[models.Sdk]) {
 	handler := &sdk{baseHandler, modelRoot, modelRoot.GetModelName()}
 	sdkRouter.GET("/enabledServer", handler.enabledServer)
 	sdkRouter.GET("/downloadOutput"+base.DataNamePath, handler.downloadOutput)
+	sdkRouter.POST("/verifyHooks", handler.verifyHooks)
 }
 
 type sdk struct {
@@ -54,3 +59,21 @@ func (d *sdk) downloadOutput(c echo.Context) error {
 	base.SetHeaderContentDisposition(c, data.Name+utils.ExtensionZip)
 	return c.Stream(http.StatusOK, "application/zip", zipBuffer)
 }
+
+// @Tags sdk
+// @Description "按照hook.swagger向钩子服务发送模拟请求，校验开启的钩子是否缺失、异常或过慢"
+// @Param slowThreshold query int false "慢钩子阈值(毫秒)"
+// @Success 200 {object} sdk.HookVerifyReport "OK"
+// @Failure 400 {object} i18n.CustomError
+// @Router /sdk/verifyHooks [post]
+func (d *sdk) verifyHooks(c echo.Context) error {
+	option := &engineSdk.HookVerifyOption{
+		SlowThreshold: time.Duration(cast.ToInt64(c.QueryParam(consts.QueryParamSlowThreshold))) * time.Millisecond,
+	}
+	report, err := engineSdk.VerifyHooks(c.Request().Context(), option)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
@@ -27,13 +27,25 @@ type (
 		CorsConfiguration *wgpb.CorsConfiguration `json:"corsConfiguration"` // WunderGraphConfiguration.UserDefinedApi.Cors
 		SecurityConfig
 
-		Appearance    *Appearance       `json:"appearance"`
-		ConsoleLogger *lumberjackLogger `json:"consoleLogger"`
-		BuildInfo     *node.BuildInfo   `json:"buildInfo"`
+		Appearance     *Appearance       `json:"appearance"`
+		ConsoleLogger  *lumberjackLogger `json:"consoleLogger"`
+		BuildInfo      *node.BuildInfo   `json:"buildInfo"`
+		SwaggerExports []*SwaggerExport  `json:"swaggerExports"`
 	}
 	Appearance struct {
 		Language string `json:"language"`
 	}
+	// SwaggerFilter swagger文档过滤条件，角色依据@rbac的配置判断，标签即operation的目录
+	SwaggerFilter struct {
+		Role       string   `json:"role"`
+		Tags       []string `json:"tags"`
+		PublicOnly bool     `json:"publicOnly"`
+	}
+	// SwaggerExport 每次编译后写入exported/generated/swagger.${name}.json的过滤文档
+	SwaggerExport struct {
+		Name string `json:"name"`
+		SwaggerFilter
+	}
 	SecurityConfig struct {
 		AllowedHostNames []*wgpb.ConfigurationVariable `json:"allowedHostNames"` // WunderGraphConfiguration.AllowedHostNames
 
@@ -20,6 +20,7 @@ const (
 
 	ExportedGeneratedSwaggerFilename            = "swagger"
 	ExportedGeneratedHookSwaggerFilename        = "hook.swagger"
+	ExportedGeneratedAsyncapiFilename           = "asyncapi"
 	ExportedGeneratedFireboomConfigFilename     = "fireboom.config"
 	ExportedGeneratedFireboomOperationsFilename = "fireboom.operations"
 	ExportedGeneratedGraphqlSchemaFilename      = "fireboom.app.schema"
@@ -35,6 +36,8 @@ const (
 	StoreConfigParent         = "config"
 	StoreRoleParent           = "role"
 	StoreFragmentParent       = "fragment"
+	StoreSeedParent           = "seed"
+	StoreStubParent           = "stub"
 )
 
 // upload目录下的子目录
@@ -44,6 +47,9 @@ const (
 	UploadPrismaParent   = "prisma"
 	UploadSqliteParent   = "sqlite"
 	UploadGraphqlParent  = "graphql"
+	UploadStaticParent   = "static"
+	UploadGrpcParent     = "grpc"
+	UploadSoapParent     = "soap"
 )
 
 // 服务端钩子工作目录下的子目录
@@ -16,10 +16,16 @@ const (
 	EnableRebuild          = "enable-rebuild"
 	EnableSwagger          = "enable-swagger"
 	EnableHookReport       = "enable-hook-report"
+	EnableHookSupervisor   = "enable-hook-supervisor"
+	EnableHookStub         = "enable-hook-stub"
+	EnableHookTrace        = "enable-hook-trace"
 	EnableWebConsole       = "enable-web-console"
 	EnableDebugPprof       = "enable-debug-pprof"
 	EnableLogicDelete      = "enable-logic-delete"
+	EnableDestructivePush  = "enable-destructive-push"
 	RegenerateKey          = "regenerate-key"
+	SunsetOperationAction  = "sunset-operation-action"
+	ResponseCacheMaxSize   = "response-cache-max-size"
 	IgnoreMergeEnvironment = "ignore-merge-environment"
 )
 
@@ -30,6 +36,13 @@ const (
 	DefaultProdActive = "prod"
 )
 
+// sunset operation action value
+const (
+	SunsetOperationWarn    = "warn"
+	SunsetOperationFail    = "fail"
+	SunsetOperationDisable = "disable"
+)
+
 // env file param
 const (
 	GithubRawProxyUrl = "GITHUB_RAW_PROXY_URL"
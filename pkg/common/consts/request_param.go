@@ -5,7 +5,8 @@
 package consts
 
 const (
-	PathParamDataName = "dataName"
+	PathParamDataName  = "dataName"
+	PathParamOperation = "operation"
 
 	QueryParamDataNames      = "dataNames"
 	QueryParamWatchAction    = "watchAction"
@@ -16,6 +17,18 @@ const (
 	QueryParamCrud           = "crud"
 	QueryParamOverwrite      = "overwrite"
 	QueryParamVersion        = "version"
+	QueryParamRole           = "role"
+	QueryParamTags           = "tags"
+	QueryParamPublicOnly     = "publicOnly"
+	QueryParamMigrationName  = "migrationName"
+	QueryParamConfirmToken   = "confirmToken"
+	QueryParamSlowThreshold  = "slowThreshold"
+	QueryParamOrigin         = "origin"
+	QueryParamOperation      = "operation"
+
+	QueryParamEngineVariables = "wg_variables"
+	QueryParamEnginePrefix    = "wg_"
+	QueryParamEngineLive      = "wg_live"
 
 	FormParamFile = "file"
 
@@ -24,5 +37,15 @@ const (
 	HeaderParamTag            = "X-FB-Tag"
 	HeaderParamUser           = "X-FB-User"
 
+	HeaderParamRetryAfter         = "Retry-After"
+	HeaderParamRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderParamRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderParamRateLimitReset     = "X-RateLimit-Reset"
+	HeaderParamDeprecation        = "Deprecation"
+	HeaderParamSunset             = "Sunset"
+	HeaderParamLink               = "Link"
+	HeaderParamETag               = "ETag"
+	HeaderParamIfNoneMatch        = "If-None-Match"
+
 	AttachmentFilenameFormat = `attachment;filename="%s"`
 )
@@ -3,7 +3,7 @@
  使用fileloader.Model管理数据源配置
  读取store/datasource下的文件，变更后会触发引擎编译，支持逻辑删除
  使用key为kind，value为func的map来支持不同类型数据源的链接配置
- 数据库类型数据源支持url和独立配置
+ 数据库类型数据源支持url和独立配置，可以追加连接参数和配置只读副本
 */
 package models
 
@@ -14,6 +14,7 @@ import (
 	"fireboom-server/pkg/plugins/fileloader"
 	"fireboom-server/pkg/plugins/i18n"
 	"fmt"
+	"net/url"
 	"path/filepath"
 	"strings"
 
@@ -34,6 +35,9 @@ type Datasource struct {
 	CustomAsyncapi *CustomRest         `json:"customAsyncapi,omitempty"`
 	CustomGraphql  *CustomGraphql      `json:"customGraphql"`
 	CustomDatabase *CustomDatabase     `json:"customDatabase"`
+	CustomStatic   *CustomStatic       `json:"customStatic,omitempty"`
+	CustomGrpc     *CustomGrpc         `json:"customGrpc,omitempty"`
+	CustomSoap     *CustomSoap         `json:"customSoap,omitempty"`
 }
 
 type CustomDatabaseKind int32
@@ -49,27 +53,124 @@ type (
 		Headers        map[string]*wgpb.HTTPHeader `json:"headers"`
 		Endpoint       string                      `json:"endpoint"`
 		SchemaFilepath string                      `json:"schemaFilepath"`
+		Auth           *CustomAuth                 `json:"auth,omitempty"`
+
+		IntrospectTimeout int64                       `json:"introspectTimeout,omitempty"`
+		SchemaPolling     *CustomGraphqlSchemaPolling `json:"schemaPolling,omitempty"`
+	}
+	// CustomGraphqlSchemaPolling 定时内省上游schema并与缓存比对，变更时推送问题并标记数据源
+	// AutoRebuild 开启后非破坏性变更会自动重新编译
+	CustomGraphqlSchemaPolling struct {
+		Enabled     bool  `json:"enabled"`
+		Interval    int64 `json:"interval"`
+		AutoRebuild bool  `json:"autoRebuild"`
 	}
 	CustomRest struct {
 		OasFilepath       string                                `json:"oasFilepath"`
 		BaseUrl           *wgpb.ConfigurationVariable           `json:"baseUrl"`
 		Headers           map[string]*wgpb.HTTPHeader           `json:"headers"`
 		ResponseExtractor *wgpb.DataSourceRESTResponseExtractor `json:"responseExtractor"`
+		Auth              *CustomAuth                           `json:"auth,omitempty"`
 	}
 	CustomDatabase struct {
 		Kind          CustomDatabaseKind          `json:"kind"`
 		DatabaseUrl   *wgpb.ConfigurationVariable `json:"databaseUrl"`
 		DatabaseAlone *CustomDatabaseAlone        `json:"databaseAlone"`
+		// SeedAfterMigrate 开发模式下迁移成功后自动写入store/seed下的种子数据
+		SeedAfterMigrate bool `json:"seedAfterMigrate,omitempty"`
+		// Options 连接参数，同时作用于主库和只读副本
+		Options *CustomDatabaseOptions `json:"options,omitempty"`
+		// Replicas 只读副本，配置后query操作路由到副本，mutation(包括事务)使用主库
+		Replicas []*CustomDatabaseReplica `json:"replicas,omitempty"`
+	}
+	// CustomDatabaseOptions 追加到连接地址上的参数，按照数据库类型转换成prisma支持的参数名
+	// SslCert/SslIdentity 证书路径，建议使用绝对路径
+	// QueryParams 其他任意参数，覆盖同名的参数
+	CustomDatabaseOptions struct {
+		SslMode         string                      `json:"sslMode,omitempty"`
+		SslCert         string                      `json:"sslCert,omitempty"`
+		SslIdentity     string                      `json:"sslIdentity,omitempty"`
+		SslPassword     *wgpb.ConfigurationVariable `json:"sslPassword,omitempty"`
+		SslAccept       string                      `json:"sslAccept,omitempty"`
+		ConnectionLimit int64                       `json:"connectionLimit,omitempty"`
+		PoolTimeout     int64                       `json:"poolTimeout,omitempty"`
+		ConnectTimeout  int64                       `json:"connectTimeout,omitempty"`
+		Schema          string                      `json:"schema,omitempty"`
+		QueryParams     map[string]string           `json:"queryParams,omitempty"`
+	}
+	// CustomDatabaseReplica 只读副本，按连接轮询分配，需与主库使用相同的账号和数据库名
+	CustomDatabaseReplica struct {
+		Kind          CustomDatabaseKind          `json:"kind"`
+		DatabaseUrl   *wgpb.ConfigurationVariable `json:"databaseUrl"`
+		DatabaseAlone *CustomDatabaseAlone        `json:"databaseAlone"`
+	}
+	// CustomStatic 静态文件数据源，上传的json(对象数组或值为对象数组的对象)或csv文件
+	// KeyFields 指定每个表按键查询的列，未指定时使用id列
+	CustomStatic struct {
+		Filepath  string            `json:"filepath"`
+		KeyFields map[string]string `json:"keyFields,omitempty"`
+	}
+	// CustomGrpc grpc数据源，以graphql类型保存，上传的.proto文件或FileDescriptorSet文件
+	// Endpoint 服务地址，https为tls，http或未指定协议时为明文
+	// MethodOperations 指定一元方法为query或mutation，key为/package.Service/Method，未指定时按照方法名前缀推断
+	CustomGrpc struct {
+		Filepath         string                      `json:"filepath"`
+		Endpoint         *wgpb.ConfigurationVariable `json:"endpoint"`
+		Headers          map[string]*wgpb.HTTPHeader `json:"headers"`
+		MethodOperations map[string]string           `json:"methodOperations,omitempty"`
+		Timeout          int64                       `json:"timeout,omitempty"`
+	}
+	// CustomSoap soap数据源，以rest类型保存，上传的wsdl文件，导入的wsdl/xsd文件需上传到同一目录下
+	// Endpoint 服务地址，未设置时使用wsdl中声明的地址
+	// OperationKinds 指定操作为query或mutation，key为操作名称，未指定时按照操作名前缀推断
+	CustomSoap struct {
+		Filepath       string                      `json:"filepath"`
+		Endpoint       *wgpb.ConfigurationVariable `json:"endpoint,omitempty"`
+		Headers        map[string]*wgpb.HTTPHeader `json:"headers"`
+		OperationKinds map[string]string           `json:"operationKinds,omitempty"`
+		Timeout        int64                       `json:"timeout,omitempty"`
 	}
 	CustomDatabaseAlone struct {
 		Host     string `json:"host"`
 		Port     int32  `json:"port"`
 		Database string `json:"database"`
 		Username string `json:"username"`
-		Password string `json:"password"`
+		// Password 明文密码，设置PasswordVariable时忽略
+		Password         string                      `json:"password"`
+		PasswordVariable *wgpb.ConfigurationVariable `json:"passwordVariable,omitempty"`
+	}
+	// CustomAuth 数据源出站认证，按照oauth2/apiKey/basic的顺序取第一个配置生效
+	CustomAuth struct {
+		Oauth2 *CustomAuthOauth2 `json:"oauth2,omitempty"`
+		ApiKey *CustomAuthApiKey `json:"apiKey,omitempty"`
+		Basic  *CustomAuthBasic  `json:"basic,omitempty"`
+	}
+	// CustomAuthOauth2 oauth2客户端凭证模式，令牌在过期前RefreshBeforeExpiry秒刷新
+	CustomAuthOauth2 struct {
+		TokenUrl            *wgpb.ConfigurationVariable `json:"tokenUrl"`
+		ClientId            *wgpb.ConfigurationVariable `json:"clientId"`
+		ClientSecret        *wgpb.ConfigurationVariable `json:"clientSecret"`
+		Scopes              []string                    `json:"scopes"`
+		RefreshBeforeExpiry int64                       `json:"refreshBeforeExpiry"`
+	}
+	CustomAuthApiKey struct {
+		In    CustomAuthApiKeyIn          `json:"in"`
+		Name  string                      `json:"name"`
+		Value *wgpb.ConfigurationVariable `json:"value"`
+	}
+	CustomAuthBasic struct {
+		Username *wgpb.ConfigurationVariable `json:"username"`
+		Password *wgpb.ConfigurationVariable `json:"password"`
 	}
 )
 
+type CustomAuthApiKeyIn string
+
+const (
+	CustomAuthApiKeyInHeader CustomAuthApiKeyIn = "header"
+	CustomAuthApiKeyInQuery  CustomAuthApiKeyIn = "query"
+)
+
 func (d *Datasource) IsCustomDatabase() bool {
 	return slices.Contains(databaseKinds, d.Kind)
 }
@@ -80,13 +181,19 @@ func (c *CustomGraphql) GetGraphqlUrlVariables(dsName string) (url, baseUrl, pat
 		return
 	}
 
+	path = utils.MakeStaticVariable(fmt.Sprintf(`/gqls/%s/graphql`, dsName))
+	// 使用内置钩子桩服务时未配置钩子服务地址，由引擎启动时替换
+	if HookStubUsed() {
+		return
+	}
+
 	serverOptions := configs.GlobalSettingRoot.FirstData().ServerOptions
 	if serverOptions == nil || serverOptions.ServerUrl == nil {
 		err = i18n.NewCustomErrorWithMode(DatasourceRoot.GetModelName(), nil, i18n.SettingServerUrlEmptyError)
 		return
 	}
 
-	baseUrl, path = serverOptions.ServerUrl, utils.MakeStaticVariable(fmt.Sprintf(`/gqls/%s/graphql`, dsName))
+	baseUrl = serverOptions.ServerUrl
 	return
 }
 
@@ -103,11 +210,55 @@ func (c *CustomDatabase) GetDatabaseUrl(dsKind wgpb.DataSourceKind, dsName strin
 		return "", i18n.NewCustomErrorWithMode(DatasourceRoot.GetModelName(), nil, i18n.DatasourceKindNotSupportedError, dsKind)
 	}
 
-	return urlFunc(c, dsName), nil
+	databaseUrl := urlFunc(c, dsName)
+	if databaseUrl == "" || c.Options == nil {
+		return databaseUrl, nil
+	}
+
+	return c.Options.appendToUrl(databaseUrl, dsKind), nil
+}
+
+// GetReplicaDatabaseUrls 只读副本的连接地址，使用与主库相同的连接参数
+func (c *CustomDatabase) GetReplicaDatabaseUrls(dsKind wgpb.DataSourceKind, dsName string) (urls []string, err error) {
+	for _, item := range c.Replicas {
+		replica := &CustomDatabase{Kind: item.Kind, DatabaseUrl: item.DatabaseUrl, DatabaseAlone: item.DatabaseAlone, Options: c.Options}
+		var replicaUrl string
+		if replicaUrl, err = replica.GetDatabaseUrl(dsKind, dsName); err != nil {
+			return
+		}
+		if replicaUrl != "" {
+			urls = append(urls, replicaUrl)
+		}
+	}
+	return
+}
+
+func (c *CustomDatabaseAlone) GetPassword() string {
+	if password := utils.GetVariableString(c.PasswordVariable); password != "" {
+		return password
+	}
+
+	return c.Password
+}
+
+// SetDatasourceSchemaDrifted 标记数据源上游schema是否漂移
+// 轮询协程与接口并发读写，且数据源重新加载后需保留，因此不存储在数据源对象上
+func SetDatasourceSchemaDrifted(dsName string, drifted bool) {
+	if drifted {
+		datasourceSchemaDrifts.Store(dsName, true)
+	} else {
+		datasourceSchemaDrifts.Delete(dsName)
+	}
+}
+
+// DatasourceSchemaDrifted 数据源上游schema是否漂移
+func DatasourceSchemaDrifted(dsName string) bool {
+	return datasourceSchemaDrifts.Contains(dsName)
 }
 
 var (
 	DatasourceRoot           *fileloader.Model[Datasource]
+	datasourceSchemaDrifts   utils.SyncMap[string, bool]
 	databaseKindUrlFuncMap   map[wgpb.DataSourceKind]func(*CustomDatabase, string) string
 	databaseKindAloneFuncMap map[wgpb.DataSourceKind]func(*CustomDatabase, string) string
 	databaseKinds            = []wgpb.DataSourceKind{
@@ -121,8 +272,8 @@ var (
 )
 
 const (
-	databaseUrlFormat = `%s://%s:%s@%s:%d/%s`
-	mongodbUrlFormat  = `%s+srv://%s:%s@%s:%d/%s`
+	databaseUrlFormat = `%s://%s@%s:%d/%s`
+	mongodbUrlFormat  = `%s+srv://%s@%s:%d/%s`
 	sqliteUrlFormat   = `file:%s`
 )
 
@@ -163,7 +314,7 @@ func init() {
 				return nil
 			}
 
-			result := map[string]any{fieldEnabled: data.Enabled, "kind": data.Kind}
+			result := map[string]any{fieldEnabled: data.Enabled, "kind": data.Kind, "schemaDrifted": DatasourceSchemaDrifted(data.Name)}
 			if data.CustomGraphql != nil {
 				result["customized"] = data.CustomGraphql.Customized
 			}
@@ -186,8 +337,13 @@ func init() {
 	}
 
 	databaseAloneFunc := func(c *CustomDatabaseAlone, kind wgpb.DataSourceKind, format string) string {
+		if c == nil {
+			return ""
+		}
+
 		databaseKind := strings.ToLower(kind.String())
-		return fmt.Sprintf(format, databaseKind, c.Username, c.Password, c.Host, c.Port, c.Database)
+		userinfo := url.UserPassword(c.Username, c.GetPassword()).String()
+		return fmt.Sprintf(format, databaseKind, userinfo, c.Host, c.Port, c.Database)
 	}
 	databaseKindAloneFuncMap = make(map[wgpb.DataSourceKind]func(*CustomDatabase, string) string)
 	databaseKindAloneFuncMap[wgpb.DataSourceKind_POSTGRESQL] = func(alone *CustomDatabase, _ string) string {
@@ -0,0 +1,135 @@
+// Package models
+/*
+ 数据库类型数据源的连接参数
+ 按照数据库类型将结构化的参数转换成prisma支持的连接参数并追加到连接地址上
+ sqlserver使用分号分隔参数，mongodb使用驱动的参数名，其他数据库使用url查询参数
+ ssl参数按照prisma连接器区分，postgresql使用sslmode，mysql仅支持sslaccept/sslcert等证书参数
+*/
+package models
+
+import (
+	"fireboom-server/pkg/common/utils"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	databaseSslModeDisable        = "disable"
+	databaseSslAcceptInvalidCerts = "accept_invalid_certs"
+	databaseSslAcceptStrict       = "strict"
+	databaseMillisecondsPerSecond = 1000
+	databaseUrlQuerySeparator     = "?"
+	sqlserverParamSeparator       = ";"
+)
+
+// 转换成参数名和参数值，值为空的参数忽略
+func (o *CustomDatabaseOptions) buildParams(dsKind wgpb.DataSourceKind) map[string]string {
+	params := make(map[string]string)
+	setParam := func(name, value string) {
+		if value != "" {
+			params[name] = value
+		}
+	}
+	setIntParam := func(name string, value int64) {
+		if value > 0 {
+			params[name] = strconv.FormatInt(value, 10)
+		}
+	}
+	var sslEnabled string
+	if o.SslMode != "" {
+		sslEnabled = strconv.FormatBool(o.SslMode != databaseSslModeDisable)
+	}
+	switch dsKind {
+	case wgpb.DataSourceKind_SQLSERVER:
+		setParam("encrypt", sslEnabled)
+		if o.SslAccept == databaseSslAcceptInvalidCerts {
+			setParam("trustServerCertificate", "true")
+		}
+		setIntParam("connectionLimit", o.ConnectionLimit)
+		setIntParam("poolTimeout", o.PoolTimeout)
+		setIntParam("connectTimeout", o.ConnectTimeout)
+		setParam("schema", o.Schema)
+	case wgpb.DataSourceKind_MONGODB:
+		setParam("tls", sslEnabled)
+		if o.SslAccept == databaseSslAcceptInvalidCerts {
+			setParam("tlsAllowInvalidCertificates", "true")
+		}
+		setParam("tlsCAFile", o.SslCert)
+		setParam("tlsCertificateKeyFile", o.SslIdentity)
+		setParam("tlsCertificateKeyFilePassword", utils.GetVariableString(o.SslPassword))
+		setIntParam("maxPoolSize", o.ConnectionLimit)
+		setIntParam("waitQueueTimeoutMS", o.PoolTimeout*databaseMillisecondsPerSecond)
+		setIntParam("connectTimeoutMS", o.ConnectTimeout*databaseMillisecondsPerSecond)
+	default:
+		switch dsKind {
+		case wgpb.DataSourceKind_POSTGRESQL:
+			setParam("sslmode", o.SslMode)
+			o.setSslParams(setParam, o.SslAccept)
+		case wgpb.DataSourceKind_MYSQL:
+			// mysql不支持sslmode参数，开启ssl且未指定证书校验方式时使用严格校验
+			sslAccept := o.SslAccept
+			if sslAccept == "" && sslEnabled == "true" {
+				sslAccept = databaseSslAcceptStrict
+			}
+			o.setSslParams(setParam, sslAccept)
+		}
+		setIntParam("connection_limit", o.ConnectionLimit)
+		setIntParam("pool_timeout", o.PoolTimeout)
+		setIntParam("connect_timeout", o.ConnectTimeout)
+		// mysql的database即schema，不支持schema参数
+		if dsKind != wgpb.DataSourceKind_MYSQL {
+			setParam("schema", o.Schema)
+		}
+	}
+	for name, value := range o.QueryParams {
+		setParam(name, value)
+	}
+	return params
+}
+
+// postgresql和mysql共用的ssl证书参数，sqlite不支持ssl参数
+func (o *CustomDatabaseOptions) setSslParams(setParam func(string, string), sslAccept string) {
+	setParam("sslcert", o.SslCert)
+	setParam("sslidentity", o.SslIdentity)
+	setParam("sslpassword", utils.GetVariableString(o.SslPassword))
+	setParam("sslaccept", sslAccept)
+}
+
+// 追加连接参数，连接地址中已存在的同名参数被覆盖
+func (o *CustomDatabaseOptions) appendToUrl(databaseUrl string, dsKind wgpb.DataSourceKind) string {
+	params := o.buildParams(dsKind)
+	if len(params) == 0 {
+		return databaseUrl
+	}
+
+	names := maps.Keys(params)
+	slices.Sort(names)
+	if dsKind == wgpb.DataSourceKind_SQLSERVER {
+		segments := strings.Split(strings.TrimSuffix(databaseUrl, sqlserverParamSeparator), sqlserverParamSeparator)
+		keptSegments := segments[:1]
+		for _, item := range segments[1:] {
+			if name, _, _ := strings.Cut(item, "="); params[name] == "" {
+				keptSegments = append(keptSegments, item)
+			}
+		}
+		for _, name := range names {
+			keptSegments = append(keptSegments, name+"="+params[name])
+		}
+		return strings.Join(keptSegments, sqlserverParamSeparator)
+	}
+
+	baseUrl, rawQuery, _ := strings.Cut(databaseUrl, databaseUrlQuerySeparator)
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		query = make(url.Values)
+	}
+	for _, name := range names {
+		query.Set(name, params[name])
+	}
+	return baseUrl + databaseUrlQuerySeparator + query.Encode()
+}
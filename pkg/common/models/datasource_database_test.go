@@ -0,0 +1,36 @@
+package models
+
+import (
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"testing"
+)
+
+func TestCustomDatabaseOptions_appendToUrl(t *testing.T) {
+	options := &CustomDatabaseOptions{
+		SslMode:         "require",
+		ConnectionLimit: 5,
+		PoolTimeout:     10,
+		Schema:          "app",
+		QueryParams:     map[string]string{"application_name": "fireboom"},
+	}
+	for _, item := range []struct {
+		kind     wgpb.DataSourceKind
+		url      string
+		expected string
+	}{
+		{wgpb.DataSourceKind_POSTGRESQL, "postgresql://u:p%40ss@db:5432/app?schema=public",
+			"postgresql://u:p%40ss@db:5432/app?application_name=fireboom&connection_limit=5&pool_timeout=10&schema=app&sslmode=require"},
+		{wgpb.DataSourceKind_MYSQL, "mysql://u:p@db:3306/app",
+			"mysql://u:p@db:3306/app?application_name=fireboom&connection_limit=5&pool_timeout=10&sslaccept=strict"},
+		{wgpb.DataSourceKind_SQLITE, "file:./dev.db",
+			"file:./dev.db?application_name=fireboom&connection_limit=5&pool_timeout=10&schema=app"},
+		{wgpb.DataSourceKind_SQLSERVER, "sqlserver://db:1433;database=app;schema=dbo;",
+			"sqlserver://db:1433;database=app;application_name=fireboom;connectionLimit=5;encrypt=true;poolTimeout=10;schema=app"},
+		{wgpb.DataSourceKind_MONGODB, "mongodb+srv://u:p@db/app",
+			"mongodb+srv://u:p@db/app?application_name=fireboom&maxPoolSize=5&tls=true&waitQueueTimeoutMS=10000"},
+	} {
+		if actual := options.appendToUrl(item.url, item.kind); actual != item.expected {
+			t.Errorf("%s: expected %s, got %s", item.kind, item.expected, actual)
+		}
+	}
+}
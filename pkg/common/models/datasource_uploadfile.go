@@ -6,6 +6,9 @@
  DatasourceUploadPrisma prisma数据源依赖的文件
  DatasourceUploadSqlite sqlite数据源依赖的文件
  DatasourceUploadGraphql graphql数据源依赖的文件
+ DatasourceUploadStatic 静态数据源依赖的json/csv文件
+ DatasourceUploadGrpc grpc数据源依赖的proto/descriptor文件
+ DatasourceUploadSoap soap数据源依赖的wsdl/xsd文件
  提供GetDatasourceUploadFilepath函数，根据类型不同返回文件路径
  当钩子配置变更时，自动重置路径字典
 */
@@ -24,11 +27,15 @@ var (
 	DatasourceUploadPrisma   *fileloader.ModelText[Datasource]
 	DatasourceUploadSqlite   *fileloader.ModelText[Datasource]
 	DatasourceUploadGraphql  *fileloader.ModelText[Datasource]
+	DatasourceUploadStatic   *fileloader.ModelText[Datasource]
+	DatasourceUploadGrpc     *fileloader.ModelText[Datasource]
+	DatasourceUploadSoap     *fileloader.ModelText[Datasource]
 
 	datasourceUploadFileMap map[wgpb.DataSourceKind]*fileloader.ModelText[Datasource]
 )
 
-func buildDatasourceUploadFile(parent string, pathFunc func(*Datasource) string, kind wgpb.DataSourceKind) *fileloader.ModelText[Datasource] {
+// kind为空时需要在GetDatasourceUploadFilepath中按照自定义配置匹配
+func buildDatasourceUploadFile(parent string, pathFunc func(*Datasource) string, kind ...wgpb.DataSourceKind) *fileloader.ModelText[Datasource] {
 	item := &fileloader.ModelText[Datasource]{
 		Title:                  parent,
 		Root:                   utils.NormalizePath(consts.RootUpload, parent),
@@ -48,7 +55,9 @@ func buildDatasourceUploadFile(parent string, pathFunc func(*Datasource) string,
 		},
 	}
 
-	datasourceUploadFileMap[kind] = item
+	if len(kind) > 0 {
+		datasourceUploadFileMap[kind[0]] = item
+	}
 	utils.RegisterInitMethod(20, func() {
 		item.RelyModel = DatasourceRoot
 		item.Init()
@@ -60,6 +69,12 @@ func buildDatasourceUploadFile(parent string, pathFunc func(*Datasource) string,
 // GetDatasourceUploadFilepath 根据类型不同返回文件路径
 func GetDatasourceUploadFilepath(data *Datasource) string {
 	uploadFile, ok := datasourceUploadFileMap[data.Kind]
+	switch {
+	case data.CustomGrpc != nil:
+		uploadFile, ok = DatasourceUploadGrpc, true
+	case data.CustomSoap != nil:
+		uploadFile, ok = DatasourceUploadSoap, true
+	}
 	if !ok {
 		return ""
 	}
@@ -96,4 +111,22 @@ func init() {
 		}
 		return datasource.CustomGraphql.SchemaFilepath
 	}, wgpb.DataSourceKind_GRAPHQL)
+	DatasourceUploadStatic = buildDatasourceUploadFile(consts.UploadStaticParent, func(datasource *Datasource) string {
+		if datasource.CustomStatic == nil {
+			return ""
+		}
+		return datasource.CustomStatic.Filepath
+	}, wgpb.DataSourceKind_STATIC)
+	DatasourceUploadGrpc = buildDatasourceUploadFile(consts.UploadGrpcParent, func(datasource *Datasource) string {
+		if datasource.CustomGrpc == nil {
+			return ""
+		}
+		return datasource.CustomGrpc.Filepath
+	})
+	DatasourceUploadSoap = buildDatasourceUploadFile(consts.UploadSoapParent, func(datasource *Datasource) string {
+		if datasource.CustomSoap == nil {
+			return ""
+		}
+		return datasource.CustomSoap.Filepath
+	})
 }
@@ -2,6 +2,10 @@
 /*
  提供快捷构建钩子代码文件路径的管理
  初始化时设置pathFunc和enabledFunc并在运行时返回真实的结果
+ dev模式开启enable-hook-stub且未配置钩子服务地址时，钩子服务地址指向飞布服务上的内置桩服务
+ 引擎调用的钩子服务地址仅在引擎启动时替换，编译生成的配置保留原配置
+ 开启enable-hook-trace时，引擎调用的钩子服务地址指向飞布服务上的转发路由，用来记录每次钩子调用
+ 转发路由的路径中携带启动时生成的标识码，仅允许引擎调用
 */
 package models
 
@@ -10,6 +14,14 @@ import (
 	"fireboom-server/pkg/common/consts"
 	"fireboom-server/pkg/common/utils"
 	"fireboom-server/pkg/plugins/fileloader"
+	"fmt"
+)
+
+const (
+	// HookStubRoutePrefix 内置钩子桩服务在飞布服务上的路由前缀
+	HookStubRoutePrefix = "/hookStub"
+	// HookTraceRoutePrefix 钩子调用记录转发在飞布服务上的路由前缀
+	HookTraceRoutePrefix = "/hookTrace"
 )
 
 type (
@@ -45,5 +57,42 @@ func getHookOptionResultItem(option *hookOption, dataName string, optional ...st
 }
 
 func GetHookServerUrl() string {
-	return utils.GetVariableString(configs.GlobalSettingRoot.FirstData().ServerOptions.ServerUrl)
+	if HookStubUsed() {
+		return fmt.Sprintf("http://localhost:%s%s", utils.GetStringWithLockViper(consts.WebPort), HookStubRoutePrefix)
+	}
+
+	return getConfiguredHookServerUrl()
+}
+
+// GetEngineHookServerUrl 引擎调用的钩子服务地址，开启钩子调用记录时经过飞布服务转发
+func GetEngineHookServerUrl() string {
+	if HookTraceUsed() {
+		return fmt.Sprintf("http://localhost:%s%s/%s", utils.GetStringWithLockViper(consts.WebPort), HookTraceRoutePrefix, utils.RandomIdentifyCode)
+	}
+
+	return GetHookServerUrl()
+}
+
+// HookServerUrlRewritten 引擎调用的钩子服务地址是否与配置的不同
+func HookServerUrlRewritten() bool {
+	return HookStubUsed() || HookTraceUsed()
+}
+
+// HookTraceUsed 是否记录钩子调用，未配置钩子服务地址时无需记录
+func HookTraceUsed() bool {
+	return utils.GetBoolWithLockViper(consts.EnableHookTrace) && GetHookServerUrl() != ""
+}
+
+// HookStubUsed 是否使用内置钩子桩服务
+func HookStubUsed() bool {
+	return utils.GetBoolWithLockViper(consts.DevMode) && utils.GetBoolWithLockViper(consts.EnableHookStub) && getConfiguredHookServerUrl() == ""
+}
+
+func getConfiguredHookServerUrl() string {
+	serverOptions := configs.GlobalSettingRoot.FirstData().ServerOptions
+	if serverOptions == nil {
+		return ""
+	}
+
+	return utils.GetVariableString(serverOptions.ServerUrl)
 }
@@ -31,7 +31,9 @@ type Operation struct {
 	Engine             wgpb.OperationExecutionEngine     `json:"engine"`
 	HooksConfiguration *wgpb.OperationHooksConfiguration `json:"hooksConfiguration"`
 	RateLimit          *wgpb.OperationRateLimit          `json:"rateLimit"`
+	RateLimitTiers     []*OperationRateLimitTier         `json:"rateLimitTiers"`
 	Semaphore          *wgpb.OperationSemaphore          `json:"semaphore"`
+	Deprecation        *OperationDeprecation             `json:"deprecation"`
 
 	ConfigCustomized        bool                                `json:"configCustomized"`
 	CacheConfig             *wgpb.OperationCacheConfig          `json:"cacheConfig"`
@@ -47,10 +49,74 @@ type Operation struct {
 	ContentModifiedTime time.Time                          `json:"-"`
 }
 
+// OperationRateLimitTier 按调用方标识限流的层级，keyBy为CLAIM/HEADER/IP，appliesTo为空时等同ALL
+type OperationRateLimitTier struct {
+	Name          string `json:"name"`
+	KeyBy         string `json:"keyBy"`
+	Key           string `json:"key"`
+	Requests      int64  `json:"requests"`
+	WindowSeconds int64  `json:"windowSeconds"`
+	AppliesTo     string `json:"appliesTo"`
+}
+
+// OperationDeprecation 接口弃用配置，replacement为替代接口路径，sunsetDate为下线时间
+type OperationDeprecation struct {
+	Reason      string `json:"reason"`
+	Replacement string `json:"replacement"`
+	SunsetDate  string `json:"sunsetDate"`
+}
+
+// OperationMaskField @mask声明的脱敏字段，path为响应中的字段路径，数组使用'[]'表示
+type OperationMaskField struct {
+	Path        []string `json:"path"`
+	Strategy    string   `json:"strategy"`
+	KeepFirst   int      `json:"keepFirst,omitempty"`
+	KeepLast    int      `json:"keepLast,omitempty"`
+	MaskChar    string   `json:"maskChar,omitempty"`
+	Replacement string   `json:"replacement,omitempty"`
+	ExceptRoles []string `json:"exceptRoles,omitempty"`
+	Rule        string   `json:"rule,omitempty"`
+}
+
+// OperationValidationRule @validate声明的入参校验规则，expression结果为false时返回code/message，fields为校验失败的参数路径
+type OperationValidationRule struct {
+	Name       string   `json:"name"`
+	Expression string   `json:"expression"`
+	Fields     []string `json:"fields,omitempty"`
+	Code       string   `json:"code"`
+	Message    string   `json:"message"`
+}
+
+// OperationResponseCache @cache/@invalidateCache声明的响应缓存，variables/claims为参与计算缓存键的参数和用户claim
+type OperationResponseCache struct {
+	TtlSeconds     int64    `json:"ttlSeconds,omitempty"`
+	Variables      []string `json:"variables,omitempty"`
+	Claims         []string `json:"claims,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	InvalidateTags []string `json:"invalidateTags,omitempty"`
+}
+
+// OperationPagination @paginate声明的游标分页，path为响应中的列表路径，first/after转换为take/skip/cursor参数后请求
+// 游标为cursorFields字段值的base64编码，totalCountPath为同级aggregate计数字段的路径
+type OperationPagination struct {
+	Path           []string `json:"path"`
+	Shape          string   `json:"shape"`
+	CursorFields   []string `json:"cursorFields"`
+	FirstVariable  string   `json:"firstVariable"`
+	AfterVariable  string   `json:"afterVariable"`
+	TakeVariable   string   `json:"takeVariable"`
+	SkipVariable   string   `json:"skipVariable"`
+	CursorVariable string   `json:"cursorVariable"`
+	DefaultFirst   int64    `json:"defaultFirst"`
+	MaxFirst       int64    `json:"maxFirst"`
+	TotalCountPath []string `json:"totalCountPath,omitempty"`
+}
+
 type operationExtra struct {
 	Enabled          bool                          `json:"enabled"`
 	Invalid          bool                          `json:"invalid"`
 	Internal         bool                          `json:"internal"`
+	Deprecated       bool                          `json:"deprecated"`
 	LiveQueryEnabled bool                          `json:"liveQueryEnabled"`
 	Method           string                        `json:"method"`
 	OperationType    wgpb.OperationType            `json:"operationType"`
@@ -59,6 +125,27 @@ type operationExtra struct {
 
 const fieldOriginContent = "originContent"
 
+const (
+	RateLimitKeyByClaim  = "CLAIM"
+	RateLimitKeyByHeader = "HEADER"
+	RateLimitKeyByIp     = "IP"
+
+	RateLimitAppliesToAll           = "ALL"
+	RateLimitAppliesToAuthenticated = "AUTHENTICATED"
+	RateLimitAppliesToAnonymous     = "ANONYMOUS"
+
+	MaskStrategyKeep  = "KEEP"
+	MaskStrategyFixed = "FIXED"
+	MaskStrategyHash  = "HASH"
+	MaskStrategyNull  = "NULL"
+
+	PaginateShapeEdges = "EDGES"
+	PaginateShapeItems = "ITEMS"
+)
+
+// 下线时间支持日期或RFC3339格式，日期格式按本地时区当天零点计算
+var operationSunsetLayouts = []string{time.DateOnly, time.RFC3339}
+
 var (
 	OperationRoot      *fileloader.Model[Operation]
 	OperationMethodMap map[wgpb.OperationType]string
@@ -79,13 +166,15 @@ func init() {
 		DataHook: &fileloader.DataHook[Operation]{
 			OnInsert: func(item *Operation) error {
 				item.CreateTime = utils.TimeFormatNow()
-				return nil
+				_, err := item.SunsetTime()
+				return err
 			},
 			OnUpdate: func(_, dst *Operation, user string) error {
 				if user != fileloader.SystemUser {
 					dst.UpdateTime = utils.TimeFormatNow()
 				}
-				return nil
+				_, err := dst.SunsetTime()
+				return err
 			},
 			AfterInsert: func(item *Operation, user string) bool { return item.Enabled },
 			AfterBatchInsert: func(datas []*Operation, _ string, extraBytes ...[]byte) bool {
@@ -110,6 +199,7 @@ func init() {
 			return &operationExtra{
 				Enabled:          item.Enabled,
 				Internal:         item.Internal,
+				Deprecated:       item.Deprecation != nil,
 				Invalid:          item.Invalid,
 				LiveQueryEnabled: item.LiveQueryConfig != nil && item.LiveQueryConfig.Enabled,
 				Method:           OperationMethodMap[item.OperationType],
@@ -136,3 +226,23 @@ func init() {
 		utils.AddBuildAndStartFuncWatcher(func(f func()) { OperationRoot.DataHook.AfterMutate = f })
 	})
 }
+
+// SunsetTime 解析弃用配置中的下线时间，未弃用或未设置时返回零值
+func (o *Operation) SunsetTime() (sunset time.Time, err error) {
+	if o.Deprecation == nil || o.Deprecation.SunsetDate == "" {
+		return
+	}
+
+	for _, layout := range operationSunsetLayouts {
+		if sunset, err = time.ParseInLocation(layout, o.Deprecation.SunsetDate, time.Local); err == nil {
+			return
+		}
+	}
+	return
+}
+
+// SunsetPassed 判断接口是否已过下线时间
+func (o *Operation) SunsetPassed() bool {
+	sunset, err := o.SunsetTime()
+	return err == nil && !sunset.IsZero() && time.Now().After(sunset)
+}
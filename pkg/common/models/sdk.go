@@ -41,6 +41,8 @@ type Sdk struct {
 	CodePackage        string   `json:"codePackage"`
 	UpperFirstBasename bool     `json:"upperFirstBasename"`
 	Keywords           []string `json:"keywords"`
+	// Supervisor 服务端sdk由飞布托管进程时的启动配置
+	Supervisor *SdkSupervisor `json:"supervisor,omitempty"`
 
 	CreateTime  string `json:"createTime"`
 	UpdateTime  string `json:"updateTime"`
@@ -52,6 +54,17 @@ type Sdk struct {
 	Description string `json:"description"`
 }
 
+// SdkSupervisor dev模式或start模式开启enable-hook-supervisor时，飞布按照此配置启动钩子服务
+// Workdir 未设置时使用OutputPath，StartupTimeout 启动后开始健康检查前的等待秒数
+// WatchDisabled 关闭dev模式下钩子文件变更后的自动重启
+type SdkSupervisor struct {
+	Command        []string          `json:"command"`
+	Workdir        string            `json:"workdir,omitempty"`
+	Env            map[string]string `json:"env,omitempty"`
+	StartupTimeout int64             `json:"startupTimeout,omitempty"`
+	WatchDisabled  bool              `json:"watchDisabled,omitempty"`
+}
+
 var SdkRoot *fileloader.Model[Sdk]
 
 func init() {
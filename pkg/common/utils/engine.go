@@ -12,6 +12,8 @@ var (
 
 	// BuildAndStart 引擎编译函数
 	BuildAndStart func()
+	// RestartEngine 引擎重启函数(不重新编译)
+	RestartEngine func()
 	// InvokeFunctionLimit license触发限制函数
 	InvokeFunctionLimit func(string, ...int) bool
 	// ReloadPrismaCache 刷新prisma缓存函数
@@ -117,10 +117,14 @@ func (x MultiFormatSchemaRef) MarshalYAML() (interface{}, error) {
 }
 
 // MarshalJSON returns the JSON encoding of SchemaRef.
+// When schemaFormat is empty the value is encoded as a plain Schema Object.
 func (x MultiFormatSchemaRef) MarshalJSON() ([]byte, error) {
 	if ref := x.Ref; ref != "" {
 		return json.Marshal(openapi3.Ref{Ref: ref})
 	}
+	if x.Value != nil && x.Value.SchemaFormat == "" {
+		return json.Marshal(x.Value.Schema)
+	}
 	return json.Marshal(x.Value)
 }
 
@@ -20,7 +20,7 @@ type (
 		// A verbose explanation of the operation. CommonMark syntax can be used for rich text representation.
 		Description string `json:"description" yaml:"description"`
 		// A declaration of which security schemes are associated with this operation. Only one of the security scheme objects MUST be satisfied to authorize an operation. In cases where Server Security also applies, it MUST also be satisfied.
-		Security *SecuritySchemeRef `json:"security" yaml:"security"`
+		Security []*SecuritySchemeRef `json:"security" yaml:"security"`
 		// A list of tags for logical grouping and categorization of operations.
 		Tags Tags `json:"tags" yaml:"tags"`
 		// Additional external documentation for this operation.
@@ -26,7 +26,7 @@ type (
 		// A map between a variable name and its value. The value is used for substitution in the server's host and pathname template.
 		Variables ServerVariables `json:"variables" yaml:"variables"`
 		// A declaration of which security schemes can be used with this server. The list of values includes alternative security scheme objects that can be used. Only one of the security scheme objects need to be satisfied to authorize a connection or operation.
-		Security *SecuritySchemeRef `json:"security" yaml:"security"`
+		Security []*SecuritySchemeRef `json:"security" yaml:"security"`
 		// A list of tags for logical grouping and categorization of servers.
 		Tags Tags `json:"tags" yaml:"tags"`
 		// Additional external documentation for this server.
@@ -24,6 +24,7 @@ var (
 	GeneratedOperationsConfigRoot *fileloader.Model[OperationsConfig]
 	GeneratedSwaggerText          *fileloader.ModelText[any]
 	GeneratedHookSwaggerText      *fileloader.ModelText[any]
+	GeneratedAsyncapiText         *fileloader.ModelText[any]
 	generatedDirname              = utils.NormalizePath(consts.RootExported, consts.ExportedGeneratedParent)
 
 	// 所有需要执行的编译，通过key排序确定执行顺序
@@ -50,8 +51,21 @@ type (
 	}
 	ResolveFetch       func() Resolve
 	AsyncGenerateFetch func() AsyncGenerate
+	// DatasourceIncrement 数据源增量编译结果，在数据源、接口编译和引擎启动的事件订阅间传递
+	DatasourceIncrement struct {
+		Event   eventbus.Event
+		Names   []string
+		Builder *Builder
+	}
 )
 
+// DatasourceIncrementEvents 支持增量编译的数据源变更事件，启用/禁用数据源通过更新事件处理
+var DatasourceIncrementEvents = []eventbus.Event{
+	eventbus.EventInsert, eventbus.EventBatchInsert,
+	eventbus.EventUpdate, eventbus.EventBatchUpdate,
+	eventbus.EventDelete, eventbus.EventBatchDelete,
+}
+
 func (f *LazyFieldHash) Hash() string {
 	if f.hashValue == "" {
 		f.hashValue, f.lazyFunc = f.lazyFunc(), nil
@@ -146,6 +160,13 @@ func init() {
 			Name: consts.ExportedGeneratedHookSwaggerFilename,
 		},
 	}
+	GeneratedAsyncapiText = &fileloader.ModelText[any]{
+		Root:      generatedDirname,
+		Extension: fileloader.ExtJson,
+		TextRW: &fileloader.SingleTextRW[any]{
+			Name: consts.ExportedGeneratedAsyncapiFilename,
+		},
+	}
 
 	utils.RegisterInitMethod(30, func() {
 		logger = zap.L()
@@ -157,5 +178,6 @@ func init() {
 		GeneratedOperationsConfigRoot.Init()
 		GeneratedSwaggerText.Init()
 		GeneratedHookSwaggerText.Init()
+		GeneratedAsyncapiText.Init()
 	})
 }
@@ -39,26 +39,107 @@ type (
 		definition   *ast.Definition
 		fieldIndexes map[string]int
 	}
+	// 单个数据源的编译结果，增量编译时仅重新生成变更的数据源后与其他数据源合并
+	datasourceItem struct {
+		name                     string
+		config                   *wgpb.DataSourceConfiguration
+		document                 *ast.SchemaDocument
+		rootOperationTypeNameMap map[string]string
+		fieldConfigurations      []*wgpb.FieldConfiguration
+		typeConfigurations       []*wgpb.TypeConfiguration
+		typeConfigurationFlags   map[string]bool
+		fieldArgumentTypeNames   map[string][]string
+	}
 	engineConfiguration struct {
 		modelName              string
+		builder                *Builder
 		engineConfig           *wgpb.EngineConfiguration
-		typeConfigurationFlags map[string]bool
 		fieldArgumentTypeNames map[string][]string
 		rootDefinitionInfos    map[string]*rootDefinitionInfo
+		datasourceItems        []*datasourceItem
 	}
 )
 
 func (e *engineConfiguration) Resolve(builder *Builder) (err error) {
+	e.builder = builder
 	sources := models.DatasourceRoot.ListByCondition(func(item *models.Datasource) bool { return item.Enabled })
 	if len(sources) == 0 {
 		logger.Warn("empty datasource")
 		return
 	}
 
+	e.datasourceItems = make([]*datasourceItem, 0, len(sources))
+	for _, ds := range sources {
+		// 判断数据源数量是否触发限制
+		if utils.InvokeFunctionLimit(e.modelName, len(e.datasourceItems)) {
+			break
+		}
+
+		item, itemErr := e.buildDatasourceItem(ds, false)
+		if item == nil {
+			e.printIntrospectError(itemErr, ds.Name)
+			continue
+		}
+
+		e.datasourceItems = append(e.datasourceItems, item)
+	}
+
+	err = e.mergeDatasourceItems()
+	return
+}
+
+// 内省单个数据源并添加数据源命名，返回nil时表示编译失败
+// incremental 增量编译时忽略上次编译后写入的内省缓存
+func (e *engineConfiguration) buildDatasourceItem(ds *models.Datasource, incremental bool) (item *datasourceItem, err error) {
+	// 添加类型获取不同的处理函数及schema文本
+	itemAction, itemSchema, err := e.fetchDatasourceGraphqlSchema(ds, incremental)
+	if err != nil {
+		return
+	}
+
+	// 将文本统一转换成一致的文档用作后续处理
+	itemDocument, err := parser.ParseSchema(&ast.Source{Name: ds.Name, Input: itemSchema})
+	if err != nil {
+		return
+	}
+
+	// 调用不同的函数构建引擎所需配置
+	itemConfig, err := itemAction.BuildDataSourceConfiguration(itemDocument)
+	if err != nil || itemConfig == nil {
+		return
+	}
+
+	if extend, ok := itemAction.(datasource.ActionExtend); ok {
+		extend.ExtendDocument(itemDocument)
+	}
+
+	// 将数据源名称添加到graphql命名中
+	item = &datasourceItem{
+		name:                   ds.Name,
+		document:               itemDocument,
+		typeConfigurationFlags: make(map[string]bool),
+		fieldArgumentTypeNames: make(map[string][]string),
+	}
+	itemRename := newDataSourceRename(ds.Name, itemDocument, item, itemAction)
+	itemRename.resolve()
+
+	itemConfig.Id = ds.Name
+	itemConfig.Kind = ds.Kind
+	itemConfig.RootNodes = itemRename.rootNodes
+	itemConfig.ChildNodes = itemRename.childNodes
+	item.config = itemConfig
+	item.rootOperationTypeNameMap = itemRename.rootOperationTypeNameMap
+	item.typeConfigurationFlags = nil
+	logger.Debug("build datasource succeed", zap.String(e.modelName, ds.Name))
+	return
+}
+
+// 合并所有数据源的编译结果，生成graphql文档和引擎配置
+func (e *engineConfiguration) mergeDatasourceItems() (err error) {
 	e.engineConfig = &wgpb.EngineConfiguration{}
-	e.typeConfigurationFlags = make(map[string]bool, math.MaxUint8)
 	e.fieldArgumentTypeNames = make(map[string][]string, math.MaxUint8)
 	e.rootDefinitionInfos = make(map[string]*rootDefinitionInfo)
+	typeConfigurationFlags := make(map[string]bool, math.MaxUint8)
 	directiveMap, otherDefinitionMap := make(map[string]*ast.DirectiveDefinition), make(map[string]*ast.Definition, math.MaxInt8)
 	// 添加根类型Query/Mutation/Subscription
 	for _, name := range datasource.RootObjectNames {
@@ -68,57 +149,27 @@ func (e *engineConfiguration) Resolve(builder *Builder) (err error) {
 		}
 	}
 
-	var itemAction datasource.Action
-	var itemSchema string
-	var itemDocument *ast.SchemaDocument
-	var itemConfig *wgpb.DataSourceConfiguration
-	var itemRename *dataSourceRename
-	for _, ds := range sources {
-		// 判断数据源数量是否触发限制
-		if utils.InvokeFunctionLimit(e.modelName, len(e.engineConfig.DatasourceConfigurations)) {
-			break
-		}
-
-		// 添加类型获取不同的处理函数及schema文本
-		if itemAction, itemSchema, err = e.fetchDatasourceGraphqlSchema(ds); err != nil {
-			e.printIntrospectError(err, ds.Name)
-			continue
-		}
-
-		// 将文本统一转换成一致的文档用作后续处理
-		if itemDocument, err = parser.ParseSchema(&ast.Source{Name: ds.Name, Input: itemSchema}); err != nil {
-			e.printIntrospectError(err, ds.Name)
-			continue
-		}
-
-		// 调用不同的函数构建引擎所需配置
-		if itemConfig, err = itemAction.BuildDataSourceConfiguration(itemDocument); err != nil || itemConfig == nil {
-			e.printIntrospectError(err, ds.Name)
-			continue
-		}
+	for _, item := range e.datasourceItems {
+		e.engineConfig.DatasourceConfigurations = append(e.engineConfig.DatasourceConfigurations, item.config)
+		e.engineConfig.FieldConfigurations = append(e.engineConfig.FieldConfigurations, item.fieldConfigurations...)
+		for _, typeConfig := range item.typeConfigurations {
+			if _, ok := typeConfigurationFlags[typeConfig.TypeName]; ok {
+				continue
+			}
 
-		if extend, ok := itemAction.(datasource.ActionExtend); ok {
-			extend.ExtendDocument(itemDocument)
+			typeConfigurationFlags[typeConfig.TypeName] = true
+			e.engineConfig.TypeConfigurations = append(e.engineConfig.TypeConfigurations, typeConfig)
 		}
-
-		// 将数据源名称添加到graphql命名中
-		itemRename = newDataSourceRename(ds.Name, itemDocument, e, itemAction)
-		itemRename.resolve()
-
-		itemConfig.Id = ds.Name
-		itemConfig.Kind = ds.Kind
-		itemConfig.RootNodes = itemRename.rootNodes
-		itemConfig.ChildNodes = itemRename.childNodes
-		e.engineConfig.DatasourceConfigurations = append(e.engineConfig.DatasourceConfigurations, itemConfig)
+		maps.Copy(e.fieldArgumentTypeNames, item.fieldArgumentTypeNames)
 
 		// 合成graphql文档中的指令
-		for _, item := range itemDocument.Directives {
-			directiveMap[item.Name] = item
+		for _, itemDirective := range item.document.Directives {
+			directiveMap[itemDirective.Name] = itemDirective
 		}
 		// 合成文档中的定义，根类型和普通类型作不同处理
-		for _, itemDefinition := range itemDocument.Definitions {
+		for _, itemDefinition := range item.document.Definitions {
 			itemDefinitionName := itemDefinition.Name
-			if itemRootOperation, ok := itemRename.rootOperationTypeNameMap[itemDefinitionName]; ok {
+			if itemRootOperation, ok := item.rootOperationTypeNameMap[itemDefinitionName]; ok {
 				itemDefinitionName = itemRootOperation
 			}
 
@@ -132,7 +183,6 @@ func (e *engineConfiguration) Resolve(builder *Builder) (err error) {
 
 			otherDefinitionMap[itemDefinitionName] = itemDefinition
 		}
-		logger.Debug("build datasource succeed", zap.String(e.modelName, ds.Name))
 	}
 
 	// 合成自定义指令到文档中
@@ -168,6 +218,7 @@ func (e *engineConfiguration) Resolve(builder *Builder) (err error) {
 	})
 	directiveDefinitions := maps.Values(directiveMap)
 	slices.SortFunc(directiveDefinitions, func(a, b *ast.DirectiveDefinition) bool { return a.Name < b.Name })
+	builder := e.builder
 	builder.Document = &ast.SchemaDocument{
 		Schema:      ast.SchemaDefinitionList{{OperationTypes: operationTypes}},
 		Definitions: append(definitions, otherDefinitions...),
@@ -181,7 +232,6 @@ func (e *engineConfiguration) Resolve(builder *Builder) (err error) {
 		return
 	}
 
-	maps.Clear(e.typeConfigurationFlags)
 	e.calculateRootFieldHash(builder, otherDefinitionMap)
 	builder.DefinedApi.EngineConfiguration = e.engineConfig
 	return
@@ -189,7 +239,8 @@ func (e *engineConfiguration) Resolve(builder *Builder) (err error) {
 
 // 根据数据源类型获取处理逻辑和schema定义
 // 当数据源开启缓存或缓存文件时间在上次编译完成后时忽略读取缓存
-func (e *engineConfiguration) fetchDatasourceGraphqlSchema(ds *models.Datasource) (action datasource.Action, content string, err error) {
+// incremental 增量编译时仅在开启缓存时读取缓存
+func (e *engineConfiguration) fetchDatasourceGraphqlSchema(ds *models.Datasource, incremental bool) (action datasource.Action, content string, err error) {
 	if action, err = datasource.GetDatasourceAction(ds); err != nil {
 		return
 	}
@@ -198,7 +249,7 @@ func (e *engineConfiguration) fetchDatasourceGraphqlSchema(ds *models.Datasource
 		var readFromCached bool
 		if ds.CacheEnabled {
 			readFromCached = true
-		} else {
+		} else if !incremental {
 			fileInfo, _ := datasource.CacheGraphqlSchemaText.Stat(ds.Name)
 			latestTime := utils.GetTimeWithLockViper(consts.EngineStartTime)
 			if fileInfo != nil && !latestTime.IsZero() && latestTime.Before(fileInfo.ModTime()) {
@@ -226,7 +277,7 @@ func (e *engineConfiguration) writeDocument(doc *ast.SchemaDocument, file *os.Fi
 
 // 将带有入参的graphql查询定义按指定格式记录
 // 后续引擎处理graphql响应时需要用到
-func (e *engineConfiguration) resolveFieldConfiguration(field *ast.FieldDefinition, fieldRename, typeName string, itemAction datasource.Action) {
+func (d *datasourceItem) resolveFieldConfiguration(field *ast.FieldDefinition, fieldRename, typeName string, itemAction datasource.Action) {
 	var (
 		requiresFields  []string
 		argumentConfigs []*wgpb.ArgumentConfiguration
@@ -262,28 +313,28 @@ func (e *engineConfiguration) resolveFieldConfiguration(field *ast.FieldDefiniti
 	if customField, ok := itemAction.(datasource.FieldConfigurationAction); ok {
 		customField.Handle(fieldConfiguration)
 	}
-	e.engineConfig.FieldConfigurations = append(e.engineConfig.FieldConfigurations, fieldConfiguration)
+	d.fieldConfigurations = append(d.fieldConfigurations, fieldConfiguration)
 }
 
 // 保存字段参数类型名称，后续用于hash判断重复
-func (e *engineConfiguration) saveFieldArgumentTypeNames(fieldRename, typeName string, argumentRenamedTypes []string) {
+func (d *datasourceItem) saveFieldArgumentTypeNames(fieldRename, typeName string, argumentRenamedTypes []string) {
 	if len(argumentRenamedTypes) == 0 {
 		return
 	}
 
-	e.fieldArgumentTypeNames[utils.JoinStringWithDot(typeName, fieldRename)] = argumentRenamedTypes
+	d.fieldArgumentTypeNames[utils.JoinStringWithDot(typeName, fieldRename)] = argumentRenamedTypes
 }
 
 // 将添加了数据源命名的类型按指定格式记录
 // 后续引擎处理graphql响应时需要用到
 // typeConfigurationFlags 用作去重复判断
-func (e *engineConfiguration) resolveTypeConfigurations(typeName, originName string) {
-	if _, ok := e.typeConfigurationFlags[typeName]; ok {
+func (d *datasourceItem) resolveTypeConfigurations(typeName, originName string) {
+	if _, ok := d.typeConfigurationFlags[typeName]; ok {
 		return
 	}
 
-	e.typeConfigurationFlags[typeName] = true
-	e.engineConfig.TypeConfigurations = append(e.engineConfig.TypeConfigurations, &wgpb.TypeConfiguration{
+	d.typeConfigurationFlags[typeName] = true
+	d.typeConfigurations = append(d.typeConfigurations, &wgpb.TypeConfiguration{
 		TypeName: typeName,
 		RenameTo: originName,
 	})
@@ -299,6 +350,7 @@ func (e *engineConfiguration) calculateRootFieldHash(builder *Builder, fieldDefi
 	}
 
 	builder.FieldHashes = &utils.SyncMap[string, *LazyFieldHash]{}
+	fieldArgumentTypeNames := e.fieldArgumentTypeNames
 	for dsIndex := range e.engineConfig.DatasourceConfigurations {
 		dsConfig := e.engineConfig.DatasourceConfigurations[dsIndex]
 		for nodeIndex := range dsConfig.RootNodes {
@@ -315,13 +367,13 @@ func (e *engineConfiguration) calculateRootFieldHash(builder *Builder, fieldDefi
 				}
 				builder.FieldHashes.Store(fieldName, &LazyFieldHash{
 					lazyFunc: func() string {
-						definitionNames := e.fieldArgumentTypeNames[utils.JoinStringWithDot(rootNode.TypeName, fieldName)]
+						definitionNames := fieldArgumentTypeNames[utils.JoinStringWithDot(rootNode.TypeName, fieldName)]
 						if quotes, ok := rootNode.Quotes[int32(fieldIndex)]; ok {
 							for _, quoteIndex := range quotes.Indexes {
 								childNode := dsConfig.ChildNodes[quoteIndex]
 								definitionNames = append(definitionNames, childNode.TypeName)
 								for _, childFieldName := range childNode.FieldNames {
-									definitionNames = append(definitionNames, e.fieldArgumentTypeNames[utils.JoinStringWithDot(childNode.TypeName, childFieldName)]...)
+									definitionNames = append(definitionNames, fieldArgumentTypeNames[utils.JoinStringWithDot(childNode.TypeName, childFieldName)]...)
 								}
 							}
 						}
@@ -0,0 +1,83 @@
+package build
+
+import (
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	"github.com/wundergraph/wundergraph/pkg/eventbus"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+func (e *engineConfiguration) Subscribe() {
+	eventbus.Subscribe(eventbus.ChannelDatasource, eventbus.EventInsert, func(data any) any {
+		return e.incrementDatasources(eventbus.EventInsert, data.(*models.Datasource))
+	})
+	eventbus.Subscribe(eventbus.ChannelDatasource, eventbus.EventBatchInsert, func(data any) any {
+		return e.incrementDatasources(eventbus.EventBatchInsert, data.([]*models.Datasource)...)
+	})
+	eventbus.Subscribe(eventbus.ChannelDatasource, eventbus.EventUpdate, func(data any) any {
+		return e.incrementDatasources(eventbus.EventUpdate, data.(*models.Datasource))
+	})
+	eventbus.Subscribe(eventbus.ChannelDatasource, eventbus.EventBatchUpdate, func(data any) any {
+		return e.incrementDatasources(eventbus.EventBatchUpdate, data.([]*models.Datasource)...)
+	})
+	eventbus.Subscribe(eventbus.ChannelDatasource, eventbus.EventDelete, func(data any) any {
+		return e.incrementDatasources(eventbus.EventDelete, &models.Datasource{Name: data.(string)})
+	})
+	eventbus.Subscribe(eventbus.ChannelDatasource, eventbus.EventBatchDelete, func(data any) any {
+		var deleted []*models.Datasource
+		for _, name := range data.([]string) {
+			deleted = append(deleted, &models.Datasource{Name: name})
+		}
+		return e.incrementDatasources(eventbus.EventBatchDelete, deleted...)
+	})
+}
+
+// 仅重新内省变更的数据源，再与其他数据源的编译结果合并
+// 未启用的数据源从编译结果中移除，返回nil时退回到全量编译
+func (e *engineConfiguration) incrementDatasources(event eventbus.Event, datas ...*models.Datasource) any {
+	if e.builder == nil {
+		return nil
+	}
+
+	var changedNames []string
+	for _, ds := range datas {
+		itemIndex := slices.IndexFunc(e.datasourceItems, func(item *datasourceItem) bool { return item.name == ds.Name })
+		if !ds.Enabled {
+			if itemIndex == -1 {
+				continue
+			}
+
+			e.datasourceItems = slices.Delete(e.datasourceItems, itemIndex, itemIndex+1)
+			changedNames = append(changedNames, ds.Name)
+			continue
+		}
+
+		if itemIndex == -1 && utils.InvokeFunctionLimit(e.modelName, len(e.datasourceItems)) {
+			return nil
+		}
+
+		item, err := e.buildDatasourceItem(ds, true)
+		if item == nil {
+			e.printIntrospectError(err, ds.Name)
+			return nil
+		}
+
+		if itemIndex == -1 {
+			e.datasourceItems = append(e.datasourceItems, item)
+		} else {
+			e.datasourceItems[itemIndex] = item
+		}
+		changedNames = append(changedNames, ds.Name)
+	}
+	if len(changedNames) == 0 {
+		return nil
+	}
+
+	if err := e.mergeDatasourceItems(); err != nil {
+		logger.Error("increment build datasource failed", zap.Error(err), zap.Strings(e.modelName, changedNames))
+		return nil
+	}
+
+	return &DatasourceIncrement{Event: event, Names: changedNames, Builder: e.builder}
+}
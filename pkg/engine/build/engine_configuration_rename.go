@@ -17,10 +17,10 @@ import (
 )
 
 type dataSourceRename struct {
-	name         string
-	doc          *ast.SchemaDocument
-	engineConfig *engineConfiguration
-	action       datasource.Action
+	name   string
+	doc    *ast.SchemaDocument
+	item   *datasourceItem
+	action datasource.Action
 
 	rootNodes          []*wgpb.TypeField
 	childNodes         []*wgpb.TypeField
@@ -31,11 +31,11 @@ type dataSourceRename struct {
 	joinFieldRequiredTypeNames []string
 }
 
-func newDataSourceRename(name string, doc *ast.SchemaDocument, engineConfig *engineConfiguration, action datasource.Action) *dataSourceRename {
+func newDataSourceRename(name string, doc *ast.SchemaDocument, item *datasourceItem, action datasource.Action) *dataSourceRename {
 	return &dataSourceRename{
 		name:               name,
 		doc:                doc,
-		engineConfig:       engineConfig,
+		item:               item,
 		action:             action,
 		fieldQuoteTypesMap: make(map[string][]string),
 	}
@@ -152,13 +152,13 @@ func (d *dataSourceRename) renameFields(definition *ast.Definition, isRootNode b
 			// 根类型时重命名所有子类型即Query/Mutation/Subscription下的graphql
 			node.TypeName = d.rootOperationTypeNameMap[definition.Name]
 			fieldRename := d.applyNamespace(field.Name, false)
-			d.engineConfig.resolveFieldConfiguration(field, fieldRename, node.TypeName, d.action)
+			d.item.resolveFieldConfiguration(field, fieldRename, node.TypeName, d.action)
 			field.Name = fieldRename
 			// 在描述中添加数据源特殊标识用作引用数据源的筛选
 			field.Description += fmt.Sprintf(datasourceFormat, d.name)
 		} else if len(field.Arguments) > 0 {
 			// 非根类型时若有入参也需要处理
-			d.engineConfig.resolveFieldConfiguration(field, field.Name, node.TypeName, d.action)
+			d.item.resolveFieldConfiguration(field, field.Name, node.TypeName, d.action)
 		}
 		node.FieldNames = append(node.FieldNames, field.Name)
 
@@ -170,7 +170,7 @@ func (d *dataSourceRename) renameFields(definition *ast.Definition, isRootNode b
 				d.fieldQuoteTypesMap[definition.Name] = append(d.fieldQuoteTypesMap[definition.Name], field.Type.Name())
 			}
 		}
-		d.engineConfig.saveFieldArgumentTypeNames(field.Name, node.TypeName, d.renameArguments(field.Arguments))
+		d.item.saveFieldArgumentTypeNames(field.Name, node.TypeName, d.renameArguments(field.Arguments))
 	}
 
 	if isRootNode {
@@ -213,7 +213,7 @@ func (d *dataSourceRename) renameType(t *ast.Type) bool {
 	if datasource.IsScalarJsonName(originName) {
 		realType.NamedType = consts.ScalarJSON
 		if originName != consts.ScalarJSON {
-			d.engineConfig.resolveTypeConfigurations(consts.ScalarJSON, originName)
+			d.item.resolveTypeConfigurations(consts.ScalarJSON, originName)
 		}
 		return false
 	}
@@ -256,7 +256,7 @@ func (d *dataSourceRename) renameArguments(arguments ast.ArgumentDefinitionList)
 func (d *dataSourceRename) applyNamespace(name string, resolved bool) string {
 	renameTo := utils.JoinString("_", d.name, name)
 	if resolved {
-		d.engineConfig.resolveTypeConfigurations(renameTo, name)
+		d.item.resolveTypeConfigurations(renameTo, name)
 	}
 	return renameTo
 }
@@ -9,6 +9,8 @@ import (
 	"fireboom-server/pkg/common/consts"
 	"fireboom-server/pkg/common/models"
 	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/engine/directives"
+	"fmt"
 	"github.com/getkin/kin-openapi/openapi3"
 	json "github.com/json-iterator/go"
 	"github.com/vektah/gqlparser/v2/ast"
@@ -27,8 +29,9 @@ func init() {
 }
 
 const (
-	buildActionResolve = "resolve"
-	buildActionExtract = "extract"
+	buildActionResolve          = "resolve"
+	buildActionExtract          = "extract"
+	operationSunsetPassedFormat = "operations %v past sunset date"
 )
 
 type operations struct {
@@ -36,6 +39,7 @@ type operations struct {
 	rootDocument *ast.SchemaDocument
 	fieldHashes  *utils.SyncMap[string, *LazyFieldHash]
 	results      []*wgpb.Operation
+	sunsets      []string
 
 	graphqlFragments       string
 	definitionIndexes      map[string]int
@@ -47,15 +51,25 @@ type operations struct {
 }
 
 func (o *operations) Resolve(builder *Builder) (err error) {
-	o.rootDocument = builder.Document
-	o.fieldHashes = builder.FieldHashes
-	o.results = o.results[:0]
-
 	if err = o.loadFragments(); err != nil {
 		logger.Warn("load fragments failed", zap.Error(err))
 		return
 	}
 
+	o.builtOperationsConfigData = GeneratedOperationsConfigRoot.FirstData()
+	if o.builtOperationsConfigData == nil {
+		o.builtOperationsConfigData = &OperationsConfig{}
+	}
+	err = o.buildOperations(builder)
+	return
+}
+
+// 编译所有operation，未变更的operation直接复用builtOperationsConfigData中的编译结果
+func (o *operations) buildOperations(builder *Builder) (err error) {
+	o.rootDocument = builder.Document
+	o.fieldHashes = builder.FieldHashes
+	o.results, o.sunsets = nil, nil
+
 	// 将数组处理成以定义名为key的map，方便后续根据名称查询定义
 	definitions := o.rootDocument.Definitions
 	o.definitionIndexes = make(map[string]int, len(definitions))
@@ -72,10 +86,6 @@ func (o *operations) Resolve(builder *Builder) (err error) {
 		ProxyOperationFiles:    make(map[string]*ExtensionOperationFile),
 		Definitions:            &utils.SyncMap[string, *openapi3.SchemaRef]{},
 	}
-	o.builtOperationsConfigData = GeneratedOperationsConfigRoot.FirstData()
-	if o.builtOperationsConfigData == nil {
-		o.builtOperationsConfigData = &OperationsConfig{}
-	}
 	for _, item := range datas {
 		// 根据类型执行operation的编译
 		itemResult, succeed, invoked := o.buildOperationItem(item)
@@ -89,6 +99,10 @@ func (o *operations) Resolve(builder *Builder) (err error) {
 	}
 
 	o.builtOperationsConfigData = nil
+	if len(o.sunsets) > 0 && utils.GetStringWithLockViper(consts.SunsetOperationAction) == consts.SunsetOperationFail {
+		err = fmt.Errorf(operationSunsetPassedFormat, o.sunsets)
+		return
+	}
 	// 将编译结果保存，留作生成swagger合成schema，运行时设置operation属性等
 	if err = GeneratedOperationsConfigRoot.InsertOrUpdate(o.operationsConfigData); err != nil {
 		return
@@ -153,7 +167,7 @@ func (o *operations) buildOperationItem(item *models.Operation) (itemResult *wgp
 	if !extracted {
 		itemResult, buildAction = o.resolveOperationItem(item), buildActionResolve
 	}
-	if succeed = item.Enabled && itemResult != nil; succeed {
+	if succeed = item.Enabled && itemResult != nil && o.resolveOperationRateLimitTiers(item) && o.resolveOperationDeprecation(item); succeed {
 		o.mergeGlobalOperation(item, itemResult)
 		o.resolveOperationHook(itemResult)
 		logger.Debug("build operation succeed", zap.String(o.modelName, item.Path), zap.String("action", buildAction))
@@ -161,6 +175,29 @@ func (o *operations) buildOperationItem(item *models.Operation) (itemResult *wgp
 	return
 }
 
+// 校验合并后的限流层级，接口配置可能在graphql文本未变更时修改，所以在提取或编译后统一校验
+func (o *operations) resolveOperationRateLimitTiers(operation *models.Operation) bool {
+	if err := directives.ValidateRateLimitTiers(o.operationsConfigData.GetRateLimitTiers(operation)); err != nil {
+		logger.Warn("build operation failed", zap.Error(err), zap.String(o.modelName, operation.Path))
+		o.operationsConfigData.Invalids = append(o.operationsConfigData.Invalids, operation.Path)
+		return false
+	}
+	return true
+}
+
+// 已过下线时间的接口按照sunset-operation-action处理，返回false时不再编译该接口
+func (o *operations) resolveOperationDeprecation(operation *models.Operation) bool {
+	if !operation.SunsetPassed() {
+		return true
+	}
+
+	action := utils.GetStringWithLockViper(consts.SunsetOperationAction)
+	logger.Warn("operation past sunset date", zap.String(o.modelName, operation.Path),
+		zap.String("sunsetDate", operation.Deprecation.SunsetDate), zap.String("action", action))
+	o.sunsets = append(o.sunsets, operation.Path)
+	return action != consts.SunsetOperationDisable
+}
+
 // 合成全局的配置，根据是否开启自定义配置来合成最终配置
 func (o *operations) mergeGlobalOperation(operation *models.Operation, operationResult *wgpb.Operation) {
 	globalOperation := models.GlobalOperationRoot.FirstData()
@@ -226,7 +263,12 @@ type (
 	}
 	GraphqlOperationFile struct {
 		BaseOperationFile
-		Internal bool `json:"internal"`
+		Internal        bool                              `json:"internal"`
+		RateLimitTiers  []*models.OperationRateLimitTier  `json:"rate_limit_tiers,omitempty"`
+		MaskFields      []*models.OperationMaskField      `json:"mask_fields,omitempty"`
+		ValidationRules []*models.OperationValidationRule `json:"validation_rules,omitempty"`
+		ResponseCache   *models.OperationResponseCache    `json:"response_cache,omitempty"`
+		Paginations     []*models.OperationPagination     `json:"paginations,omitempty"`
 	}
 	ExtensionOperationFile struct {
 		BaseOperationFile
@@ -234,6 +276,15 @@ type (
 	}
 )
 
+// GetRateLimitTiers 合并接口配置中的rateLimitTiers和@rateLimit声明的限流层级
+func (o *OperationsConfig) GetRateLimitTiers(operation *models.Operation) []*models.OperationRateLimitTier {
+	tiers := slices.Clone(operation.RateLimitTiers)
+	if graphqlFile, ok := o.GraphqlOperationFiles[operation.Path]; ok {
+		tiers = append(tiers, graphqlFile.RateLimitTiers...)
+	}
+	return tiers
+}
+
 func normalizeOperationName(path string) string {
 	return strings.ReplaceAll(path, "/", "__")
 }
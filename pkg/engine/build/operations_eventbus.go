@@ -5,6 +5,7 @@ import (
 	"fireboom-server/pkg/common/models"
 	"github.com/wundergraph/wundergraph/pkg/eventbus"
 	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"go.uber.org/zap"
 	"strings"
 )
 
@@ -109,6 +110,20 @@ func (o *operations) Subscribe() {
 		reloadFunc()
 		return updated
 	})
+	// 数据源增量编译后仅重新编译引用了变更字段(字段hash不一致)的operation
+	datasourceHandler := func(data any) any {
+		increment := data.(*DatasourceIncrement)
+		o.builtOperationsConfigData = o.operationsConfigData
+		if err := o.buildOperations(increment.Builder); err != nil {
+			logger.Warn("increment build operations failed", zap.Error(err), zap.Strings(string(eventbus.ChannelDatasource), increment.Names))
+			return nil
+		}
+
+		return increment
+	}
+	for _, event := range DatasourceIncrementEvents {
+		eventbus.Subscribe(eventbus.ChannelDatasource, event, datasourceHandler)
+	}
 }
 
 func (o *operations) removeOperationItem(operationPath string) (removed bool) {
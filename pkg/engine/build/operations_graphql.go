@@ -102,7 +102,14 @@ func (o *operations) resolveGraphqlOperation(operation *models.Operation, graphq
 			OperationType:       operationResult.OperationType,
 			AuthorizationConfig: operationResult.AuthorizationConfig,
 		},
-		Internal: operationResult.Internal,
+		Internal:        operationResult.Internal,
+		RateLimitTiers:  queryItem.rateLimitTiers,
+		MaskFields:      queryItem.maskFields,
+		ValidationRules: queryItem.validationRules,
+		Paginations:     queryItem.paginations,
+	}
+	if responseCache := queryItem.responseCache; responseCache.TtlSeconds > 0 || len(responseCache.InvalidateTags) > 0 {
+		graphqlFile.ResponseCache = &responseCache
 	}
 	graphqlFiles[operation.Path] = graphqlFile
 	if len(queryItem.Errors) > 0 {
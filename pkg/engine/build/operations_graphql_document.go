@@ -65,6 +65,11 @@ type QueryDocumentItem struct {
 	variablesRefs           []string
 	variablesRefVisited     map[string]bool
 	variablesExported       map[string]bool
+	rateLimitTiers          []*models.OperationRateLimitTier
+	maskFields              []*models.OperationMaskField
+	validationRules         []*models.OperationValidationRule
+	responseCache           models.OperationResponseCache
+	paginations             []*models.OperationPagination
 	definitionFieldIndexes  map[*ast.Definition]*definitionFieldOverview
 	fieldArgumentIndexes    map[*ast.FieldDefinition]*fieldArgumentOverview
 	Errors                  []string
@@ -118,6 +123,11 @@ func (i *QueryDocumentItem) Reset() {
 	i.variablesRefVisited = nil
 	maps.Clear(i.variablesExported)
 	i.variablesExported = nil
+	i.rateLimitTiers = nil
+	i.maskFields = nil
+	i.validationRules = nil
+	i.responseCache = models.OperationResponseCache{}
+	i.paginations = nil
 	i.definitionFieldIndexes = nil
 	i.fieldArgumentIndexes = nil
 	i.Errors = i.Errors[:0]
@@ -175,6 +185,7 @@ func (i *QueryDocumentItem) resolveOperationList() {
 	i.operationSchema.Variables = &openapi3.SchemaRef{Value: openapi3.NewObjectSchema()}
 	i.operationSchema.InternalVariables = &openapi3.SchemaRef{Value: openapi3.NewObjectSchema()}
 	i.resolveVariableDefinitions(i.operationDefinition, i.operationSchema.Variables, i.operationSchema.InternalVariables)
+	i.resolvePaginateVariables()
 
 	i.resolveOperationDirectives()
 	return
@@ -259,6 +270,7 @@ func (i *QueryDocumentItem) resolveSelectionSet(datasourceQuote string, selectio
 					return
 				}, path...)
 				itemNonNull = fieldDefinition.Type.NonNull
+				savedSet = i.resolvePaginateField(field, itemName, fieldDefinition, definition, datasourceQuote, savedSet, path...)
 				i.resolveSelectionArguments(field.Name, field.Arguments, fieldDefinition, path...)
 			} else {
 				itemPath = CopyAndAppendItem(path, itemName)
@@ -578,8 +590,12 @@ func (i *QueryDocumentItem) resolveOperationDirectives() {
 		}
 
 		operationResolver := &directives.OperationResolver{
-			Operation: i.operation,
-			Arguments: directives.ResolveDirectiveArguments(directiveItem.Arguments),
+			Operation:       i.operation,
+			Arguments:       directives.ResolveDirectiveArguments(directiveItem.Arguments),
+			RateLimitTiers:  &i.rateLimitTiers,
+			Variables:       i.operationSchema.Variables,
+			ValidationRules: &i.validationRules,
+			ResponseCache:   &i.responseCache,
 		}
 		if err := directiveResolve.Resolve(operationResolver); err != nil {
 			i.reportError(directiveResolveErrorFormat, directiveItem.Name, err)
@@ -624,7 +640,7 @@ func (i *QueryDocumentItem) makeSelectionResolver(path []string) *directives.Sel
 		VariableExported:    i.variablesExported,
 		OperationDefinition: i.operationDefinition,
 	}
-	selectionResolver.Operation = i.operation
+	selectionResolver.Operation, selectionResolver.MaskFields, selectionResolver.Paginations = i.operation, &i.maskFields, &i.paginations
 	return selectionResolver
 }
 
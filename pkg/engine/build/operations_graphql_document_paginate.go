@@ -0,0 +1,72 @@
+// Package build
+/*
+ 处理@paginate声明的查询字段
+ 解析字段前生成take/skip/cursor内部参数，需要totalCount时在同级添加aggregate计数字段
+ 解析参数定义后将first/after添加到对外公开的入参定义中，飞布服务转发请求时转换为内部参数
+*/
+package build
+
+import (
+	"fireboom-server/pkg/engine/datasource"
+	"fireboom-server/pkg/engine/directives"
+	"github.com/vektah/gqlparser/v2/ast"
+	"strings"
+)
+
+const (
+	paginateFindManyPrefix       = "findMany"
+	paginateAggregatePrefix      = "aggregate"
+	paginateVariableRepeatFormat = "variable [%s] generated by @paginate defined repeatedly"
+)
+
+// 修改@paginate声明的根字段，返回添加计数字段后的savedSet
+func (i *QueryDocumentItem) resolvePaginateField(field *ast.Field, itemName string, fieldDefinition *ast.FieldDefinition,
+	definition *ast.Definition, datasourceQuote string, savedSet ast.SelectionSet, path ...string) ast.SelectionSet {
+	directive, totalCount := directives.PaginateFieldDirective(field.Directives)
+	// 非根字段交由指令解析时报错
+	if directive == nil || !datasource.ContainsRootDefinition(definition.Name) {
+		return savedSet
+	}
+
+	variableDefinitions, err := directives.PaginateFieldArguments(itemName, field, fieldDefinition)
+	if err != nil {
+		i.reportError(directiveResolveErrorFormat, directive.Name, err)
+		return savedSet
+	}
+	for _, item := range variableDefinitions {
+		if i.operationDefinition.VariableDefinitions.ForName(item.Variable) != nil {
+			i.reportError(paginateVariableRepeatFormat, item.Variable)
+			return savedSet
+		}
+	}
+	i.operationDefinition.VariableDefinitions = append(i.operationDefinition.VariableDefinitions, variableDefinitions...)
+	if !totalCount {
+		return savedSet
+	}
+
+	aggregateFieldName := strings.Replace(field.Name, paginateFindManyPrefix, paginateAggregatePrefix, 1)
+	totalCountField := directives.PaginateTotalCountField(itemName, field, aggregateFieldName)
+	// 解析计数字段用于校验aggregate定义，不加入响应定义，飞布服务转发响应时转换为totalCount
+	if i.resolveSelectionSet(datasourceQuote, ast.SelectionSet{totalCountField}, definition, path...); i.resolveErrored() {
+		return savedSet
+	}
+	return append(savedSet, totalCountField)
+}
+
+// 将@paginate对外公开的first/after添加到入参定义中，转发前会被移除所以不加入内部参数定义
+func (i *QueryDocumentItem) resolvePaginateVariables() {
+	if i.resolveErrored() {
+		return
+	}
+
+	for _, pagination := range i.paginations {
+		for name, schemaRef := range directives.PaginateVariableSchemas(pagination) {
+			if _, ok := i.operationSchema.InternalVariables.Value.Properties[name]; ok {
+				i.reportError(paginateVariableRepeatFormat, name)
+				return
+			}
+
+			i.operationSchema.Variables.Value.Properties[name] = schemaRef
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// Package datasource
+/*
+ rest/graphql数据源的出站认证，支持oauth2客户端凭证、apiKey(header/query)和basic
+ 认证信息仅在内省请求和引擎运行时配置中注入，不会写入编译生成的配置文件
+ oauth2令牌缓存在内存中，过期前刷新令牌并通知引擎原地替换运行时配置使新令牌生效，不重启引擎
+ 同一数据源并发获取令牌时合并为一次请求，不同数据源之间互不阻塞
+ 获取令牌失败时打印带有数据源名称的日志，通过日志收集器推送到问题列表
+*/
+package datasource
+
+import (
+	"encoding/base64"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	json "github.com/json-iterator/go"
+	"github.com/labstack/echo/v4"
+	"github.com/wundergraph/wundergraph/pkg/eventbus"
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/protobuf/proto"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	oauth2GrantTypeClientCredentials = "client_credentials"
+	oauth2DefaultTokenType           = "Bearer"
+	oauth2DefaultRefreshBeforeExpiry = 60
+	oauth2MinRefreshInterval         = 5
+	oauth2TokenFetchTimeout          = 10
+	basicAuthPrefix                  = "Basic "
+)
+
+type (
+	oauth2Token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+
+		configHash   string
+		refreshTime  time.Time
+		refreshTimer *time.Timer
+	}
+	authValues struct {
+		headers map[string]string
+		queries map[string]string
+	}
+)
+
+var (
+	oauth2TokenCache      utils.SyncMap[string, *oauth2Token]
+	oauth2TokenFetchGroup singleflight.Group
+)
+
+// 解析认证配置得到需要注入的header和query，仅第一个配置的认证方式生效
+func resolveAuthValues(dsName string, auth *models.CustomAuth) (values *authValues, err error) {
+	if auth == nil {
+		return
+	}
+
+	values = &authValues{headers: make(map[string]string), queries: make(map[string]string)}
+	switch {
+	case auth.Oauth2 != nil:
+		var token *oauth2Token
+		if token, err = fetchOauth2Token(dsName, auth.Oauth2); err != nil {
+			return
+		}
+
+		tokenType := token.TokenType
+		if tokenType == "" || strings.EqualFold(tokenType, oauth2DefaultTokenType) {
+			tokenType = oauth2DefaultTokenType
+		}
+		values.headers[echo.HeaderAuthorization] = tokenType + " " + token.AccessToken
+	case auth.ApiKey != nil:
+		apiKeyValue := utils.GetVariableString(auth.ApiKey.Value)
+		if auth.ApiKey.In == models.CustomAuthApiKeyInQuery {
+			values.queries[auth.ApiKey.Name] = apiKeyValue
+		} else {
+			values.headers[auth.ApiKey.Name] = apiKeyValue
+		}
+	case auth.Basic != nil:
+		credentials := utils.GetVariableString(auth.Basic.Username) + ":" + utils.GetVariableString(auth.Basic.Password)
+		values.headers[echo.HeaderAuthorization] = basicAuthPrefix + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+	return
+}
+
+// 运行时获取认证信息，失败时仅打印日志，引擎仍以未认证的配置启动
+func resolveRuntimeAuthValues(dsName string, auth *models.CustomAuth) *authValues {
+	values, err := resolveAuthValues(dsName, auth)
+	if err != nil {
+		logger.Warn("resolve datasource auth failed", zap.Error(err), zap.String(datasourceModelName, dsName))
+		return nil
+	}
+
+	return values
+}
+
+// 复制fetch配置并注入认证信息，避免修改编译生成的配置
+// graphql数据源的query参数追加到url中，rest数据源追加到query配置中
+func copyFetchWithAuthValues(fetch *wgpb.FetchConfiguration, values *authValues, queryInUrl bool) *wgpb.FetchConfiguration {
+	if fetch == nil || values == nil {
+		return fetch
+	}
+
+	fetch = proto.Clone(fetch).(*wgpb.FetchConfiguration)
+	if fetch.Header == nil {
+		fetch.Header = make(map[string]*wgpb.HTTPHeader, len(values.headers))
+	}
+	for name, value := range values.headers {
+		fetch.Header[name] = &wgpb.HTTPHeader{Values: []*wgpb.ConfigurationVariable{utils.MakeStaticVariable(value)}}
+	}
+	if len(values.queries) == 0 {
+		return fetch
+	}
+
+	if !queryInUrl {
+		for name, value := range values.queries {
+			fetch.Query = append(fetch.Query, &wgpb.URLQueryConfiguration{Name: name, Value: value})
+		}
+		return fetch
+	}
+
+	if fetch.Url != nil {
+		fetch.Url = utils.MakeStaticVariable(appendUrlQueries(utils.GetVariableString(fetch.Url), values.queries))
+	}
+	return fetch
+}
+
+func appendUrlQueries(rawUrl string, queries map[string]string) string {
+	if len(queries) == 0 {
+		return rawUrl
+	}
+
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+
+	query := parsedUrl.Query()
+	for name, value := range queries {
+		query.Set(name, value)
+	}
+	parsedUrl.RawQuery = query.Encode()
+	return parsedUrl.String()
+}
+
+// 获取oauth2令牌，配置未变更且未到刷新时间时使用缓存
+func fetchOauth2Token(dsName string, config *models.CustomAuthOauth2) (token *oauth2Token, err error) {
+	tokenUrl := utils.GetVariableString(config.TokenUrl)
+	clientId := utils.GetVariableString(config.ClientId)
+	clientSecret := utils.GetVariableString(config.ClientSecret)
+	configHash := fmt.Sprintf("%s|%s|%s|%s", tokenUrl, clientId, clientSecret, strings.Join(config.Scopes, " "))
+	if cached, ok := oauth2TokenCache.Load(dsName); ok && cached.configHash == configHash &&
+		(cached.refreshTime.IsZero() || time.Now().Before(cached.refreshTime)) {
+		token = cached
+		return
+	}
+
+	result, err, _ := oauth2TokenFetchGroup.Do(dsName+"|"+configHash, func() (any, error) {
+		return requestOauth2Token(dsName, config, configHash, tokenUrl, clientId, clientSecret)
+	})
+	if err != nil {
+		return
+	}
+
+	token = result.(*oauth2Token)
+	return
+}
+
+// 请求令牌接口并写入缓存，同时设置到期前的刷新定时器
+func requestOauth2Token(dsName string, config *models.CustomAuthOauth2, configHash, tokenUrl, clientId, clientSecret string) (token *oauth2Token, err error) {
+	form := url.Values{}
+	form.Set("grant_type", oauth2GrantTypeClientCredentials)
+	if len(config.Scopes) > 0 {
+		form.Set("scope", strings.Join(config.Scopes, " "))
+	}
+	headers := map[string]string{
+		echo.HeaderContentType:   echo.MIMEApplicationForm,
+		echo.HeaderAuthorization: basicAuthPrefix + base64.StdEncoding.EncodeToString([]byte(url.QueryEscape(clientId)+":"+url.QueryEscape(clientSecret))),
+	}
+	respBody, err := utils.HttpPost(tokenUrl, []byte(form.Encode()), headers, oauth2TokenFetchTimeout)
+	if err == nil {
+		token = &oauth2Token{}
+		err = json.Unmarshal(respBody, token)
+	}
+	if err == nil && token.AccessToken == "" {
+		err = fmt.Errorf("access_token not found in response: %s", string(respBody))
+	}
+	if err != nil {
+		token = nil
+		err = i18n.NewCustomErrorWithMode(datasourceModelName, err, i18n.DatasourceAuthTokenFetchError)
+		return
+	}
+
+	token.configHash = configHash
+	// 未返回expires_in或为0时视为令牌不过期，不设置刷新定时器
+	if token.ExpiresIn > 0 {
+		refreshDuration := oauth2RefreshDuration(token.ExpiresIn, config.RefreshBeforeExpiry)
+		token.refreshTime = time.Now().Add(refreshDuration)
+		token.refreshTimer = time.AfterFunc(refreshDuration, func() { refreshOauth2Token(dsName, configHash) })
+	}
+	if existed, ok := oauth2TokenCache.Load(dsName); ok && existed.refreshTimer != nil {
+		existed.refreshTimer.Stop()
+	}
+	oauth2TokenCache.Store(dsName, token)
+	return
+}
+
+// 计算令牌的刷新间隔(秒)，提前刷新时间超过有效期一半时按有效期一半刷新，且不小于最小刷新间隔
+// 防止有效期较短时刷新间隔为0导致反复获取令牌并发布运行时变更
+func oauth2RefreshDuration(expiresIn, refreshBeforeExpiry int64) time.Duration {
+	if refreshBeforeExpiry <= 0 {
+		refreshBeforeExpiry = oauth2DefaultRefreshBeforeExpiry
+	}
+	return time.Duration(max(expiresIn-refreshBeforeExpiry, expiresIn/2, oauth2MinRefreshInterval)) * time.Second
+}
+
+// 令牌即将过期时重新获取，获取成功后发布运行时变更，引擎原地替换运行时配置使用新令牌
+func refreshOauth2Token(dsName, configHash string) {
+	ds, _ := models.DatasourceRoot.GetByDataName(dsName)
+	if ds == nil || !ds.Enabled {
+		oauth2TokenCache.Delete(dsName)
+		return
+	}
+
+	auth := getDatasourceAuth(ds)
+	if auth == nil || auth.Oauth2 == nil {
+		oauth2TokenCache.Delete(dsName)
+		return
+	}
+
+	token, err := fetchOauth2Token(dsName, auth.Oauth2)
+	if err != nil {
+		logger.Warn("refresh datasource oauth2 token failed", zap.Error(err), zap.String(datasourceModelName, dsName))
+		return
+	}
+
+	if token.configHash == configHash && utils.EngineStarted() {
+		logger.Debug("datasource oauth2 token refreshed", zap.String(datasourceModelName, dsName))
+		eventbus.Publish(eventbus.ChannelDatasource, eventbus.EventRuntime, dsName)
+	}
+}
+
+func getDatasourceAuth(ds *models.Datasource) *models.CustomAuth {
+	switch ds.Kind {
+	case wgpb.DataSourceKind_REST:
+		if ds.CustomRest != nil {
+			return ds.CustomRest.Auth
+		}
+	case wgpb.DataSourceKind_GRAPHQL:
+		if ds.CustomGraphql != nil {
+			return ds.CustomGraphql.Auth
+		}
+	}
+	return nil
+}
+
+// 运行时根据数据源名称读取认证配置
+func getDatasourceAuthByName(dsName string) *models.CustomAuth {
+	ds, _ := models.DatasourceRoot.GetByDataName(dsName)
+	if ds == nil {
+		return nil
+	}
+
+	return getDatasourceAuth(ds)
+}
@@ -5,13 +5,16 @@
 package datasource
 
 import (
+	"fireboom-server/pkg/common/consts"
 	"fireboom-server/pkg/common/models"
 	"fireboom-server/pkg/common/utils"
 	"fireboom-server/pkg/plugins/fileloader"
 	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
 	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/wundergraph/wundergraph/pkg/wgpb"
 	"go.uber.org/zap"
+	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 	"sync"
 )
@@ -53,6 +56,20 @@ func cacheGraphqlSchema(dsName string, graphqlSchema string) {
 	go func() { _ = CacheGraphqlSchemaText.Write(dsName, fileloader.SystemUser, []byte(graphqlSchema)) }()
 }
 
+// 请求飞布服务内部接口的配置，请求头携带启动时生成的标识码用于内部路由鉴权
+func buildInternalFetch(method wgpb.HTTPMethod, header map[string]*wgpb.HTTPHeader, pathFormat string, pathArgs ...any) *wgpb.FetchConfiguration {
+	fetchHeader := maps.Clone(header)
+	if fetchHeader == nil {
+		fetchHeader = make(map[string]*wgpb.HTTPHeader)
+	}
+	fetchHeader[consts.HeaderParamTag] = &wgpb.HTTPHeader{Values: []*wgpb.ConfigurationVariable{utils.MakeStaticVariable(utils.RandomIdentifyCode)}}
+	return &wgpb.FetchConfiguration{
+		Url:    utils.MakeStaticVariable(fmt.Sprintf("http://localhost:%s%s", utils.GetStringWithLockViper(consts.WebPort), fmt.Sprintf(pathFormat, pathArgs...))),
+		Method: method,
+		Header: fetchHeader,
+	}
+}
+
 // 将数据源拆解成多个，组合方式为一个rootNode+其引用的childNodes
 // 有jsonField的childNode需要额外添加字段
 // 从在编译期保存的根字段引用解析出实际引用的字段定义
@@ -1,6 +1,7 @@
 // Package datasource
 /*
  数据库类型数据源的实现
+ 配置只读副本时生成连接地址指向副本负载均衡的prisma schema，query字段使用该schema，mutation字段使用主库
 */
 package datasource
 
@@ -12,6 +13,11 @@ import (
 	"fmt"
 	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -24,7 +30,12 @@ func init() {
 	actionMap[wgpb.DataSourceKind_SQLITE] = generateDatabaseFunc
 }
 
-var ignoreEmptyDatabaseError = fmt.Sprintf("[P%d]%s", i18n.PrismaError_P4001, i18n.PrismaError_P4001.Error())
+var (
+	ignoreEmptyDatabaseError  = fmt.Sprintf("[P%d]%s", i18n.PrismaError_P4001, i18n.PrismaError_P4001.Error())
+	prismaSchemaUrlLineRegexp = regexp.MustCompile(`(?m)^[ \t]*url[ \t]*=.*$`)
+)
+
+const replicaSchemaFilename = "replica.prisma"
 
 const introspectSchemaFormat = `datasource db {
   provider = "%s"
@@ -55,9 +66,75 @@ func (a *actionDatabase) BuildDataSourceConfiguration(*ast.SchemaDocument) (conf
 func (a *actionDatabase) RuntimeDataSourceConfiguration(config *wgpb.DataSourceConfiguration) (configs []*wgpb.DataSourceConfiguration, fields []*wgpb.FieldConfiguration, err error) {
 	prismaSchemaFilepath := CachePrismaSchemaText.GetPath(config.Id)
 	configs, fields = buildRuntimeDataSourceConfigurationForPrisma(prismaSchemaFilepath, config)
+	replicaSchemaFilepath, err := writeReplicaPrismaSchema(config.Id, prismaSchemaFilepath)
+	if err != nil {
+		logger.Warn("route query to database replicas failed", zap.Error(err), zap.String(datasourceModelName, config.Id))
+		err = nil
+		return
+	}
+	if replicaSchemaFilepath == "" {
+		return
+	}
+
+	routeQueryToReplica(configs, replicaSchemaFilepath)
+	return
+}
+
+// 读取主库的prisma schema并将连接地址替换成副本负载均衡的本机地址，写入到缓存目录下
+// 未配置副本时关闭负载均衡，返回空路径
+func writeReplicaPrismaSchema(dsName, prismaSchemaFilepath string) (replicaSchemaFilepath string, err error) {
+	ds, _ := models.DatasourceRoot.GetByDataName(dsName)
+	if ds == nil || ds.CustomDatabase == nil || len(ds.CustomDatabase.Replicas) == 0 {
+		closeReplicaBalancer(dsName)
+		return
+	}
+
+	primaryUrl, err := ds.CustomDatabase.GetDatabaseUrl(ds.Kind, ds.Name)
+	if err != nil {
+		return
+	}
+
+	replicaUrls, err := ds.CustomDatabase.GetReplicaDatabaseUrls(ds.Kind, ds.Name)
+	if err != nil || len(replicaUrls) == 0 {
+		closeReplicaBalancer(dsName)
+		return
+	}
+
+	balancedUrl, err := startReplicaBalancer(dsName, primaryUrl, replicaUrls)
+	if err != nil {
+		return
+	}
+
+	prismaSchema, err := os.ReadFile(prismaSchemaFilepath)
+	if err != nil {
+		return
+	}
+
+	replicaSchema := prismaSchemaUrlLineRegexp.ReplaceAllLiteral(prismaSchema, []byte(fmt.Sprintf("  url      = %s", strconv.Quote(balancedUrl))))
+	replicaSchemaFilepath = filepath.Join(filepath.Dir(prismaSchemaFilepath), replicaSchemaFilename)
+	if err = os.WriteFile(replicaSchemaFilepath, replicaSchema, 0644); err != nil {
+		replicaSchemaFilepath = ""
+	}
 	return
 }
 
+// 引擎按照字段选择数据源，query字段统一使用副本负载均衡的schema，由负载均衡按连接分配副本
+func routeQueryToReplica(configs []*wgpb.DataSourceConfiguration, replicaSchemaFilepath string) {
+	var replicaDatabase *wgpb.DataSourceCustom_Database
+	for _, item := range configs {
+		if item.CustomDatabase == nil || len(item.RootNodes) != 1 || item.RootNodes[0].TypeName != consts.TypeQuery {
+			continue
+		}
+
+		if replicaDatabase == nil {
+			replicaDatabaseCopy := *item.CustomDatabase
+			replicaDatabaseCopy.PrismaSchema, _ = filepath.Abs(replicaSchemaFilepath)
+			replicaDatabase = &replicaDatabaseCopy
+		}
+		item.CustomDatabase = replicaDatabase
+	}
+}
+
 func (a *actionDatabase) ExtendDocument(document *ast.SchemaDocument) {
 	extendOptionalRawField(a.ds.Kind, document)
 }
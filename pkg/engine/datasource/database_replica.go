@@ -0,0 +1,177 @@
+// Package datasource
+/*
+ 数据库只读副本的负载均衡
+ 引擎按照字段静态选择数据源，请求时无法切换副本，因此为每个数据源在本机监听一个端口作为副本的连接地址
+ prisma建立的每个连接按照轮询分配到健康的副本，连接失败的副本在冷却时间内跳过，所有副本不可用时连接主库
+ 转发在tcp层进行，副本需与主库使用相同的账号和数据库名，校验证书主机名的ssl配置会因连接地址为本机而失败
+*/
+package datasource
+
+import (
+	"fireboom-server/pkg/common/utils"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type (
+	replicaBalancer struct {
+		listener       net.Listener
+		targets        atomic.Pointer[replicaBalancerTargets]
+		next           atomic.Uint64
+		unhealthyUntil utils.SyncMap[string, time.Time]
+	}
+	replicaBalancerTargets struct {
+		primary  string
+		replicas []string
+	}
+)
+
+const (
+	replicaBalancerListenAddr = "127.0.0.1:0"
+	replicaDialTimeout        = 3 * time.Second
+	replicaUnhealthyCooldown  = 10 * time.Second
+	databaseUrlSchemeSep      = "://"
+	databaseUrlUserinfoSep    = "@"
+)
+
+var (
+	replicaBalancers        utils.SyncMap[string, *replicaBalancer]
+	databaseDefaultPortsMap = map[string]string{
+		"postgresql": "5432",
+		"postgres":   "5432",
+		"mysql":      "3306",
+		"sqlserver":  "1433",
+	}
+)
+
+// 启动或更新数据源的副本负载均衡，返回将主机地址替换成本机监听地址的副本连接地址
+func startReplicaBalancer(dsName, primaryUrl string, replicaUrls []string) (balancedUrl string, err error) {
+	targets := &replicaBalancerTargets{}
+	if _, targets.primary, _, err = splitDatabaseUrlHost(primaryUrl); err != nil {
+		return
+	}
+	for _, item := range replicaUrls {
+		var replica string
+		if _, replica, _, err = splitDatabaseUrlHost(item); err != nil {
+			return
+		}
+		targets.replicas = append(targets.replicas, replica)
+	}
+
+	balancer, ok := replicaBalancers.Load(dsName)
+	if !ok {
+		var listener net.Listener
+		if listener, err = net.Listen("tcp", replicaBalancerListenAddr); err != nil {
+			return
+		}
+
+		balancer = &replicaBalancer{listener: listener}
+		replicaBalancers.Store(dsName, balancer)
+		go balancer.serve()
+	}
+	balancer.targets.Store(targets)
+	prefix, _, suffix, _ := splitDatabaseUrlHost(replicaUrls[0])
+	balancedUrl = prefix + balancer.listener.Addr().String() + suffix
+	return
+}
+
+// 数据源删除副本配置后关闭本机监听
+func closeReplicaBalancer(dsName string) {
+	if balancer, ok := replicaBalancers.Load(dsName); ok {
+		replicaBalancers.Delete(dsName)
+		_ = balancer.listener.Close()
+	}
+}
+
+func (b *replicaBalancer) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go b.forward(conn)
+	}
+}
+
+func (b *replicaBalancer) forward(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	upstream := b.dial()
+	if upstream == nil {
+		return
+	}
+
+	defer func() { _ = upstream.Close() }()
+	done := make(chan struct{}, 2)
+	copyFunc := func(dst, src net.Conn) {
+		_, _ = io.Copy(dst, src)
+		done <- struct{}{}
+	}
+	go copyFunc(upstream, conn)
+	go copyFunc(conn, upstream)
+	<-done
+}
+
+// 按照轮询顺序选择健康的副本，连接失败的副本在冷却时间内跳过，所有副本不可用时连接主库
+func (b *replicaBalancer) dial() net.Conn {
+	targets := b.targets.Load()
+	start, count := b.next.Add(1), uint64(len(targets.replicas))
+	for i := uint64(0); i < count; i++ {
+		replica := targets.replicas[(start+i)%count]
+		if until, ok := b.unhealthyUntil.Load(replica); ok && time.Now().Before(until) {
+			continue
+		}
+
+		conn, err := net.DialTimeout("tcp", replica, replicaDialTimeout)
+		if err == nil {
+			return conn
+		}
+
+		b.unhealthyUntil.Store(replica, time.Now().Add(replicaUnhealthyCooldown))
+		logger.Warn("dial database replica failed", zap.Error(err), zap.String("replica", replica))
+	}
+
+	conn, err := net.DialTimeout("tcp", targets.primary, replicaDialTimeout)
+	if err != nil {
+		logger.Warn("dial database primary failed", zap.Error(err), zap.String("primary", targets.primary))
+		return nil
+	}
+	return conn
+}
+
+// 拆分连接地址中的主机和端口，sqlserver使用分号分隔参数，未指定端口时使用数据库的默认端口
+func splitDatabaseUrlHost(databaseUrl string) (prefix, host, suffix string, err error) {
+	scheme, rest, ok := strings.Cut(databaseUrl, databaseUrlSchemeSep)
+	if !ok {
+		err = fmt.Errorf("invalid database url without scheme")
+		return
+	}
+
+	authority := rest
+	if end := strings.IndexAny(rest, "/?;"); end >= 0 {
+		authority, suffix = rest[:end], rest[end:]
+	}
+	prefix = scheme + databaseUrlSchemeSep
+	if at := strings.LastIndex(authority, databaseUrlUserinfoSep); at >= 0 {
+		prefix += authority[:at+1]
+		authority = authority[at+1:]
+	}
+	if _, _, splitErr := net.SplitHostPort(authority); splitErr == nil {
+		host = authority
+		return
+	}
+
+	defaultPort, ok := databaseDefaultPortsMap[scheme]
+	if !ok || authority == "" {
+		err = fmt.Errorf("database url scheme [%s] not support replica balance", scheme)
+		return
+	}
+	host = net.JoinHostPort(authority, defaultPort)
+	return
+}
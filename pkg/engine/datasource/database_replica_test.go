@@ -0,0 +1,77 @@
+package datasource
+
+import (
+	"net"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// 副本按连接轮询分配，连接失败的副本被跳过，所有副本不可用时连接主库
+func TestReplicaBalancerDial(t *testing.T) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	listen := func() net.Listener {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = listener.Close() })
+		return listener
+	}
+	primary, replica1, replica2, stopped := listen(), listen(), listen(), listen()
+	stoppedAddr := stopped.Addr().String()
+	_ = stopped.Close()
+
+	balancer := &replicaBalancer{}
+	balancer.targets.Store(&replicaBalancerTargets{
+		primary:  primary.Addr().String(),
+		replicas: []string{replica1.Addr().String(), stoppedAddr, replica2.Addr().String()},
+	})
+	var dialed []string
+	for i := 0; i < 4; i++ {
+		conn := balancer.dial()
+		if conn == nil {
+			t.Fatalf("dial %d failed", i)
+		}
+		dialed = append(dialed, conn.RemoteAddr().String())
+		_ = conn.Close()
+	}
+	// 轮询到已停止的副本时连接下一个副本，之后在冷却时间内不再尝试
+	expected := []string{replica2.Addr().String(), replica2.Addr().String(), replica1.Addr().String(), replica2.Addr().String()}
+	for i := range expected {
+		if dialed[i] != expected[i] {
+			t.Fatalf("expected dial order %v, got %v", expected, dialed)
+		}
+	}
+	if _, ok := balancer.unhealthyUntil.Load(stoppedAddr); !ok {
+		t.Error("expected stopped replica marked unhealthy")
+	}
+
+	balancer.targets.Store(&replicaBalancerTargets{primary: primary.Addr().String(), replicas: []string{stoppedAddr}})
+	conn := balancer.dial()
+	if conn == nil || conn.RemoteAddr().String() != primary.Addr().String() {
+		t.Fatalf("expected fallback to primary, got %v", conn)
+	}
+	_ = conn.Close()
+}
+
+// 连接地址中的主机替换成本机地址，保留账号、数据库名和参数
+func TestSplitDatabaseUrlHost(t *testing.T) {
+	for _, item := range []struct {
+		url, prefix, host, suffix string
+	}{
+		{"postgresql://u:p%40ss@db:6432/app?sslmode=require", "postgresql://u:p%40ss@", "db:6432", "/app?sslmode=require"},
+		{"mysql://u:p@db/app", "mysql://u:p@", "db:3306", "/app"},
+		{"sqlserver://db;database=app", "sqlserver://", "db:1433", ";database=app"},
+	} {
+		prefix, host, suffix, err := splitDatabaseUrlHost(item.url)
+		if err != nil || prefix != item.prefix || host != item.host || suffix != item.suffix {
+			t.Errorf("%s: got %s|%s|%s (%v)", item.url, prefix, host, suffix, err)
+		}
+	}
+	if _, _, _, err := splitDatabaseUrlHost("mongodb+srv://u:p@cluster/app"); err == nil {
+		t.Error("expected mongodb srv url not supported")
+	}
+}
@@ -17,15 +17,24 @@ import (
 	"github.com/tidwall/gjson"
 	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"golang.org/x/exp/maps"
+	"google.golang.org/protobuf/proto"
 )
 
 func init() {
-	actionMap[wgpb.DataSourceKind_GRAPHQL] = func(ds *models.Datasource, _ string) Action { return &actionGraphql{ds: ds} }
+	actionMap[wgpb.DataSourceKind_GRAPHQL] = func(ds *models.Datasource, _ string) Action {
+		if ds.CustomGrpc != nil {
+			return &actionGrpc{ds: ds}
+		}
+		return &actionGraphql{ds: ds}
+	}
 }
 
 const (
 	graphqlResultDataPath   = "data.__schema"
 	graphqlResultErrorsPath = "errors.0.message"
+
+	graphqlIntrospectDefaultTimeout = 5
 )
 
 var caseInsensitive = iterator.ConfigCompatibleWithStandardLibrary
@@ -53,36 +62,63 @@ func (a *actionGraphql) Introspect() (graphqlSchema string, err error) {
 		if __schema, err = models.DatasourceCustomize.Read(a.ds.Name); err != nil {
 			return
 		}
-	} else {
-		// 发送post请求获取内省的文本
-		introspectBytes := configs.IntrospectText.GetFirstCache()
-		headers := map[string]string{echo.HeaderContentType: echo.MIMEApplicationJSON}
-		for s, httpHeader := range graphqlConfig.Headers {
-			for _, value := range httpHeader.Values {
-				headers[s] = utils.GetVariableString(value)
-			}
-		}
-		var respBody []byte
-		if respBody, err = utils.HttpPost(graphqlConfig.Endpoint, introspectBytes, headers, 5); err != nil {
-			return
-		}
+	} else if __schema, err = a.fetchEndpointSchema(); err != nil {
+		return
+	}
 
-		if errorMsg := gjson.GetBytes(respBody, graphqlResultErrorsPath); errorMsg.Exists() {
-			err = errors.New(errorMsg.String())
-			return
+	// 格式化graphql文档成文本并缓存到本地
+	if graphqlSchema, err = formatIntrospectSchema(__schema); err != nil {
+		return
+	}
+
+	cacheGraphqlSchema(a.ds.Name, graphqlSchema)
+	return
+}
+
+// 发送post请求获取内省的文本
+func (a *actionGraphql) fetchEndpointSchema() (__schema string, err error) {
+	graphqlConfig := a.ds.CustomGraphql
+	introspectBytes := configs.IntrospectText.GetFirstCache()
+	headers := map[string]string{echo.HeaderContentType: echo.MIMEApplicationJSON}
+	for s, httpHeader := range graphqlConfig.Headers {
+		for _, value := range httpHeader.Values {
+			headers[s] = utils.GetVariableString(value)
 		}
+	}
+	endpoint := graphqlConfig.Endpoint
+	var introspectAuth *authValues
+	if introspectAuth, err = resolveAuthValues(a.ds.Name, graphqlConfig.Auth); err != nil {
+		return
+	}
+	if introspectAuth != nil {
+		maps.Copy(headers, introspectAuth.headers)
+		endpoint = appendUrlQueries(endpoint, introspectAuth.queries)
+	}
+	timeout := graphqlConfig.IntrospectTimeout
+	if timeout <= 0 {
+		timeout = graphqlIntrospectDefaultTimeout
+	}
+	var respBody []byte
+	if respBody, err = utils.HttpPost(endpoint, introspectBytes, headers, int(timeout)); err != nil {
+		return
+	}
 
-		__schema = gjson.GetBytes(respBody, graphqlResultDataPath).String()
+	if errorMsg := gjson.GetBytes(respBody, graphqlResultErrorsPath); errorMsg.Exists() {
+		err = errors.New(errorMsg.String())
+		return
 	}
 
+	__schema = gjson.GetBytes(respBody, graphqlResultDataPath).String()
+	return
+}
+
+func formatIntrospectSchema(__schema string) (graphqlSchema string, err error) {
 	var result schema
 	if err = caseInsensitive.Unmarshal([]byte(__schema), &result); err != nil {
 		return
 	}
 
-	// 格式化graphql文档成文本并缓存到本地
 	graphqlSchema = formatSchemaString(&result)
-	cacheGraphqlSchema(a.ds.Name, graphqlSchema)
 	return
 }
 
@@ -128,6 +164,14 @@ func (a *actionGraphql) RuntimeDataSourceConfiguration(config *wgpb.DataSourceCo
 
 	customGraphql := *config.CustomGraphql
 	customGraphql.UpstreamSchema = graphqlSchema
+	// 钩子服务地址替换时，钩子中自定义的graphql数据源同样请求替换后的地址，不修改编译生成的配置
+	if a.ds.CustomGraphql != nil && a.ds.CustomGraphql.Customized && models.HookServerUrlRewritten() {
+		customGraphql.Fetch = proto.Clone(customGraphql.Fetch).(*wgpb.FetchConfiguration)
+		customGraphql.Fetch.BaseUrl = utils.MakeStaticVariable(models.GetEngineHookServerUrl())
+	}
+	if auth := getDatasourceAuthByName(config.Id); auth != nil {
+		customGraphql.Fetch = copyFetchWithAuthValues(customGraphql.Fetch, resolveRuntimeAuthValues(config.Id, auth), true)
+	}
 	configs, fields = copyDatasourceWithRootNodes(config, func(_ *wgpb.TypeField, configItem *wgpb.DataSourceConfiguration) bool {
 		configItem.CustomGraphql = &customGraphql
 		return true
@@ -0,0 +1,207 @@
+package datasource
+
+import (
+	"bytes"
+	"fireboom-server/pkg/common/consts"
+	"fmt"
+	json "github.com/json-iterator/go"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/validator"
+	"golang.org/x/exp/slices"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	graphqlTypenameField = "__typename"
+	// graphql要求必须存在Query类型，没有查询操作时使用的占位字段
+	graphqlPlaceholderField = "_service"
+)
+
+var queryOperationPrefixes = []string{"Get", "List", "Find", "Search", "Query", "Count", "Lookup", "Fetch", "Read", "Check"}
+
+type (
+	graphqlExecuteRequest struct {
+		Query         string         `json:"query"`
+		OperationName string         `json:"operationName"`
+		Variables     map[string]any `json:"variables"`
+	}
+	graphqlExecuteResponse struct {
+		Data   any                    `json:"data"`
+		Errors []*graphqlExecuteError `json:"errors,omitempty"`
+	}
+	graphqlExecuteError struct {
+		Message string `json:"message"`
+	}
+	// 保持查询中字段顺序的对象
+	graphqlObject      []graphqlObjectField
+	graphqlObjectField struct {
+		key   string
+		value any
+	}
+)
+
+func (o graphqlObject) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		keyBytes, _ := json.Marshal(field.key)
+		valueBytes, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(keyBytes)
+		buffer.WriteByte(':')
+		buffer.Write(valueBytes)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+// 生成标准的graphql响应，出错时仅返回errors
+func marshalGraphqlResponse(data any, err error) []byte {
+	response := &graphqlExecuteResponse{}
+	if err != nil {
+		response.Errors = []*graphqlExecuteError{{Message: err.Error()}}
+	} else {
+		response.Data = data
+	}
+
+	responseBytes, _ := json.Marshal(response)
+	return responseBytes
+}
+
+// 读取graphql请求，GET请求(sse订阅)从查询参数中读取，其他从请求体中读取
+func readGraphqlExecuteRequest(r *http.Request) (request *graphqlExecuteRequest, err error) {
+	request = &graphqlExecuteRequest{}
+	if r.Method == http.MethodGet {
+		queryValues := r.URL.Query()
+		request.Query, request.OperationName = queryValues.Get("query"), queryValues.Get("operationName")
+		if variables := queryValues.Get("variables"); variables != "" {
+			err = json.Unmarshal([]byte(variables), &request.Variables)
+		}
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(body, request)
+	return
+}
+
+// 解析并校验请求中的operation，返回校验后的变量
+func parseGraphqlOperation(schema *ast.Schema, request *graphqlExecuteRequest) (operation *ast.OperationDefinition, variables map[string]any, err error) {
+	query, queryErrs := gqlparser.LoadQuery(schema, request.Query)
+	if len(queryErrs) > 0 {
+		err = queryErrs
+		return
+	}
+
+	if operation = query.Operations.ForName(request.OperationName); operation == nil {
+		err = fmt.Errorf("operation [%s] not found", request.OperationName)
+		return
+	}
+
+	variables, err = validator.VariableValues(schema, operation, request.Variables)
+	return
+}
+
+// 展开片段并处理skip/include指令，相同别名的字段仅保留第一个
+func collectGraphqlFields(selectionSet ast.SelectionSet, variables map[string]any) (fields []*ast.Field) {
+	for _, selection := range selectionSet {
+		switch item := selection.(type) {
+		case *ast.Field:
+			if shouldIncludeGraphqlSelection(item.Directives, variables) && !slices.ContainsFunc(fields, func(field *ast.Field) bool { return field.Alias == item.Alias }) {
+				fields = append(fields, item)
+			}
+		case *ast.InlineFragment:
+			if shouldIncludeGraphqlSelection(item.Directives, variables) {
+				fields = append(fields, collectGraphqlFields(item.SelectionSet, variables)...)
+			}
+		case *ast.FragmentSpread:
+			if shouldIncludeGraphqlSelection(item.Directives, variables) && item.Definition != nil {
+				fields = append(fields, collectGraphqlFields(item.Definition.SelectionSet, variables)...)
+			}
+		}
+	}
+	return
+}
+
+func shouldIncludeGraphqlSelection(directives ast.DirectiveList, variables map[string]any) bool {
+	if skip := directives.ForName("skip"); skip != nil && skip.ArgumentMap(variables)["if"] == true {
+		return false
+	}
+	if include := directives.ForName("include"); include != nil && include.ArgumentMap(variables)["if"] == false {
+		return false
+	}
+	return true
+}
+
+// 按照查询中选择的字段裁剪对象和数组，typeName用于__typename
+func projectGraphqlValue(value any, typeName string, selectionSet ast.SelectionSet, variables map[string]any) any {
+	if len(selectionSet) == 0 {
+		return value
+	}
+
+	switch v := value.(type) {
+	case []any:
+		items := make([]any, 0, len(v))
+		for _, item := range v {
+			items = append(items, projectGraphqlValue(item, typeName, selectionSet, variables))
+		}
+		return items
+	case map[string]any:
+		var object graphqlObject
+		for _, field := range collectGraphqlFields(selectionSet, variables) {
+			var fieldValue any = typeName
+			if field.Name != graphqlTypenameField {
+				fieldValue = projectGraphqlValue(v[field.Name], graphqlFieldTypeName(field), field.SelectionSet, variables)
+			}
+			object = append(object, graphqlObjectField{key: field.Alias, value: fieldValue})
+		}
+		return object
+	}
+	return value
+}
+
+func graphqlFieldTypeName(field *ast.Field) string {
+	if field.Definition == nil {
+		return ""
+	}
+
+	return field.Definition.Type.Name()
+}
+
+func rootOperationTypeName(operation ast.Operation) string {
+	switch operation {
+	case ast.Mutation:
+		return consts.TypeMutation
+	case ast.Subscription:
+		return consts.TypeSubscription
+	default:
+		return consts.TypeQuery
+	}
+}
+
+// 配置的操作类型优先，否则按照名称前缀推断为query或mutation
+func resolveOperationByName(name, configured string) ast.Operation {
+	switch strings.ToLower(configured) {
+	case strings.ToLower(consts.TypeQuery):
+		return ast.Query
+	case strings.ToLower(consts.TypeMutation):
+		return ast.Mutation
+	}
+
+	if slices.ContainsFunc(queryOperationPrefixes, func(prefix string) bool { return strings.HasPrefix(name, prefix) }) {
+		return ast.Query
+	}
+	return ast.Mutation
+}
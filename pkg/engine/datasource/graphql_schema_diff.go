@@ -0,0 +1,307 @@
+// Package datasource
+/*
+ 比对graphql数据源上游schema与缓存schema的差异
+ 变更按类型、字段、参数、枚举值和联合类型成员记录，并区分是否为破坏性变更
+ 通过解析operation的查询文档，找出引用了破坏性变更的operation
+*/
+package datasource
+
+import (
+	"fmt"
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/exp/slices"
+	"strings"
+)
+
+const (
+	SchemaChangeAdded   = "added"
+	SchemaChangeRemoved = "removed"
+	SchemaChangeChanged = "changed"
+)
+
+type (
+	// SchemaDriftChange schema变更项，coordinate格式为Type、Type.field、Type.field(arg)
+	SchemaDriftChange struct {
+		Kind       string `json:"kind"`
+		Coordinate string `json:"coordinate"`
+		Breaking   bool   `json:"breaking"`
+		Detail     string `json:"detail,omitempty"`
+
+		usageKey string
+	}
+	// 记录operation引用到的类型、字段和参数
+	graphqlSchemaUsage struct {
+		doc       *ast.SchemaDocument
+		query     *ast.QueryDocument
+		prefix    string
+		keys      map[string]bool
+		fragments map[string]bool
+	}
+)
+
+// 比对新旧schema文档，返回按coordinate排序的变更列表
+func diffGraphqlSchema(oldDoc, newDoc *ast.SchemaDocument) (changes []*SchemaDriftChange) {
+	for _, oldDef := range oldDoc.Definitions {
+		newDef := newDoc.Definitions.ForName(oldDef.Name)
+		if newDef == nil {
+			changes = append(changes, &SchemaDriftChange{Kind: SchemaChangeRemoved, Coordinate: oldDef.Name, Breaking: true})
+			continue
+		}
+
+		if oldDef.Kind != newDef.Kind {
+			changes = append(changes, &SchemaDriftChange{Kind: SchemaChangeChanged, Coordinate: oldDef.Name, Breaking: true,
+				Detail: fmt.Sprintf("%s -> %s", oldDef.Kind, newDef.Kind)})
+			continue
+		}
+
+		switch oldDef.Kind {
+		case ast.Object, ast.Interface:
+			changes = append(changes, diffOutputFields(oldDef, newDef)...)
+		case ast.InputObject:
+			changes = append(changes, diffInputFields(oldDef, newDef)...)
+		case ast.Enum:
+			changes = append(changes, diffNames(oldDef.Name, enumValueNames(oldDef), enumValueNames(newDef))...)
+		case ast.Union:
+			changes = append(changes, diffNames(oldDef.Name, oldDef.Types, newDef.Types)...)
+		}
+	}
+	for _, newDef := range newDoc.Definitions {
+		if oldDoc.Definitions.ForName(newDef.Name) == nil {
+			changes = append(changes, &SchemaDriftChange{Kind: SchemaChangeAdded, Coordinate: newDef.Name})
+		}
+	}
+
+	for _, change := range changes {
+		if change.usageKey == "" {
+			change.usageKey = change.Coordinate
+		}
+	}
+	slices.SortFunc(changes, func(a, b *SchemaDriftChange) bool { return a.Coordinate < b.Coordinate })
+	return
+}
+
+// 输出字段删除或类型变更为破坏性变更，参数删除、类型变更及新增必填参数为破坏性变更
+func diffOutputFields(oldDef, newDef *ast.Definition) (changes []*SchemaDriftChange) {
+	for _, oldField := range oldDef.Fields {
+		coordinate := oldDef.Name + "." + oldField.Name
+		newField := newDef.Fields.ForName(oldField.Name)
+		if newField == nil {
+			changes = append(changes, &SchemaDriftChange{Kind: SchemaChangeRemoved, Coordinate: coordinate, Breaking: true})
+			continue
+		}
+
+		if oldType, newType := oldField.Type.String(), newField.Type.String(); oldType != newType {
+			changes = append(changes, &SchemaDriftChange{Kind: SchemaChangeChanged, Coordinate: coordinate,
+				Breaking: !isOutputTypeCompatible(oldField.Type, newField.Type), Detail: oldType + " -> " + newType})
+		}
+		for _, oldArg := range oldField.Arguments {
+			argCoordinate := fmt.Sprintf("%s(%s)", coordinate, oldArg.Name)
+			newArg := newField.Arguments.ForName(oldArg.Name)
+			if newArg == nil {
+				changes = append(changes, &SchemaDriftChange{Kind: SchemaChangeRemoved, Coordinate: argCoordinate, Breaking: true})
+				continue
+			}
+
+			if oldType, newType := oldArg.Type.String(), newArg.Type.String(); oldType != newType {
+				changes = append(changes, &SchemaDriftChange{Kind: SchemaChangeChanged, Coordinate: argCoordinate,
+					Breaking: !isInputTypeCompatible(oldArg.Type, newArg.Type), Detail: oldType + " -> " + newType})
+			}
+		}
+		for _, newArg := range newField.Arguments {
+			if oldField.Arguments.ForName(newArg.Name) == nil {
+				// 新增的必填参数会影响所有引用该字段的operation
+				changes = append(changes, &SchemaDriftChange{Kind: SchemaChangeAdded, Coordinate: fmt.Sprintf("%s(%s)", coordinate, newArg.Name),
+					Breaking: isRequiredInput(newArg.Type, newArg.DefaultValue), usageKey: coordinate})
+			}
+		}
+	}
+	for _, newField := range newDef.Fields {
+		if oldDef.Fields.ForName(newField.Name) == nil {
+			changes = append(changes, &SchemaDriftChange{Kind: SchemaChangeAdded, Coordinate: newDef.Name + "." + newField.Name})
+		}
+	}
+	return
+}
+
+// 输入字段删除、类型变更及新增必填字段为破坏性变更，按类型名称匹配引用
+func diffInputFields(oldDef, newDef *ast.Definition) (changes []*SchemaDriftChange) {
+	for _, oldField := range oldDef.Fields {
+		coordinate := oldDef.Name + "." + oldField.Name
+		newField := newDef.Fields.ForName(oldField.Name)
+		if newField == nil {
+			changes = append(changes, &SchemaDriftChange{Kind: SchemaChangeRemoved, Coordinate: coordinate, Breaking: true, usageKey: oldDef.Name})
+			continue
+		}
+
+		if oldType, newType := oldField.Type.String(), newField.Type.String(); oldType != newType {
+			changes = append(changes, &SchemaDriftChange{Kind: SchemaChangeChanged, Coordinate: coordinate,
+				Breaking: !isInputTypeCompatible(oldField.Type, newField.Type), Detail: oldType + " -> " + newType, usageKey: oldDef.Name})
+		}
+	}
+	for _, newField := range newDef.Fields {
+		if oldDef.Fields.ForName(newField.Name) == nil {
+			changes = append(changes, &SchemaDriftChange{Kind: SchemaChangeAdded, Coordinate: newDef.Name + "." + newField.Name,
+				Breaking: isRequiredInput(newField.Type, newField.DefaultValue), usageKey: newDef.Name})
+		}
+	}
+	return
+}
+
+// 枚举值和联合类型成员，删除为破坏性变更，按类型名称匹配引用
+func diffNames(typeName string, oldNames, newNames []string) (changes []*SchemaDriftChange) {
+	for _, name := range oldNames {
+		if !slices.Contains(newNames, name) {
+			changes = append(changes, &SchemaDriftChange{Kind: SchemaChangeRemoved, Coordinate: typeName + "." + name, Breaking: true, usageKey: typeName})
+		}
+	}
+	for _, name := range newNames {
+		if !slices.Contains(oldNames, name) {
+			changes = append(changes, &SchemaDriftChange{Kind: SchemaChangeAdded, Coordinate: typeName + "." + name, usageKey: typeName})
+		}
+	}
+	return
+}
+
+func enumValueNames(definition *ast.Definition) (names []string) {
+	for _, value := range definition.EnumValues {
+		names = append(names, value.Name)
+	}
+	return
+}
+
+func isRequiredInput(t *ast.Type, defaultValue *ast.Value) bool {
+	return t.NonNull && defaultValue == nil
+}
+
+// 输出类型仅由可空变为非空时兼容
+func isOutputTypeCompatible(oldType, newType *ast.Type) bool {
+	if newType.NonNull && !oldType.NonNull {
+		return isOutputTypeCompatible(oldType, &ast.Type{NamedType: newType.NamedType, Elem: newType.Elem})
+	}
+
+	return isSameTypeStructure(oldType, newType, isOutputTypeCompatible)
+}
+
+// 输入类型仅由非空变为可空时兼容
+func isInputTypeCompatible(oldType, newType *ast.Type) bool {
+	if oldType.NonNull && !newType.NonNull {
+		return isInputTypeCompatible(&ast.Type{NamedType: oldType.NamedType, Elem: oldType.Elem}, newType)
+	}
+
+	return isSameTypeStructure(oldType, newType, isInputTypeCompatible)
+}
+
+func isSameTypeStructure(oldType, newType *ast.Type, elemCompatible func(*ast.Type, *ast.Type) bool) bool {
+	if oldType.NonNull != newType.NonNull {
+		return false
+	}
+
+	if oldType.Elem != nil || newType.Elem != nil {
+		return oldType.Elem != nil && newType.Elem != nil && elemCompatible(oldType.Elem, newType.Elem)
+	}
+
+	return oldType.NamedType == newType.NamedType
+}
+
+// 收集operation引用的类型、字段和参数，根字段和类型名称需去除数据源名称前缀
+func collectGraphqlSchemaUsage(dsName string, doc *ast.SchemaDocument, query *ast.QueryDocument) map[string]bool {
+	usage := &graphqlSchemaUsage{
+		doc:       doc,
+		query:     query,
+		prefix:    dsName + "_",
+		keys:      make(map[string]bool),
+		fragments: make(map[string]bool),
+	}
+	for _, operation := range query.Operations {
+		usage.walkSelectionSet(usage.rootTypeName(operation.Operation), operation.SelectionSet, true)
+	}
+	return usage.keys
+}
+
+func (u *graphqlSchemaUsage) rootTypeName(operation ast.Operation) string {
+	for _, schemaDef := range u.doc.Schema {
+		for _, operationType := range schemaDef.OperationTypes {
+			if operationType.Operation == operation {
+				return operationType.Type
+			}
+		}
+	}
+
+	return strings.ToUpper(string(operation[:1])) + string(operation[1:])
+}
+
+func (u *graphqlSchemaUsage) walkSelectionSet(typeName string, selectionSet ast.SelectionSet, isRoot bool) {
+	definition := u.doc.Definitions.ForName(typeName)
+	if definition == nil {
+		return
+	}
+
+	u.keys[typeName] = true
+	for _, selection := range selectionSet {
+		switch item := selection.(type) {
+		case *ast.Field:
+			fieldName := item.Name
+			if isRoot {
+				// 根字段仅处理当前数据源的字段
+				if !strings.HasPrefix(fieldName, u.prefix) {
+					continue
+				}
+				fieldName = strings.TrimPrefix(fieldName, u.prefix)
+			}
+			fieldDef := definition.Fields.ForName(fieldName)
+			if fieldDef == nil {
+				continue
+			}
+
+			coordinate := typeName + "." + fieldName
+			u.keys[coordinate] = true
+			for _, arg := range item.Arguments {
+				if argDef := fieldDef.Arguments.ForName(arg.Name); argDef != nil {
+					u.keys[fmt.Sprintf("%s(%s)", coordinate, arg.Name)] = true
+					u.walkInputType(argDef.Type.Name())
+				}
+			}
+			u.walkSelectionSet(fieldDef.Type.Name(), item.SelectionSet, false)
+			u.keys[fieldDef.Type.Name()] = true
+		case *ast.InlineFragment:
+			u.walkSelectionSet(u.trimTypeCondition(item.TypeCondition, typeName), item.SelectionSet, isRoot)
+		case *ast.FragmentSpread:
+			if u.fragments[item.Name] {
+				continue
+			}
+
+			u.fragments[item.Name] = true
+			if fragment := u.query.Fragments.ForName(item.Name); fragment != nil {
+				u.walkSelectionSet(u.trimTypeCondition(fragment.TypeCondition, typeName), fragment.SelectionSet, isRoot)
+			}
+		}
+	}
+}
+
+// 记录入参引用的输入类型和枚举，输入类型需递归处理其字段
+func (u *graphqlSchemaUsage) walkInputType(typeName string) {
+	if u.keys[typeName] {
+		return
+	}
+
+	definition := u.doc.Definitions.ForName(typeName)
+	if definition == nil {
+		return
+	}
+
+	u.keys[typeName] = true
+	for _, field := range definition.Fields {
+		u.walkInputType(field.Type.Name())
+	}
+}
+
+func (u *graphqlSchemaUsage) trimTypeCondition(typeCondition, defaultName string) string {
+	if typeCondition == "" {
+		return defaultName
+	}
+
+	if trimmed := strings.TrimPrefix(typeCondition, u.prefix); trimmed != typeCondition || u.doc.Definitions.ForName(trimmed) != nil {
+		return trimmed
+	}
+	return defaultName
+}
@@ -0,0 +1,105 @@
+package datasource
+
+import (
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"testing"
+)
+
+func TestDiffGraphqlSchema(t *testing.T) {
+	tests := []struct {
+		name, oldSchema, newSchema string
+		expected                   []SchemaDriftChange
+	}{
+		{
+			name:      "unchanged",
+			oldSchema: `type Query { pet(id: ID!): Pet } type Pet { name: String }`,
+			newSchema: `type Query { pet(id: ID!): Pet } type Pet { name: String }`,
+		},
+		{
+			name:      "type added and removed",
+			oldSchema: `type Query { a: Int } type Old { a: Int }`,
+			newSchema: `type Query { a: Int } type New { a: Int }`,
+			expected: []SchemaDriftChange{
+				{Kind: SchemaChangeAdded, Coordinate: "New"},
+				{Kind: SchemaChangeRemoved, Coordinate: "Old", Breaking: true},
+			},
+		},
+		{
+			name:      "type kind changed",
+			oldSchema: `type Query { a: Pet } type Pet { a: Int }`,
+			newSchema: `type Query { a: Pet } interface Pet { a: Int }`,
+			expected: []SchemaDriftChange{
+				{Kind: SchemaChangeChanged, Coordinate: "Pet", Breaking: true, Detail: "OBJECT -> INTERFACE"},
+			},
+		},
+		{
+			name:      "output field nullability",
+			oldSchema: `type Query { a: Int b: Int! c: [Int] }`,
+			newSchema: `type Query { a: Int! b: Int c: [Int!]! }`,
+			expected: []SchemaDriftChange{
+				{Kind: SchemaChangeChanged, Coordinate: "Query.a", Detail: "Int -> Int!"},
+				{Kind: SchemaChangeChanged, Coordinate: "Query.b", Breaking: true, Detail: "Int! -> Int"},
+				{Kind: SchemaChangeChanged, Coordinate: "Query.c", Detail: "[Int] -> [Int!]!"},
+			},
+		},
+		{
+			name:      "field arguments",
+			oldSchema: `type Query { pets(limit: Int, tag: String!, owner: String): [String] }`,
+			newSchema: `type Query { pets(limit: Int!, tag: String, page: Int!, size: Int = 10, sort: String): [String] }`,
+			expected: []SchemaDriftChange{
+				{Kind: SchemaChangeChanged, Coordinate: "Query.pets(limit)", Breaking: true, Detail: "Int -> Int!"},
+				{Kind: SchemaChangeRemoved, Coordinate: "Query.pets(owner)", Breaking: true},
+				{Kind: SchemaChangeAdded, Coordinate: "Query.pets(page)", Breaking: true},
+				{Kind: SchemaChangeAdded, Coordinate: "Query.pets(size)"},
+				{Kind: SchemaChangeAdded, Coordinate: "Query.pets(sort)"},
+				{Kind: SchemaChangeChanged, Coordinate: "Query.pets(tag)", Detail: "String! -> String"},
+			},
+		},
+		{
+			name:      "input fields",
+			oldSchema: `input Filter { name: String! tag: String }`,
+			newSchema: `input Filter { name: String age: Int! page: Int }`,
+			expected: []SchemaDriftChange{
+				{Kind: SchemaChangeAdded, Coordinate: "Filter.age", Breaking: true},
+				{Kind: SchemaChangeChanged, Coordinate: "Filter.name", Detail: "String! -> String"},
+				{Kind: SchemaChangeAdded, Coordinate: "Filter.page"},
+				{Kind: SchemaChangeRemoved, Coordinate: "Filter.tag", Breaking: true},
+			},
+		},
+		{
+			name:      "enum values and union members",
+			oldSchema: `enum Status { ON OFF } union Result = Cat | Dog type Cat { a: Int } type Dog { a: Int }`,
+			newSchema: `enum Status { ON PAUSED } union Result = Cat type Cat { a: Int } type Dog { a: Int }`,
+			expected: []SchemaDriftChange{
+				{Kind: SchemaChangeRemoved, Coordinate: "Result.Dog", Breaking: true},
+				{Kind: SchemaChangeRemoved, Coordinate: "Status.OFF", Breaking: true},
+				{Kind: SchemaChangeAdded, Coordinate: "Status.PAUSED"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldDoc, err := parser.ParseSchema(&ast.Source{Name: "old", Input: tt.oldSchema})
+			if err != nil {
+				t.Fatal(err)
+			}
+			newDoc, err := parser.ParseSchema(&ast.Source{Name: "new", Input: tt.newSchema})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			changes := diffGraphqlSchema(oldDoc, newDoc)
+			if len(changes) != len(tt.expected) {
+				t.Fatalf("expected %d changes, got %d: %v", len(tt.expected), len(changes), changes)
+			}
+			for i, change := range changes {
+				actual := *change
+				actual.usageKey = ""
+				if actual != tt.expected[i] {
+					t.Errorf("change %d: expected %+v, got %+v", i, tt.expected[i], actual)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+// Package datasource
+/*
+ graphql数据源上游schema定时轮询
+ 开启轮询的数据源按照设定间隔在后台重新内省，与缓存的schema比对差异(不会覆盖缓存)
+ 存在差异时标记数据源schema漂移，并打印带有数据源名称的日志推送到问题列表
+ 开启自动编译且无破坏性变更时触发增量编译
+*/
+package datasource
+
+import (
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/plugins/i18n"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/wundergraph/wundergraph/pkg/eventbus"
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+	"strings"
+	"time"
+)
+
+const (
+	graphqlSchemaPollingTick            = 5 * time.Second
+	graphqlSchemaPollingDefaultInterval = 300
+	graphqlSchemaPollingMinInterval     = 30
+	graphqlSchemaDriftSeparator         = ", "
+)
+
+// SchemaDrift 上游schema与缓存schema的差异，BrokenOperations为引用了破坏性变更的operation
+type SchemaDrift struct {
+	Datasource       string               `json:"datasource"`
+	CheckTime        string               `json:"checkTime"`
+	Breaking         bool                 `json:"breaking"`
+	Changes          []*SchemaDriftChange `json:"changes"`
+	BrokenOperations []string             `json:"brokenOperations"`
+}
+
+var (
+	graphqlSchemaPollingTimes utils.SyncMap[string, time.Time]
+	graphqlSchemaPollingFlags utils.SyncMap[string, bool]
+	graphqlSchemaDrifts       utils.SyncMap[string, *SchemaDrift]
+)
+
+func init() {
+	utils.RegisterInitMethod(40, func() {
+		go func() {
+			for range time.Tick(graphqlSchemaPollingTick) {
+				pollGraphqlSchemas()
+			}
+		}()
+	})
+}
+
+// GetSchemaDrift 获取数据源最近一次轮询发现的schema差异，无差异时返回nil
+func GetSchemaDrift(dsName string) *SchemaDrift {
+	drift, _ := graphqlSchemaDrifts.Load(dsName)
+	return drift
+}
+
+// 检查所有开启轮询的数据源是否到达轮询时间，引擎未启动时跳过
+func pollGraphqlSchemas() {
+	if !utils.EngineStarted() {
+		return
+	}
+
+	now := time.Now()
+	sources := models.DatasourceRoot.ListByCondition(func(item *models.Datasource) bool {
+		return item.Enabled && isGraphqlSchemaPollingEnabled(item)
+	})
+	graphqlSchemaPollingTimes.Range(func(name string, _ time.Time) bool {
+		if !slices.ContainsFunc(sources, func(item *models.Datasource) bool { return item.Name == name }) {
+			graphqlSchemaPollingTimes.Delete(name)
+			clearSchemaDrift(name)
+		}
+		return true
+	})
+	for _, ds := range sources {
+		interval := max(ds.CustomGraphql.SchemaPolling.Interval, graphqlSchemaPollingMinInterval)
+		if ds.CustomGraphql.SchemaPolling.Interval <= 0 {
+			interval = graphqlSchemaPollingDefaultInterval
+		}
+		nextTime, ok := graphqlSchemaPollingTimes.Load(ds.Name)
+		if !ok {
+			// 首次轮询从下一个间隔开始，编译时已经完成内省
+			graphqlSchemaPollingTimes.Store(ds.Name, now.Add(time.Duration(interval)*time.Second))
+			continue
+		}
+		if now.Before(nextTime) {
+			continue
+		}
+
+		graphqlSchemaPollingTimes.Store(ds.Name, now.Add(time.Duration(interval)*time.Second))
+		if _, polling := graphqlSchemaPollingFlags.LoadOrStore(ds.Name, true); polling {
+			continue
+		}
+
+		go func(item *models.Datasource) {
+			defer graphqlSchemaPollingFlags.Delete(item.Name)
+			pollGraphqlSchema(item)
+		}(ds)
+	}
+}
+
+// 仅通过endpoint内省的数据源支持轮询
+func isGraphqlSchemaPollingEnabled(ds *models.Datasource) bool {
+	if ds.Kind != wgpb.DataSourceKind_GRAPHQL || ds.CustomGraphql == nil {
+		return false
+	}
+
+	graphqlConfig := ds.CustomGraphql
+	return !graphqlConfig.Customized && graphqlConfig.SchemaFilepath == "" &&
+		graphqlConfig.SchemaPolling != nil && graphqlConfig.SchemaPolling.Enabled
+}
+
+// 重新内省并与缓存比对，内省失败时仅打印日志
+func pollGraphqlSchema(ds *models.Datasource) {
+	cachedSchema, _ := CacheGraphqlSchemaText.Read(ds.Name)
+	if cachedSchema == "" {
+		return
+	}
+
+	action := &actionGraphql{ds: ds}
+	__schema, err := action.fetchEndpointSchema()
+	if err != nil {
+		logger.Warn("poll graphql schema failed", zap.Error(err), zap.String(datasourceModelName, ds.Name))
+		return
+	}
+
+	upstreamSchema, err := formatIntrospectSchema(__schema)
+	if err != nil {
+		logger.Warn("poll graphql schema failed", zap.Error(err), zap.String(datasourceModelName, ds.Name))
+		return
+	}
+
+	if upstreamSchema == cachedSchema {
+		clearSchemaDrift(ds.Name)
+		return
+	}
+
+	cachedDoc, err := parser.ParseSchema(&ast.Source{Name: ds.Name, Input: cachedSchema})
+	if err != nil {
+		return
+	}
+	upstreamDoc, err := parser.ParseSchema(&ast.Source{Name: ds.Name, Input: upstreamSchema})
+	if err != nil {
+		logger.Warn("poll graphql schema failed", zap.Error(err), zap.String(datasourceModelName, ds.Name))
+		return
+	}
+
+	changes := diffGraphqlSchema(cachedDoc, upstreamDoc)
+	if len(changes) == 0 {
+		clearSchemaDrift(ds.Name)
+		return
+	}
+
+	drift := &SchemaDrift{Datasource: ds.Name, CheckTime: utils.TimeFormatNow(), Changes: changes}
+	drift.Breaking = slices.ContainsFunc(changes, func(item *SchemaDriftChange) bool { return item.Breaking })
+	if drift.Breaking {
+		drift.BrokenOperations = searchBrokenOperations(ds.Name, cachedDoc, changes)
+	}
+	// 与上次轮询的差异一致时仅更新检查时间，避免重复推送问题
+	previous, _ := graphqlSchemaDrifts.Load(ds.Name)
+	graphqlSchemaDrifts.Store(ds.Name, drift)
+	models.SetDatasourceSchemaDrifted(ds.Name, true)
+	if previous != nil && slices.EqualFunc(previous.Changes, drift.Changes, func(a, b *SchemaDriftChange) bool { return *a == *b }) {
+		return
+	}
+
+	printSchemaDrift(drift)
+
+	if !drift.Breaking && ds.CustomGraphql.SchemaPolling.AutoRebuild && utils.GetBoolWithLockViper(consts.DevMode) {
+		// 非破坏性变更自动编译，增量编译失败时全量编译
+		if !eventbus.Publish(eventbus.ChannelDatasource, eventbus.EventUpdate, ds) && utils.BuildAndStart != nil {
+			utils.BuildAndStart()
+		}
+	}
+}
+
+func clearSchemaDrift(dsName string) {
+	graphqlSchemaDrifts.Delete(dsName)
+	models.SetDatasourceSchemaDrifted(dsName, false)
+}
+
+func printSchemaDrift(drift *SchemaDrift) {
+	var coordinates []string
+	for _, change := range drift.Changes {
+		if !drift.Breaking || change.Breaking {
+			coordinates = append(coordinates, change.Kind+" "+change.Coordinate)
+		}
+	}
+	if !drift.Breaking {
+		err := i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.DatasourceSchemaDriftError, strings.Join(coordinates, graphqlSchemaDriftSeparator))
+		logger.Warn("graphql schema drifted", zap.Error(err), zap.String(datasourceModelName, drift.Datasource))
+		return
+	}
+
+	err := i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.DatasourceSchemaBreakingError,
+		strings.Join(coordinates, graphqlSchemaDriftSeparator), strings.Join(drift.BrokenOperations, graphqlSchemaDriftSeparator))
+	logger.Error("graphql schema drifted with breaking changes", zap.Error(err), zap.String(datasourceModelName, drift.Datasource))
+}
+
+// 查找引用了当前数据源破坏性变更的graphql operation
+func searchBrokenOperations(dsName string, cachedDoc *ast.SchemaDocument, changes []*SchemaDriftChange) (operationPaths []string) {
+	var breakingKeys []string
+	for _, change := range changes {
+		if change.Breaking {
+			breakingKeys = append(breakingKeys, change.usageKey)
+		}
+	}
+
+	models.OperationResultMap.Range(func(path string, operation *wgpb.Operation) bool {
+		if _, ok := operation.DatasourceQuotes[dsName]; !ok || operation.Engine != wgpb.OperationExecutionEngine_ENGINE_GRAPHQL {
+			return true
+		}
+
+		content, _ := models.OperationGraphql.Read(path)
+		query, err := parser.ParseQuery(&ast.Source{Name: path, Input: content})
+		if err != nil {
+			return true
+		}
+
+		usage := collectGraphqlSchemaUsage(dsName, cachedDoc, query)
+		if slices.ContainsFunc(breakingKeys, func(key string) bool { return usage[key] }) {
+			operationPaths = append(operationPaths, path)
+		}
+		return true
+	})
+	slices.Sort(operationPaths)
+	return
+}
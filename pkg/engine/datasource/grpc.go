@@ -0,0 +1,514 @@
+// Package datasource
+/*
+ grpc类型数据源的实现
+ 以graphql类型保存并通过customGrpc区分，读取上传的.proto文件(使用protocompile编译)或FileDescriptorSet文件，将服务方法转换成graphql字段
+ 一元方法按照配置或方法名前缀映射为query/mutation，服务端流方法映射为subscription，客户端流和双向流方法忽略
+ 消息和枚举转换成graphql类型，Timestamp/Duration/包装类型等按照protojson的格式映射为标量
+ 运行时作为graphql数据源请求飞布服务上的grpc转换接口，订阅使用sse，转换接口使用grpc-go调用服务
+*/
+package datasource
+
+import (
+	"context"
+	"errors"
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	"github.com/bufbuild/protocompile"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"go.uber.org/zap"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "google.golang.org/protobuf/types/known/anypb"
+	_ "google.golang.org/protobuf/types/known/durationpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/fieldmaskpb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func init() {
+	utils.RegisterInitMethod(40, func() {
+		models.DatasourceRoot.AddRemoveAction(func(dataName string) error {
+			closeGrpcClientConn(dataName)
+			return nil
+		})
+		models.DatasourceRoot.AddRenameAction(func(srcDataName, _ string) error {
+			closeGrpcClientConn(srcDataName)
+			return nil
+		})
+	})
+}
+
+const (
+	grpcGraphqlPathFormat = "/grpc/%s/graphql"
+	grpcProtoExtension    = ".proto"
+	grpcInputArgument     = "input"
+	grpcInputTypeSuffix   = "Input"
+	grpcTypeJson          = "JSON"
+)
+
+// GrpcGraphqlRoutePath 飞布服务上grpc转换接口的路由
+var GrpcGraphqlRoutePath = fmt.Sprintf(grpcGraphqlPathFormat, ":"+consts.PathParamDataName)
+
+var (
+	grpcDescriptors utils.SyncMap[string, *grpcDescriptor]
+	// 按照protojson的格式映射的知名类型
+	grpcWellKnownTypes = map[protoreflect.FullName]string{
+		"google.protobuf.Timestamp":   "String",
+		"google.protobuf.Duration":    "String",
+		"google.protobuf.FieldMask":   "String",
+		"google.protobuf.DoubleValue": "Float",
+		"google.protobuf.FloatValue":  "Float",
+		"google.protobuf.Int32Value":  "Int",
+		"google.protobuf.UInt32Value": "Float",
+		"google.protobuf.Int64Value":  "String",
+		"google.protobuf.UInt64Value": "String",
+		"google.protobuf.BoolValue":   "Boolean",
+		"google.protobuf.StringValue": "String",
+		"google.protobuf.BytesValue":  "String",
+		"google.protobuf.Struct":      grpcTypeJson,
+		"google.protobuf.Value":       grpcTypeJson,
+		"google.protobuf.ListValue":   grpcTypeJson,
+		"google.protobuf.Any":         grpcTypeJson,
+		"google.protobuf.Empty":       grpcTypeJson,
+	}
+)
+
+type (
+	actionGrpc struct {
+		ds *models.Datasource
+	}
+	grpcDescriptor struct {
+		filepath         string
+		modTime          time.Time
+		methodOperations map[string]string
+		types            *dynamicpb.Types
+		methods          map[string]*grpcMethod // key为graphql字段名称
+		graphqlSchema    string
+		schema           *ast.Schema
+	}
+	grpcMethod struct {
+		fieldName     string
+		operation     ast.Operation
+		inputArgument bool // 请求消息映射为标量时使用input参数，否则消息字段平铺为参数
+		descriptor    protoreflect.MethodDescriptor
+	}
+	grpcSchemaBuilder struct {
+		builder      strings.Builder
+		definedTypes map[string]bool
+		pendingTypes []func()
+		jsonRequired bool
+	}
+)
+
+func (a *actionGrpc) Introspect() (graphqlSchema string, err error) {
+	descriptor, err := loadGrpcDescriptor(a.ds)
+	if err != nil {
+		return
+	}
+
+	graphqlSchema = descriptor.graphqlSchema
+	cacheGraphqlSchema(a.ds.Name, graphqlSchema)
+	return
+}
+
+// BuildDataSourceConfiguration 服务地址在运行时确定，编译时仅保存请求头和订阅配置
+func (a *actionGrpc) BuildDataSourceConfiguration(doc *ast.SchemaDocument) (*wgpb.DataSourceConfiguration, error) {
+	rewriteHeaders := make(map[string]*wgpb.HTTPHeader)
+	if a.ds.CustomGrpc != nil {
+		rewriteHttpHeaders(a.ds.CustomGrpc.Headers, rewriteHeaders)
+	}
+	return &wgpb.DataSourceConfiguration{
+		CustomGraphql: &wgpb.DataSourceCustom_GraphQL{
+			Fetch: &wgpb.FetchConfiguration{
+				Method: wgpb.HTTPMethod_POST,
+				Header: rewriteHeaders,
+			},
+			Subscription: &wgpb.GraphQLSubscriptionConfiguration{
+				Enabled: doc.Definitions.ForName(consts.TypeSubscription) != nil,
+				UseSSE:  true,
+			},
+			Federation: &wgpb.GraphQLFederationConfiguration{},
+		},
+	}, nil
+}
+
+// RuntimeDataSourceConfiguration 转换成请求飞布服务grpc转换接口的graphql数据源
+func (a *actionGrpc) RuntimeDataSourceConfiguration(config *wgpb.DataSourceConfiguration) (configs []*wgpb.DataSourceConfiguration, fields []*wgpb.FieldConfiguration, err error) {
+	graphqlSchema, err := CacheGraphqlSchemaText.Read(config.Id)
+	if err != nil {
+		return
+	}
+
+	if config.CustomGraphql == nil {
+		err = i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.StructParamEmtpyError, "customGraphql")
+		return
+	}
+
+	customGraphql := *config.CustomGraphql
+	customGraphql.UpstreamSchema = graphqlSchema
+	customGraphql.Fetch = buildInternalFetch(customGraphql.Fetch.GetMethod(), customGraphql.Fetch.GetHeader(), grpcGraphqlPathFormat, config.Id)
+	customGraphql.Subscription = &wgpb.GraphQLSubscriptionConfiguration{Enabled: customGraphql.Subscription.GetEnabled(), Url: customGraphql.Fetch.Url, UseSSE: true}
+	configs, fields = copyDatasourceWithRootNodes(config, func(_ *wgpb.TypeField, configItem *wgpb.DataSourceConfiguration) bool {
+		configItem.Kind = wgpb.DataSourceKind_GRAPHQL
+		configItem.CustomGraphql = &customGraphql
+		return true
+	})
+	return
+}
+
+// 读取grpc描述文件，文件和方法配置未变更时使用缓存
+func loadGrpcDescriptor(ds *models.Datasource) (descriptor *grpcDescriptor, err error) {
+	if ds.CustomGrpc == nil {
+		err = i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.StructParamEmtpyError, "customGrpc")
+		return
+	}
+
+	descriptorFilepath := models.DatasourceUploadGrpc.GetPath(ds.Name)
+	fileInfo, err := os.Stat(descriptorFilepath)
+	if err != nil {
+		return
+	}
+
+	descriptor, ok := grpcDescriptors.Load(ds.Name)
+	if ok && descriptor.filepath == descriptorFilepath && descriptor.modTime.Equal(fileInfo.ModTime()) &&
+		maps.Equal(descriptor.methodOperations, ds.CustomGrpc.MethodOperations) {
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			err = i18n.NewCustomErrorWithMode(datasourceModelName, err, i18n.DatasourceGrpcDescriptorParseError, filepath.Base(descriptorFilepath))
+		}
+	}()
+	var descriptorSet *descriptorpb.FileDescriptorSet
+	if filepath.Ext(descriptorFilepath) == grpcProtoExtension {
+		descriptorSet, err = compileGrpcProtoFile(descriptorFilepath)
+	} else {
+		descriptorSet, err = readGrpcDescriptorSet(descriptorFilepath)
+	}
+	if err != nil {
+		return
+	}
+
+	files, err := buildGrpcFiles(descriptorSet)
+	if err != nil {
+		return
+	}
+
+	descriptor = &grpcDescriptor{
+		filepath:         descriptorFilepath,
+		modTime:          fileInfo.ModTime(),
+		methodOperations: maps.Clone(ds.CustomGrpc.MethodOperations),
+		types:            dynamicpb.NewTypes(files),
+		methods:          make(map[string]*grpcMethod),
+	}
+	descriptor.graphqlSchema = descriptor.buildGraphqlSchema(files)
+	if descriptor.schema, err = gqlparser.LoadSchema(&ast.Source{Name: ds.Name, Input: descriptor.graphqlSchema}); err != nil {
+		return
+	}
+
+	grpcDescriptors.Store(ds.Name, descriptor)
+	return
+}
+
+// 使用protocompile将.proto文件编译成FileDescriptorSet，导入路径为文件所在目录，知名类型使用内置的定义
+// 已注册的知名类型不放入描述集合，由buildGrpcFiles从全局注册中查找
+func compileGrpcProtoFile(protoFilepath string) (descriptorSet *descriptorpb.FileDescriptorSet, err error) {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: []string{filepath.Dir(protoFilepath)}}),
+	}
+	compiledFiles, err := compiler.Compile(context.Background(), filepath.Base(protoFilepath))
+	if err != nil {
+		return
+	}
+
+	descriptorSet = &descriptorpb.FileDescriptorSet{}
+	visitedPaths := make(map[string]bool)
+	var appendFile func(protoreflect.FileDescriptor)
+	appendFile = func(file protoreflect.FileDescriptor) {
+		if visitedPaths[file.Path()] {
+			return
+		}
+
+		visitedPaths[file.Path()] = true
+		if _, findErr := protoregistry.GlobalFiles.FindFileByPath(file.Path()); findErr == nil {
+			return
+		}
+
+		for i := 0; i < file.Imports().Len(); i++ {
+			appendFile(file.Imports().Get(i).FileDescriptor)
+		}
+		descriptorSet.File = append(descriptorSet.File, protodesc.ToFileDescriptorProto(file))
+	}
+	for _, file := range compiledFiles {
+		appendFile(file)
+	}
+	return
+}
+
+func readGrpcDescriptorSet(descriptorFilepath string) (descriptorSet *descriptorpb.FileDescriptorSet, err error) {
+	descriptorBytes, err := os.ReadFile(descriptorFilepath)
+	if err != nil {
+		return
+	}
+
+	descriptorSet = &descriptorpb.FileDescriptorSet{}
+	err = proto.Unmarshal(descriptorBytes, descriptorSet)
+	return
+}
+
+// 解析FileDescriptorSet，描述文件中未包含的依赖从已注册的知名类型中查找
+func buildGrpcFiles(descriptorSet *descriptorpb.FileDescriptorSet) (files *protoregistry.Files, err error) {
+	if len(descriptorSet.File) == 0 {
+		err = errors.New("descriptor set contains no file")
+		return
+	}
+
+	files = new(protoregistry.Files)
+	fileProtos := make(map[string]*descriptorpb.FileDescriptorProto, len(descriptorSet.File))
+	for _, item := range descriptorSet.File {
+		fileProtos[item.GetName()] = item
+	}
+	var registerFile func(string) error
+	registerFile = func(path string) error {
+		if _, findErr := files.FindFileByPath(path); findErr == nil {
+			return nil
+		}
+
+		fileProto, ok := fileProtos[path]
+		if !ok {
+			globalFile, findErr := protoregistry.GlobalFiles.FindFileByPath(path)
+			if findErr != nil {
+				return fmt.Errorf("import [%s] not found in descriptor set", path)
+			}
+			return files.RegisterFile(globalFile)
+		}
+
+		for _, dependency := range fileProto.Dependency {
+			if dependencyErr := registerFile(dependency); dependencyErr != nil {
+				return dependencyErr
+			}
+		}
+		file, newErr := protodesc.NewFile(fileProto, files)
+		if newErr != nil {
+			return newErr
+		}
+		return files.RegisterFile(file)
+	}
+	for _, item := range descriptorSet.File {
+		if err = registerFile(item.GetName()); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// 生成graphql定义，仅包含描述文件中声明的服务，服务和方法按名称排序保证输出稳定
+func (d *grpcDescriptor) buildGraphqlSchema(files *protoregistry.Files) string {
+	var services []protoreflect.ServiceDescriptor
+	files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		if _, err := protoregistry.GlobalFiles.FindFileByPath(file.Path()); err == nil {
+			return true
+		}
+		for i := 0; i < file.Services().Len(); i++ {
+			services = append(services, file.Services().Get(i))
+		}
+		return true
+	})
+	slices.SortFunc(services, func(a, b protoreflect.ServiceDescriptor) bool { return a.FullName() < b.FullName() })
+
+	schemaBuilder := &grpcSchemaBuilder{definedTypes: make(map[string]bool)}
+	operationFields := make(map[ast.Operation][]string)
+	for _, service := range services {
+		for i := 0; i < service.Methods().Len(); i++ {
+			method := service.Methods().Get(i)
+			operation, ok := d.resolveMethodOperation(method)
+			if !ok {
+				logger.Debug("ignore grpc streaming method", zap.String("method", grpcMethodPath(method)))
+				continue
+			}
+
+			item := &grpcMethod{
+				fieldName:  normalizeGrpcName(string(service.FullName())) + "_" + string(method.Name()),
+				operation:  operation,
+				descriptor: method,
+			}
+			fieldDefinition := item.fieldName + schemaBuilder.buildArguments(item)
+			fieldDefinition += ": " + schemaBuilder.messageType(method.Output(), false)
+			operationFields[operation] = append(operationFields[operation], "  "+fieldDefinition)
+			d.methods[item.fieldName] = item
+		}
+	}
+	schemaBuilder.flushPendingTypes()
+
+	var builder strings.Builder
+	if schemaBuilder.jsonRequired {
+		builder.WriteString(fmt.Sprintf("scalar %s\n\n", grpcTypeJson))
+	}
+	builder.WriteString(schemaBuilder.builder.String())
+	if len(operationFields[ast.Query]) == 0 {
+		// graphql要求必须存在Query类型
+		operationFields[ast.Query] = append(operationFields[ast.Query], "  "+graphqlPlaceholderField+": String")
+	}
+	for _, operation := range []ast.Operation{ast.Query, ast.Mutation, ast.Subscription} {
+		if fields := operationFields[operation]; len(fields) > 0 {
+			builder.WriteString(fmt.Sprintf("type %s {\n%s\n}\n\n", rootOperationTypeName(operation), strings.Join(fields, "\n")))
+		}
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// 服务端流方法为subscription，一元方法优先使用配置，否则按照方法名前缀推断
+func (d *grpcDescriptor) resolveMethodOperation(method protoreflect.MethodDescriptor) (ast.Operation, bool) {
+	switch {
+	case method.IsStreamingClient():
+		return "", false
+	case method.IsStreamingServer():
+		return ast.Subscription, true
+	}
+
+	return resolveOperationByName(string(method.Name()), d.methodOperations[grpcMethodPath(method)]), true
+}
+
+// 请求消息字段平铺为参数，请求为知名类型时使用input参数，Empty无参数
+func (b *grpcSchemaBuilder) buildArguments(method *grpcMethod) string {
+	input := method.descriptor.Input()
+	if input.FullName() == "google.protobuf.Empty" {
+		return ""
+	}
+
+	if _, ok := grpcWellKnownTypes[input.FullName()]; ok || input.Fields().Len() == 0 {
+		method.inputArgument = true
+		return fmt.Sprintf("(%s: %s)", grpcInputArgument, b.messageType(input, true))
+	}
+
+	var arguments []string
+	for i := 0; i < input.Fields().Len(); i++ {
+		field := input.Fields().Get(i)
+		arguments = append(arguments, fmt.Sprintf("%s: %s", field.JSONName(), b.fieldType(field, true)))
+	}
+	return "(" + strings.Join(arguments, ", ") + ")"
+}
+
+// 字段类型，输出时无显式存在性的字段不为空，输入时均可为空
+func (b *grpcSchemaBuilder) fieldType(field protoreflect.FieldDescriptor, input bool) string {
+	if field.IsMap() {
+		b.jsonRequired = true
+		return grpcTypeJson
+	}
+
+	var typeName string
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		typeName = "Boolean"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		typeName = "Int"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.FloatKind, protoreflect.DoubleKind:
+		typeName = "Float"
+	case protoreflect.EnumKind:
+		typeName = b.enumType(field.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		typeName = b.messageType(field.Message(), input)
+	default:
+		// 64位整数和bytes在protojson中均为字符串
+		typeName = "String"
+	}
+
+	switch {
+	case field.IsList() && input:
+		return fmt.Sprintf("[%s!]", typeName)
+	case field.IsList():
+		return fmt.Sprintf("[%s!]!", typeName)
+	case !input && !field.HasPresence():
+		return typeName + "!"
+	default:
+		return typeName
+	}
+}
+
+func (b *grpcSchemaBuilder) enumType(enum protoreflect.EnumDescriptor) string {
+	if enum.FullName() == "google.protobuf.NullValue" {
+		b.jsonRequired = true
+		return grpcTypeJson
+	}
+
+	typeName := normalizeGrpcName(string(enum.FullName()))
+	if b.definedTypes[typeName] {
+		return typeName
+	}
+
+	b.definedTypes[typeName] = true
+	b.builder.WriteString(fmt.Sprintf("enum %s {\n", typeName))
+	for i := 0; i < enum.Values().Len(); i++ {
+		b.builder.WriteString(fmt.Sprintf("  %s\n", enum.Values().Get(i).Name()))
+	}
+	b.builder.WriteString("}\n\n")
+	return typeName
+}
+
+// 消息类型，知名类型和无字段的消息映射为标量，其他消息延迟生成定义以支持递归引用
+func (b *grpcSchemaBuilder) messageType(message protoreflect.MessageDescriptor, input bool) string {
+	if scalar, ok := grpcWellKnownTypes[message.FullName()]; ok || message.Fields().Len() == 0 {
+		if !ok {
+			scalar = grpcTypeJson
+		}
+		b.jsonRequired = b.jsonRequired || scalar == grpcTypeJson
+		return scalar
+	}
+
+	typeName := normalizeGrpcName(string(message.FullName()))
+	definitionKind := "type"
+	if input {
+		typeName += grpcInputTypeSuffix
+		definitionKind = "input"
+	}
+	if b.definedTypes[typeName] {
+		return typeName
+	}
+
+	b.definedTypes[typeName] = true
+	b.pendingTypes = append(b.pendingTypes, func() {
+		var fieldDefinitions []string
+		for i := 0; i < message.Fields().Len(); i++ {
+			field := message.Fields().Get(i)
+			fieldDefinitions = append(fieldDefinitions, fmt.Sprintf("  %s: %s", field.JSONName(), b.fieldType(field, input)))
+		}
+		b.builder.WriteString(fmt.Sprintf("%s %s {\n%s\n}\n\n", definitionKind, typeName, strings.Join(fieldDefinitions, "\n")))
+	})
+	return typeName
+}
+
+func (b *grpcSchemaBuilder) flushPendingTypes() {
+	for len(b.pendingTypes) > 0 {
+		pending := b.pendingTypes[0]
+		b.pendingTypes = b.pendingTypes[1:]
+		pending()
+	}
+}
+
+// 方法的请求路径，格式为/package.Service/Method
+func grpcMethodPath(method protoreflect.MethodDescriptor) string {
+	return fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+}
+
+func normalizeGrpcName(fullName string) string {
+	return strings.ReplaceAll(fullName, ".", "_")
+}
@@ -0,0 +1,129 @@
+package datasource
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"io"
+	"strings"
+	"sync"
+)
+
+// 响应消息的最大字节数，grpc-go默认为4MB
+const grpcMaxReceiveMessageSize = 16 << 20
+
+var (
+	grpcClientConns     = make(map[string]*grpcClientConn)
+	grpcClientConnMutex sync.Mutex
+)
+
+// 按照数据源缓存的连接，服务地址变更或数据源删除/重命名时关闭
+type grpcClientConn struct {
+	endpoint string
+	conn     *grpc.ClientConn
+}
+
+// 获取数据源的grpc连接，服务地址变更时重建连接
+func getGrpcClientConn(dsName, endpoint string) (*grpc.ClientConn, error) {
+	grpcClientConnMutex.Lock()
+	defer grpcClientConnMutex.Unlock()
+
+	item, ok := grpcClientConns[dsName]
+	if ok && item.endpoint == endpoint {
+		return item.conn, nil
+	}
+
+	conn, err := newGrpcClientConn(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		_ = item.conn.Close()
+	}
+	grpcClientConns[dsName] = &grpcClientConn{endpoint: endpoint, conn: conn}
+	return conn, nil
+}
+
+func closeGrpcClientConn(dsName string) {
+	grpcClientConnMutex.Lock()
+	defer grpcClientConnMutex.Unlock()
+
+	if item, ok := grpcClientConns[dsName]; ok {
+		_ = item.conn.Close()
+		delete(grpcClientConns, dsName)
+	}
+}
+
+// https地址使用tls，http地址及未指定协议的地址使用明文
+func newGrpcClientConn(endpoint string) (*grpc.ClientConn, error) {
+	target, transportCredentials := endpoint, insecure.NewCredentials()
+	if scheme, address, ok := strings.Cut(endpoint, "://"); ok {
+		target = strings.TrimSuffix(address, "/")
+		if scheme == "https" {
+			transportCredentials = credentials.NewTLS(&tls.Config{})
+		}
+	}
+	return grpc.NewClient(target,
+		grpc.WithTransportCredentials(transportCredentials),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(grpcMaxReceiveMessageSize)))
+}
+
+// 调用grpc方法，响应中的每条消息回调一次onMessage，一元方法仅回调一次
+// 请求头转发为grpc的metadata，超时时间由ctx的deadline传递
+func invokeGrpcMethod(ctx context.Context, conn *grpc.ClientConn, method protoreflect.MethodDescriptor, header map[string]string, request proto.Message, onMessage func(proto.Message) error) (err error) {
+	ctx, cancel := context.WithCancel(metadata.NewOutgoingContext(ctx, metadata.New(header)))
+	defer cancel()
+
+	methodPath := grpcMethodPath(method)
+	if !method.IsStreamingServer() {
+		response := dynamicpb.NewMessage(method.Output())
+		if err = conn.Invoke(ctx, methodPath, request, response); err != nil {
+			return grpcStatusError(err)
+		}
+		return onMessage(response)
+	}
+
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, methodPath)
+	if err != nil {
+		return grpcStatusError(err)
+	}
+	// 发送失败时返回io.EOF，实际的错误由RecvMsg返回
+	if err = stream.SendMsg(request); err != nil && !errors.Is(err, io.EOF) {
+		return grpcStatusError(err)
+	}
+	if err = stream.CloseSend(); err != nil {
+		return grpcStatusError(err)
+	}
+
+	for {
+		response := dynamicpb.NewMessage(method.Output())
+		if err = stream.RecvMsg(response); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return grpcStatusError(err)
+		}
+		if err = onMessage(response); err != nil {
+			return
+		}
+	}
+}
+
+func grpcStatusError(err error) error {
+	grpcStatus, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	return fmt.Errorf("grpc status %s: %s", grpcStatus.Code(), grpcStatus.Message())
+}
@@ -0,0 +1,215 @@
+package datasource
+
+import (
+	"context"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	json "github.com/json-iterator/go"
+	"github.com/vektah/gqlparser/v2/ast"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type grpcExecutor struct {
+	conn       *grpc.ClientConn
+	timeout    time.Duration
+	descriptor *grpcDescriptor
+	variables  map[string]any
+	metadata   map[string]string
+}
+
+// ServeGrpcGraphql 将graphql请求转换成grpc调用，subscription以sse格式返回服务端流中的消息
+// 数据源配置的请求头由引擎解析后传入，转发为grpc的metadata
+func ServeGrpcGraphql(w http.ResponseWriter, r *http.Request, dsName string) {
+	executor, operation, err := newGrpcExecutor(r, dsName)
+	if err == nil && operation.Operation == ast.Subscription {
+		executor.serveSubscription(w, r.Context(), operation)
+		return
+	}
+
+	var data graphqlObject
+	if err == nil {
+		data, err = executor.resolveOperation(r.Context(), operation)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(marshalGraphqlResponse(data, err))
+}
+
+func newGrpcExecutor(r *http.Request, dsName string) (executor *grpcExecutor, operation *ast.OperationDefinition, err error) {
+	ds, err := models.DatasourceRoot.GetByDataName(dsName)
+	if err != nil {
+		return
+	}
+
+	descriptor, err := loadGrpcDescriptor(ds)
+	if err != nil {
+		return
+	}
+
+	endpoint := utils.GetVariableString(ds.CustomGrpc.Endpoint)
+	if endpoint == "" {
+		err = i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.StructParamEmtpyError, "endpoint")
+		return
+	}
+
+	conn, err := getGrpcClientConn(ds.Name, endpoint)
+	if err != nil {
+		return
+	}
+
+	request, err := readGraphqlExecuteRequest(r)
+	if err != nil {
+		return
+	}
+
+	operation, variables, err := parseGraphqlOperation(descriptor.schema, request)
+	if err != nil {
+		return
+	}
+
+	metadata := make(map[string]string)
+	for name := range ds.CustomGrpc.Headers {
+		if value := r.Header.Get(name); value != "" {
+			metadata[strings.ToLower(name)] = value
+		}
+	}
+	executor = &grpcExecutor{
+		conn:       conn,
+		timeout:    time.Duration(ds.CustomGrpc.Timeout) * time.Second,
+		descriptor: descriptor,
+		variables:  variables,
+		metadata:   metadata,
+	}
+	return
+}
+
+// 按照顺序依次调用一元方法
+func (e *grpcExecutor) resolveOperation(ctx context.Context, operation *ast.OperationDefinition) (result graphqlObject, err error) {
+	for _, field := range collectGraphqlFields(operation.SelectionSet, e.variables) {
+		var value any
+		switch field.Name {
+		case graphqlTypenameField:
+			value = rootOperationTypeName(operation.Operation)
+		case graphqlPlaceholderField:
+		default:
+			if value, err = e.resolveUnaryField(ctx, field); err != nil {
+				return
+			}
+		}
+		result = append(result, graphqlObjectField{key: field.Alias, value: value})
+	}
+	return
+}
+
+func (e *grpcExecutor) resolveUnaryField(ctx context.Context, field *ast.Field) (value any, err error) {
+	method, err := e.getMethod(field)
+	if err != nil {
+		return
+	}
+
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+	err = e.invoke(ctx, method, field, func(item any) error {
+		value = item
+		return nil
+	})
+	return
+}
+
+// 服务端流的每条消息作为一个next事件，结束或出错后发送complete事件
+func (e *grpcExecutor) serveSubscription(w http.ResponseWriter, ctx context.Context, operation *ast.OperationDefinition) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	writeEvent := func(event string, data []byte) {
+		if data != nil {
+			_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		} else {
+			_, _ = fmt.Fprintf(w, "event: %s\n\n", event)
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	fields := collectGraphqlFields(operation.SelectionSet, e.variables)
+	if len(fields) > 0 {
+		field := fields[0]
+		method, err := e.getMethod(field)
+		if err == nil {
+			err = e.invoke(ctx, method, field, func(item any) error {
+				writeEvent("next", marshalGraphqlResponse(graphqlObject{{key: field.Alias, value: item}}, nil))
+				return nil
+			})
+		}
+		if err != nil && ctx.Err() == nil {
+			writeEvent("next", marshalGraphqlResponse(nil, err))
+		}
+	}
+	writeEvent("complete", nil)
+}
+
+func (e *grpcExecutor) getMethod(field *ast.Field) (*grpcMethod, error) {
+	method, ok := e.descriptor.methods[field.Name]
+	if !ok {
+		return nil, i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.DatasourceGrpcMethodNotFoundError, field.Name)
+	}
+
+	return method, nil
+}
+
+// 参数使用protojson转换成请求消息，响应消息使用protojson转换后按照选择的字段裁剪
+func (e *grpcExecutor) invoke(ctx context.Context, method *grpcMethod, field *ast.Field, onValue func(any) error) error {
+	requestMessage, err := e.buildRequestMessage(method, field)
+	if err != nil {
+		return err
+	}
+
+	typeName := graphqlFieldTypeName(field)
+	return invokeGrpcMethod(ctx, e.conn, method.descriptor, e.metadata, requestMessage, func(responseMessage proto.Message) error {
+		responseBytes, marshalErr := protojson.MarshalOptions{EmitUnpopulated: true, Resolver: e.descriptor.types}.Marshal(responseMessage)
+		if marshalErr != nil {
+			return marshalErr
+		}
+
+		var response any
+		if unmarshalErr := json.Unmarshal(responseBytes, &response); unmarshalErr != nil {
+			return unmarshalErr
+		}
+		return onValue(projectGraphqlValue(response, typeName, field.SelectionSet, e.variables))
+	})
+}
+
+func (e *grpcExecutor) buildRequestMessage(method *grpcMethod, field *ast.Field) (*dynamicpb.Message, error) {
+	arguments := field.ArgumentMap(e.variables)
+	var requestValue any = arguments
+	if method.inputArgument {
+		requestValue = arguments[grpcInputArgument]
+	}
+
+	requestMessage := dynamicpb.NewMessage(method.descriptor.Input())
+	if requestValue == nil {
+		return requestMessage, nil
+	}
+
+	requestBytes, err := json.Marshal(requestValue)
+	if err != nil {
+		return nil, err
+	}
+
+	err = protojson.UnmarshalOptions{DiscardUnknown: true, Resolver: e.descriptor.types}.Unmarshal(requestBytes, requestMessage)
+	return requestMessage, err
+}
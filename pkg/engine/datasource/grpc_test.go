@@ -0,0 +1,262 @@
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 测试用的服务，依赖的知名类型不包含在描述文件中
+func buildGrpcTestDescriptorSet() *descriptorpb.FileDescriptorSet {
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	field := func(name string, number int32, label *descriptorpb.FieldDescriptorProto_Label, kind descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		item := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), JsonName: proto.String(name), Number: proto.Int32(number), Label: label, Type: kind.Enum()}
+		if typeName != "" {
+			item.TypeName = proto.String(typeName)
+		}
+		return item
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("greeter.proto"),
+		Package:    proto.String("test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto", "google/protobuf/wrappers.proto"},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Mood"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("MOOD_UNKNOWN"), Number: proto.Int32(0)},
+				{Name: proto.String("MOOD_HAPPY"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("HelloRequest"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, optional, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("times", 2, optional, descriptorpb.FieldDescriptorProto_TYPE_INT32, ""),
+				field("mood", 3, optional, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".test.Mood"),
+			},
+		}, {
+			Name: proto.String("HelloReply"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("message", 1, optional, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("createdAt", 2, optional, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp"),
+				field("total", 3, optional, descriptorpb.FieldDescriptorProto_TYPE_INT64, ""),
+				field("nickname", 4, optional, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.StringValue"),
+				field("tags", 5, repeated, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+				field("mood", 6, optional, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".test.Mood"),
+			},
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Greeter"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("GetHello"), InputType: proto.String(".test.HelloRequest"), OutputType: proto.String(".test.HelloReply")},
+				{Name: proto.String("SayHello"), InputType: proto.String(".test.HelloRequest"), OutputType: proto.String(".test.HelloReply")},
+				{Name: proto.String("StreamHello"), InputType: proto.String(".test.HelloRequest"), OutputType: proto.String(".test.HelloReply"), ServerStreaming: proto.Bool(true)},
+				{Name: proto.String("Chat"), InputType: proto.String(".test.HelloRequest"), OutputType: proto.String(".test.HelloReply"), ClientStreaming: proto.Bool(true), ServerStreaming: proto.Bool(true)},
+			},
+		}},
+	}
+	return &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}}
+}
+
+func loadGrpcTestDescriptor(t *testing.T, methodOperations map[string]string) *grpcDescriptor {
+	files, err := buildGrpcFiles(buildGrpcTestDescriptorSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	descriptor := &grpcDescriptor{methodOperations: methodOperations, types: dynamicpb.NewTypes(files), methods: make(map[string]*grpcMethod)}
+	descriptor.graphqlSchema = descriptor.buildGraphqlSchema(files)
+	if descriptor.schema, err = gqlparser.LoadSchema(&ast.Source{Input: descriptor.graphqlSchema}); err != nil {
+		t.Fatal(err, descriptor.graphqlSchema)
+	}
+	return descriptor
+}
+
+// 进程内的grpc服务，一元方法返回一条消息，流方法按照times返回多条消息
+func startGrpcTestServer(t *testing.T, descriptor *grpcDescriptor) (endpoint string, stop func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := grpc.NewServer(grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+		fullMethod, _ := grpc.MethodFromServerStream(stream)
+		method := descriptor.methods["test_Greeter_"+fullMethod[strings.LastIndex(fullMethod, "/")+1:]].descriptor
+		request := dynamicpb.NewMessage(method.Input())
+		if err := stream.RecvMsg(request); err != nil {
+			return err
+		}
+
+		name := request.Get(method.Input().Fields().ByName("name")).String()
+		if name == "" {
+			return status.Error(codes.InvalidArgument, "name required")
+		}
+
+		times := int(request.Get(method.Input().Fields().ByName("times")).Int())
+		if !method.IsStreamingServer() {
+			times = 1
+		}
+		for i := 0; i < times; i++ {
+			reply := dynamicpb.NewMessage(method.Output())
+			reply.Set(method.Output().Fields().ByName("message"), protoreflect.ValueOfString(fmt.Sprintf("hello %s %d", name, i)))
+			reply.Set(method.Output().Fields().ByName("total"), protoreflect.ValueOfInt64(1<<40))
+			reply.Set(method.Output().Fields().ByName("mood"), request.Get(method.Input().Fields().ByName("mood")))
+			createdAt := timestamppb.New(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+			createdAtBytes, _ := proto.Marshal(createdAt)
+			createdAtMessage := reply.NewField(method.Output().Fields().ByName("createdAt")).Message()
+			_ = proto.Unmarshal(createdAtBytes, createdAtMessage.Interface())
+			reply.Set(method.Output().Fields().ByName("createdAt"), protoreflect.ValueOfMessage(createdAtMessage))
+			if err := stream.SendMsg(reply); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	go func() { _ = server.Serve(listener) }()
+	return "http://" + listener.Addr().String(), server.Stop
+}
+
+func TestGrpcGraphqlSchema(t *testing.T) {
+	descriptor := loadGrpcTestDescriptor(t, map[string]string{"/test.Greeter/SayHello": "query"})
+	for _, expected := range []string{
+		"type Query {\n  test_Greeter_GetHello(name: String, times: Int, mood: test_Mood): test_HelloReply\n  test_Greeter_SayHello(",
+		"type Subscription {\n  test_Greeter_StreamHello(",
+		"  createdAt: String\n  total: String!\n  nickname: String\n  tags: [String!]!\n  mood: test_Mood!",
+		"enum test_Mood {\n  MOOD_UNKNOWN\n  MOOD_HAPPY\n}",
+	} {
+		if !strings.Contains(descriptor.graphqlSchema, expected) {
+			t.Fatalf("schema missing %q:\n%s", expected, descriptor.graphqlSchema)
+		}
+	}
+	if strings.Contains(descriptor.graphqlSchema, "Mutation") || strings.Contains(descriptor.graphqlSchema, "Chat") {
+		t.Fatalf("unexpected mutation or streaming client method:\n%s", descriptor.graphqlSchema)
+	}
+}
+
+func TestGrpcExecutor(t *testing.T) {
+	descriptor := loadGrpcTestDescriptor(t, nil)
+	endpoint, stop := startGrpcTestServer(t, descriptor)
+	defer stop()
+	conn, err := newGrpcClientConn(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	execute := func(query string, variables map[string]any) (operation *ast.OperationDefinition, executor *grpcExecutor) {
+		operation, variables, err := parseGraphqlOperation(descriptor.schema, &graphqlExecuteRequest{Query: query, Variables: variables})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return operation, &grpcExecutor{conn: conn, descriptor: descriptor, variables: variables}
+	}
+
+	operation, executor := execute(`query($name: String) { __typename hi: test_Greeter_GetHello(name: $name, mood: MOOD_HAPPY) { message createdAt total nickname tags mood } }`, map[string]any{"name": "fb"})
+	data, err := executor.resolveOperation(context.Background(), operation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataBytes := marshalGraphqlResponse(data, nil)
+	expected := `{"data":{"__typename":"Query","hi":{"message":"hello fb 0","createdAt":"2024-01-02T03:04:05Z","total":"1099511627776","nickname":null,"tags":[],"mood":"MOOD_HAPPY"}}}`
+	if string(dataBytes) != expected {
+		t.Fatalf("unexpected response %s", dataBytes)
+	}
+
+	operation, executor = execute(`mutation { test_Greeter_SayHello(name: "") { message } }`, nil)
+	if _, err = executor.resolveOperation(context.Background(), operation); err == nil || err.Error() != "grpc status InvalidArgument: name required" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	operation, executor = execute(`subscription { test_Greeter_StreamHello(name: "fb", times: 2) { message } }`, nil)
+	recorder := httptest.NewRecorder()
+	executor.serveSubscription(recorder, context.Background(), operation)
+	expected = "event: next\ndata: {\"data\":{\"test_Greeter_StreamHello\":{\"message\":\"hello fb 0\"}}}\n\n" +
+		"event: next\ndata: {\"data\":{\"test_Greeter_StreamHello\":{\"message\":\"hello fb 1\"}}}\n\n" +
+		"event: complete\n\n"
+	if recorder.Body.String() != expected {
+		t.Fatalf("unexpected events %s", recorder.Body.String())
+	}
+}
+
+func TestCompileGrpcProtoFile(t *testing.T) {
+	protoDir := t.TempDir()
+	protoFiles := map[string]string{
+		"common/mood.proto": `syntax = "proto3";
+package test;
+enum Mood {
+  MOOD_UNKNOWN = 0;
+  MOOD_HAPPY = 1;
+}`,
+		"greeter.proto": `syntax = "proto3";
+package test;
+import "google/protobuf/timestamp.proto";
+import "common/mood.proto";
+message HelloRequest {
+  string name = 1;
+  Mood mood = 2;
+}
+message HelloReply {
+  string message = 1;
+  google.protobuf.Timestamp created_at = 2;
+}
+service Greeter {
+  rpc GetHello (HelloRequest) returns (HelloReply);
+}`,
+	}
+	for path, content := range protoFiles {
+		path = filepath.Join(protoDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	descriptorSet, err := compileGrpcProtoFile(filepath.Join(protoDir, "greeter.proto"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, file := range descriptorSet.File {
+		paths = append(paths, file.GetName())
+	}
+	if strings.Join(paths, ",") != "common/mood.proto,greeter.proto" {
+		t.Fatalf("unexpected descriptor files %v", paths)
+	}
+
+	files, err := buildGrpcFiles(descriptorSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	descriptor := &grpcDescriptor{methods: make(map[string]*grpcMethod)}
+	expected := "type Query {\n  test_Greeter_GetHello(name: String, mood: test_Mood): test_HelloReply\n}"
+	if graphqlSchema := descriptor.buildGraphqlSchema(files); !strings.Contains(graphqlSchema, expected) {
+		t.Fatalf("schema missing %q:\n%s", expected, graphqlSchema)
+	}
+
+	if err = os.WriteFile(filepath.Join(protoDir, "broken.proto"), []byte(`syntax = "proto3"; message Broken { Unknown value = 1; }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = compileGrpcProtoFile(filepath.Join(protoDir, "broken.proto")); err == nil || !strings.Contains(err.Error(), "Unknown") {
+		t.Fatalf("unexpected compile error %v", err)
+	}
+}
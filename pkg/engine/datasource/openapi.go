@@ -31,6 +31,9 @@ import (
 
 func init() {
 	actionMap[wgpb.DataSourceKind_REST] = func(ds *models.Datasource, _ string) Action {
+		if ds.CustomSoap != nil {
+			return &actionSoap{ds: ds}
+		}
 		return &actionOpenapi{
 			ds:                                 ds,
 			customRestExistedRequestRewriters:  make(map[string]bool),
@@ -70,6 +73,8 @@ type (
 	actionOpenapi struct {
 		ds  *models.Datasource
 		doc *openapi3.T
+		// 3.1文档规范化后type数组中的null转为nullable，仅此时nullable影响字段是否必填
+		openapi31 bool
 
 		customRestExistedRequestRewriters  map[string]bool
 		customRestExistedResponseRewriters map[string]bool
@@ -136,6 +141,10 @@ func (a *actionOpenapi) RuntimeDataSourceConfiguration(config *wgpb.DataSourceCo
 	customRestMap := config.CustomRestMap
 	requestRewriterMap := config.CustomRestRequestRewriterMap
 	responseRewriterMap := config.CustomRestResponseRewriterMap
+	var runtimeAuthValues *authValues
+	if auth := getDatasourceAuthByName(config.Id); auth != nil {
+		runtimeAuthValues = resolveRuntimeAuthValues(config.Id, auth)
+	}
 	configs, fields = copyDatasourceWithRootNodes(config, func(rootItem *wgpb.TypeField, configItem *wgpb.DataSourceConfiguration) bool {
 		existedCustomRest, ok := customRestMap[utils.JoinStringWithDot(rootItem.TypeName, rootItem.FieldNames[0])]
 		if ok {
@@ -143,13 +152,14 @@ func (a *actionOpenapi) RuntimeDataSourceConfiguration(config *wgpb.DataSourceCo
 			rootFieldOriginName := strings.TrimPrefix(rootItem.FieldNames[0], config.Id+"_")
 			requestRewriters := a.searchRewriters(rootFieldOriginName, requestRewriterMap)
 			responseRewriters := a.searchRewriters(rootFieldOriginName, responseRewriterMap)
-			if len(requestRewriters) == 0 && len(responseRewriters) == 0 {
+			if len(requestRewriters) == 0 && len(responseRewriters) == 0 && runtimeAuthValues == nil {
 				configItem.CustomRest = existedCustomRest
 			} else {
-				a.sortRewriters(requestRewriters)
-				a.sortRewriters(responseRewriters)
+				sortRestRewriters(requestRewriters)
+				sortRestRewriters(responseRewriters)
 				configItem.CustomRest = &wgpb.DataSourceCustom_REST{
-					Fetch:                  existedCustomRest.Fetch,
+					Fetch:                  copyFetchWithAuthValues(existedCustomRest.Fetch, runtimeAuthValues, false),
+					ResponseExtractor:      existedCustomRest.ResponseExtractor,
 					Subscription:           existedCustomRest.Subscription,
 					StatusCodeTypeMappings: existedCustomRest.StatusCodeTypeMappings,
 					DefaultTypeName:        existedCustomRest.DefaultTypeName,
@@ -163,7 +173,8 @@ func (a *actionOpenapi) RuntimeDataSourceConfiguration(config *wgpb.DataSourceCo
 	return
 }
 
-func (a *actionOpenapi) sortRewriters(rewriters []*wgpb.DataSourceRESTRewriter) {
+// 路径长的重写规则优先执行
+func sortRestRewriters(rewriters []*wgpb.DataSourceRESTRewriter) {
 	slices.SortFunc(rewriters, func(a, b *wgpb.DataSourceRESTRewriter) bool {
 		pathLengthA, pathLengthB := len(a.PathComponents), len(b.PathComponents)
 		return pathLengthA > pathLengthB || pathLengthA == pathLengthB && a.Type < b.Type
@@ -219,6 +230,11 @@ func (a *actionOpenapi) fetchDocument() (err error) {
 		}
 	}
 
+	return a.loadDocument(oasBytes)
+}
+
+// 按照文档版本解析json格式的文档，3.1文档先规范化为3.0
+func (a *actionOpenapi) loadDocument(oasBytes []byte) (err error) {
 	versionResults := gjson.GetManyBytes(oasBytes, versionKeys...)
 	var version string
 	for _, result := range versionResults {
@@ -240,6 +256,11 @@ func (a *actionOpenapi) fetchDocument() (err error) {
 			return
 		}
 	case strings.HasPrefix(version, "3."):
+		if a.openapi31 = strings.HasPrefix(version, openapi31Prefix); a.openapi31 {
+			if oasBytes, err = normalizeOpenapi31(oasBytes); err != nil {
+				return
+			}
+		}
 		if err = json.Unmarshal(oasBytes, &a.doc); err != nil {
 			return
 		}
@@ -142,7 +142,7 @@ func (r *resolveGraphqlSchema) visitSchema(input *visitSchemaInput, schemaRef *o
 	if len(visitResult.name) > 0 {
 		result.Name = &visitResult.name
 	}
-	if required {
+	if required && !(r.openapi.openapi31 && schemaRef.Value.Nullable) {
 		result = &valueDefinition{Kind: KindNonNull, OfType: result}
 	}
 	return
@@ -92,10 +92,14 @@ func visitSchemaAllOf(r *resolveGraphqlSchema, input *visitSchemaInput, schemaVa
 
 func visitSchemaType(r *resolveGraphqlSchema, input *visitSchemaInput, schemaValue *openapi3.Schema, hasSchemaRefStr bool) (result visitSchemaOutput) {
 	if schemaValue.Type == "" {
-		if len(schemaValue.Properties) == 0 {
+		switch {
+		case len(schemaValue.Properties) > 0:
+			schemaValue.Type = openapi3.TypeObject
+		case schemaValue.Items != nil && r.openapi.openapi31:
+			schemaValue.Type = openapi3.TypeArray
+		default:
 			return
 		}
-		schemaValue.Type = openapi3.TypeObject
 	}
 
 	switch schemaValue.Type {
@@ -0,0 +1,389 @@
+// Package datasource
+/*
+ 将Postman v2.1集合或HAR文件转换成openapi3文档，作为rest数据源的OAS文件
+ 出入参的schema根据示例报文推断，同一接口的多个示例会合并属性
+ 路径参数根据Postman的:name/{{name}}变量或HAR中的数字/uuid路径段识别
+ origin仅对HAR文件生效，用于选择导入哪个源的请求
+*/
+package datasource
+
+import (
+	"context"
+	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	json "github.com/json-iterator/go"
+	"github.com/labstack/echo/v4"
+	"github.com/tidwall/gjson"
+	"golang.org/x/exp/slices"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	importOpenapiVersion  = "3.0.3"
+	importInfoVersion     = "1.0.0"
+	importDefaultTitle    = "imported"
+	importPostmanSchema   = "info.schema"
+	importPostmanVersion  = "v2.1"
+	importHarEntries      = "log.entries"
+	importParamFormat     = "{%s}"
+	importDefaultStatus   = "200"
+	importFormFileType    = "file"
+	importMimeJsonSuffix  = "json"
+	importMimeFormPrefix  = "application/x-www-form-urlencoded"
+	importMimeMultipart   = "multipart/form-data"
+	importOperationIdJoin = "_"
+)
+
+var (
+	// 浏览器及通用请求头不作为接口参数
+	importIgnoredHeaders = []string{
+		"accept", "accept-encoding", "accept-language", "authorization", "cache-control", "connection",
+		"content-length", "content-type", "cookie", "dnt", "host", "origin", "pragma", "referer",
+		"upgrade-insecure-requests", "user-agent", "postman-token",
+	}
+	importUuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+type (
+	importParam struct {
+		name, value string
+		isFile      bool
+	}
+	importResponse struct {
+		status      int
+		description string
+		contentType string
+		body        string
+	}
+	importRequest struct {
+		name, description string
+		tags              []string
+		method            string
+		origin            string
+		path              string
+		pathParams        []importParam
+		queries           []importParam
+		headers           []importParam
+		bodyContentType   string
+		body              string
+		formParams        []importParam
+		responses         []importResponse
+	}
+	openapiImporter struct {
+		doc          *openapi3.T
+		operationIds map[string]bool
+	}
+)
+
+// ConvertToOpenapi 识别Postman集合或HAR文件并转换成openapi3文档
+func ConvertToOpenapi(content []byte, origin string) (doc *openapi3.T, err error) {
+	importer := &openapiImporter{
+		doc: &openapi3.T{
+			OpenAPI:    importOpenapiVersion,
+			Info:       &openapi3.Info{Title: importDefaultTitle, Version: importInfoVersion},
+			Paths:      openapi3.Paths{},
+			Components: &openapi3.Components{},
+		},
+		operationIds: make(map[string]bool),
+	}
+	switch {
+	case gjson.GetBytes(content, importHarEntries).IsArray():
+		err = importer.importHar(content, origin)
+	case strings.Contains(gjson.GetBytes(content, importPostmanSchema).String(), importPostmanVersion):
+		err = importer.importPostman(content)
+	default:
+		err = i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.DatasourceImportFormatError)
+	}
+	if err != nil {
+		return
+	}
+
+	if err = importer.doc.Validate(context.Background(), openapi3.DisableExamplesValidation()); err != nil {
+		err = i18n.NewCustomErrorWithMode(datasourceModelName, err, i18n.DatasourceImportConvertError)
+		return
+	}
+
+	doc = importer.doc
+	return
+}
+
+func (i *openapiImporter) addServer(origin string) {
+	if origin == "" || slices.ContainsFunc(i.doc.Servers, func(server *openapi3.Server) bool { return server.URL == origin }) {
+		return
+	}
+
+	i.doc.Servers = append(i.doc.Servers, &openapi3.Server{URL: origin})
+}
+
+// 添加请求到文档中，相同路径和方法的请求合并为一个operation
+func (i *openapiImporter) addRequest(req *importRequest) {
+	if req.path == "" {
+		req.path = "/"
+	}
+	i.addServer(req.origin)
+	pathItem, ok := i.doc.Paths[req.path]
+	if !ok {
+		pathItem = &openapi3.PathItem{}
+		i.doc.Paths[req.path] = pathItem
+	}
+
+	method := strings.ToUpper(req.method)
+	operation := pathItem.GetOperation(method)
+	if operation == nil {
+		operation = openapi3.NewOperation()
+		operation.OperationID = i.makeOperationId(req)
+		operation.Summary, operation.Description, operation.Tags = req.name, req.description, req.tags
+		operation.Responses = openapi3.Responses{}
+		pathItem.SetOperation(method, operation)
+	}
+
+	for _, param := range req.pathParams {
+		i.mergeParameter(operation, openapi3.NewPathParameter(param.name).WithSchema(inferSchemaFromText(param.value)))
+	}
+	for _, param := range req.queries {
+		i.mergeParameter(operation, openapi3.NewQueryParameter(param.name).WithSchema(inferSchemaFromText(param.value)))
+	}
+	for _, param := range req.headers {
+		if isImportIgnoredHeader(param.name) {
+			continue
+		}
+		i.mergeParameter(operation, openapi3.NewHeaderParameter(param.name).WithSchema(openapi3.NewStringSchema()))
+	}
+	i.mergeRequestBody(operation, req)
+	for _, resp := range req.responses {
+		i.mergeResponse(operation, resp)
+	}
+	if len(operation.Responses) == 0 {
+		operation.Responses[importDefaultStatus] = &openapi3.ResponseRef{Value: openapi3.NewResponse().WithDescription(http.StatusText(http.StatusOK))}
+	}
+}
+
+func (i *openapiImporter) makeOperationId(req *importRequest) string {
+	operationId := req.name
+	if operationId == "" {
+		operationId = strings.ToLower(req.method) + importOperationIdJoin + strings.Trim(req.path, "/")
+	}
+	operationId = strings.Trim(utils.NormalizeName(operationId), importOperationIdJoin)
+	uniqueId := operationId
+	for index := 1; i.operationIds[uniqueId]; index++ {
+		uniqueId = operationId + importOperationIdJoin + strconv.Itoa(index)
+	}
+	i.operationIds[uniqueId] = true
+	return uniqueId
+}
+
+func (i *openapiImporter) mergeParameter(operation *openapi3.Operation, parameter *openapi3.Parameter) {
+	if existed := operation.Parameters.GetByInAndName(parameter.In, parameter.Name); existed != nil {
+		existed.Schema.Value = mergeInferredSchema(existed.Schema.Value, parameter.Schema.Value)
+		return
+	}
+
+	operation.Parameters = append(operation.Parameters, &openapi3.ParameterRef{Value: parameter})
+}
+
+func (i *openapiImporter) mergeRequestBody(operation *openapi3.Operation, req *importRequest) {
+	var (
+		contentType string
+		schema      *openapi3.Schema
+	)
+	switch {
+	case len(req.formParams) > 0:
+		contentType, schema = req.bodyContentType, openapi3.NewObjectSchema()
+		if contentType == "" {
+			contentType = importMimeFormPrefix
+		}
+		for _, param := range req.formParams {
+			if param.isFile {
+				schema.Properties[param.name] = openapi3.NewStringSchema().WithFormat("binary").NewRef()
+			} else {
+				schema.Properties[param.name] = inferSchemaFromText(param.value).NewRef()
+			}
+		}
+	case req.body != "":
+		contentType = req.bodyContentType
+		if contentType == "" && gjson.Valid(req.body) {
+			contentType = echo.MIMEApplicationJSON
+		}
+		schema = inferSchemaFromBody(contentType, req.body)
+	default:
+		return
+	}
+
+	if operation.RequestBody == nil {
+		operation.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithSchema(schema, []string{contentType})}
+		return
+	}
+	mergeContentSchema(operation.RequestBody.Value.Content, contentType, schema)
+}
+
+func (i *openapiImporter) mergeResponse(operation *openapi3.Operation, resp importResponse) {
+	if resp.status == 0 {
+		resp.status = http.StatusOK
+	}
+	status := strconv.Itoa(resp.status)
+	description := resp.description
+	if description == "" {
+		description = http.StatusText(resp.status)
+	}
+
+	responseRef, ok := operation.Responses[status]
+	if !ok {
+		responseRef = &openapi3.ResponseRef{Value: openapi3.NewResponse().WithDescription(description)}
+		operation.Responses[status] = responseRef
+	}
+	if resp.body == "" {
+		return
+	}
+
+	contentType := resp.contentType
+	if contentType == "" && gjson.Valid(resp.body) {
+		contentType = echo.MIMEApplicationJSON
+	}
+	schema := inferSchemaFromBody(contentType, resp.body)
+	if responseRef.Value.Content == nil {
+		responseRef.Value.Content = openapi3.NewContentWithSchema(schema, []string{contentType})
+		return
+	}
+	mergeContentSchema(responseRef.Value.Content, contentType, schema)
+}
+
+func mergeContentSchema(content openapi3.Content, contentType string, schema *openapi3.Schema) {
+	mediaType, ok := content[contentType]
+	if !ok || mediaType.Schema == nil {
+		content[contentType] = openapi3.NewMediaType().WithSchema(schema)
+		return
+	}
+
+	mediaType.Schema.Value = mergeInferredSchema(mediaType.Schema.Value, schema)
+}
+
+// 去除Content-Type中的charset等参数
+func normalizeImportContentType(contentType string) string {
+	contentType, _, _ = strings.Cut(contentType, ";")
+	return strings.TrimSpace(contentType)
+}
+
+func isImportIgnoredHeader(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasPrefix(name, ":") || strings.HasPrefix(name, "sec-") || slices.Contains(importIgnoredHeaders, name)
+}
+
+func isImportJsonContentType(contentType string) bool {
+	return strings.HasSuffix(normalizeImportContentType(contentType), importMimeJsonSuffix)
+}
+
+// 根据报文推断schema，非json报文按照字符串处理
+func inferSchemaFromBody(contentType, body string) *openapi3.Schema {
+	if !isImportJsonContentType(contentType) {
+		return openapi3.NewStringSchema()
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(body), &value); err != nil {
+		return openapi3.NewStringSchema()
+	}
+	return inferSchema(value)
+}
+
+// 根据参数文本推断schema，仅识别整数、浮点数和布尔值
+func inferSchemaFromText(text string) *openapi3.Schema {
+	if _, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return openapi3.NewIntegerSchema()
+	}
+	if _, err := strconv.ParseFloat(text, 64); err == nil {
+		return &openapi3.Schema{Type: openapi3.TypeNumber}
+	}
+	if _, err := strconv.ParseBool(text); err == nil {
+		return openapi3.NewBoolSchema()
+	}
+	return inferSchema(text)
+}
+
+func inferSchema(value any) *openapi3.Schema {
+	switch v := value.(type) {
+	case map[string]any:
+		schema := openapi3.NewObjectSchema()
+		for key, item := range v {
+			schema.Properties[key] = inferSchema(item).NewRef()
+		}
+		return schema
+	case []any:
+		var itemSchema *openapi3.Schema
+		for _, item := range v {
+			itemSchema = mergeInferredSchema(itemSchema, inferSchema(item))
+		}
+		if itemSchema == nil {
+			itemSchema = openapi3.NewSchema()
+		}
+		return openapi3.NewArraySchema().WithItems(itemSchema)
+	case string:
+		schema := openapi3.NewStringSchema()
+		if _, err := time.Parse(time.RFC3339, v); err == nil {
+			schema.Format = "date-time"
+		} else if importUuidRegexp.MatchString(v) {
+			schema.Format = "uuid"
+		}
+		return schema
+	case float64:
+		if v == float64(int64(v)) {
+			return openapi3.NewIntegerSchema()
+		}
+		return &openapi3.Schema{Type: openapi3.TypeNumber}
+	case bool:
+		return openapi3.NewBoolSchema()
+	case nil:
+		return &openapi3.Schema{Nullable: true}
+	default:
+		return openapi3.NewStringSchema()
+	}
+}
+
+// 合并两个推断出的schema，对象合并属性，数组合并元素，整数与浮点数合并为浮点数
+func mergeInferredSchema(dst, src *openapi3.Schema) *openapi3.Schema {
+	switch {
+	case dst == nil:
+		return src
+	case src == nil:
+		return dst
+	case src.Type == "":
+		dst.Nullable = dst.Nullable || src.Nullable
+		return dst
+	case dst.Type == "":
+		src.Nullable = src.Nullable || dst.Nullable
+		return src
+	}
+
+	switch {
+	case dst.Type == openapi3.TypeObject && src.Type == openapi3.TypeObject:
+		for key, item := range src.Properties {
+			if existed, ok := dst.Properties[key]; ok {
+				existed.Value = mergeInferredSchema(existed.Value, item.Value)
+			} else {
+				dst.Properties[key] = item
+			}
+		}
+	case dst.Type == openapi3.TypeArray && src.Type == openapi3.TypeArray:
+		dst.Items.Value = mergeInferredSchema(dst.Items.Value, src.Items.Value)
+	case dst.Type == openapi3.TypeInteger && src.Type == openapi3.TypeNumber:
+		dst.Type = openapi3.TypeNumber
+	case dst.Type == openapi3.TypeString && src.Type == openapi3.TypeString && dst.Format != src.Format:
+		dst.Format = ""
+	}
+	return dst
+}
+
+// 将路径段转换为路径参数名，冲突时追加序号
+func makeImportPathParamName(existed []importParam, name string) string {
+	name = utils.NormalizeName(name)
+	uniqueName := name
+	for index := 1; slices.ContainsFunc(existed, func(param importParam) bool { return param.name == uniqueName }); index++ {
+		uniqueName = fmt.Sprintf("%s%d", name, index)
+	}
+	return uniqueName
+}
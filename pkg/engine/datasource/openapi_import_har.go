@@ -0,0 +1,160 @@
+// Package datasource
+/*
+ 解析浏览器导出的HAR文件，仅保留json/表单报文的请求，忽略静态资源
+ 数字或uuid路径段识别为路径参数，参数名称由上一级路径段加Id组成
+ 浏览器请求头较多，仅保留x-开头的自定义请求头
+ HAR文件通常包含多个源的请求，仅导入指定源的请求，未指定时使用请求数最多的源
+*/
+package datasource
+
+import (
+	"encoding/base64"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	"github.com/tidwall/gjson"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	harCustomHeaderPrefix = "x-"
+	harPathParamSuffix    = "Id"
+	harDefaultPathParam   = "id"
+	harEncodingBase64     = "base64"
+)
+
+type harEntry struct {
+	request, response                       gjson.Result
+	requestContentType, responseContentType string
+	url                                     *url.URL
+	origin                                  string
+}
+
+func (i *openapiImporter) importHar(content []byte, origin string) error {
+	var entries []*harEntry
+	originCounts := make(map[string]int)
+	gjson.GetBytes(content, importHarEntries).ForEach(func(_, entry gjson.Result) bool {
+		request, response := entry.Get("request"), entry.Get("response")
+		requestContentType := normalizeImportContentType(request.Get("postData.mimeType").String())
+		responseContentType := normalizeImportContentType(response.Get("content.mimeType").String())
+		if !isImportJsonContentType(responseContentType) && !isImportJsonContentType(requestContentType) &&
+			requestContentType != importMimeFormPrefix && requestContentType != importMimeMultipart {
+			return true
+		}
+
+		entryUrl, err := url.Parse(request.Get("url").String())
+		if err != nil || entryUrl.Host == "" {
+			return true
+		}
+
+		item := &harEntry{
+			request:             request,
+			response:            response,
+			requestContentType:  requestContentType,
+			responseContentType: responseContentType,
+			url:                 entryUrl,
+			origin:              strings.ToLower(entryUrl.Scheme + "://" + entryUrl.Host),
+		}
+		entries = append(entries, item)
+		originCounts[item.origin]++
+		return true
+	})
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if origin = strings.ToLower(strings.TrimSuffix(origin, "/")); origin == "" {
+		origin = pickHarOrigin(originCounts)
+	}
+	if _, ok := originCounts[origin]; !ok {
+		return i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.DatasourceImportOriginNotFoundError, origin)
+	}
+
+	for _, entry := range entries {
+		if entry.origin == origin {
+			i.importHarEntry(entry)
+		}
+	}
+	return nil
+}
+
+// 选择请求数最多的源，数量相同时取字典序最小的源保证结果稳定
+func pickHarOrigin(originCounts map[string]int) (origin string) {
+	for itemOrigin, count := range originCounts {
+		if originCount := originCounts[origin]; count > originCount || count == originCount && itemOrigin < origin {
+			origin = itemOrigin
+		}
+	}
+	return
+}
+
+func (i *openapiImporter) importHarEntry(entry *harEntry) {
+	request, response := entry.request, entry.response
+	requestContentType, responseContentType := entry.requestContentType, entry.responseContentType
+	req := &importRequest{method: request.Get("method").String(), origin: entry.origin}
+	i.resolveHarPath(req, entry.url.Path)
+	request.Get("queryString").ForEach(func(_, query gjson.Result) bool {
+		req.queries = append(req.queries, importParam{name: query.Get("name").String(), value: query.Get("value").String()})
+		return true
+	})
+	request.Get("headers").ForEach(func(_, header gjson.Result) bool {
+		if name := header.Get("name").String(); strings.HasPrefix(strings.ToLower(name), harCustomHeaderPrefix) {
+			req.headers = append(req.headers, importParam{name: name, value: header.Get("value").String()})
+		}
+		return true
+	})
+	req.bodyContentType = requestContentType
+	if params := request.Get("postData.params"); params.IsArray() && len(params.Array()) > 0 {
+		params.ForEach(func(_, param gjson.Result) bool {
+			req.formParams = append(req.formParams, importParam{
+				name:   param.Get("name").String(),
+				value:  param.Get("value").String(),
+				isFile: param.Get("fileName").Exists(),
+			})
+			return true
+		})
+	} else {
+		req.body = request.Get("postData.text").String()
+	}
+
+	responseBody := response.Get("content.text").String()
+	if response.Get("content.encoding").String() == harEncodingBase64 {
+		if decoded, decodeErr := base64.StdEncoding.DecodeString(responseBody); decodeErr == nil {
+			responseBody = string(decoded)
+		}
+	}
+	req.responses = append(req.responses, importResponse{
+		status:      int(response.Get("status").Int()),
+		description: response.Get("statusText").String(),
+		contentType: responseContentType,
+		body:        responseBody,
+	})
+	i.addRequest(req)
+}
+
+// 将数字或uuid路径段替换为路径参数
+func (i *openapiImporter) resolveHarPath(req *importRequest, path string) {
+	var pathSegments []string
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+
+		_, intErr := strconv.ParseInt(segment, 10, 64)
+		if intErr != nil && !importUuidRegexp.MatchString(segment) {
+			pathSegments = append(pathSegments, segment)
+			continue
+		}
+
+		paramName := harDefaultPathParam
+		if lastIndex := len(pathSegments) - 1; lastIndex >= 0 && !strings.HasPrefix(pathSegments[lastIndex], "{") {
+			paramName = pathSegments[lastIndex] + harPathParamSuffix
+		}
+		paramName = makeImportPathParamName(req.pathParams, paramName)
+		req.pathParams = append(req.pathParams, importParam{name: paramName, value: segment})
+		pathSegments = append(pathSegments, fmt.Sprintf(importParamFormat, paramName))
+	}
+	req.path = "/" + strings.Join(pathSegments, "/")
+}
@@ -0,0 +1,244 @@
+// Package datasource
+/*
+ 解析Postman v2.1集合，文件夹名称作为接口标签
+ 集合变量会替换到url中，未定义的{{var}}路径段和:name路径段识别为路径参数
+*/
+package datasource
+
+import (
+	"fireboom-server/pkg/common/utils"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/tidwall/gjson"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const (
+	postmanBodyModeRaw        = "raw"
+	postmanBodyModeUrlencoded = "urlencoded"
+	postmanBodyModeFormdata   = "formdata"
+	postmanBodyModeGraphql    = "graphql"
+	postmanDefaultScheme      = "http://"
+	postmanTagSeparator       = "/"
+)
+
+var (
+	postmanVariableRegexp = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+	postmanRawLanguageMap = map[string]string{
+		"json": echo.MIMEApplicationJSON,
+		"xml":  echo.MIMEApplicationXML,
+		"html": echo.MIMETextHTML,
+		"text": echo.MIMETextPlain,
+	}
+)
+
+func (i *openapiImporter) importPostman(content []byte) error {
+	collection := gjson.ParseBytes(content)
+	if title := collection.Get("info.name").String(); title != "" {
+		i.doc.Info.Title = title
+	}
+	i.doc.Info.Description = postmanDescription(collection.Get("info.description"))
+
+	variables := make(map[string]string)
+	collection.Get("variable").ForEach(func(_, variable gjson.Result) bool {
+		if key := variable.Get("key").String(); key != "" {
+			variables[key] = variable.Get("value").String()
+		}
+		return true
+	})
+	i.importPostmanItems(collection.Get("item"), nil, variables)
+	return nil
+}
+
+// 递归处理集合中的文件夹和请求
+func (i *openapiImporter) importPostmanItems(items gjson.Result, folders []string, variables map[string]string) {
+	items.ForEach(func(_, item gjson.Result) bool {
+		name := item.Get("name").String()
+		if children := item.Get("item"); children.IsArray() {
+			i.importPostmanItems(children, utils.CopyAndAppendItem(folders, name), variables)
+			return true
+		}
+
+		request := item.Get("request")
+		if !request.Exists() {
+			return true
+		}
+
+		req := &importRequest{name: name, description: postmanDescription(item.Get("description"))}
+		if len(folders) > 0 {
+			req.tags = []string{strings.Join(folders, postmanTagSeparator)}
+		}
+		if request.Type == gjson.String {
+			req.method = http.MethodGet
+			i.resolvePostmanUrl(req, request, variables)
+		} else {
+			if req.method = request.Get("method").String(); req.method == "" {
+				req.method = http.MethodGet
+			}
+			if req.description == "" {
+				req.description = postmanDescription(request.Get("description"))
+			}
+			i.resolvePostmanUrl(req, request.Get("url"), variables)
+			req.headers = postmanKeyValues(request.Get("header"), variables)
+			i.resolvePostmanBody(req, request.Get("body"), variables)
+		}
+		item.Get("response").ForEach(func(_, response gjson.Result) bool {
+			req.responses = append(req.responses, importResponse{
+				status:      int(response.Get("code").Int()),
+				description: response.Get("name").String(),
+				contentType: normalizeImportContentType(postmanHeaderValue(response.Get("header"), echo.HeaderContentType)),
+				body:        response.Get("body").String(),
+			})
+			return true
+		})
+		i.addRequest(req)
+		return true
+	})
+}
+
+// 解析url得到服务地址、路径模板、路径参数和查询参数
+func (i *openapiImporter) resolvePostmanUrl(req *importRequest, url gjson.Result, variables map[string]string) {
+	rawUrl := url.String()
+	pathVariables := make(map[string]string)
+	if url.IsObject() {
+		rawUrl = url.Get("raw").String()
+		for _, item := range postmanKeyValues(url.Get("variable"), variables) {
+			pathVariables[item.name] = item.value
+		}
+	}
+
+	// 以变量开头的url将变量值整体作为服务地址，保留其中的基础路径
+	if matched := postmanVariableRegexp.FindStringSubmatchIndex(rawUrl); len(matched) > 0 && matched[0] == 0 {
+		if baseUrl, ok := variables[rawUrl[matched[2]:matched[3]]]; ok && strings.Contains(baseUrl, "://") {
+			req.origin, rawUrl = strings.TrimSuffix(baseUrl, "/"), rawUrl[matched[1]:]
+		}
+	}
+	resolvedUrl := replacePostmanVariables(rawUrl, variables)
+	resolvedUrl, _, _ = strings.Cut(resolvedUrl, "#")
+	rawPath, rawQuery, _ := strings.Cut(resolvedUrl, "?")
+	if schemeIndex := strings.Index(rawPath, "://"); req.origin == "" && schemeIndex != -1 {
+		hostEnd := strings.Index(rawPath[schemeIndex+3:], "/")
+		if hostEnd == -1 {
+			req.origin, rawPath = rawPath, ""
+		} else {
+			req.origin, rawPath = rawPath[:schemeIndex+3+hostEnd], rawPath[schemeIndex+3+hostEnd:]
+		}
+	}
+
+	var pathSegments []string
+	for index, segment := range strings.Split(strings.Trim(rawPath, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		if index == 0 && req.origin == "" {
+			// 未定义的服务地址变量及不带协议的域名作为服务地址处理
+			if strings.HasPrefix(segment, "{{") && strings.HasSuffix(segment, "}}") {
+				continue
+			}
+			if strings.Contains(segment, ".") || strings.Contains(segment, ":") && !strings.HasPrefix(segment, ":") {
+				req.origin = postmanDefaultScheme + segment
+				continue
+			}
+		}
+
+		var paramName, paramValue string
+		if strings.HasPrefix(segment, ":") {
+			paramName = strings.TrimPrefix(segment, ":")
+			paramValue = pathVariables[paramName]
+		} else if matched := postmanVariableRegexp.FindStringSubmatch(segment); len(matched) > 1 && matched[0] == segment {
+			paramName = matched[1]
+		} else {
+			pathSegments = append(pathSegments, segment)
+			continue
+		}
+		paramName = makeImportPathParamName(req.pathParams, paramName)
+		req.pathParams = append(req.pathParams, importParam{name: paramName, value: paramValue})
+		pathSegments = append(pathSegments, fmt.Sprintf(importParamFormat, paramName))
+	}
+	req.path = "/" + strings.Join(pathSegments, "/")
+
+	if query := url.Get("query"); query.IsArray() {
+		req.queries = postmanKeyValues(query, variables)
+		return
+	}
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if name, value, _ := strings.Cut(pair, "="); name != "" {
+			req.queries = append(req.queries, importParam{name: name, value: value})
+		}
+	}
+}
+
+func (i *openapiImporter) resolvePostmanBody(req *importRequest, body gjson.Result, variables map[string]string) {
+	for _, header := range req.headers {
+		if strings.EqualFold(header.name, echo.HeaderContentType) {
+			req.bodyContentType = normalizeImportContentType(header.value)
+		}
+	}
+
+	switch body.Get("mode").String() {
+	case postmanBodyModeRaw:
+		req.body = replacePostmanVariables(body.Get("raw").String(), variables)
+		if req.bodyContentType == "" {
+			req.bodyContentType = postmanRawLanguageMap[body.Get("options.raw.language").String()]
+		}
+	case postmanBodyModeUrlencoded:
+		req.formParams = postmanKeyValues(body.Get(postmanBodyModeUrlencoded), variables)
+		req.bodyContentType = importMimeFormPrefix
+	case postmanBodyModeFormdata:
+		req.formParams = postmanKeyValues(body.Get(postmanBodyModeFormdata), variables)
+		req.bodyContentType = importMimeMultipart
+	case postmanBodyModeGraphql:
+		req.body = body.Get(postmanBodyModeGraphql).Raw
+		req.bodyContentType = echo.MIMEApplicationJSON
+	}
+}
+
+// 解析key/value列表，忽略禁用项
+func postmanKeyValues(list gjson.Result, variables map[string]string) (params []importParam) {
+	list.ForEach(func(_, item gjson.Result) bool {
+		if item.Get("disabled").Bool() {
+			return true
+		}
+
+		if key := item.Get("key").String(); key != "" {
+			params = append(params, importParam{
+				name:   key,
+				value:  replacePostmanVariables(item.Get("value").String(), variables),
+				isFile: item.Get("type").String() == importFormFileType,
+			})
+		}
+		return true
+	})
+	return
+}
+
+func postmanHeaderValue(headers gjson.Result, name string) (value string) {
+	headers.ForEach(func(_, header gjson.Result) bool {
+		if strings.EqualFold(header.Get("key").String(), name) {
+			value = header.Get("value").String()
+			return false
+		}
+		return true
+	})
+	return
+}
+
+// 描述可能为字符串或者包含content的对象
+func postmanDescription(description gjson.Result) string {
+	if description.IsObject() {
+		return description.Get("content").String()
+	}
+	return description.String()
+}
+
+// 替换已定义的集合变量，未定义的变量保持原样
+func replacePostmanVariables(text string, variables map[string]string) string {
+	return postmanVariableRegexp.ReplaceAllStringFunc(text, func(matched string) string {
+		if value, ok := variables[matched[2:len(matched)-2]]; ok {
+			return value
+		}
+		return matched
+	})
+}
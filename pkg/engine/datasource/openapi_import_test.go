@@ -0,0 +1,63 @@
+package datasource
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const importTestdataDir = "testdata/import"
+
+func TestConvertToOpenapi(t *testing.T) {
+	tests := []struct {
+		name, input, origin, golden string
+		wantErr                     bool
+	}{
+		{name: "postman", input: "pets.postman.json", golden: "pets.golden.json"},
+		// 未指定源时仅导入请求数最多的源，忽略静态资源
+		{name: "har most requested origin", input: "shop.har", golden: "shop.golden.json"},
+		{name: "har chosen origin", input: "shop.har", origin: "https://Analytics.example.net/", golden: "shop_analytics.golden.json"},
+		{name: "har unknown origin", input: "shop.har", origin: "https://unknown.example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputBytes, err := os.ReadFile(filepath.Join(importTestdataDir, tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			doc, err := ConvertToOpenapi(inputBytes, tt.origin)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actualBytes, err := json.MarshalIndent(doc, "", openapi31GoldenIndent)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actualBytes = append(actualBytes, '\n')
+			goldenFile := filepath.Join(importTestdataDir, tt.golden)
+			if *updateGolden {
+				if err = os.WriteFile(goldenFile, actualBytes, openapi31GoldenFilePerm); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			expectedBytes, err := os.ReadFile(goldenFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(expectedBytes) != string(actualBytes) {
+				t.Errorf("converted document mismatch %s\nexpected:\n%s\nactual:\n%s", goldenFile, expectedBytes, actualBytes)
+			}
+		})
+	}
+}
@@ -0,0 +1,469 @@
+// Package datasource
+/*
+ 将openapi3.1文档规范化为3.0文档，kin-openapi仅支持3.0的类型定义
+ 主要处理JSON Schema 2020-12中新增或变更的关键字：
+ type数组(含null)转为nullable，const转为单值enum，examples转为example，
+ $defs提升到components.schemas并改写引用，prefixItems转为items，数值型exclusiveMinimum/exclusiveMaximum转为布尔型
+ 3.0中不存在的关键字会被kin-openapi视为extensions导致校验失败，因此直接移除
+*/
+package datasource
+
+import (
+	"fmt"
+	json "github.com/json-iterator/go"
+	"github.com/spf13/cast"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"reflect"
+	"strings"
+)
+
+const (
+	openapi31Prefix       = "3.1"
+	openapi30Version      = "3.0.3"
+	schemaTypeNull        = "null"
+	schemaKeyType         = "type"
+	schemaKeyRef          = "$ref"
+	schemaKeyDefs         = "$defs"
+	schemaKeyNullable     = "nullable"
+	schemaKeyFormat       = "format"
+	schemaTypeString      = "string"
+	componentsSchemasPath = "#/components/schemas"
+)
+
+var (
+	// 3.0中不支持的关键字
+	openapi31UnsupportedSchemaKeys = []string{
+		"$schema", "$id", "$anchor", "$comment", "$dynamicRef", "$dynamicAnchor", "$vocabulary",
+		"if", "then", "else", "contains", "minContains", "maxContains", "propertyNames", "patternProperties",
+		"dependentSchemas", "dependentRequired", "unevaluatedItems", "unevaluatedProperties",
+		"contentMediaType", "contentEncoding", "contentSchema",
+	}
+	// type数组拆分为oneOf时，各类型独有的关键字随类型移动
+	openapi31TypeScopedKeys = map[string][]string{
+		"array":   {"items", "minItems", "maxItems", "uniqueItems"},
+		"object":  {"properties", "required", "additionalProperties", "minProperties", "maxProperties"},
+		"string":  {"pattern", "minLength", "maxLength"},
+		"number":  {"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf"},
+		"integer": {"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf"},
+	}
+	// format由多个类型共用，按值判断所属类型，未列出的format均为字符串格式
+	openapi31NumericFormatTypes = map[string][]string{
+		"int32":  {"integer", "number"},
+		"int64":  {"integer", "number"},
+		"float":  {"number"},
+		"double": {"number"},
+	}
+)
+
+type openapi31Normalizer struct {
+	schemas         map[string]any
+	hoistedDefs     map[string]any
+	hoistedDefNames map[string][]string
+	rewrittenRefs   map[string]string
+}
+
+// 规范化3.1文档，返回可以被openapi3.T解析的3.0文档
+func normalizeOpenapi31(oasBytes []byte) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(oasBytes, &doc); err != nil {
+		return nil, err
+	}
+
+	n := &openapi31Normalizer{
+		hoistedDefs:     make(map[string]any),
+		hoistedDefNames: make(map[string][]string),
+		rewrittenRefs:   make(map[string]string),
+	}
+	n.normalizeDocument(doc)
+	return json.Marshal(doc)
+}
+
+func (n *openapi31Normalizer) normalizeDocument(doc map[string]any) {
+	doc["openapi"] = openapi30Version
+	delete(doc, "jsonSchemaDialect")
+	delete(doc, "webhooks")
+	if info, ok := doc["info"].(map[string]any); ok {
+		delete(info, "summary")
+		if license, ok := info["license"].(map[string]any); ok {
+			delete(license, "identifier")
+		}
+	}
+	if _, ok := doc["paths"].(map[string]any); !ok {
+		doc["paths"] = make(map[string]any)
+	}
+
+	components, ok := doc["components"].(map[string]any)
+	if !ok {
+		components = make(map[string]any)
+		doc["components"] = components
+	}
+	delete(components, "pathItems")
+	if n.schemas, ok = components["schemas"].(map[string]any); !ok {
+		n.schemas = make(map[string]any)
+	}
+	if securitySchemes, ok := components["securitySchemes"].(map[string]any); ok {
+		for name, scheme := range securitySchemes {
+			if schemeMap, ok := scheme.(map[string]any); ok && schemeMap[schemaKeyType] == "mutualTLS" {
+				delete(securitySchemes, name)
+			}
+		}
+	}
+
+	schemaNames := maps.Keys(n.schemas)
+	slices.Sort(schemaNames)
+	for _, name := range schemaNames {
+		n.normalizeSchema(n.schemas[name], joinJsonPointer(componentsSchemasPath, name))
+	}
+	for name, schema := range components {
+		if name != "schemas" {
+			n.walk(schema, joinJsonPointer("#/components", name))
+		}
+	}
+	n.walk(doc["paths"], "#/paths")
+
+	for name, schema := range n.hoistedDefs {
+		n.schemas[name] = schema
+	}
+	if len(n.schemas) > 0 {
+		components["schemas"] = n.schemas
+	}
+	n.rewriteRefs(doc)
+}
+
+// 遍历非schema节点，遇到schema字段时进行规范化，示例值不做处理
+func (n *openapi31Normalizer) walk(node any, pointer string) {
+	switch value := node.(type) {
+	case map[string]any:
+		for key, item := range value {
+			switch key {
+			case "schema":
+				n.normalizeSchema(item, joinJsonPointer(pointer, key))
+			case "example", "examples":
+			default:
+				n.walk(item, joinJsonPointer(pointer, key))
+			}
+		}
+	case []any:
+		for i, item := range value {
+			n.walk(item, joinJsonPointer(pointer, cast.ToString(i)))
+		}
+	}
+}
+
+func (n *openapi31Normalizer) normalizeSchema(schema any, pointer string) {
+	schemaMap, ok := schema.(map[string]any)
+	if !ok {
+		return
+	}
+
+	n.hoistDefs(schemaMap, pointer)
+	for _, key := range openapi31UnsupportedSchemaKeys {
+		delete(schemaMap, key)
+	}
+	n.normalizeConst(schemaMap)
+	n.normalizeExamples(schemaMap)
+	n.normalizeExclusiveBound(schemaMap, "exclusiveMinimum", "minimum")
+	n.normalizeExclusiveBound(schemaMap, "exclusiveMaximum", "maximum")
+	n.normalizePrefixItems(schemaMap, pointer)
+
+	for _, key := range []string{schemaOneOf, schemaAnyOf} {
+		n.normalizeNullableOf(schemaMap, key)
+	}
+	for _, key := range []string{schemaOneOf, schemaAnyOf, schemaAllOf} {
+		if items, ok := schemaMap[key].([]any); ok {
+			for i, item := range items {
+				n.normalizeSchema(item, joinJsonPointer(pointer, key, cast.ToString(i)))
+			}
+		}
+	}
+	if properties, ok := schemaMap["properties"].(map[string]any); ok {
+		for name, item := range properties {
+			n.normalizeSchema(item, joinJsonPointer(pointer, "properties", name))
+		}
+	}
+	switch items := schemaMap["items"].(type) {
+	case bool:
+		delete(schemaMap, "items")
+	case map[string]any:
+		n.normalizeSchema(items, joinJsonPointer(pointer, "items"))
+	}
+	n.normalizeSchema(schemaMap["additionalProperties"], joinJsonPointer(pointer, "additionalProperties"))
+	n.normalizeSchema(schemaMap["not"], joinJsonPointer(pointer, "not"))
+	n.normalizeType(schemaMap)
+}
+
+// 将$defs提升到components.schemas，名称冲突时追加序号，按名称排序保证序号稳定
+func (n *openapi31Normalizer) hoistDefs(schemaMap map[string]any, pointer string) {
+	defs, ok := schemaMap[schemaKeyDefs].(map[string]any)
+	if !ok {
+		return
+	}
+
+	delete(schemaMap, schemaKeyDefs)
+	names := maps.Keys(defs)
+	slices.Sort(names)
+	for _, name := range names {
+		def := defs[name]
+		defPointer := joinJsonPointer(pointer, schemaKeyDefs, name)
+		n.normalizeSchema(def, defPointer)
+		hoistedName := name
+		for i := 1; n.existSchemaName(hoistedName); i++ {
+			hoistedName = fmt.Sprintf("%s_%d", name, i)
+		}
+		n.hoistedDefs[hoistedName] = def
+		n.hoistedDefNames[name] = append(n.hoistedDefNames[name], hoistedName)
+		n.rewrittenRefs[defPointer] = joinJsonPointer(componentsSchemasPath, hoistedName)
+	}
+}
+
+func (n *openapi31Normalizer) existSchemaName(name string) bool {
+	_, inSchemas := n.schemas[name]
+	_, inHoisted := n.hoistedDefs[name]
+	return inSchemas || inHoisted
+}
+
+// type数组中的null转为nullable，剩余多个类型时拆分为oneOf
+func (n *openapi31Normalizer) normalizeType(schemaMap map[string]any) {
+	var types []string
+	switch schemaType := schemaMap[schemaKeyType].(type) {
+	case string:
+		types = []string{schemaType}
+	case []any:
+		types = cast.ToStringSlice(schemaType)
+	default:
+		return
+	}
+
+	var notNullTypes []string
+	for _, item := range types {
+		if item == schemaTypeNull {
+			schemaMap[schemaKeyNullable] = true
+		} else {
+			notNullTypes = append(notNullTypes, item)
+		}
+	}
+	switch len(notNullTypes) {
+	case 0:
+		delete(schemaMap, schemaKeyType)
+	case 1:
+		schemaMap[schemaKeyType] = notNullTypes[0]
+	default:
+		if _, ok := schemaMap[schemaOneOf]; ok {
+			schemaMap[schemaKeyType] = notNullTypes[0]
+			return
+		}
+
+		delete(schemaMap, schemaKeyType)
+		format, formatExisted := schemaMap[schemaKeyFormat].(string)
+		oneOfItems := make([]any, 0, len(notNullTypes))
+		for _, item := range notNullTypes {
+			oneOfItem := map[string]any{schemaKeyType: item}
+			for _, key := range openapi31TypeScopedKeys[item] {
+				if value, ok := schemaMap[key]; ok {
+					oneOfItem[key] = value
+				}
+			}
+			if formatExisted && openapi31FormatMatchType(format, item) {
+				oneOfItem[schemaKeyFormat] = format
+			}
+			oneOfItems = append(oneOfItems, oneOfItem)
+		}
+		for _, item := range notNullTypes {
+			for _, key := range openapi31TypeScopedKeys[item] {
+				delete(schemaMap, key)
+			}
+		}
+		delete(schemaMap, schemaKeyFormat)
+		schemaMap[schemaOneOf] = oneOfItems
+	}
+}
+
+func openapi31FormatMatchType(format, schemaType string) bool {
+	if formatTypes, ok := openapi31NumericFormatTypes[format]; ok {
+		return slices.Contains(formatTypes, schemaType)
+	}
+	return schemaType == schemaTypeString
+}
+
+// const转为单值enum，缺少type时根据值推断
+func (n *openapi31Normalizer) normalizeConst(schemaMap map[string]any) {
+	constValue, ok := schemaMap["const"]
+	if !ok {
+		return
+	}
+
+	delete(schemaMap, "const")
+	if constValue == nil {
+		schemaMap[schemaKeyNullable] = true
+		return
+	}
+	if _, ok = schemaMap["enum"]; !ok {
+		schemaMap["enum"] = []any{constValue}
+	}
+	if _, ok = schemaMap[schemaKeyType]; ok {
+		return
+	}
+
+	switch value := constValue.(type) {
+	case string:
+		schemaMap[schemaKeyType] = "string"
+	case bool:
+		schemaMap[schemaKeyType] = "boolean"
+	case float64:
+		if value == float64(int64(value)) {
+			schemaMap[schemaKeyType] = "integer"
+		} else {
+			schemaMap[schemaKeyType] = "number"
+		}
+	}
+}
+
+func (n *openapi31Normalizer) normalizeExamples(schemaMap map[string]any) {
+	examples, ok := schemaMap["examples"].([]any)
+	if !ok {
+		return
+	}
+
+	delete(schemaMap, "examples")
+	if _, ok = schemaMap["example"]; !ok && len(examples) > 0 {
+		schemaMap["example"] = examples[0]
+	}
+}
+
+// 3.1中exclusiveMinimum/exclusiveMaximum为数值，3.0中为布尔值并配合minimum/maximum使用
+func (n *openapi31Normalizer) normalizeExclusiveBound(schemaMap map[string]any, exclusiveKey, boundKey string) {
+	bound, ok := schemaMap[exclusiveKey].(float64)
+	if !ok {
+		return
+	}
+
+	schemaMap[boundKey] = bound
+	schemaMap[exclusiveKey] = true
+}
+
+// prefixItems元素类型一致时作为items，否则使用oneOf描述items
+func (n *openapi31Normalizer) normalizePrefixItems(schemaMap map[string]any, pointer string) {
+	prefixItems, ok := schemaMap["prefixItems"].([]any)
+	if !ok {
+		return
+	}
+
+	delete(schemaMap, "prefixItems")
+	for i, item := range prefixItems {
+		n.normalizeSchema(item, joinJsonPointer(pointer, "prefixItems", cast.ToString(i)))
+	}
+	if _, ok = schemaMap[schemaKeyType]; !ok {
+		schemaMap[schemaKeyType] = "array"
+	}
+	if _, ok = schemaMap["items"].(map[string]any); ok || len(prefixItems) == 0 {
+		return
+	}
+
+	sameItems := true
+	for _, item := range prefixItems[1:] {
+		if !reflect.DeepEqual(item, prefixItems[0]) {
+			sameItems = false
+			break
+		}
+	}
+	if sameItems {
+		schemaMap["items"] = prefixItems[0]
+	} else {
+		schemaMap["items"] = map[string]any{schemaOneOf: prefixItems}
+	}
+	if _, ok = schemaMap["minItems"]; !ok {
+		schemaMap["minItems"] = len(prefixItems)
+	}
+}
+
+// 移除oneOf/anyOf中的null类型并标记为nullable
+func (n *openapi31Normalizer) normalizeNullableOf(schemaMap map[string]any, key string) {
+	items, ok := schemaMap[key].([]any)
+	if !ok {
+		return
+	}
+
+	notNullItems := make([]any, 0, len(items))
+	for _, item := range items {
+		if isNullSchema(item) {
+			schemaMap[schemaKeyNullable] = true
+		} else {
+			notNullItems = append(notNullItems, item)
+		}
+	}
+	if len(notNullItems) == 0 {
+		delete(schemaMap, key)
+		return
+	}
+
+	schemaMap[key] = notNullItems
+}
+
+// 改写$defs的引用，未记录的文档内引用按照名称匹配提升后的定义，外部文档和名称不唯一的引用保持不变
+func (n *openapi31Normalizer) rewriteRefs(node any) {
+	switch value := node.(type) {
+	case map[string]any:
+		if ref, ok := value[schemaKeyRef].(string); ok {
+			if rewritten, ok := n.rewrittenRefs[ref]; ok {
+				value[schemaKeyRef] = rewritten
+			} else if hoistedName, ok := n.searchHoistedDefName(ref); ok {
+				value[schemaKeyRef] = joinJsonPointer(componentsSchemasPath, hoistedName)
+			}
+		}
+		for _, item := range value {
+			n.rewriteRefs(item)
+		}
+	case []any:
+		for _, item := range value {
+			n.rewriteRefs(item)
+		}
+	}
+}
+
+// 按照引用中$defs后的名称查找提升后的名称(含冲突时追加的序号)，仅处理'#'开头的文档内引用
+func (n *openapi31Normalizer) searchHoistedDefName(ref string) (string, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return "", false
+	}
+
+	index := strings.LastIndex(ref, "/"+schemaKeyDefs+"/")
+	if index == -1 {
+		return "", false
+	}
+	name := ref[index+len(schemaKeyDefs)+2:]
+	if strings.Contains(name, "/") {
+		return "", false
+	}
+	name = strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")
+	if hoistedNames := n.hoistedDefNames[name]; len(hoistedNames) == 1 {
+		return hoistedNames[0], true
+	}
+	return "", false
+}
+
+func isNullSchema(schema any) bool {
+	schemaMap, ok := schema.(map[string]any)
+	if !ok {
+		return false
+	}
+
+	switch schemaType := schemaMap[schemaKeyType].(type) {
+	case string:
+		return schemaType == schemaTypeNull
+	case []any:
+		return len(schemaType) == 1 && schemaType[0] == schemaTypeNull
+	}
+	constValue, ok := schemaMap["const"]
+	return ok && constValue == nil
+}
+
+// 拼接json pointer，按照RFC6901转义'~'和'/'
+func joinJsonPointer(pointer string, tokens ...string) string {
+	for _, token := range tokens {
+		token = strings.ReplaceAll(token, "~", "~0")
+		token = strings.ReplaceAll(token, "/", "~1")
+		pointer += "/" + token
+	}
+	return pointer
+}
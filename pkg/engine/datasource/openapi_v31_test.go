@@ -0,0 +1,135 @@
+package datasource
+
+import (
+	"context"
+	"encoding/json"
+	"fireboom-server/pkg/common/models"
+	"flag"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"golang.org/x/exp/maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+const (
+	openapi30TestdataDir    = "testdata/openapi30"
+	openapi31TestdataDir    = "testdata/openapi31"
+	openapi31InputSuffix    = ".input.json"
+	openapi31GoldenSuffix   = ".golden.json"
+	openapiGraphqlSuffix    = ".golden.graphql"
+	openapi31GoldenIndent   = "  "
+	openapi31GoldenFilePerm = 0644
+)
+
+func TestNormalizeOpenapi31(t *testing.T) {
+	inputFiles, err := filepath.Glob(filepath.Join(openapi31TestdataDir, "*"+openapi31InputSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, inputFile := range inputFiles {
+		goldenFile := strings.TrimSuffix(inputFile, openapi31InputSuffix) + openapi31GoldenSuffix
+		t.Run(filepath.Base(inputFile), func(t *testing.T) {
+			inputBytes, err := os.ReadFile(inputFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			normalizedBytes, err := normalizeOpenapi31(inputBytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 规范化后的文档需要能被kin-openapi解析并通过校验
+			var doc openapi3.T
+			if err = json.Unmarshal(normalizedBytes, &doc); err != nil {
+				t.Fatal(err)
+			}
+			if err = openapi3.NewLoader().ResolveRefsIn(&doc, nil); err != nil {
+				t.Fatal(err)
+			}
+			if err = doc.Validate(context.Background(), openapi3.DisableExamplesValidation(), openapi3.DisableSchemaDefaultsValidation()); err != nil {
+				t.Fatal(err)
+			}
+
+			// 通过标准库重新序列化保证key有序，便于与golden文件比较
+			var normalized any
+			if err = json.Unmarshal(normalizedBytes, &normalized); err != nil {
+				t.Fatal(err)
+			}
+			actualBytes, err := json.MarshalIndent(normalized, "", openapi31GoldenIndent)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actualBytes = append(actualBytes, '\n')
+			if *updateGolden {
+				if err = os.WriteFile(goldenFile, actualBytes, openapi31GoldenFilePerm); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			expectedBytes, err := os.ReadFile(goldenFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(expectedBytes) != string(actualBytes) {
+				t.Errorf("normalized document mismatch %s\nexpected:\n%s\nactual:\n%s", goldenFile, expectedBytes, actualBytes)
+			}
+		})
+	}
+}
+
+// 3.0和3.1文档经过visitor生成的graphql定义，3.0文档中nullable不影响字段是否必填
+func TestResolveOpenapiGraphqlSchema(t *testing.T) {
+	var inputFiles []string
+	for _, dir := range []string{openapi30TestdataDir, openapi31TestdataDir} {
+		dirInputFiles, err := filepath.Glob(filepath.Join(dir, "*"+openapi31InputSuffix))
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputFiles = append(inputFiles, dirInputFiles...)
+	}
+
+	for _, inputFile := range inputFiles {
+		goldenFile := strings.TrimSuffix(inputFile, openapi31InputSuffix) + openapiGraphqlSuffix
+		t.Run(inputFile, func(t *testing.T) {
+			inputBytes, err := os.ReadFile(inputFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			action := actionMap[wgpb.DataSourceKind_REST](&models.Datasource{Name: "pets"}, "").(*actionOpenapi)
+			if err = action.loadDocument(inputBytes); err != nil {
+				t.Fatal(err)
+			}
+
+			resolveSchema := newResolveGraphqlSchema(action)
+			resolveSchema.emptyResolve = newResolveGraphqlSchema(action)
+			if err = action.resolveDocument(resolveSchema); err != nil {
+				t.Fatal(err)
+			}
+			resolveSchema.schema.Types = maps.Values(resolveSchema.types)
+			actual := formatSchemaString(resolveSchema.schema)
+			if *updateGolden {
+				if err = os.WriteFile(goldenFile, []byte(actual), openapi31GoldenFilePerm); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			expectedBytes, err := os.ReadFile(goldenFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(expectedBytes) != actual {
+				t.Errorf("graphql schema mismatch %s\nexpected:\n%s\nactual:\n%s", goldenFile, expectedBytes, actual)
+			}
+		})
+	}
+}
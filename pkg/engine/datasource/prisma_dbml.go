@@ -0,0 +1,370 @@
+// Package datasource
+/*
+ 数据库类型数据源的dbml导出及ER图
+ 基于缓存的dmmf生成dbml文本以及供控制台渲染的ER图(表、列、关联关系及基数)
+ 关联关系通过包含relationFromFields的关联字段识别，对端字段为列表时为多对一，否则为一对一
+ 两端均为列表且没有外键字段时为隐式多对多
+*/
+package datasource
+
+import (
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/plugins/fileloader"
+	"fmt"
+	json "github.com/json-iterator/go"
+	"github.com/prisma/prisma-client-go/generator/ast/dmmf"
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"strings"
+)
+
+type (
+	ErDiagram struct {
+		Tables    []*ErTable    `json:"tables"`
+		Enums     []*ErEnum     `json:"enums"`
+		Relations []*ErRelation `json:"relations"`
+	}
+	ErTable struct {
+		Name       string      `json:"name"`
+		DbName     string      `json:"dbName"`
+		Note       string      `json:"note"`
+		Columns    []*ErColumn `json:"columns"`
+		PrimaryKey []string    `json:"primaryKey"`
+		Uniques    [][]string  `json:"uniques"`
+	}
+	ErColumn struct {
+		Name       string `json:"name"`
+		DbName     string `json:"dbName"`
+		Type       string `json:"type"`
+		Enum       bool   `json:"enum"`
+		Required   bool   `json:"required"`
+		List       bool   `json:"list"`
+		PrimaryKey bool   `json:"primaryKey"`
+		Unique     bool   `json:"unique"`
+		Increment  bool   `json:"increment"`
+		Default    string `json:"default"`
+		Note       string `json:"note"`
+	}
+	ErEnum struct {
+		Name   string   `json:"name"`
+		Values []string `json:"values"`
+	}
+	ErRelation struct {
+		Name        string                `json:"name"`
+		From        *ErRelationEnd        `json:"from"`
+		To          *ErRelationEnd        `json:"to"`
+		Cardinality ErRelationCardinality `json:"cardinality"`
+		OnDelete    string                `json:"onDelete"`
+	}
+	ErRelationEnd struct {
+		Table   string   `json:"table"`
+		Columns []string `json:"columns"`
+	}
+	ErRelationCardinality string
+)
+
+const (
+	ErRelationOneToOne   ErRelationCardinality = "one-to-one"
+	ErRelationManyToOne  ErRelationCardinality = "many-to-one"
+	ErRelationManyToMany ErRelationCardinality = "many-to-many"
+)
+
+const (
+	dmmfDefaultFuncName      = "name"
+	dmmfDefaultFuncArgs      = "args"
+	dmmfDefaultAutoincrement = "autoincrement"
+)
+
+var erRelationDbmlSymbols = map[ErRelationCardinality]string{
+	ErRelationOneToOne:   "-",
+	ErRelationManyToOne:  ">",
+	ErRelationManyToMany: "<>",
+}
+
+// LoadDmmfDocument 读取缓存的dmmf，缓存不存在时通过缓存的prisma文本内省并写入缓存
+func LoadDmmfDocument(ds *models.Datasource) (document *dmmf.Document, err error) {
+	dmmfContent, _ := CacheDmmfText.Read(ds.Name)
+	if dmmfContent == "" {
+		engineInput := EngineInput{
+			PrismaSchemaFilepath: CachePrismaSchemaText.GetPath(ds.Name),
+			EnvironmentRequired:  ds.Kind == wgpb.DataSourceKind_PRISMA,
+		}
+		if dmmfContent, err = IntrospectDMMF(engineInput); err != nil {
+			return
+		}
+		_ = CacheDmmfText.Write(ds.Name, fileloader.SystemUser, []byte(dmmfContent))
+	}
+
+	err = json.Unmarshal([]byte(dmmfContent), &document)
+	return
+}
+
+// BuildErDiagram 根据dmmf构建ER图
+func BuildErDiagram(document *dmmf.Document) (diagram *ErDiagram) {
+	diagram = &ErDiagram{}
+	for _, enum := range document.Datamodel.Enums {
+		erEnum := &ErEnum{Name: string(enum.Name)}
+		for _, value := range enum.Values {
+			erEnum.Values = append(erEnum.Values, string(value.Name))
+		}
+		diagram.Enums = append(diagram.Enums, erEnum)
+	}
+
+	dmmfModels := document.Datamodel.Models
+	for _, model := range dmmfModels {
+		table := &ErTable{
+			Name:       string(model.Name),
+			DbName:     string(model.DBName),
+			Note:       string(model.Documentation),
+			PrimaryKey: stringsOfDmmf(model.PrimaryKey.Fields),
+		}
+		for _, uniqueFields := range model.UniqueFields {
+			table.Uniques = append(table.Uniques, stringsOfDmmf(uniqueFields))
+		}
+		for _, field := range model.Fields {
+			if field.Kind.IsRelation() {
+				if relation := buildErRelation(dmmfModels, model, field); relation != nil {
+					diagram.Relations = append(diagram.Relations, relation)
+				}
+				continue
+			}
+
+			column := &ErColumn{
+				Name:       string(field.Name),
+				Type:       string(field.Type),
+				Enum:       field.Kind == dmmf.FieldKindEnum,
+				Required:   field.IsRequired,
+				List:       field.IsList,
+				PrimaryKey: field.IsID,
+				Unique:     field.IsUnique,
+				Note:       string(field.Documentation),
+			}
+			if len(field.DBNames) > 0 {
+				column.DbName = string(field.DBNames[0])
+			}
+			column.Default, column.Increment = formatDmmfDefault(field.Default)
+			table.Columns = append(table.Columns, column)
+		}
+		diagram.Tables = append(diagram.Tables, table)
+	}
+	return
+}
+
+// 外键字段所在的一端作为起点，隐式多对多按照模型名称排序后仅保留一次
+func buildErRelation(dmmfModels []dmmf.Model, model dmmf.Model, field dmmf.Field) *ErRelation {
+	oppositeField, ok := searchOppositeRelationField(dmmfModels, model, field)
+	if !ok {
+		return nil
+	}
+
+	relation := &ErRelation{Name: string(field.RelationName), OnDelete: string(field.RelationOnDelete)}
+	switch {
+	case len(field.RelationFromFields) > 0:
+		relation.From = &ErRelationEnd{Table: string(model.Name), Columns: stringsOfAny(field.RelationFromFields)}
+		relation.To = &ErRelationEnd{Table: string(field.Type), Columns: stringsOfAny(field.RelationToFields)}
+		relation.Cardinality = ErRelationOneToOne
+		if oppositeField.IsList {
+			relation.Cardinality = ErRelationManyToOne
+		}
+	case field.IsList && oppositeField.IsList && string(model.Name) <= string(field.Type):
+		relation.From = &ErRelationEnd{Table: string(model.Name)}
+		relation.To = &ErRelationEnd{Table: string(field.Type)}
+		relation.Cardinality = ErRelationManyToMany
+	default:
+		return nil
+	}
+	return relation
+}
+
+// 查找关联关系另一端的字段，自关联时排除自身
+func searchOppositeRelationField(dmmfModels []dmmf.Model, model dmmf.Model, field dmmf.Field) (opposite dmmf.Field, ok bool) {
+	for _, item := range dmmfModels {
+		if string(item.Name) != string(field.Type) {
+			continue
+		}
+		for _, itemField := range item.Fields {
+			if itemField.RelationName == field.RelationName && (item.Name != model.Name || itemField.Name != field.Name) {
+				return itemField, true
+			}
+		}
+	}
+	return
+}
+
+// BuildDbml 根据ER图生成dbml文本
+func BuildDbml(diagram *ErDiagram) string {
+	var builder strings.Builder
+	for _, enum := range diagram.Enums {
+		builder.WriteString(fmt.Sprintf("Enum %s {\n", quoteDbmlName(enum.Name)))
+		for _, value := range enum.Values {
+			builder.WriteString(fmt.Sprintf("  %s\n", quoteDbmlName(value)))
+		}
+		builder.WriteString("}\n\n")
+	}
+
+	for _, table := range diagram.Tables {
+		builder.WriteString(fmt.Sprintf("Table %s {\n", quoteDbmlName(table.Name)))
+		for _, column := range table.Columns {
+			columnType := column.Type
+			if column.List {
+				columnType += "[]"
+			}
+			builder.WriteString(fmt.Sprintf("  %s %s", quoteDbmlName(column.Name), quoteDbmlType(columnType)))
+			if settings := buildDbmlColumnSettings(column, len(table.PrimaryKey) > 1); len(settings) > 0 {
+				builder.WriteString(fmt.Sprintf(" [%s]", strings.Join(settings, ", ")))
+			}
+			builder.WriteString("\n")
+		}
+
+		var indexes []string
+		if len(table.PrimaryKey) > 1 {
+			indexes = append(indexes, fmt.Sprintf("    (%s) [pk]", joinDbmlNames(table.PrimaryKey)))
+		}
+		for _, unique := range table.Uniques {
+			indexes = append(indexes, fmt.Sprintf("    (%s) [unique]", joinDbmlNames(unique)))
+		}
+		if len(indexes) > 0 {
+			builder.WriteString(fmt.Sprintf("\n  indexes {\n%s\n  }\n", strings.Join(indexes, "\n")))
+		}
+		if table.Note != "" {
+			builder.WriteString(fmt.Sprintf("\n  Note: %s\n", quoteDbmlString(table.Note)))
+		}
+		builder.WriteString("}\n\n")
+	}
+
+	for _, relation := range diagram.Relations {
+		if relation.Cardinality == ErRelationManyToMany {
+			// 隐式多对多没有外键字段，使用双方主键表示
+			fromKey, toKey := searchErPrimaryKey(diagram, relation.From.Table), searchErPrimaryKey(diagram, relation.To.Table)
+			if fromKey == "" || toKey == "" {
+				continue
+			}
+			builder.WriteString(fmt.Sprintf("Ref: %s.%s <> %s.%s\n", quoteDbmlName(relation.From.Table), quoteDbmlName(fromKey),
+				quoteDbmlName(relation.To.Table), quoteDbmlName(toKey)))
+			continue
+		}
+
+		builder.WriteString(fmt.Sprintf("Ref: %s.%s %s %s.%s", quoteDbmlName(relation.From.Table), formatDbmlRefColumns(relation.From.Columns),
+			erRelationDbmlSymbols[relation.Cardinality], quoteDbmlName(relation.To.Table), formatDbmlRefColumns(relation.To.Columns)))
+		if relation.OnDelete != "" {
+			builder.WriteString(fmt.Sprintf(" [delete: %s]", relation.OnDelete))
+		}
+		builder.WriteString("\n")
+	}
+	return strings.TrimSpace(builder.String()) + "\n"
+}
+
+func buildDbmlColumnSettings(column *ErColumn, compositePrimaryKey bool) (settings []string) {
+	if column.PrimaryKey && !compositePrimaryKey {
+		settings = append(settings, "pk")
+	}
+	if column.Increment {
+		settings = append(settings, "increment")
+	}
+	if column.Unique {
+		settings = append(settings, "unique")
+	}
+	if column.Required && !column.PrimaryKey {
+		settings = append(settings, "not null")
+	}
+	if column.Default != "" {
+		settings = append(settings, "default: "+column.Default)
+	}
+	if column.Note != "" {
+		settings = append(settings, "note: "+quoteDbmlString(column.Note))
+	}
+	return
+}
+
+// 格式化默认值，函数使用反引号表达式，autoincrement转换为increment
+func formatDmmfDefault(value any) (defaultValue string, increment bool) {
+	switch val := value.(type) {
+	case nil:
+	case string:
+		defaultValue = quoteDbmlString(val)
+	case bool, float64:
+		defaultValue = fmt.Sprintf("%v", val)
+	case map[string]any:
+		funcName, _ := val[dmmfDefaultFuncName].(string)
+		if funcName == dmmfDefaultAutoincrement {
+			increment = true
+			break
+		}
+		var args []string
+		if funcArgs, ok := val[dmmfDefaultFuncArgs].([]any); ok {
+			for _, arg := range funcArgs {
+				args = append(args, fmt.Sprintf("%v", arg))
+			}
+		}
+		defaultValue = fmt.Sprintf("`%s(%s)`", funcName, strings.Join(args, ", "))
+	default:
+		valueBytes, _ := json.Marshal(val)
+		defaultValue = quoteDbmlString(string(valueBytes))
+	}
+	return
+}
+
+func searchErPrimaryKey(diagram *ErDiagram, tableName string) string {
+	for _, table := range diagram.Tables {
+		if table.Name != tableName {
+			continue
+		}
+		for _, column := range table.Columns {
+			if column.PrimaryKey {
+				return column.Name
+			}
+		}
+	}
+	return ""
+}
+
+func formatDbmlRefColumns(columns []string) string {
+	if len(columns) == 1 {
+		return quoteDbmlName(columns[0])
+	}
+	return "(" + joinDbmlNames(columns) + ")"
+}
+
+func joinDbmlNames(names []string) string {
+	quotedNames := make([]string, len(names))
+	for i, name := range names {
+		quotedNames[i] = quoteDbmlName(name)
+	}
+	return strings.Join(quotedNames, ", ")
+}
+
+// 名称包含特殊字符时使用双引号
+func quoteDbmlName(name string) string {
+	if dbmlIdentifierRegexp.MatchString(name) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `\"`) + `"`
+}
+
+// 类型包含空格或者为列表时使用双引号，避免与设置的方括号混淆
+func quoteDbmlType(columnType string) string {
+	if strings.ContainsAny(columnType, " []") {
+		return `"` + columnType + `"`
+	}
+	return columnType
+}
+
+func quoteDbmlString(text string) string {
+	if strings.Contains(text, "\n") {
+		return "'''" + strings.ReplaceAll(text, "'''", `\'''`) + "'''"
+	}
+	return "'" + strings.ReplaceAll(text, "'", `\'`) + "'"
+}
+
+func stringsOfDmmf[T ~string](items []T) (result []string) {
+	for _, item := range items {
+		result = append(result, string(item))
+	}
+	return
+}
+
+func stringsOfAny(items []any) (result []string) {
+	for _, item := range items {
+		result = append(result, fmt.Sprintf("%v", item))
+	}
+	return
+}
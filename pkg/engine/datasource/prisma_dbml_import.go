@@ -0,0 +1,850 @@
+// Package datasource
+/*
+ 解析dbml并转换成prisma模型，用于在画图工具中设计数据库后导入
+ 支持Table/Enum/Ref以及列上的ref设置，忽略TableGroup/Project等与模型无关的定义
+ 已存在于prisma文本中的模型和枚举会被跳过，关联的表不在本次导入中时跳过该关联
+*/
+package datasource
+
+import (
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	"golang.org/x/exp/slices"
+	"regexp"
+	"strings"
+)
+
+type (
+	DbmlImportResult struct {
+		PrismaSchema string   `json:"prismaSchema"`
+		Models       []string `json:"models"`
+		Enums        []string `json:"enums"`
+		Skipped      []string `json:"skipped"`
+	}
+	dbmlDocument struct {
+		tables []*dbmlTable
+		enums  []*dbmlEnum
+		refs   []*dbmlRef
+	}
+	dbmlTable struct {
+		name, note string
+		columns    []*dbmlColumn
+		primaryKey []string
+		uniques    [][]string
+	}
+	dbmlColumn struct {
+		name, columnType, note, defaultValue string
+		primaryKey, increment, unique        bool
+		notNull                              bool
+	}
+	dbmlEnum struct {
+		name   string
+		values []string
+	}
+	dbmlRef struct {
+		fromTable, toTable     string
+		fromColumns, toColumns []string
+		symbol                 string
+		onDelete, onUpdate     string
+	}
+	// 转换过程中的prisma模型，fields按照列、关联字段的顺序输出
+	prismaModelBuilder struct {
+		name, originName string
+		table            *dbmlTable
+		fields           []*prismaFieldBuilder
+		attributes       []string
+	}
+	prismaFieldBuilder struct {
+		name, fieldType, note string
+		attributes            []string
+	}
+)
+
+const (
+	dbmlSymbolManyToOne  = ">"
+	dbmlSymbolOneToMany  = "<"
+	dbmlSymbolOneToOne   = "-"
+	dbmlSymbolManyToMany = "<>"
+	dbmlListSuffix       = "[]"
+)
+
+var (
+	dbmlIdentifierRegexp    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	dbmlInvalidNameRegexp   = regexp.MustCompile(`[^A-Za-z0-9_]`)
+	dbmlBlockCommentRegexp  = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	dbmlMultilineRegexp     = regexp.MustCompile(`(?s)'''(.*?)'''`)
+	dbmlBlockHeaderRegexp   = regexp.MustCompile(`(?i)^(Table|Enum|Ref|TableGroup|TablePartial|Project|Note|Records)\b\s*(.*?)\s*\{\s*(.*?)\s*(})?$`)
+	dbmlRefLineRegexp       = regexp.MustCompile(`(?i)^Ref\b[^:{]*:\s*(.+)$`)
+	dbmlNoteLineRegexp      = regexp.MustCompile(`(?i)^Note\s*:\s*(.+)$`)
+	dbmlIndexesHeaderRegexp = regexp.MustCompile(`(?i)^indexes\s*\{$`)
+	dbmlSymbols             = []string{dbmlSymbolManyToMany, dbmlSymbolManyToOne, dbmlSymbolOneToMany, dbmlSymbolOneToOne}
+	dbmlRefActionMap        = map[string]string{
+		"cascade":     "Cascade",
+		"restrict":    "Restrict",
+		"set null":    "SetNull",
+		"set default": "SetDefault",
+		"no action":   "NoAction",
+	}
+	dbmlPrismaScalars   = []string{"String", "Boolean", "Int", "BigInt", "Float", "Decimal", "DateTime", "Json", "Bytes"}
+	dbmlPrismaTypeRules = []struct {
+		prefixes   []string
+		prismaType string
+	}{
+		{[]string{"bigint", "int8", "bigserial", "serial8"}, "BigInt"},
+		{[]string{"int", "mediumint", "smallint", "tinyint", "serial", "smallserial"}, "Int"},
+		{[]string{"float", "double", "real"}, "Float"},
+		{[]string{"decimal", "numeric", "money", "number"}, "Decimal"},
+		{[]string{"bool", "bit"}, "Boolean"},
+		{[]string{"date", "time", "timestamp"}, "DateTime"},
+		{[]string{"json"}, "Json"},
+		{[]string{"blob", "bytea", "binary", "varbinary", "longblob", "bytes"}, "Bytes"},
+		{[]string{"varchar", "char", "nvarchar", "nchar", "text", "tinytext", "mediumtext", "longtext", "string", "uuid", "citext", "character", "enum", "xml"}, "String"},
+	}
+	dbmlDefaultFuncMap = map[string]string{
+		"now()":               "now()",
+		"current_timestamp":   "now()",
+		"current_timestamp()": "now()",
+		"uuid()":              "uuid()",
+		"gen_random_uuid()":   "uuid()",
+		"uuid_generate_v4()":  "uuid()",
+		"cuid()":              "cuid()",
+		"autoincrement()":     "autoincrement()",
+	}
+)
+
+// ConvertDbmlToPrisma 将dbml转换为prisma模型文本，existPrismaSchema用于跳过已存在的模型和枚举
+func ConvertDbmlToPrisma(dbmlContent, existPrismaSchema string) (result *DbmlImportResult, err error) {
+	document, err := parseDbml(dbmlContent)
+	if err != nil {
+		return
+	}
+	if len(document.tables) == 0 && len(document.enums) == 0 {
+		err = i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.DatasourceDbmlParseError, "empty")
+		return
+	}
+
+	result = &DbmlImportResult{}
+	existNames := searchPrismaBlockNames(existPrismaSchema)
+	enumNames := make(map[string]string)
+	var builder strings.Builder
+	for _, enum := range document.enums {
+		enumName := sanitizePrismaName(enum.name)
+		if existNames[enumName] {
+			result.Skipped = append(result.Skipped, "enum "+enumName)
+			enumNames[enum.name] = enumName
+			continue
+		}
+
+		enumNames[enum.name] = enumName
+		result.Enums = append(result.Enums, enumName)
+		builder.WriteString(fmt.Sprintf("enum %s {\n", enumName))
+		for _, value := range enum.values {
+			if valueName := sanitizePrismaName(value); valueName != value {
+				builder.WriteString(fmt.Sprintf("  %s @map(%q)\n", valueName, value))
+			} else {
+				builder.WriteString(fmt.Sprintf("  %s\n", valueName))
+			}
+		}
+		if enumName != enum.name {
+			builder.WriteString(fmt.Sprintf("\n  @@map(%q)\n", enum.name))
+		}
+		builder.WriteString("}\n\n")
+	}
+
+	var modelBuilders []*prismaModelBuilder
+	for _, table := range document.tables {
+		modelName := sanitizePrismaName(table.name)
+		if existNames[modelName] {
+			result.Skipped = append(result.Skipped, "model "+modelName)
+			continue
+		}
+
+		modelBuilders = append(modelBuilders, buildPrismaModel(table, modelName, enumNames))
+		result.Models = append(result.Models, modelName)
+	}
+	result.Skipped = append(result.Skipped, appendPrismaRelations(modelBuilders, document.refs)...)
+	for _, model := range modelBuilders {
+		builder.WriteString(model.String())
+	}
+	result.PrismaSchema = strings.TrimSpace(builder.String()) + "\n"
+	return
+}
+
+// 查找prisma文本中已定义的模型和枚举名称
+func searchPrismaBlockNames(prismaSchema string) map[string]bool {
+	names := make(map[string]bool)
+	for _, line := range strings.Split(prismaSchema, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && (fields[0] == "model" || fields[0] == "enum" || fields[0] == "view" || fields[0] == "type") {
+			names[fields[1]] = true
+		}
+	}
+	return names
+}
+
+func buildPrismaModel(table *dbmlTable, modelName string, enumNames map[string]string) *prismaModelBuilder {
+	model := &prismaModelBuilder{name: modelName, originName: table.name, table: table}
+	compositePrimaryKey := len(table.primaryKey) > 1
+	for _, column := range table.columns {
+		fieldName := sanitizePrismaName(column.name)
+		fieldType, isList, isEnum, serial := convertDbmlType(column.columnType, enumNames)
+		field := &prismaFieldBuilder{name: fieldName, fieldType: fieldType, note: column.note}
+		switch {
+		case isList:
+			field.fieldType += dbmlListSuffix
+		case !column.notNull && !column.primaryKey && !slices.Contains(table.primaryKey, column.name):
+			field.fieldType += "?"
+		}
+		if column.primaryKey && !compositePrimaryKey {
+			field.attributes = append(field.attributes, "@id")
+		}
+		if defaultValue := convertDbmlDefault(column, fieldType, isEnum, serial); defaultValue != "" {
+			field.attributes = append(field.attributes, fmt.Sprintf("@default(%s)", defaultValue))
+		}
+		if column.unique {
+			field.attributes = append(field.attributes, "@unique")
+		}
+		if fieldName != column.name {
+			field.attributes = append(field.attributes, fmt.Sprintf("@map(%q)", column.name))
+		}
+		model.fields = append(model.fields, field)
+	}
+
+	if compositePrimaryKey {
+		model.attributes = append(model.attributes, fmt.Sprintf("@@id([%s])", joinPrismaNames(table.primaryKey)))
+	}
+	for _, unique := range table.uniques {
+		model.attributes = append(model.attributes, fmt.Sprintf("@@unique([%s])", joinPrismaNames(unique)))
+	}
+	if modelName != table.name {
+		model.attributes = append(model.attributes, fmt.Sprintf("@@map(%q)", table.name))
+	}
+	return model
+}
+
+// 为每个关联在两端模型上添加关联字段，同一对模型存在多个关联或自关联时指定关联名称
+func appendPrismaRelations(modelBuilders []*prismaModelBuilder, refs []*dbmlRef) (skipped []string) {
+	searchModel := func(tableName string) *prismaModelBuilder {
+		for _, model := range modelBuilders {
+			if model.originName == tableName || model.name == tableName {
+				return model
+			}
+		}
+		return nil
+	}
+	pairCounts := make(map[string]int)
+	pairKey := func(a, b string) string {
+		if a > b {
+			a, b = b, a
+		}
+		return a + "," + b
+	}
+	for _, ref := range refs {
+		pairCounts[pairKey(ref.fromTable, ref.toTable)]++
+	}
+
+	for _, ref := range refs {
+		// 统一为外键所在的一端作为起点
+		if ref.symbol == dbmlSymbolOneToMany {
+			ref = &dbmlRef{fromTable: ref.toTable, fromColumns: ref.toColumns, toTable: ref.fromTable, toColumns: ref.fromColumns,
+				symbol: dbmlSymbolManyToOne, onDelete: ref.onDelete, onUpdate: ref.onUpdate}
+		}
+		refText := fmt.Sprintf("ref %s.(%s) %s %s.(%s)", ref.fromTable, strings.Join(ref.fromColumns, ","), ref.symbol, ref.toTable, strings.Join(ref.toColumns, ","))
+		fromModel, toModel := searchModel(ref.fromTable), searchModel(ref.toTable)
+		if fromModel == nil || toModel == nil || len(ref.fromColumns) != len(ref.toColumns) {
+			skipped = append(skipped, refText)
+			continue
+		}
+
+		var relationName string
+		if fromModel == toModel || pairCounts[pairKey(ref.fromTable, ref.toTable)] > 1 {
+			relationName = fmt.Sprintf("%q", fromModel.name+"_"+strings.Join(ref.fromColumns, "_"))
+		}
+		if ref.symbol == dbmlSymbolManyToMany {
+			fromModel.addField(lowerFirstPrismaName(toModel.name)+"List", toModel.name+dbmlListSuffix, relationAttribute(relationName))
+			toModel.addField(lowerFirstPrismaName(fromModel.name)+"List", fromModel.name+dbmlListSuffix, relationAttribute(relationName))
+			continue
+		}
+
+		fromFieldName := lowerFirstPrismaName(toModel.name)
+		if len(ref.fromColumns) == 1 {
+			if trimmed := trimForeignKeySuffix(ref.fromColumns[0]); trimmed != "" {
+				fromFieldName = sanitizePrismaName(trimmed)
+			}
+		}
+		relationArgs := []string{
+			fmt.Sprintf("fields: [%s]", joinPrismaNames(ref.fromColumns)),
+			fmt.Sprintf("references: [%s]", joinPrismaNames(ref.toColumns)),
+		}
+		if relationName != "" {
+			relationArgs = append([]string{relationName}, relationArgs...)
+		}
+		if action, ok := dbmlRefActionMap[ref.onDelete]; ok {
+			relationArgs = append(relationArgs, "onDelete: "+action)
+		}
+		if action, ok := dbmlRefActionMap[ref.onUpdate]; ok {
+			relationArgs = append(relationArgs, "onUpdate: "+action)
+		}
+
+		fromRequired := fromModel.isRequiredColumns(ref.fromColumns)
+		fromFieldType := toModel.name
+		if !fromRequired {
+			fromFieldType += "?"
+		}
+		fromModel.addField(fromFieldName, fromFieldType, fmt.Sprintf("@relation(%s)", strings.Join(relationArgs, ", ")))
+
+		backFieldName, backFieldType := lowerFirstPrismaName(fromModel.name)+"List", fromModel.name+dbmlListSuffix
+		if ref.symbol == dbmlSymbolOneToOne {
+			backFieldName, backFieldType = lowerFirstPrismaName(fromModel.name), fromModel.name+"?"
+			fromModel.ensureUnique(ref.fromColumns)
+		}
+		toModel.addField(backFieldName, backFieldType, relationAttribute(relationName))
+	}
+	return
+}
+
+func relationAttribute(relationName string) string {
+	if relationName == "" {
+		return ""
+	}
+	return fmt.Sprintf("@relation(%s)", relationName)
+}
+
+// 添加字段，名称冲突时追加数字后缀
+func (m *prismaModelBuilder) addField(name, fieldType, attribute string) {
+	fieldName := name
+	for i := 2; m.searchField(fieldName) != nil; i++ {
+		fieldName = fmt.Sprintf("%s%d", name, i)
+	}
+	field := &prismaFieldBuilder{name: fieldName, fieldType: fieldType}
+	if attribute != "" {
+		field.attributes = append(field.attributes, attribute)
+	}
+	m.fields = append(m.fields, field)
+}
+
+func (m *prismaModelBuilder) searchField(name string) *prismaFieldBuilder {
+	for _, field := range m.fields {
+		if field.name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+func (m *prismaModelBuilder) isRequiredColumns(columns []string) bool {
+	for _, column := range columns {
+		if field := m.searchField(sanitizePrismaName(column)); field == nil || strings.HasSuffix(field.fieldType, "?") {
+			return false
+		}
+	}
+	return true
+}
+
+// 一对一关联的外键字段需要唯一约束
+func (m *prismaModelBuilder) ensureUnique(columns []string) {
+	if len(columns) == 1 {
+		field := m.searchField(sanitizePrismaName(columns[0]))
+		if field != nil && !slices.Contains(field.attributes, "@id") && !slices.Contains(field.attributes, "@unique") {
+			field.attributes = append(field.attributes, "@unique")
+		}
+		return
+	}
+
+	attribute := fmt.Sprintf("@@unique([%s])", joinPrismaNames(columns))
+	if !slices.Contains(m.attributes, attribute) {
+		m.attributes = append(m.attributes, attribute)
+	}
+}
+
+// 按照prisma format的风格对齐字段名称和类型
+func (m *prismaModelBuilder) String() string {
+	var nameWidth, typeWidth int
+	for _, field := range m.fields {
+		nameWidth, typeWidth = max(nameWidth, len(field.name)), max(typeWidth, len(field.fieldType))
+	}
+
+	var builder strings.Builder
+	writePrismaDocumentation(&builder, "", m.table.note)
+	builder.WriteString(fmt.Sprintf("model %s {\n", m.name))
+	for _, field := range m.fields {
+		writePrismaDocumentation(&builder, "  ", field.note)
+		line := fmt.Sprintf("  %-*s %-*s %s", nameWidth, field.name, typeWidth, field.fieldType, strings.Join(field.attributes, " "))
+		builder.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	if len(m.attributes) > 0 {
+		builder.WriteString("\n")
+		for _, attribute := range m.attributes {
+			builder.WriteString("  " + attribute + "\n")
+		}
+	}
+	builder.WriteString("}\n\n")
+	return builder.String()
+}
+
+// 注释转换为prisma文档注释(///)，生成dmmf时作为documentation
+func writePrismaDocumentation(builder *strings.Builder, indent, note string) {
+	if note == "" {
+		return
+	}
+	for _, line := range strings.Split(note, "\n") {
+		builder.WriteString(indent + "/// " + strings.TrimSpace(line) + "\n")
+	}
+}
+
+// 转换列类型，返回prisma类型、是否列表、是否枚举、是否自增类型(serial)
+func convertDbmlType(columnType string, enumNames map[string]string) (prismaType string, isList, isEnum, serial bool) {
+	if isList = strings.HasSuffix(columnType, dbmlListSuffix); isList {
+		columnType = strings.TrimSuffix(columnType, dbmlListSuffix)
+	}
+	if enumName, ok := enumNames[columnType]; ok {
+		return enumName, isList, true, false
+	}
+	if slices.Contains(dbmlPrismaScalars, columnType) {
+		return columnType, isList, false, false
+	}
+
+	baseType, _, _ := strings.Cut(strings.ToLower(columnType), "(")
+	baseType = strings.TrimSpace(baseType)
+	serial = strings.Contains(baseType, "serial")
+	for _, rule := range dbmlPrismaTypeRules {
+		for _, prefix := range rule.prefixes {
+			if strings.HasPrefix(baseType, prefix) {
+				return rule.prismaType, isList, false, serial
+			}
+		}
+	}
+	return fmt.Sprintf("Unsupported(%q)", columnType), isList, false, serial
+}
+
+// 转换默认值，字符串使用双引号，枚举值不加引号，函数表达式尽量转换为prisma内置函数
+func convertDbmlDefault(column *dbmlColumn, fieldType string, isEnum, serial bool) string {
+	if column.increment || serial {
+		return "autoincrement()"
+	}
+
+	value := column.defaultValue
+	switch {
+	case value == "" || strings.EqualFold(value, "null"):
+		return ""
+	case strings.HasPrefix(value, "`"):
+		expression := strings.Trim(value, "`")
+		if prismaFunc, ok := dbmlDefaultFuncMap[strings.ToLower(expression)]; ok {
+			return prismaFunc
+		}
+		return fmt.Sprintf("dbgenerated(%q)", expression)
+	case strings.HasPrefix(value, "'") || strings.HasPrefix(value, `"`):
+		text := unquoteDbmlString(value)
+		if isEnum {
+			return sanitizePrismaName(text)
+		}
+		if fieldType != "String" {
+			return fmt.Sprintf("dbgenerated(%q)", "'"+text+"'")
+		}
+		return fmt.Sprintf("%q", text)
+	default:
+		return value
+	}
+}
+
+// 去除外键列的id后缀作为关联字段名称
+func trimForeignKeySuffix(column string) string {
+	for _, suffix := range []string{"_id", "Id", "ID", "_ID"} {
+		if trimmed := strings.TrimSuffix(column, suffix); trimmed != column && trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
+
+func sanitizePrismaName(name string) string {
+	name = dbmlInvalidNameRegexp.ReplaceAllString(name, "_")
+	if name == "" || name[0] >= '0' && name[0] <= '9' || name[0] == '_' {
+		name = "x" + name
+	}
+	return name
+}
+
+func lowerFirstPrismaName(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+func joinPrismaNames(names []string) string {
+	sanitizedNames := make([]string, len(names))
+	for i, name := range names {
+		sanitizedNames[i] = sanitizePrismaName(name)
+	}
+	return strings.Join(sanitizedNames, ", ")
+}
+
+// 按行解析dbml，多行字符串先转换为单行
+func parseDbml(content string) (document *dbmlDocument, err error) {
+	content = dbmlBlockCommentRegexp.ReplaceAllString(content, "")
+	content = dbmlMultilineRegexp.ReplaceAllStringFunc(content, func(matched string) string {
+		text := strings.TrimSpace(matched[3 : len(matched)-3])
+		return "'" + strings.ReplaceAll(strings.ReplaceAll(text, "'", `\'`), "\n", `\n`) + "'"
+	})
+
+	document = &dbmlDocument{}
+	var (
+		blockKind   string
+		table       *dbmlTable
+		enum        *dbmlEnum
+		nestedKind  string
+		ignoreDepth int
+	)
+	for lineNumber, rawLine := range strings.Split(content, "\n") {
+		line := strings.TrimSpace(stripDbmlLineComment(rawLine))
+		if line == "" {
+			continue
+		}
+
+		if ignoreDepth > 0 {
+			ignoreDepth += strings.Count(line, "{") - strings.Count(line, "}")
+			continue
+		}
+
+		if nestedKind != "" {
+			if line == "}" {
+				nestedKind = ""
+				continue
+			}
+			if nestedKind == "indexes" {
+				parseDbmlIndex(table, line)
+			} else if nestedKind == "note" && table.note == "" {
+				table.note = unquoteDbmlString(line)
+			}
+			continue
+		}
+
+		switch blockKind {
+		case "":
+			if matches := dbmlRefLineRegexp.FindStringSubmatch(line); len(matches) == 2 {
+				if err = document.appendRef(matches[1], lineNumber); err != nil {
+					return
+				}
+				continue
+			}
+
+			matches := dbmlBlockHeaderRegexp.FindStringSubmatch(line)
+			if len(matches) != 5 {
+				err = i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.DatasourceDbmlParseError, fmt.Sprintf("line %d: %s", lineNumber+1, line))
+				return
+			}
+			kind, header, inline, closed := strings.ToLower(matches[1]), matches[2], matches[3], matches[4] != ""
+			switch kind {
+			case "table":
+				table = &dbmlTable{name: parseDbmlBlockName(header)}
+				document.tables = append(document.tables, table)
+			case "enum":
+				enum = &dbmlEnum{name: parseDbmlBlockName(header)}
+				document.enums = append(document.enums, enum)
+			case "ref":
+				if inline != "" {
+					if err = document.appendRef(inline, lineNumber); err != nil {
+						return
+					}
+				}
+			default:
+				if !closed {
+					ignoreDepth = 1
+				}
+				continue
+			}
+			if !closed {
+				blockKind = kind
+			}
+		case "table":
+			switch {
+			case line == "}":
+				blockKind = ""
+			case dbmlIndexesHeaderRegexp.MatchString(line):
+				nestedKind = "indexes"
+			case strings.EqualFold(strings.TrimSpace(strings.TrimSuffix(line, "{")), "note") && strings.HasSuffix(line, "{"):
+				nestedKind = "note"
+			default:
+				if matches := dbmlNoteLineRegexp.FindStringSubmatch(line); len(matches) == 2 {
+					table.note = unquoteDbmlString(matches[1])
+					continue
+				}
+				if err = document.appendColumn(table, line, lineNumber); err != nil {
+					return
+				}
+			}
+		case "enum":
+			if line == "}" {
+				blockKind = ""
+				continue
+			}
+			value, _ := splitDbmlSettings(line)
+			enum.values = append(enum.values, unquoteDbmlName(value))
+		case "ref":
+			if line == "}" {
+				blockKind = ""
+				continue
+			}
+			if err = document.appendRef(line, lineNumber); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// 解析列定义: name type [settings]
+func (d *dbmlDocument) appendColumn(table *dbmlTable, line string, lineNumber int) error {
+	definition, settings := splitDbmlSettings(line)
+	name, rest := cutDbmlToken(definition)
+	columnType, _ := cutDbmlToken(rest)
+	if name == "" || columnType == "" {
+		return i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.DatasourceDbmlParseError, fmt.Sprintf("line %d: %s", lineNumber+1, line))
+	}
+
+	column := &dbmlColumn{name: unquoteDbmlName(name), columnType: unquoteDbmlName(columnType)}
+	for _, setting := range settings {
+		key, value, hasValue := cutDbmlSetting(setting)
+		switch lowerKey := strings.ToLower(key); {
+		case !hasValue && (lowerKey == "pk" || lowerKey == "primary key"):
+			column.primaryKey = true
+		case !hasValue && lowerKey == "increment":
+			column.increment = true
+		case !hasValue && lowerKey == "unique":
+			column.unique = true
+		case !hasValue && lowerKey == "not null":
+			column.notNull = true
+		case hasValue && lowerKey == "default":
+			column.defaultValue = value
+		case hasValue && lowerKey == "note":
+			column.note = unquoteDbmlString(value)
+		case hasValue && lowerKey == "ref":
+			if err := d.appendRef(fmt.Sprintf("%s.%s %s", quoteDbmlName(table.name), quoteDbmlName(column.name), value), lineNumber); err != nil {
+				return err
+			}
+		}
+	}
+	table.columns = append(table.columns, column)
+	return nil
+}
+
+// 解析关联: A.a > B.b [delete: cascade]
+func (d *dbmlDocument) appendRef(text string, lineNumber int) error {
+	expression, settings := splitDbmlSettings(text)
+	for _, symbol := range dbmlSymbols {
+		left, right, found := strings.Cut(expression, " "+symbol+" ")
+		if !found {
+			continue
+		}
+
+		ref := &dbmlRef{symbol: symbol}
+		ref.fromTable, ref.fromColumns = parseDbmlRefEndpoint(left)
+		ref.toTable, ref.toColumns = parseDbmlRefEndpoint(right)
+		if ref.fromTable == "" || ref.toTable == "" {
+			break
+		}
+		for _, setting := range settings {
+			key, value, _ := cutDbmlSetting(setting)
+			switch strings.ToLower(key) {
+			case "delete":
+				ref.onDelete = strings.ToLower(value)
+			case "update":
+				ref.onUpdate = strings.ToLower(value)
+			}
+		}
+		d.refs = append(d.refs, ref)
+		return nil
+	}
+	return i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.DatasourceDbmlParseError, fmt.Sprintf("line %d: %s", lineNumber+1, text))
+}
+
+func parseDbmlIndex(table *dbmlTable, line string) {
+	definition, settings := splitDbmlSettings(line)
+	var columns []string
+	definition = strings.TrimSpace(definition)
+	if strings.HasPrefix(definition, "(") {
+		for _, item := range strings.Split(strings.Trim(definition, "()"), ",") {
+			columns = append(columns, unquoteDbmlName(strings.TrimSpace(item)))
+		}
+	} else {
+		columns = []string{unquoteDbmlName(definition)}
+	}
+
+	for _, setting := range settings {
+		switch key, _, _ := cutDbmlSetting(setting); strings.ToLower(key) {
+		case "pk", "primary key":
+			table.primaryKey = columns
+		case "unique":
+			if len(columns) == 1 {
+				for _, column := range table.columns {
+					if column.name == columns[0] {
+						column.unique = true
+					}
+				}
+				continue
+			}
+			table.uniques = append(table.uniques, columns)
+		}
+	}
+}
+
+// 表名去除schema和别名
+func parseDbmlBlockName(header string) string {
+	header, _ = splitDbmlSettings(header)
+	name, _ := cutDbmlToken(header)
+	return unquoteDbmlName(lastDbmlSegment(name))
+}
+
+// 解析关联端点，最后一段为列(或括号中的多列)，倒数第二段为表
+func parseDbmlRefEndpoint(endpoint string) (table string, columns []string) {
+	endpoint = strings.TrimSpace(endpoint)
+	if index := strings.Index(endpoint, ".("); index != -1 {
+		table = unquoteDbmlName(lastDbmlSegment(endpoint[:index]))
+		for _, item := range strings.Split(strings.Trim(endpoint[index+1:], "()"), ",") {
+			columns = append(columns, unquoteDbmlName(strings.TrimSpace(item)))
+		}
+		return
+	}
+
+	segments := splitDbmlSegments(endpoint)
+	if len(segments) < 2 {
+		return
+	}
+	table, columns = unquoteDbmlName(segments[len(segments)-2]), []string{unquoteDbmlName(segments[len(segments)-1])}
+	return
+}
+
+func lastDbmlSegment(name string) string {
+	segments := splitDbmlSegments(name)
+	return segments[len(segments)-1]
+}
+
+// 按照不在双引号内的点拆分名称
+func splitDbmlSegments(name string) (segments []string) {
+	var start int
+	var quoted bool
+	for i, char := range name {
+		switch {
+		case char == '"':
+			quoted = !quoted
+		case char == '.' && !quoted:
+			segments = append(segments, name[start:i])
+			start = i + 1
+		}
+	}
+	return append(segments, name[start:])
+}
+
+// 拆分定义和方括号中的设置，设置按照不在引号和括号内的逗号拆分
+func splitDbmlSettings(line string) (definition string, settings []string) {
+	start := indexDbmlOutsideQuotes(line, '[')
+	if start == -1 {
+		return strings.TrimSpace(line), nil
+	}
+	end := strings.LastIndex(line, "]")
+	if end < start {
+		return strings.TrimSpace(line), nil
+	}
+
+	definition = strings.TrimSpace(line[:start])
+	var depth, itemStart int
+	var quote rune
+	text := line[start+1 : end]
+	for i, char := range text {
+		switch {
+		case quote != 0:
+			if char == quote && (i == 0 || text[i-1] != '\\') {
+				quote = 0
+			}
+		case char == '\'' || char == '"' || char == '`':
+			quote = char
+		case char == '(':
+			depth++
+		case char == ')':
+			depth--
+		case char == ',' && depth == 0:
+			settings = append(settings, strings.TrimSpace(text[itemStart:i]))
+			itemStart = i + 1
+		}
+	}
+	if item := strings.TrimSpace(text[itemStart:]); item != "" {
+		settings = append(settings, item)
+	}
+	return
+}
+
+func indexDbmlOutsideQuotes(line string, target rune) int {
+	var quote rune
+	for i, char := range line {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '\'' || char == '"' || char == '`':
+			quote = char
+		case char == target:
+			return i
+		}
+	}
+	return -1
+}
+
+func stripDbmlLineComment(line string) string {
+	var quote rune
+	for i, char := range line {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '\'' || char == '"' || char == '`':
+			quote = char
+		case char == '/' && strings.HasPrefix(line[i:], "//"):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// 截取第一个名称或类型，双引号内及括号内的空格不拆分
+func cutDbmlToken(text string) (token, rest string) {
+	text = strings.TrimSpace(text)
+	var depth int
+	var quoted bool
+	for i, char := range text {
+		switch {
+		case char == '"':
+			quoted = !quoted
+		case char == '(' && !quoted:
+			depth++
+		case char == ')' && !quoted:
+			depth--
+		case (char == ' ' || char == '\t') && !quoted && depth == 0:
+			return text[:i], strings.TrimSpace(text[i+1:])
+		}
+	}
+	return text, ""
+}
+
+func cutDbmlSetting(setting string) (key, value string, hasValue bool) {
+	index := indexDbmlOutsideQuotes(setting, ':')
+	if index == -1 {
+		return strings.Join(strings.Fields(setting), " "), "", false
+	}
+	return strings.TrimSpace(setting[:index]), strings.TrimSpace(setting[index+1:]), true
+}
+
+func unquoteDbmlName(name string) string {
+	name = strings.TrimSpace(name)
+	if len(name) >= 2 && name[0] == '"' && name[len(name)-1] == '"' {
+		return name[1 : len(name)-1]
+	}
+	return name
+}
+
+func unquoteDbmlString(text string) string {
+	text = strings.TrimSpace(text)
+	if len(text) >= 2 && (text[0] == '\'' || text[0] == '"') && text[len(text)-1] == text[0] {
+		text = text[1 : len(text)-1]
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(text, `\'`, "'"), `\n`, "\n")
+}
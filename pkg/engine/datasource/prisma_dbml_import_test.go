@@ -0,0 +1,160 @@
+package datasource
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParseDbml(t *testing.T) {
+	tests := []struct {
+		name, content string
+		expected      *dbmlDocument
+	}{
+		{
+			name: "refs",
+			content: `Table users {
+  id int [pk, increment]
+}
+Table posts {
+  id int [pk]
+  user_id int [ref: > users.id, not null]
+  editor_id int
+}
+Ref: posts.editor_id > users.id [delete: set null, update: cascade]
+Ref post_users {
+  users.id < posts.id
+}
+Ref: public."posts".(id, user_id) - users.(id, id)`,
+			expected: &dbmlDocument{
+				tables: []*dbmlTable{
+					{name: "users", columns: []*dbmlColumn{{name: "id", columnType: "int", primaryKey: true, increment: true}}},
+					{name: "posts", columns: []*dbmlColumn{
+						{name: "id", columnType: "int", primaryKey: true},
+						{name: "user_id", columnType: "int", notNull: true},
+						{name: "editor_id", columnType: "int"},
+					}},
+				},
+				refs: []*dbmlRef{
+					{fromTable: "posts", fromColumns: []string{"user_id"}, toTable: "users", toColumns: []string{"id"}, symbol: dbmlSymbolManyToOne},
+					{fromTable: "posts", fromColumns: []string{"editor_id"}, toTable: "users", toColumns: []string{"id"}, symbol: dbmlSymbolManyToOne, onDelete: "set null", onUpdate: "cascade"},
+					{fromTable: "users", fromColumns: []string{"id"}, toTable: "posts", toColumns: []string{"id"}, symbol: dbmlSymbolOneToMany},
+					{fromTable: "posts", fromColumns: []string{"id", "user_id"}, toTable: "users", toColumns: []string{"id", "id"}, symbol: dbmlSymbolOneToOne},
+				},
+			},
+		},
+		{
+			name: "enums",
+			content: `Enum order_status {
+  created [note: 'waiting for payment']
+  "in stock"
+  shipped // delivered later
+}
+Enum shop."level" {
+  low
+  high
+}
+Table orders {
+  status order_status [default: 'created']
+}`,
+			expected: &dbmlDocument{
+				tables: []*dbmlTable{
+					{name: "orders", columns: []*dbmlColumn{{name: "status", columnType: "order_status", defaultValue: "'created'"}}},
+				},
+				enums: []*dbmlEnum{
+					{name: "order_status", values: []string{"created", "in stock", "shipped"}},
+					{name: "level", values: []string{"low", "high"}},
+				},
+			},
+		},
+		{
+			name: "composite indexes",
+			content: `Table members {
+  team_id int
+  user_id int
+  email varchar(255)
+  nickname varchar
+
+  indexes {
+    (team_id, user_id) [pk]
+    (team_id, nickname) [unique, name: 'team_nickname']
+    email [unique]
+    nickname
+  }
+}`,
+			expected: &dbmlDocument{
+				tables: []*dbmlTable{{
+					name: "members",
+					columns: []*dbmlColumn{
+						{name: "team_id", columnType: "int"},
+						{name: "user_id", columnType: "int"},
+						{name: "email", columnType: "varchar(255)", unique: true},
+						{name: "nickname", columnType: "varchar"},
+					},
+					primaryKey: []string{"team_id", "user_id"},
+					uniques:    [][]string{{"team_id", "nickname"}},
+				}},
+			},
+		},
+		{
+			name: "notes",
+			content: `Project shop {
+  database_type: 'PostgreSQL'
+}
+Table "products" as P [headercolor: #3498DB] {
+  id int [pk, note: 'product id, generated']
+  name varchar [note: "display name"]
+  Note: 'Stores product\'s basic info'
+}
+Table categories {
+  id int [pk]
+  Note {
+    'category tree'
+  }
+}
+Table tags {
+  id int [pk]
+  Note: '''
+Tags attached to
+products'''
+}`,
+			expected: &dbmlDocument{
+				tables: []*dbmlTable{
+					{name: "products", note: "Stores product's basic info", columns: []*dbmlColumn{
+						{name: "id", columnType: "int", primaryKey: true, note: "product id, generated"},
+						{name: "name", columnType: "varchar", note: "display name"},
+					}},
+					{name: "categories", note: "category tree", columns: []*dbmlColumn{{name: "id", columnType: "int", primaryKey: true}}},
+					{name: "tags", note: "Tags attached to\nproducts", columns: []*dbmlColumn{{name: "id", columnType: "int", primaryKey: true}}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := parseDbml(tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(document, tt.expected) {
+				t.Errorf("parseDbml() = %s, want %s", formatDbmlDocument(document), formatDbmlDocument(tt.expected))
+			}
+		})
+	}
+}
+
+func formatDbmlDocument(document *dbmlDocument) (text string) {
+	for _, table := range document.tables {
+		text += fmt.Sprintf("\n%+v", *table)
+		for _, column := range table.columns {
+			text += fmt.Sprintf("\n  %+v", *column)
+		}
+	}
+	for _, enum := range document.enums {
+		text += fmt.Sprintf("\n%+v", *enum)
+	}
+	for _, ref := range document.refs {
+		text += fmt.Sprintf("\n%+v", *ref)
+	}
+	return
+}
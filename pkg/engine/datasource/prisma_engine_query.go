@@ -3,6 +3,7 @@ package datasource
 import (
 	"context"
 	"fireboom-server/pkg/common/consts"
+	engineClient "github.com/prisma/prisma-client-go/engine"
 	"github.com/wundergraph/wundergraph/pkg/datasources/database"
 	"go.uber.org/zap"
 	"net/http"
@@ -41,3 +42,19 @@ func startQueryEngineWithAction[O any](engineInput EngineInput, action func(cont
 
 	return action(ctx, engine)
 }
+
+// 使用缓存的prisma文本连接query引擎执行操作(原生sql、增删改等)，执行完成后断开连接
+func connectQueryEngineWithAction(dsName string, action func(*engineClient.QueryEngine) error) (err error) {
+	prismaSchema, err := CachePrismaSchemaText.Read(dsName)
+	if err != nil {
+		return
+	}
+
+	queryEngine := engineClient.NewQueryEngine(prismaSchema, false)
+	if err = queryEngine.Connect(); err != nil {
+		return
+	}
+	defer func() { _ = queryEngine.Disconnect() }()
+
+	return action(queryEngine)
+}
@@ -112,6 +112,10 @@ type (
 const (
 	DiffCmdOptionMigrationsToDev DiffCmdOptionType = "migrations_to_dev"
 	DiffCmdOptionDevToProd       DiffCmdOptionType = "dev_to_prod"
+	// DiffCmdOptionMigrationsToDatabase 迁移历史与数据源实际数据库的差异(漂移)，databaseUrl为影子数据库
+	DiffCmdOptionMigrationsToDatabase DiffCmdOptionType = "migrations_to_database"
+	// DiffCmdOptionDatabaseToSchema 数据源实际数据库与prisma文件的差异，用于推送前检查破坏性变更
+	DiffCmdOptionDatabaseToSchema DiffCmdOptionType = "database_to_schema"
 )
 
 // Diff 比较不同
@@ -141,6 +145,27 @@ func Diff(ctx context.Context, engineInput EngineInput, dsName string, diffOptio
 		diffInput.To = database.DiffInputTarget{
 			URL: &database.UrlContainer{Url: utils.GetVariableString(diffOption.DatabaseUrl)},
 		}
+	case DiffCmdOptionMigrationsToDatabase:
+		if diffOption.DatabaseUrl == nil {
+			err = i18n.NewCustomError(nil, i18n.PrismaShadowDatabaseUrlEmptyError)
+			return
+		}
+		var databaseUrl string
+		if databaseUrl, err = fetchMigrationDatabaseUrl(dsName); err != nil {
+			return
+		}
+		diffInput.ShadowDatabaseUrl = utils.GetVariableString(diffOption.DatabaseUrl)
+		diffInput.From = database.DiffInputTarget{
+			Migrations: &database.PathContainer{Path: utils.NormalizePath(migrationDirname, dsName)},
+		}
+		diffInput.To = database.DiffInputTarget{URL: &database.UrlContainer{Url: databaseUrl}}
+	case DiffCmdOptionDatabaseToSchema:
+		var databaseUrl string
+		if databaseUrl, err = fetchMigrationDatabaseUrl(dsName); err != nil {
+			return
+		}
+		diffInput.From = database.DiffInputTarget{URL: &database.UrlContainer{Url: databaseUrl}}
+		diffInput.To = schemaDataModelTarget
 	}
 	rpcExt := database.SchemaCommandRPCExtension{CmdEnvs: buildEnvironments(engineInput)}
 	ctx, cancel := buildDatasourceContext(ctx, dsName)
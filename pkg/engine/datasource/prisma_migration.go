@@ -0,0 +1,321 @@
+// Package datasource
+/*
+ prisma迁移历史
+ 读取exported/migration/<数据源>下的迁移目录，与数据库_prisma_migrations表中的记录比对得到迁移状态
+ 通过query引擎的原生sql查询/更新迁移记录，支持将失败的迁移标记为已回滚
+*/
+package datasource
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	json "github.com/json-iterator/go"
+	engineClient "github.com/prisma/prisma-client-go/engine"
+	"github.com/tidwall/gjson"
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"golang.org/x/exp/slices"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type (
+	PrismaMigration struct {
+		Name              string                `json:"name"`
+		Sql               string                `json:"sql"`
+		Status            PrismaMigrationStatus `json:"status"`
+		Checksum          string                `json:"checksum"`
+		Modified          bool                  `json:"modified"` // 本地迁移文件与已应用的checksum不一致
+		Missing           bool                  `json:"missing"`  // 数据库中存在记录但本地迁移目录缺失
+		StartedAt         string                `json:"startedAt"`
+		FinishedAt        string                `json:"finishedAt"`
+		RolledBackAt      string                `json:"rolledBackAt"`
+		Logs              string                `json:"logs"`
+		AppliedStepsCount int64                 `json:"appliedStepsCount"`
+	}
+	PrismaMigrationStatus string
+)
+
+const (
+	PrismaMigrationApplied    PrismaMigrationStatus = "applied"
+	PrismaMigrationPending    PrismaMigrationStatus = "pending"
+	PrismaMigrationFailed     PrismaMigrationStatus = "failed"
+	PrismaMigrationRolledBack PrismaMigrationStatus = "rolledBack"
+)
+
+const (
+	migrationSqlFilename     = "migration.sql"
+	migrationTableName       = "_prisma_migrations"
+	migrationSelectSql       = `SELECT * FROM _prisma_migrations ORDER BY started_at`
+	migrationRollbackSql     = `UPDATE _prisma_migrations SET rolled_back_at = CURRENT_TIMESTAMP WHERE migration_name = '%s' AND finished_at IS NULL AND rolled_back_at IS NULL`
+	migrationRawQueryFormat  = `mutation { result: %s(query: %s, parameters: "[]") }`
+	migrationRawQueryAction  = "queryRaw"
+	migrationRawExecAction   = "executeRaw"
+	migrationPrismaTypeValue = "prisma__value"
+)
+
+var (
+	migrationTableMissingMessages = []string{"does not exist", "doesn't exist", "no such table", "invalid object name"}
+	prismaSchemaUrlRegexp         = regexp.MustCompile(`url\s*=\s*"([^"]+)"`)
+)
+
+// NewMigrationEngineInput 构建迁移命令的引擎输入，schemaRequired时读取缓存的prisma文本
+func NewMigrationEngineInput(ds *models.Datasource, schemaRequired bool) (engineInput EngineInput, err error) {
+	if _, err = fetchMigrationProvider(ds); err != nil {
+		return
+	}
+
+	engineInput = EngineInput{
+		PrismaSchemaFilepath: CachePrismaSchemaText.GetPath(ds.Name),
+		EnvironmentRequired:  ds.Kind == wgpb.DataSourceKind_PRISMA,
+	}
+	if schemaRequired {
+		engineInput.PrismaSchema, err = CachePrismaSchemaText.Read(ds.Name)
+	}
+	return
+}
+
+// ListMigrations 列出本地迁移及数据库中的迁移记录，按照迁移名称排序
+func ListMigrations(ctx context.Context, ds *models.Datasource) (migrations []*PrismaMigration, err error) {
+	if _, err = fetchMigrationProvider(ds); err != nil {
+		return
+	}
+
+	migrations, err = readLocalMigrations(ds.Name)
+	if err != nil {
+		return
+	}
+
+	records, err := queryMigrationRecords(ctx, ds.Name)
+	if err != nil {
+		err = i18n.NewCustomErrorWithMode(datasourceModelName, err, i18n.PrismaMigrationStatusError)
+		return
+	}
+
+	migrations = mergeMigrationRecords(migrations, records)
+	return
+}
+
+// MarkMigrationRolledBack 将失败的迁移标记为已回滚，修复后可以重新应用
+func MarkMigrationRolledBack(ctx context.Context, ds *models.Datasource, migrationName string) (err error) {
+	migrations, err := ListMigrations(ctx, ds)
+	if err != nil {
+		return
+	}
+
+	index := searchMigrationIndex(migrations, migrationName)
+	if index == -1 {
+		return i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.PrismaMigrationNotFoundError, migrationName)
+	}
+	if migrations[index].Status != PrismaMigrationFailed {
+		return i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.PrismaMigrationNotFailedError, migrationName)
+	}
+
+	rollbackSql := fmt.Sprintf(migrationRollbackSql, strings.ReplaceAll(migrationName, "'", "''"))
+	if _, err = doMigrationRawQuery(ctx, ds.Name, migrationRawExecAction, rollbackSql); err != nil {
+		err = i18n.NewCustomErrorWithMode(datasourceModelName, err, i18n.PrismaMigrationRollbackError)
+	}
+	return
+}
+
+func searchMigrationIndex(migrations []*PrismaMigration, migrationName string) int {
+	return slices.IndexFunc(migrations, func(item *PrismaMigration) bool { return item.Name == migrationName })
+}
+
+// 读取本地迁移目录，每个包含migration.sql的子目录为一个迁移
+func readLocalMigrations(dsName string) (migrations []*PrismaMigration, err error) {
+	migrationDir := utils.NormalizePath(migrationDirname, dsName)
+	entries, err := os.ReadDir(migrationDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		sqlBytes, readErr := os.ReadFile(filepath.Join(migrationDir, entry.Name(), migrationSqlFilename))
+		if readErr != nil {
+			continue
+		}
+
+		checksum := sha256.Sum256(sqlBytes)
+		migrations = append(migrations, &PrismaMigration{
+			Name:     entry.Name(),
+			Sql:      string(sqlBytes),
+			Status:   PrismaMigrationPending,
+			Checksum: hex.EncodeToString(checksum[:]),
+		})
+	}
+	return
+}
+
+// 合并数据库中的迁移记录，同名迁移以最后开始的记录为准
+func mergeMigrationRecords(migrations []*PrismaMigration, records []map[string]string) []*PrismaMigration {
+	for _, record := range records {
+		name := record["migration_name"]
+		index := searchMigrationIndex(migrations, name)
+		if index == -1 {
+			migrations = append(migrations, &PrismaMigration{Name: name, Missing: true})
+			index = len(migrations) - 1
+		}
+
+		migration := migrations[index]
+		migration.StartedAt = record["started_at"]
+		migration.FinishedAt = record["finished_at"]
+		migration.RolledBackAt = record["rolled_back_at"]
+		migration.Logs = record["logs"]
+		migration.AppliedStepsCount = gjson.Parse(record["applied_steps_count"]).Int()
+		switch {
+		case migration.RolledBackAt != "":
+			migration.Status = PrismaMigrationRolledBack
+		case migration.FinishedAt == "":
+			migration.Status = PrismaMigrationFailed
+		default:
+			migration.Status = PrismaMigrationApplied
+		}
+		if !migration.Missing && migration.Status == PrismaMigrationApplied {
+			migration.Modified = migration.Checksum != record["checksum"]
+		}
+		if migration.Missing {
+			migration.Checksum = record["checksum"]
+		}
+	}
+
+	slices.SortFunc(migrations, func(a, b *PrismaMigration) bool { return a.Name < b.Name })
+	return migrations
+}
+
+// 查询_prisma_migrations表，表不存在时说明还未应用过迁移
+func queryMigrationRecords(ctx context.Context, dsName string) (records []map[string]string, err error) {
+	result, err := doMigrationRawQuery(ctx, dsName, migrationRawQueryAction, migrationSelectSql)
+	if err != nil {
+		errMessage := strings.ToLower(err.Error())
+		if strings.Contains(errMessage, migrationTableName) && slices.ContainsFunc(migrationTableMissingMessages, func(item string) bool {
+			return strings.Contains(errMessage, item)
+		}) {
+			err = nil
+		}
+		return
+	}
+
+	records = parseRawQueryRows(result)
+	return
+}
+
+// 启动query引擎执行原生sql，执行完成后断开连接
+func doMigrationRawQuery(ctx context.Context, dsName, action, sql string) (result gjson.Result, err error) {
+	sqlBytes, _ := json.Marshal(sql)
+	request := engineClient.GQLRequest{
+		Query:     fmt.Sprintf(migrationRawQueryFormat, action, sqlBytes),
+		Variables: map[string]any{},
+	}
+	var resultValue any
+	err = connectQueryEngineWithAction(dsName, func(queryEngine *engineClient.QueryEngine) error {
+		return queryEngine.Do(ctx, request, &resultValue)
+	})
+	if err != nil {
+		return
+	}
+
+	result = parseRawQueryResult(resultValue)
+	return
+}
+
+// QueryEngine.Do已经将响应中的data.result解析到resultValue，此处无需再解包
+func parseRawQueryResult(resultValue any) gjson.Result {
+	resultBytes, _ := json.Marshal(resultValue)
+	return gjson.ParseBytes(resultBytes)
+}
+
+// 兼容对象数组及columns/rows两种原生查询结果，带类型包装的值取prisma__value
+func parseRawQueryRows(result gjson.Result) (rows []map[string]string) {
+	resolveValue := func(value gjson.Result) string {
+		if value.IsObject() && value.Get(migrationPrismaTypeValue).Exists() {
+			value = value.Get(migrationPrismaTypeValue)
+		}
+		if value.Type == gjson.Null {
+			return ""
+		}
+		return value.String()
+	}
+
+	if result.IsArray() {
+		result.ForEach(func(_, item gjson.Result) bool {
+			row := make(map[string]string)
+			item.ForEach(func(key, value gjson.Result) bool {
+				row[key.String()] = resolveValue(value)
+				return true
+			})
+			rows = append(rows, row)
+			return true
+		})
+		return
+	}
+
+	columns := result.Get("columns").Array()
+	result.Get("rows").ForEach(func(_, item gjson.Result) bool {
+		row := make(map[string]string)
+		for i, value := range item.Array() {
+			if i < len(columns) {
+				row[columns[i].String()] = resolveValue(value)
+			}
+		}
+		rows = append(rows, row)
+		return true
+	})
+	return
+}
+
+// 获取数据源的数据库类型，mongodb不支持迁移
+func fetchMigrationProvider(ds *models.Datasource) (provider wgpb.DataSourceKind, err error) {
+	provider = ds.Kind
+	if provider == wgpb.DataSourceKind_PRISMA {
+		introspectSchema, _ := extractIntrospectSchema(models.DatasourceUploadPrisma.GetPath(ds.Name))
+		provider = extractDatabaseProvider(introspectSchema)
+	}
+	if !ds.IsCustomDatabase() || provider == wgpb.DataSourceKind_MONGODB {
+		err = i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.PrismaMigrationNotSupportedError, provider.String())
+	}
+	return
+}
+
+// 获取数据源实际连接的数据库地址，prisma数据源从上传的schema中提取
+func fetchMigrationDatabaseUrl(dsName string) (databaseUrl string, err error) {
+	ds, err := models.DatasourceRoot.GetByDataName(dsName)
+	if err != nil {
+		return
+	}
+
+	if ds.Kind != wgpb.DataSourceKind_PRISMA {
+		if ds.CustomDatabase == nil {
+			err = i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.DatasourceDatabaseUrlEmptyError)
+			return
+		}
+		return ds.CustomDatabase.GetDatabaseUrl(ds.Kind, ds.Name)
+	}
+
+	introspectSchema, err := extractIntrospectSchema(models.DatasourceUploadPrisma.GetPath(ds.Name))
+	if err != nil {
+		return
+	}
+	if variable, ok := extractEnvironmentVariable(introspectSchema); ok {
+		databaseUrl = utils.GetStringWithLockViper(variable)
+	} else if matches := prismaSchemaUrlRegexp.FindStringSubmatch(introspectSchema); len(matches) == 2 {
+		databaseUrl = matches[1]
+	}
+	if databaseUrl == "" {
+		err = i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.DatasourceDatabaseUrlEmptyError)
+	}
+	return
+}
@@ -0,0 +1,70 @@
+package datasource
+
+import (
+	"encoding/json"
+	engineClient "github.com/prisma/prisma-client-go/engine"
+	"reflect"
+	"testing"
+)
+
+func TestParseRawQueryRows(t *testing.T) {
+	tests := []struct {
+		name, responseBody string
+		expected           []map[string]string
+	}{
+		{
+			name: "typed object array",
+			responseBody: `{"data":{"result":[
+				{"id":{"prisma__type":"string","prisma__value":"a1"},"migration_name":{"prisma__type":"string","prisma__value":"20240101000000_init"},"finished_at":{"prisma__type":"null","prisma__value":null},"applied_steps_count":{"prisma__type":"int","prisma__value":1}},
+				{"id":{"prisma__type":"string","prisma__value":"b2"},"migration_name":{"prisma__type":"string","prisma__value":"20240201000000_user"},"finished_at":{"prisma__type":"datetime","prisma__value":"2024-02-01T00:00:00+00:00"},"applied_steps_count":{"prisma__type":"int","prisma__value":0}}
+			]}}`,
+			expected: []map[string]string{
+				{"id": "a1", "migration_name": "20240101000000_init", "finished_at": "", "applied_steps_count": "1"},
+				{"id": "b2", "migration_name": "20240201000000_user", "finished_at": "2024-02-01T00:00:00+00:00", "applied_steps_count": "0"},
+			},
+		},
+		{
+			name: "plain object array",
+			responseBody: `{"data":{"result":[
+				{"id":"a1","migration_name":"20240101000000_init","rolled_back_at":null}
+			]}}`,
+			expected: []map[string]string{
+				{"id": "a1", "migration_name": "20240101000000_init", "rolled_back_at": ""},
+			},
+		},
+		{
+			name: "columns and rows",
+			responseBody: `{"data":{"result":{
+				"columns":["id","migration_name","finished_at"],
+				"types":["string","string","datetime"],
+				"rows":[["a1","20240101000000_init","2024-01-01T00:00:00+00:00"],["b2","20240201000000_user",null]]
+			}}}`,
+			expected: []map[string]string{
+				{"id": "a1", "migration_name": "20240101000000_init", "finished_at": "2024-01-01T00:00:00+00:00"},
+				{"id": "b2", "migration_name": "20240201000000_user", "finished_at": ""},
+			},
+		},
+		{
+			name:         "empty table",
+			responseBody: `{"data":{"result":[]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 与QueryEngine.Do一致，先解析响应再将data.result解析到目标值
+			var response engineClient.GQLResponse
+			if err := json.Unmarshal([]byte(tt.responseBody), &response); err != nil {
+				t.Fatal(err)
+			}
+			var resultValue any
+			if err := json.Unmarshal(response.Data.Result, &resultValue); err != nil {
+				t.Fatal(err)
+			}
+
+			rows := parseRawQueryRows(parseRawQueryResult(resultValue))
+			if !reflect.DeepEqual(rows, tt.expected) {
+				t.Errorf("parseRawQueryRows() = %v, want %v", rows, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+// Package datasource
+/*
+ 推送前比较列的原有类型与新类型，判断类型修改是否收窄或有损
+ 同类类型比较长度、精度、字节数，跨类型仅允许整数转精度足够的小数/浮点、任意类型转无限长度字符串等无损转换
+ 无法识别的类型一律视为有损
+*/
+package datasource
+
+import (
+	"golang.org/x/exp/slices"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type sqlColumnType struct {
+	name      string
+	args      []int
+	unlimited bool // 无长度限制或长度为max
+	unsigned  bool
+}
+
+const (
+	sqlTypeTinyint     = "tinyint"
+	sqlTypeSmallint    = "smallint"
+	sqlTypeMediumint   = "mediumint"
+	sqlTypeInt         = "int"
+	sqlTypeBigint      = "bigint"
+	sqlTypeDecimal     = "decimal"
+	sqlTypeReal        = "real"
+	sqlTypeDouble      = "double"
+	sqlTypeChar        = "char"
+	sqlTypeVarchar     = "varchar"
+	sqlTypeText        = "text"
+	sqlTypeBinary      = "binary"
+	sqlTypeVarbinary   = "varbinary"
+	sqlTypeBlob        = "blob"
+	sqlTypeBoolean     = "boolean"
+	sqlTypeDate        = "date"
+	sqlTypeTimestamp   = "timestamp"
+	sqlTypeTimestampTz = "timestamptz"
+	sqlTypeTime        = "time"
+	sqlTypeTimeTz      = "timetz"
+	sqlTypeJson        = "json"
+	sqlTypeUuid        = "uuid"
+)
+
+var (
+	sqlTypeAliases = map[string]string{
+		"int1": sqlTypeTinyint, "int2": sqlTypeSmallint, "smallserial": sqlTypeSmallint, "int3": sqlTypeMediumint,
+		"integer": sqlTypeInt, "int4": sqlTypeInt, "serial": sqlTypeInt, "int8": sqlTypeBigint, "bigserial": sqlTypeBigint,
+		"numeric": sqlTypeDecimal, "dec": sqlTypeDecimal, "money": sqlTypeDecimal,
+		"float4": sqlTypeReal, "float8": sqlTypeDouble, "double precision": sqlTypeDouble,
+		"character": sqlTypeChar, "nchar": sqlTypeChar, "bpchar": sqlTypeChar,
+		"character varying": sqlTypeVarchar, "nvarchar": sqlTypeVarchar,
+		"ntext": sqlTypeText, "citext": sqlTypeText, "tinytext": sqlTypeText, "mediumtext": sqlTypeText, "longtext": sqlTypeText,
+		"bytea": sqlTypeBlob, "image": sqlTypeBlob, "tinyblob": sqlTypeBlob, "mediumblob": sqlTypeBlob, "longblob": sqlTypeBlob,
+		"bool": sqlTypeBoolean, "bit": sqlTypeBoolean,
+		"timestamp without time zone": sqlTypeTimestamp, "datetime": sqlTypeTimestamp, "datetime2": sqlTypeTimestamp, "smalldatetime": sqlTypeTimestamp,
+		"timestamp with time zone": sqlTypeTimestampTz, "datetimeoffset": sqlTypeTimestampTz,
+		"time without time zone": sqlTypeTime, "time with time zone": sqlTypeTimeTz,
+		"jsonb": sqlTypeJson, "uniqueidentifier": sqlTypeUuid,
+	}
+	// 整数类型的字节数及对应的十进制位数，mysql中有长度上限的文本/二进制类型
+	sqlIntegerBytes   = map[string]int{sqlTypeTinyint: 1, sqlTypeSmallint: 2, sqlTypeMediumint: 3, sqlTypeInt: 4, sqlTypeBigint: 8}
+	sqlIntegerDigits  = map[int]int{1: 3, 2: 5, 3: 8, 4: 10, 8: 20}
+	sqlFixedLengths   = map[string]int{"tinytext": 255, "tinyblob": 255, "blob": 65535, "mediumtext": 16777215, "mediumblob": 16777215}
+	sqlDatetimeRanks  = map[string]int{sqlTypeDate: 1, sqlTypeTimestamp: 2, sqlTypeTimestampTz: 3}
+	sqlTypeStopWords  = []string{"NULL", "NOT", "DEFAULT", "USING", "COLLATE", "CHARSET", "AUTO_INCREMENT", "PRIMARY", "UNIQUE", "COMMENT", "ON", "GENERATED", "CHECK", "REFERENCES", "CONSTRAINT", "IDENTITY", "FIRST", "AFTER"}
+	sqlTypeArgsRegexp = regexp.MustCompile(`\(([^)]*)\)`)
+	sqlTypeSpaceRegex = regexp.MustCompile(`\s+`)
+)
+
+// 截取列定义开头的类型，如VARCHAR(10) NOT NULL取VARCHAR(10)，DOUBLE PRECISION USING ...取DOUBLE PRECISION
+func leadingSqlColumnType(definition string) string {
+	var words []string
+	for _, word := range scanSqlWords(definition) {
+		upper := strings.ToUpper(word)
+		if isSqlTypeStopWord(upper) || upper == "SET" && len(words) > 0 && strings.EqualFold(words[len(words)-1], "CHARACTER") {
+			if upper == "SET" {
+				words = words[:len(words)-1]
+			}
+			break
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " ")
+}
+
+// 按空白拆分，括号内的内容不拆分
+func scanSqlWords(text string) (words []string) {
+	var depth, start int
+	for i, char := range text + " " {
+		switch {
+		case char == '(':
+			depth++
+		case char == ')':
+			depth--
+		case (char == ' ' || char == '\t' || char == '\n' || char == '\r') && depth == 0:
+			if word := strings.TrimSpace(text[start:min(i, len(text))]); word != "" {
+				words = append(words, word)
+			}
+			start = i + 1
+		}
+	}
+	return
+}
+
+func isSqlTypeStopWord(word string) bool {
+	for _, stopWord := range sqlTypeStopWords {
+		if word == stopWord {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeSqlTypeName(name string) string {
+	name = sqlTypeSpaceRegex.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), " ")
+	if alias, ok := sqlTypeAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// 解析类型名称、参数及unsigned修饰，名称统一为各数据库通用的写法
+func parseSqlColumnType(text string) (columnType sqlColumnType) {
+	text = strings.ToLower(strings.Trim(strings.TrimSpace(text), "`\"[]"))
+	rawArgs := ""
+	if matches := sqlTypeArgsRegexp.FindStringSubmatch(text); len(matches) == 2 {
+		rawArgs = matches[1]
+		text = sqlTypeArgsRegexp.ReplaceAllString(text, " ")
+	}
+	var nameWords []string
+	for _, word := range strings.Fields(text) {
+		switch word {
+		case "unsigned":
+			columnType.unsigned = true
+		case "zerofill", "signed":
+		default:
+			nameWords = append(nameWords, strings.Trim(word, "`\"[]"))
+		}
+	}
+	// time(3) with time zone形式的参数位于名称中间，移除参数后再识别名称
+	rawName := strings.Join(nameWords, " ")
+	columnType.name = normalizeSqlTypeName(rawName)
+	for _, arg := range strings.Split(rawArgs, ",") {
+		if arg = strings.TrimSpace(arg); arg == "" {
+			continue
+		}
+		if value, err := strconv.Atoi(arg); err == nil {
+			columnType.args = append(columnType.args, value)
+		} else {
+			columnType.unlimited = true
+		}
+	}
+	if length, ok := sqlFixedLengths[rawName]; ok {
+		columnType.args = []int{length}
+	}
+	switch columnType.name {
+	case sqlTypeText, sqlTypeBlob, sqlTypeVarchar, sqlTypeVarbinary, sqlTypeDecimal:
+		columnType.unlimited = columnType.unlimited || len(columnType.args) == 0
+	case "float":
+		// float(p)以精度区分单双精度，未声明精度时按单精度处理
+		columnType.name = sqlTypeReal
+		if len(columnType.args) > 0 && columnType.args[0] > 24 {
+			columnType.name = sqlTypeDouble
+		}
+		columnType.args = nil
+	case sqlTypeBoolean:
+		columnType.args = nil
+	}
+	return
+}
+
+// 判断oldType到newType的修改是否可能丢失或截断数据
+func isLossyColumnTypeChange(oldType, newType string) bool {
+	if newType == "" {
+		return true
+	}
+	oldColumn, newColumn := parseSqlColumnType(oldType), parseSqlColumnType(newType)
+	if oldColumn.name == newColumn.name && oldColumn.unsigned == newColumn.unsigned && oldColumn.unlimited == newColumn.unlimited &&
+		slices.Equal(oldColumn.args, newColumn.args) {
+		return false
+	}
+
+	// 转换为无长度限制的字符串不会丢失数据
+	if isSqlStringType(newColumn.name) && newColumn.unlimited && !isSqlBinaryType(oldColumn.name) {
+		return false
+	}
+
+	if oldBytes, ok := sqlIntegerBytes[oldColumn.name]; ok {
+		return isLossyIntegerChange(oldBytes, oldColumn.unsigned, newColumn)
+	}
+
+	switch {
+	case oldColumn.name == sqlTypeBoolean:
+		_, integer := sqlIntegerBytes[newColumn.name]
+		return !integer && newColumn.name != sqlTypeBoolean
+	case oldColumn.name == sqlTypeDecimal:
+		return newColumn.name != sqlTypeDecimal || isLossyDecimalChange(oldColumn, newColumn)
+	case oldColumn.name == sqlTypeReal:
+		return newColumn.name != sqlTypeReal && newColumn.name != sqlTypeDouble
+	case oldColumn.name == sqlTypeDouble:
+		return newColumn.name != sqlTypeDouble
+	case isSqlStringType(oldColumn.name):
+		return !isSqlStringType(newColumn.name) || isLossyLengthChange(oldColumn, newColumn)
+	case isSqlBinaryType(oldColumn.name):
+		return !isSqlBinaryType(newColumn.name) || isLossyLengthChange(oldColumn, newColumn)
+	case sqlDatetimeRanks[oldColumn.name] > 0:
+		newRank := sqlDatetimeRanks[newColumn.name]
+		return newRank < sqlDatetimeRanks[oldColumn.name] || isLossyPrecisionChange(oldColumn, newColumn)
+	case oldColumn.name == sqlTypeTime || oldColumn.name == sqlTypeTimeTz:
+		return newColumn.name != oldColumn.name && newColumn.name != sqlTypeTimeTz || isLossyPrecisionChange(oldColumn, newColumn)
+	case oldColumn.name == sqlTypeUuid:
+		return !isSqlStringType(newColumn.name) || sqlTypeLength(newColumn) < 36
+	default:
+		// json/jsonb等同类不同写法的类型视为无损，其余无法识别的类型均视为有损
+		return oldColumn.name != newColumn.name
+	}
+}
+
+// 整数仅允许转为范围更大的整数、整数位足够的小数或精度足够的浮点数
+func isLossyIntegerChange(oldBytes int, oldUnsigned bool, newColumn sqlColumnType) bool {
+	if newBytes, ok := sqlIntegerBytes[newColumn.name]; ok {
+		if oldUnsigned == newColumn.unsigned {
+			return newBytes < oldBytes
+		}
+		return newColumn.unsigned || newBytes <= oldBytes
+	}
+
+	switch newColumn.name {
+	case sqlTypeDecimal:
+		if newColumn.unlimited {
+			return false
+		}
+		precision, scale := sqlDecimalArgs(newColumn)
+		return precision-scale < sqlIntegerDigits[oldBytes]
+	case sqlTypeReal:
+		return oldBytes > 2
+	case sqlTypeDouble:
+		return oldBytes > 4
+	case sqlTypeChar, sqlTypeVarchar:
+		// 预留负号的长度
+		return sqlTypeLength(newColumn) <= sqlIntegerDigits[oldBytes]
+	}
+	return true
+}
+
+// 小数的整数位或小数位减少时有损
+func isLossyDecimalChange(oldColumn, newColumn sqlColumnType) bool {
+	if newColumn.unlimited {
+		return false
+	}
+	if oldColumn.unlimited {
+		return true
+	}
+	oldPrecision, oldScale := sqlDecimalArgs(oldColumn)
+	newPrecision, newScale := sqlDecimalArgs(newColumn)
+	return newScale < oldScale || newPrecision-newScale < oldPrecision-oldScale
+}
+
+func isLossyLengthChange(oldColumn, newColumn sqlColumnType) bool {
+	if newColumn.unlimited {
+		return false
+	}
+	if oldColumn.unlimited {
+		return true
+	}
+	return sqlTypeLength(newColumn) < sqlTypeLength(oldColumn)
+}
+
+// 时间精度仅在新旧类型都声明时比较
+func isLossyPrecisionChange(oldColumn, newColumn sqlColumnType) bool {
+	return len(oldColumn.args) > 0 && len(newColumn.args) > 0 && newColumn.args[0] < oldColumn.args[0]
+}
+
+func sqlDecimalArgs(columnType sqlColumnType) (precision, scale int) {
+	if len(columnType.args) > 0 {
+		precision = columnType.args[0]
+	}
+	if len(columnType.args) > 1 {
+		scale = columnType.args[1]
+	}
+	return
+}
+
+// 未声明长度的char/binary长度为1
+func sqlTypeLength(columnType sqlColumnType) int {
+	if len(columnType.args) > 0 {
+		return columnType.args[0]
+	}
+	return 1
+}
+
+func isSqlStringType(name string) bool {
+	return name == sqlTypeChar || name == sqlTypeVarchar || name == sqlTypeText
+}
+
+func isSqlBinaryType(name string) bool {
+	return name == sqlTypeBinary || name == sqlTypeVarbinary || name == sqlTypeBlob
+}
@@ -0,0 +1,385 @@
+// Package datasource
+/*
+ prisma schema推送前的破坏性变更检查
+ 推送前计算数据库到待推送schema的差异脚本，识别删表、删列、有损的列类型修改、新增无默认值的非空列
+ 列类型修改时查询数据库中原有的列类型，仅在类型收窄或转换有损时视为破坏性变更
+ 存在破坏性变更时返回报告及与差异脚本绑定的确认令牌，调用方携带令牌确认后才允许推送
+*/
+package datasource
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/plugins/fileloader"
+	"fmt"
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"go.uber.org/zap"
+	"os"
+	"regexp"
+	"strings"
+)
+
+type (
+	SchemaPushReport struct {
+		Destructive  bool                `json:"destructive"`
+		Changes      []*SchemaPushChange `json:"changes"`
+		Script       string              `json:"script"`
+		ConfirmToken string              `json:"confirmToken"`
+	}
+	SchemaPushChange struct {
+		Kind      SchemaPushChangeKind `json:"kind"`
+		Table     string               `json:"table"`
+		Column    string               `json:"column"`
+		OldType   string               `json:"oldType,omitempty"`
+		NewType   string               `json:"newType,omitempty"`
+		Statement string               `json:"statement"`
+	}
+	SchemaPushChangeKind string
+)
+
+const (
+	SchemaPushDropTable         SchemaPushChangeKind = "dropTable"
+	SchemaPushDropColumn        SchemaPushChangeKind = "dropColumn"
+	SchemaPushAlterColumnType   SchemaPushChangeKind = "alterColumnType"
+	SchemaPushAddRequiredColumn SchemaPushChangeKind = "addRequiredColumn"
+	SchemaPushRedefineTable     SchemaPushChangeKind = "redefineTable"
+)
+
+const pushColumnTypesSqlFormat = `SELECT table_name, column_name, data_type, character_maximum_length, numeric_precision, numeric_scale, datetime_precision, %s AS column_type FROM information_schema.columns WHERE table_schema = %s AND table_name IN (%s)`
+
+var (
+	pushDropTableRegexp       = regexp.MustCompile(`(?is)^DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?(\S+)$`)
+	pushAlterTableRegexp      = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(\S+)\s+(.*)$`)
+	pushDropColumnRegexp      = regexp.MustCompile(`(?is)^DROP\s+COLUMN\s+(?:IF\s+EXISTS\s+)?(\S+)`)
+	pushModifyColumnRegexp    = regexp.MustCompile(`(?is)^MODIFY\s+(?:COLUMN\s+)?(\S+)\s+(.*)$`)
+	pushAlterColumnRegexp     = regexp.MustCompile(`(?is)^ALTER\s+COLUMN\s+(\S+)\s+(.*)$`)
+	pushAddColumnRegexp       = regexp.MustCompile(`(?is)^ADD\s+(?:COLUMN\s+)?(\S+)\s+(.*)$`)
+	pushRenameTableRegexp     = regexp.MustCompile(`(?is)^RENAME\s+TO\s+(\S+)`)
+	pushSetDataTypeRegexp     = regexp.MustCompile(`(?is)^(?:SET\s+DATA\s+TYPE|TYPE)\s+(.*)$`)
+	pushSetOrDropRegexp       = regexp.MustCompile(`(?is)^(SET|DROP)\s`)
+	pushNotNullRegexp         = regexp.MustCompile(`(?i)\bNOT\s+NULL\b`)
+	pushImplicitDefaultRegexp = regexp.MustCompile(`(?i)\b(DEFAULT|SERIAL|SMALLSERIAL|BIGSERIAL|AUTO_INCREMENT|AUTOINCREMENT|IDENTITY)\b`)
+	pushAddKeywords           = []string{"CONSTRAINT", "INDEX", "KEY", "PRIMARY", "FOREIGN", "UNIQUE", "FULLTEXT", "SPATIAL", "CHECK"}
+	// 各数据库information_schema中当前schema及完整列类型的写法
+	pushColumnTypesSchemaExprs = map[wgpb.DataSourceKind][2]string{
+		wgpb.DataSourceKind_POSTGRESQL: {"NULL", "current_schema()"},
+		wgpb.DataSourceKind_MYSQL:      {"column_type", "DATABASE()"},
+		wgpb.DataSourceKind_SQLSERVER:  {"NULL", "SCHEMA_NAME()"},
+	}
+)
+
+// CheckSchemaPush 计算数据库到待推送prisma的差异并识别破坏性变更，不支持迁移的数据源(mongodb)返回nil
+func CheckSchemaPush(ctx context.Context, engineInput EngineInput, ds *models.Datasource) (report *SchemaPushReport, err error) {
+	provider, providerErr := fetchMigrationProvider(ds)
+	if providerErr != nil {
+		return
+	}
+
+	schemaFile, err := os.CreateTemp("", ds.Name+"-*"+string(fileloader.ExtPrisma))
+	if err != nil {
+		return
+	}
+	defer func() { _ = os.Remove(schemaFile.Name()) }()
+	_, err = schemaFile.WriteString(engineInput.PrismaSchema)
+	_ = schemaFile.Close()
+	if err != nil {
+		return
+	}
+
+	engineInput.PrismaSchemaFilepath = schemaFile.Name()
+	script, err := Diff(ctx, engineInput, ds.Name, &DiffCmdOption{Type: DiffCmdOptionDatabaseToSchema})
+	if err != nil {
+		return
+	}
+
+	changes := parseSchemaPushChanges(script)
+	if tables := alterColumnTypeTables(changes); len(tables) > 0 {
+		columnTypes, queryErr := queryColumnTypes(ctx, ds.Name, provider, tables)
+		if queryErr != nil {
+			// 无法获取原有类型时保守处理，所有类型修改均视为破坏性变更
+			zap.L().Warn("query column types failed", zap.String("dataName", ds.Name), zap.Error(queryErr))
+		}
+		changes = filterLossyColumnTypeChanges(changes, columnTypes)
+	}
+	report = &SchemaPushReport{Script: script, Changes: changes}
+	report.Destructive = len(report.Changes) > 0
+	report.ConfirmToken = buildSchemaPushConfirmToken(ds.Name, script)
+	return
+}
+
+// 令牌由数据源名称和差异脚本计算，数据库或schema变化后令牌失效
+func buildSchemaPushConfirmToken(dsName, script string) string {
+	checksum := sha256.Sum256([]byte(dsName + "\n" + script))
+	return hex.EncodeToString(checksum[:])
+}
+
+// 按语句解析差异脚本，兼容mysql/postgresql/sqlserver/sqlite的写法
+func parseSchemaPushChanges(script string) (changes []*SchemaPushChange) {
+	renamedTables := make(map[string]bool)
+	for _, statement := range splitSqlStatements(script) {
+		if matches := pushDropTableRegexp.FindStringSubmatch(statement); len(matches) == 2 {
+			changes = append(changes, &SchemaPushChange{Kind: SchemaPushDropTable, Table: unquoteSqlName(matches[1]), Statement: statement})
+			continue
+		}
+
+		matches := pushAlterTableRegexp.FindStringSubmatch(statement)
+		if len(matches) != 3 {
+			continue
+		}
+		table := unquoteSqlName(matches[1])
+		for _, clause := range splitSqlClauses(matches[2]) {
+			change := &SchemaPushChange{Table: table, Statement: statement}
+			if clauseMatches := pushDropColumnRegexp.FindStringSubmatch(clause); len(clauseMatches) == 2 {
+				change.Kind, change.Column = SchemaPushDropColumn, clauseMatches[1]
+			} else if clauseMatches = pushModifyColumnRegexp.FindStringSubmatch(clause); len(clauseMatches) == 3 {
+				change.Kind, change.Column, change.NewType = SchemaPushAlterColumnType, clauseMatches[1], leadingSqlColumnType(clauseMatches[2])
+			} else if clauseMatches = pushAlterColumnRegexp.FindStringSubmatch(clause); len(clauseMatches) == 3 {
+				// postgresql仅SET DATA TYPE修改类型，sqlserver直接跟随新的类型
+				definition := clauseMatches[2]
+				if typeMatches := pushSetDataTypeRegexp.FindStringSubmatch(definition); len(typeMatches) == 2 {
+					definition = typeMatches[1]
+				} else if pushSetOrDropRegexp.MatchString(definition) {
+					continue
+				}
+				change.Kind, change.Column, change.NewType = SchemaPushAlterColumnType, clauseMatches[1], leadingSqlColumnType(definition)
+			} else if clauseMatches = pushAddColumnRegexp.FindStringSubmatch(clause); len(clauseMatches) == 3 {
+				if isSqlAddKeyword(clauseMatches[1]) || !pushNotNullRegexp.MatchString(clauseMatches[2]) ||
+					pushImplicitDefaultRegexp.MatchString(clauseMatches[2]) {
+					continue
+				}
+				change.Kind, change.Column = SchemaPushAddRequiredColumn, clauseMatches[1]
+			} else if clauseMatches = pushRenameTableRegexp.FindStringSubmatch(clause); len(clauseMatches) == 2 {
+				renamedTables[unquoteSqlName(clauseMatches[1])] = true
+				continue
+			} else {
+				continue
+			}
+			change.Column = unquoteSqlName(change.Column)
+			changes = append(changes, change)
+		}
+	}
+
+	// sqlite通过新建表、复制数据、删除旧表、重命名的方式重建表
+	for _, change := range changes {
+		if change.Kind == SchemaPushDropTable && renamedTables[change.Table] {
+			change.Kind = SchemaPushRedefineTable
+		}
+	}
+	return
+}
+
+// 保留有损的列类型修改，原有类型未知时同样保留
+func filterLossyColumnTypeChanges(changes []*SchemaPushChange, columnTypes map[string]string) (filtered []*SchemaPushChange) {
+	for _, change := range changes {
+		if change.Kind == SchemaPushAlterColumnType {
+			change.OldType = columnTypes[columnTypeKey(change.Table, change.Column)]
+			if change.OldType != "" && !isLossyColumnTypeChange(change.OldType, change.NewType) {
+				continue
+			}
+		}
+		filtered = append(filtered, change)
+	}
+	return
+}
+
+func alterColumnTypeTables(changes []*SchemaPushChange) (tables []string) {
+	seen := make(map[string]bool)
+	for _, change := range changes {
+		if change.Kind == SchemaPushAlterColumnType && !seen[change.Table] {
+			seen[change.Table] = true
+			tables = append(tables, change.Table)
+		}
+	}
+	return
+}
+
+// 通过information_schema查询表中列的当前类型，返回表名.列名到类型的映射
+func queryColumnTypes(ctx context.Context, dsName string, provider wgpb.DataSourceKind, tables []string) (columnTypes map[string]string, err error) {
+	schemaExprs, ok := pushColumnTypesSchemaExprs[provider]
+	if !ok {
+		return
+	}
+
+	quotedTables := make([]string, len(tables))
+	for i, table := range tables {
+		quotedTables[i] = "'" + strings.ReplaceAll(table, "'", "''") + "'"
+	}
+	sql := fmt.Sprintf(pushColumnTypesSqlFormat, schemaExprs[0], schemaExprs[1], strings.Join(quotedTables, ", "))
+	result, err := doMigrationRawQuery(ctx, dsName, migrationRawQueryAction, sql)
+	if err != nil {
+		return
+	}
+
+	columnTypes = make(map[string]string)
+	for _, row := range parseRawQueryRows(result) {
+		row = lowerRowKeys(row)
+		columnTypes[columnTypeKey(row["table_name"], row["column_name"])] = buildInformationSchemaType(row)
+	}
+	return
+}
+
+// 由information_schema的字段拼接类型，mysql直接使用column_type
+func buildInformationSchemaType(row map[string]string) string {
+	if columnType := row["column_type"]; columnType != "" {
+		return columnType
+	}
+
+	dataType := row["data_type"]
+	switch normalizeSqlTypeName(dataType) {
+	case sqlTypeChar, sqlTypeVarchar, sqlTypeBinary, sqlTypeVarbinary:
+		if length := row["character_maximum_length"]; length != "" {
+			if length == "-1" {
+				length = "max"
+			}
+			return dataType + "(" + length + ")"
+		}
+	case sqlTypeDecimal:
+		if precision := row["numeric_precision"]; precision != "" {
+			return dataType + "(" + precision + "," + row["numeric_scale"] + ")"
+		}
+	case "float":
+		if precision := row["numeric_precision"]; precision != "" {
+			return dataType + "(" + precision + ")"
+		}
+	case sqlTypeTimestamp, sqlTypeTimestampTz, sqlTypeTime, sqlTypeTimeTz:
+		if precision := row["datetime_precision"]; precision != "" {
+			return dataType + "(" + precision + ")"
+		}
+	}
+	return dataType
+}
+
+// sqlserver/mysql返回的列名可能为大写
+func lowerRowKeys(row map[string]string) map[string]string {
+	lowered := make(map[string]string, len(row))
+	for key, value := range row {
+		lowered[strings.ToLower(key)] = value
+	}
+	return lowered
+}
+
+func columnTypeKey(table, column string) string {
+	return strings.ToLower(table + "." + column)
+}
+
+// 按照不在括号、引号内的分号拆分语句，并移除注释
+func splitSqlStatements(script string) (statements []string) {
+	for _, statement := range scanSql(script, ';') {
+		if statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+	return
+}
+
+// 按照不在括号、引号内的逗号拆分子句，避免拆分DECIMAL(10,2)、DEFAULT 'a,b'等
+func splitSqlClauses(text string) []string {
+	return scanSql(text, ',')
+}
+
+// 扫描sql文本并按分隔符拆分，跳过字符串、带引号的名称及postgresql的$tag$字符串，丢弃行注释和块注释
+func scanSql(text string, separator byte) (parts []string) {
+	var (
+		builder strings.Builder
+		depth   int
+	)
+	flush := func() {
+		parts = append(parts, strings.TrimSpace(builder.String()))
+		builder.Reset()
+	}
+	for i := 0; i < len(text); i++ {
+		char := text[i]
+		switch {
+		case char == '-' && i+1 < len(text) && text[i+1] == '-':
+			if end := strings.IndexByte(text[i:], '\n'); end != -1 {
+				i += end - 1
+			} else {
+				i = len(text)
+			}
+			continue
+		case char == '/' && i+1 < len(text) && text[i+1] == '*':
+			if end := strings.Index(text[i+2:], "*/"); end != -1 {
+				i += end + 3
+			} else {
+				i = len(text)
+			}
+			builder.WriteByte(' ')
+			continue
+		case char == '\'' || char == '"' || char == '`' || char == '[':
+			closeChar := char
+			if char == '[' {
+				closeChar = ']'
+			}
+			end := i + 1
+			for end < len(text) {
+				if text[end] == closeChar {
+					// 连续两个引号为转义
+					if closeChar != ']' && end+1 < len(text) && text[end+1] == closeChar {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			end = min(end, len(text)-1)
+			builder.WriteString(text[i : end+1])
+			i = end
+			continue
+		case char == '$':
+			if tag := sqlDollarQuoteTag(text[i:]); tag != "" {
+				end := len(text)
+				if index := strings.Index(text[i+len(tag):], tag); index != -1 {
+					end = i + len(tag) + index + len(tag)
+				}
+				builder.WriteString(text[i:end])
+				i = end - 1
+				continue
+			}
+		case char == '(':
+			depth++
+		case char == ')':
+			depth--
+		case char == separator && depth == 0:
+			flush()
+			continue
+		}
+		builder.WriteByte(char)
+	}
+	flush()
+	return
+}
+
+// 匹配postgresql的$$或$tag$起始标记
+func sqlDollarQuoteTag(text string) string {
+	for i := 1; i < len(text); i++ {
+		char := text[i]
+		if char == '$' {
+			return text[:i+1]
+		}
+		if !(char == '_' || char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || i > 1 && char >= '0' && char <= '9') {
+			break
+		}
+	}
+	return ""
+}
+
+func isSqlAddKeyword(name string) bool {
+	for _, keyword := range pushAddKeywords {
+		if strings.EqualFold(name, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// 去除名称的引号，带schema的名称仅保留最后一段
+func unquoteSqlName(name string) string {
+	name = strings.TrimRight(name, ",")
+	if index := strings.LastIndex(name, "."); index != -1 {
+		name = name[index+1:]
+	}
+	return strings.Trim(name, "`\"[]")
+}
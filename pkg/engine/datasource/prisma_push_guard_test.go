@@ -0,0 +1,136 @@
+package datasource
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSchemaPushChanges(t *testing.T) {
+	tests := []struct {
+		name, script string
+		expected     []*SchemaPushChange
+	}{
+		{
+			name: "semicolons inside literals and comments",
+			script: `-- drop the old; table
+/* DROP TABLE "Ignored"; */
+ALTER TABLE "Post" ADD COLUMN "note" TEXT NOT NULL DEFAULT 'a;b, c';
+CREATE FUNCTION f() RETURNS trigger AS $body$ BEGIN DROP TABLE x; END $body$ LANGUAGE plpgsql;
+DROP TABLE "Comment";`,
+			expected: []*SchemaPushChange{
+				{Kind: SchemaPushDropTable, Table: "Comment", Statement: `DROP TABLE "Comment"`},
+			},
+		},
+		{
+			name:   "postgresql alter column",
+			script: `ALTER TABLE "public"."User" DROP COLUMN "age", ALTER COLUMN "name" SET DATA TYPE VARCHAR(10), ALTER COLUMN "bio" SET NOT NULL, ALTER COLUMN "role" TYPE "Role_new" USING ("role"::text::"Role_new");`,
+			expected: []*SchemaPushChange{
+				{Kind: SchemaPushDropColumn, Table: "User", Column: "age"},
+				{Kind: SchemaPushAlterColumnType, Table: "User", Column: "name", NewType: "VARCHAR(10)"},
+				{Kind: SchemaPushAlterColumnType, Table: "User", Column: "role", NewType: `"Role_new"`},
+			},
+		},
+		{
+			name:   "mysql modify and required column",
+			script: "ALTER TABLE `User` MODIFY `price` DECIMAL(10, 2) NOT NULL, ADD COLUMN `code` VARCHAR(191) NOT NULL, ADD COLUMN `id2` INTEGER NOT NULL AUTO_INCREMENT, ADD UNIQUE INDEX `User_code_key`(`code`);",
+			expected: []*SchemaPushChange{
+				{Kind: SchemaPushAlterColumnType, Table: "User", Column: "price", NewType: "DECIMAL(10, 2)"},
+				{Kind: SchemaPushAddRequiredColumn, Table: "User", Column: "code"},
+			},
+		},
+		{
+			name:   "sqlserver alter column",
+			script: `ALTER TABLE [dbo].[User] ALTER COLUMN [name] NVARCHAR(100) NULL;`,
+			expected: []*SchemaPushChange{
+				{Kind: SchemaPushAlterColumnType, Table: "User", Column: "name", NewType: "NVARCHAR(100)"},
+			},
+		},
+		{
+			name: "sqlite redefine table",
+			script: `CREATE TABLE "new_User" ("id" INTEGER NOT NULL PRIMARY KEY);
+INSERT INTO "new_User" ("id") SELECT "id" FROM "User";
+DROP TABLE "User";
+ALTER TABLE "new_User" RENAME TO "User";`,
+			expected: []*SchemaPushChange{
+				{Kind: SchemaPushRedefineTable, Table: "User", Statement: `DROP TABLE "User"`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := parseSchemaPushChanges(tt.script)
+			for _, change := range changes {
+				if change.Kind != SchemaPushDropTable && change.Kind != SchemaPushRedefineTable {
+					change.Statement = ""
+				}
+			}
+			if !reflect.DeepEqual(changes, tt.expected) {
+				t.Errorf("parseSchemaPushChanges() = %+v, want %+v", changes, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsLossyColumnTypeChange(t *testing.T) {
+	tests := []struct {
+		oldType, newType string
+		lossy            bool
+	}{
+		{"character varying(10)", "VARCHAR(20)", false},
+		{"character varying(20)", "VARCHAR(10)", true},
+		{"varchar(191)", "TEXT", false},
+		{"text", "VARCHAR(191)", true},
+		{"nvarchar(max)", "NVARCHAR(100)", true},
+		{"char(10)", "VARCHAR(10)", false},
+		{"int(11)", "INTEGER", false},
+		{"integer", "BIGINT", false},
+		{"bigint", "INTEGER", true},
+		{"int unsigned", "INTEGER", true},
+		{"int unsigned", "BIGINT", false},
+		{"integer", "DECIMAL(12,2)", false},
+		{"integer", "DECIMAL(10,2)", true},
+		{"integer", "DOUBLE PRECISION", false},
+		{"bigint", "DOUBLE PRECISION", true},
+		{"numeric(10,2)", "DECIMAL(12,2)", false},
+		{"numeric(10,2)", "DECIMAL(10,3)", true},
+		{"numeric(10,2)", "DECIMAL(10,1)", true},
+		{"real", "DOUBLE PRECISION", false},
+		{"float(53)", "REAL", true},
+		{"date", "TIMESTAMP(3)", false},
+		{"timestamp without time zone(6)", "TIMESTAMP(3)", true},
+		{"timestamp with time zone(3)", "TIMESTAMP(3)", true},
+		{"datetime(3)", "DATETIME(3)", false},
+		{"boolean", "INTEGER", false},
+		{"integer", "BOOLEAN", true},
+		{"uuid", "TEXT", false},
+		{"uuid", "VARCHAR(10)", true},
+		{"json", "JSONB", false},
+		{"bytea", "TEXT", true},
+		{"USER-DEFINED", `"Role_new"`, true},
+		{"integer", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.oldType+"->"+tt.newType, func(t *testing.T) {
+			if lossy := isLossyColumnTypeChange(tt.oldType, tt.newType); lossy != tt.lossy {
+				t.Errorf("isLossyColumnTypeChange(%q, %q) = %v, want %v", tt.oldType, tt.newType, lossy, tt.lossy)
+			}
+		})
+	}
+}
+
+func TestFilterLossyColumnTypeChanges(t *testing.T) {
+	changes := parseSchemaPushChanges(`ALTER TABLE "User" ALTER COLUMN "name" SET DATA TYPE VARCHAR(50), ALTER COLUMN "age" SET DATA TYPE SMALLINT, ALTER COLUMN "bio" SET DATA TYPE VARCHAR(10), DROP COLUMN "tmp";`)
+	columnTypes := map[string]string{
+		columnTypeKey("User", "name"): "character varying(20)",
+		columnTypeKey("User", "age"):  "integer",
+	}
+	var kept []string
+	for _, change := range filterLossyColumnTypeChanges(changes, columnTypes) {
+		kept = append(kept, change.Column+":"+change.OldType)
+	}
+	// bio的原有类型未知，保守视为有损
+	expected := []string{"age:integer", "bio:", "tmp:"}
+	if !reflect.DeepEqual(kept, expected) {
+		t.Errorf("filterLossyColumnTypeChanges() = %v, want %v", kept, expected)
+	}
+}
@@ -0,0 +1,390 @@
+// Package datasource
+/*
+ 数据库类型数据源的种子数据
+ 读取store/seed/<数据源>下以prisma模型名称命名的json(对象数组)或csv(首行为字段名)文件，同名时json优先
+ 根据dmmf中关联字段的外键计算模型依赖顺序，被依赖的模型优先写入
+ 记录包含主键或唯一键时使用upsert写入(可重复执行)，否则直接创建
+*/
+package datasource
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/plugins/fileloader"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	json "github.com/json-iterator/go"
+	engineClient "github.com/prisma/prisma-client-go/engine"
+	"github.com/prisma/prisma-client-go/generator/ast/dmmf"
+	"golang.org/x/exp/slices"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type SeedModelResult struct {
+	Model   string `json:"model"`
+	File    string `json:"file"`
+	Records int    `json:"records"`
+}
+
+const (
+	seedUpsertFormat = `mutation { result: upsertOne%s(where: %s, create: %s, update: %s) { %s } }`
+	seedCreateFormat = `mutation { result: createOne%s(data: %s) { %s } }`
+)
+
+var seedDirname = utils.NormalizePath(consts.RootStore, consts.StoreSeedParent)
+
+// ApplySeeds 按照模型依赖顺序写入数据源的种子数据，遇到错误立即停止并返回已完成的结果
+func ApplySeeds(ctx context.Context, ds *models.Datasource) (results []*SeedModelResult, err error) {
+	seedFiles, err := readSeedFiles(ds.Name)
+	if err != nil || len(seedFiles) == 0 {
+		return
+	}
+
+	document, err := LoadDmmfDocument(ds)
+	if err != nil {
+		return
+	}
+
+	dmmfModels := document.Datamodel.Models
+	for modelName, seedFile := range seedFiles {
+		if !slices.ContainsFunc(dmmfModels, func(item dmmf.Model) bool { return string(item.Name) == modelName }) {
+			err = i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.DatasourceSeedModelNotFoundError, filepath.Base(seedFile))
+			return
+		}
+	}
+
+	err = connectQueryEngineWithAction(ds.Name, func(queryEngine *engineClient.QueryEngine) error {
+		for _, model := range sortSeedModels(dmmfModels) {
+			seedFile, ok := seedFiles[string(model.Name)]
+			if !ok {
+				continue
+			}
+
+			result, applyErr := applyModelSeed(ctx, queryEngine, model, seedFile)
+			if applyErr != nil {
+				return applyErr
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	return
+}
+
+func applyModelSeed(ctx context.Context, queryEngine *engineClient.QueryEngine, model dmmf.Model, seedFile string) (result *SeedModelResult, err error) {
+	seedFilename := filepath.Base(seedFile)
+	records, err := readSeedRecords(model, seedFile)
+	if err != nil {
+		err = i18n.NewCustomErrorWithMode(datasourceModelName, err, i18n.DatasourceSeedParseError, seedFilename)
+		return
+	}
+
+	for i, record := range records {
+		request := engineClient.GQLRequest{Variables: map[string]any{}}
+		if request.Query, err = buildSeedMutation(model, record); err == nil {
+			var resultValue any
+			err = queryEngine.Do(ctx, request, &resultValue)
+		}
+		if err != nil {
+			err = i18n.NewCustomErrorWithMode(datasourceModelName, err, i18n.DatasourceSeedApplyError, seedFilename, i+1)
+			return
+		}
+	}
+
+	result = &SeedModelResult{Model: string(model.Name), File: seedFilename, Records: len(records)}
+	return
+}
+
+// 读取种子目录下的文件，key为模型名称
+func readSeedFiles(dsName string) (seedFiles map[string]string, err error) {
+	seedDir := utils.NormalizePath(seedDirname, dsName)
+	entries, err := os.ReadDir(seedDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+
+	seedFiles = make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		extension := fileloader.Extension(filepath.Ext(entry.Name()))
+		if extension != fileloader.ExtJson && extension != fileloader.ExtCsv {
+			continue
+		}
+
+		modelName := strings.TrimSuffix(entry.Name(), string(extension))
+		if _, ok := seedFiles[modelName]; ok && extension == fileloader.ExtCsv {
+			continue
+		}
+		seedFiles[modelName] = filepath.Join(seedDir, entry.Name())
+	}
+	return
+}
+
+// 读取种子记录，csv中列表和Json类型的值使用json文本，空值在字段有默认值时忽略，可选字段时为null
+func readSeedRecords(model dmmf.Model, seedFile string) (records []map[string]any, err error) {
+	content, err := os.ReadFile(seedFile)
+	if err != nil {
+		return
+	}
+
+	if fileloader.Extension(filepath.Ext(seedFile)) == fileloader.ExtJson {
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		err = decoder.Decode(&records)
+		return
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil || len(rows) == 0 {
+		return
+	}
+
+	header := rows[0]
+	for _, row := range rows[1:] {
+		record := make(map[string]any)
+		for i, value := range row {
+			if i >= len(header) {
+				break
+			}
+
+			fieldName := strings.TrimSpace(header[i])
+			field, ok := searchSeedField(model, fieldName)
+			if !ok {
+				err = fmt.Errorf("unknown field [%s] of model [%s]", fieldName, model.Name)
+				return
+			}
+
+			switch {
+			case value == "" && field.HasDefaultValue:
+				continue
+			case value == "" && !field.IsRequired:
+				record[fieldName] = nil
+			case field.IsList || field.Type == "Json":
+				var jsonValue any
+				if err = json.Unmarshal([]byte(value), &jsonValue); err != nil {
+					return
+				}
+				record[fieldName] = jsonValue
+			default:
+				record[fieldName] = value
+			}
+		}
+		records = append(records, record)
+	}
+	return
+}
+
+// 构建upsert/create语句，返回值仅查询第一个标量字段
+func buildSeedMutation(model dmmf.Model, record map[string]any) (query string, err error) {
+	fieldNames := make([]string, 0, len(record))
+	for fieldName := range record {
+		fieldNames = append(fieldNames, fieldName)
+	}
+	slices.Sort(fieldNames)
+
+	arguments := make([]string, 0, len(fieldNames))
+	for _, fieldName := range fieldNames {
+		field, ok := searchSeedField(model, fieldName)
+		if !ok {
+			err = fmt.Errorf("unknown field [%s] of model [%s]", fieldName, model.Name)
+			return
+		}
+
+		var valueText string
+		if valueText, err = formatSeedValue(field, record[fieldName]); err != nil {
+			return
+		}
+		arguments = append(arguments, fmt.Sprintf("%s: %s", fieldName, valueText))
+	}
+
+	var selection string
+	for _, field := range model.Fields {
+		if field.Kind == dmmf.FieldKindScalar && !field.IsList {
+			selection = string(field.Name)
+			break
+		}
+	}
+
+	data := fmt.Sprintf("{%s}", strings.Join(arguments, ", "))
+	where, ok, err := buildSeedUniqueWhere(model, record)
+	if err != nil {
+		return
+	}
+	if ok {
+		query = fmt.Sprintf(seedUpsertFormat, model.Name, where, data, data, selection)
+	} else {
+		query = fmt.Sprintf(seedCreateFormat, model.Name, data, selection)
+	}
+	return
+}
+
+// 按照主键、复合主键、唯一字段、复合唯一索引的顺序查找记录中值完整的唯一键
+func buildSeedUniqueWhere(model dmmf.Model, record map[string]any) (where string, ok bool, err error) {
+	type uniqueKey struct {
+		name   string
+		fields []string
+	}
+	var uniqueKeys []uniqueKey
+	for _, field := range model.Fields {
+		if field.IsID {
+			uniqueKeys = append(uniqueKeys, uniqueKey{fields: []string{string(field.Name)}})
+		}
+	}
+	if primaryKeyFields := stringsOfDmmf(model.PrimaryKey.Fields); len(primaryKeyFields) > 0 {
+		uniqueKeys = append(uniqueKeys, uniqueKey{name: string(model.PrimaryKey.Name), fields: primaryKeyFields})
+	}
+	for _, field := range model.Fields {
+		if field.IsUnique {
+			uniqueKeys = append(uniqueKeys, uniqueKey{fields: []string{string(field.Name)}})
+		}
+	}
+	for _, index := range model.UniqueIndexes {
+		uniqueKeys = append(uniqueKeys, uniqueKey{name: index.InternalName, fields: stringsOfDmmf(index.Fields)})
+	}
+
+	for _, key := range uniqueKeys {
+		if slices.ContainsFunc(key.fields, func(item string) bool { return record[item] == nil }) {
+			continue
+		}
+
+		arguments := make([]string, 0, len(key.fields))
+		for _, fieldName := range key.fields {
+			field, _ := searchSeedField(model, fieldName)
+			var valueText string
+			if valueText, err = formatSeedValue(field, record[fieldName]); err != nil {
+				return
+			}
+			arguments = append(arguments, fmt.Sprintf("%s: %s", fieldName, valueText))
+		}
+
+		ok = true
+		if len(key.fields) == 1 {
+			where = fmt.Sprintf("{%s}", arguments[0])
+			return
+		}
+
+		// 复合唯一键未命名时prisma使用下划线连接字段名称
+		keyName := key.name
+		if keyName == "" {
+			keyName = strings.Join(key.fields, "_")
+		}
+		where = fmt.Sprintf("{%s: {%s}}", keyName, strings.Join(arguments, ", "))
+		return
+	}
+	return
+}
+
+// 将值转换为graphql字面量，枚举不加引号，Json类型转为json文本字符串
+func formatSeedValue(field dmmf.Field, value any) (string, error) {
+	if value == nil {
+		return "null", nil
+	}
+
+	if field.IsList {
+		items, ok := value.([]any)
+		if !ok {
+			return "", fmt.Errorf("field [%s] expect list value but got [%v]", field.Name, value)
+		}
+
+		itemField := field
+		itemField.IsList = false
+		itemTexts := make([]string, 0, len(items))
+		for _, item := range items {
+			itemText, err := formatSeedValue(itemField, item)
+			if err != nil {
+				return "", err
+			}
+			itemTexts = append(itemTexts, itemText)
+		}
+		return fmt.Sprintf("[%s]", strings.Join(itemTexts, ", ")), nil
+	}
+
+	if field.Type == "Json" {
+		jsonBytes, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return quoteSeedString(string(jsonBytes)), nil
+	}
+
+	switch value.(type) {
+	case map[string]any, []any:
+		return "", fmt.Errorf("field [%s] expect scalar value but got [%v]", field.Name, value)
+	}
+
+	text := fmt.Sprintf("%v", value)
+	if field.Kind == dmmf.FieldKindEnum {
+		return text, nil
+	}
+
+	switch field.Type {
+	case "Int", "Float":
+		if _, err := strconv.ParseFloat(text, 64); err != nil {
+			return "", fmt.Errorf("field [%s] expect number value but got [%s]", field.Name, text)
+		}
+		return text, nil
+	case "Boolean":
+		boolValue, err := strconv.ParseBool(text)
+		if err != nil {
+			return "", fmt.Errorf("field [%s] expect boolean value but got [%s]", field.Name, text)
+		}
+		return strconv.FormatBool(boolValue), nil
+	default:
+		return quoteSeedString(text), nil
+	}
+}
+
+// 按照外键依赖排序模型，被依赖的模型在前，自关联及循环依赖保持dmmf中的顺序
+func sortSeedModels(dmmfModels []dmmf.Model) (sortedModels []dmmf.Model) {
+	visited := make(map[string]bool)
+	var visit func(dmmf.Model)
+	visit = func(model dmmf.Model) {
+		modelName := string(model.Name)
+		if visited[modelName] {
+			return
+		}
+
+		visited[modelName] = true
+		for _, field := range model.Fields {
+			if !field.Kind.IsRelation() || len(field.RelationFromFields) == 0 || string(field.Type) == modelName {
+				continue
+			}
+			if index := slices.IndexFunc(dmmfModels, func(item dmmf.Model) bool { return string(item.Name) == string(field.Type) }); index != -1 {
+				visit(dmmfModels[index])
+			}
+		}
+		sortedModels = append(sortedModels, model)
+	}
+	for _, model := range dmmfModels {
+		visit(model)
+	}
+	return
+}
+
+func searchSeedField(model dmmf.Model, fieldName string) (field dmmf.Field, ok bool) {
+	index := slices.IndexFunc(model.Fields, func(item dmmf.Field) bool {
+		return string(item.Name) == fieldName && !item.Kind.IsRelation()
+	})
+	if ok = index != -1; ok {
+		field = model.Fields[index]
+	}
+	return
+}
+
+func quoteSeedString(text string) string {
+	quoted, _ := json.Marshal(text)
+	return string(quoted)
+}
@@ -0,0 +1,214 @@
+// Package datasource
+/*
+ soap类型数据源的实现，以rest类型保存
+ 读取上传的wsdl文件及其导入的wsdl/xsd文件，将绑定的操作转换成graphql字段
+ 操作按照配置或操作名前缀映射为query/mutation，支持document/literal和rpc风格，优先使用soap1.1的端口
+ xsd类型转换成graphql类型，枚举值均为合法名称的简单类型转换成枚举，无字段的复杂类型映射为JSON
+ 运行时每个操作作为rest数据源请求飞布服务上的soap转换接口，由其组装soap报文并将响应的xml转换成json
+ 不是合法graphql名称的字段使用rest数据源的字段重写规则与原始名称互相转换
+*/
+package datasource
+
+import (
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/httpclient"
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const soapOperationPathFormat = "/soap/%s/%s"
+
+// SoapOperationRoutePath 飞布服务上soap转换接口的路由
+var SoapOperationRoutePath = fmt.Sprintf(soapOperationPathFormat, ":"+consts.PathParamDataName, ":"+consts.PathParamOperation)
+
+var soapServices utils.SyncMap[string, *soapService]
+
+type actionSoap struct {
+	ds *models.Datasource
+}
+
+// Handle 实现了FieldConfigurationAction接口，与rest数据源一致由引擎按照rest配置处理参数
+func (a *actionSoap) Handle(configuration *wgpb.FieldConfiguration) {
+	configuration.DisableDefaultFieldMapping = true
+	configuration.ArgumentsConfiguration = nil
+	configuration.Path = nil
+	configuration.RequiresFields = nil
+}
+
+func (a *actionSoap) Introspect() (graphqlSchema string, err error) {
+	service, err := loadSoapService(a.ds)
+	if err != nil {
+		return
+	}
+
+	graphqlSchema = service.graphqlSchema
+	cacheGraphqlSchema(a.ds.Name, graphqlSchema)
+	return
+}
+
+// BuildDataSourceConfiguration 每个操作生成一个rest配置，请求地址在运行时确定
+// 请求体为graphql参数组成的json对象，重写规则以操作的字段名称保存
+func (a *actionSoap) BuildDataSourceConfiguration(*ast.SchemaDocument) (config *wgpb.DataSourceConfiguration, err error) {
+	service, err := loadSoapService(a.ds)
+	if err != nil {
+		return
+	}
+
+	rewriteHeaders := make(map[string]*wgpb.HTTPHeader)
+	rewriteHttpHeaders(a.ds.CustomSoap.Headers, rewriteHeaders)
+	config = &wgpb.DataSourceConfiguration{
+		CustomRestMap:                 make(map[string]*wgpb.DataSourceCustom_REST),
+		CustomRestRequestRewriterMap:  make(map[string]*wgpb.DataSourceCustom_REST_Rewriter),
+		CustomRestResponseRewriterMap: make(map[string]*wgpb.DataSourceCustom_REST_Rewriter),
+	}
+	for _, operation := range service.operations {
+		fieldName := fmt.Sprintf("%s.%s_%s", rootOperationTypeName(operation.operation), a.ds.Name, operation.fieldName)
+		config.CustomRestMap[fieldName] = &wgpb.DataSourceCustom_REST{
+			Fetch: &wgpb.FetchConfiguration{
+				Method:              wgpb.HTTPMethod_POST,
+				Header:              rewriteHeaders,
+				Body:                utils.MakePlaceHolderVariable(operation.requestBodyTemplate()),
+				RequestContentType:  echo.MIMEApplicationJSON,
+				ResponseContentType: echo.MIMEApplicationJSON,
+			},
+			Subscription: &wgpb.RESTSubscriptionConfiguration{},
+		}
+		requestRewriters, responseRewriters := operation.buildRewriters()
+		if len(requestRewriters) > 0 {
+			config.CustomRestRequestRewriterMap[operation.fieldName] = &wgpb.DataSourceCustom_REST_Rewriter{Rewriters: requestRewriters}
+		}
+		if len(responseRewriters) > 0 {
+			config.CustomRestResponseRewriterMap[operation.fieldName] = &wgpb.DataSourceCustom_REST_Rewriter{Rewriters: responseRewriters}
+		}
+	}
+	return
+}
+
+// RuntimeDataSourceConfiguration 转换成请求飞布服务soap转换接口的rest数据源
+func (a *actionSoap) RuntimeDataSourceConfiguration(config *wgpb.DataSourceConfiguration) (configs []*wgpb.DataSourceConfiguration, fields []*wgpb.FieldConfiguration, err error) {
+	configs, fields = copyDatasourceWithRootNodes(config, func(rootItem *wgpb.TypeField, configItem *wgpb.DataSourceConfiguration) bool {
+		existedCustomRest, ok := config.CustomRestMap[utils.JoinStringWithDot(rootItem.TypeName, rootItem.FieldNames[0])]
+		if !ok {
+			return false
+		}
+
+		configItem.ChildNodes = nil
+		operationName := strings.TrimPrefix(rootItem.FieldNames[0], config.Id+"_")
+		fetch := buildInternalFetch(wgpb.HTTPMethod_POST, existedCustomRest.Fetch.GetHeader(), soapOperationPathFormat, config.Id, operationName)
+		fetch.Body = existedCustomRest.Fetch.GetBody()
+		fetch.RequestContentType = existedCustomRest.Fetch.GetRequestContentType()
+		fetch.ResponseContentType = existedCustomRest.Fetch.GetResponseContentType()
+		configItem.CustomRest = &wgpb.DataSourceCustom_REST{
+			Fetch:             fetch,
+			Subscription:      existedCustomRest.Subscription,
+			RequestRewriters:  config.CustomRestRequestRewriterMap[operationName].GetRewriters(),
+			ResponseRewriters: config.CustomRestResponseRewriterMap[operationName].GetRewriters(),
+		}
+		return true
+	})
+	return
+}
+
+// 请求体中的key为graphql参数名称，由请求重写规则转换成原始名称
+func (o *soapOperation) requestBodyTemplate() string {
+	var items []string
+	for _, field := range o.arguments() {
+		items = append(items, fmt.Sprintf(`"%s":%s`, field.name, fmt.Sprintf(argumentsFormat, field.name)))
+	}
+	return "{" + strings.Join(items, ",") + "}"
+}
+
+// 生成请求和响应的字段重写规则，请求规则的路径以body开始，响应规则的路径从操作返回值开始
+func (o *soapOperation) buildRewriters() (requestRewriters, responseRewriters []*wgpb.DataSourceRESTRewriter) {
+	requestRewriters = appendSoapRewriters(nil, []string{httpclient.BODY}, o.arguments(), nil)
+	if o.outputType.isObject() {
+		responseRewriters = appendSoapRewriters(nil, nil, o.outputType.fields, []*soapType{o.outputType})
+	}
+	sortRestRewriters(requestRewriters)
+	sortRestRewriters(responseRewriters)
+	return
+}
+
+// 名称经过规范化的字段添加重写规则，路径中的字段均为graphql名称，数组元素使用[]
+// 递归引用的类型仅处理首次出现的层级
+func appendSoapRewriters(rewriters []*wgpb.DataSourceRESTRewriter, path []string, fields []*soapField, visited []*soapType) []*wgpb.DataSourceRESTRewriter {
+	for _, field := range fields {
+		fieldPath := utils.CopyAndAppendItem(path, field.name)
+		if field.name != field.xmlName.Local {
+			rewriters = append(rewriters, &wgpb.DataSourceRESTRewriter{
+				PathComponents: fieldPath,
+				Type:           wgpb.DataSourceRESTRewriterType_fieldRewrite,
+				FieldRewriteTo: field.xmlName.Local,
+			})
+		}
+		if !field.typ.isObject() || slices.Contains(visited, field.typ) {
+			continue
+		}
+
+		if field.list {
+			fieldPath = utils.CopyAndAppendItem(fieldPath, utils.ArrayPath)
+		}
+		rewriters = appendSoapRewriters(rewriters, fieldPath, field.typ.fields, append(slices.Clip(visited), field.typ))
+	}
+	return rewriters
+}
+
+// 读取wsdl文件，所有加载的文件和操作配置未变更时使用缓存
+func loadSoapService(ds *models.Datasource) (service *soapService, err error) {
+	if ds.CustomSoap == nil {
+		err = i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.StructParamEmtpyError, "customSoap")
+		return
+	}
+
+	wsdlFilepath := models.DatasourceUploadSoap.GetPath(ds.Name)
+	service, ok := soapServices.Load(ds.Name)
+	if ok && soapServiceUnchanged(service, wsdlFilepath) && maps.Equal(service.operationKinds, ds.CustomSoap.OperationKinds) {
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			err = i18n.NewCustomErrorWithMode(datasourceModelName, err, i18n.DatasourceSoapWsdlParseError, filepath.Base(wsdlFilepath))
+		}
+	}()
+	loader := newWsdlLoader(models.DatasourceUploadSoap.Root)
+	if err = loader.loadDefinitions(wsdlFilepath); err != nil {
+		return
+	}
+
+	if service, err = buildSoapService(loader, maps.Clone(ds.CustomSoap.OperationKinds)); err != nil {
+		return
+	}
+
+	if _, err = gqlparser.LoadSchema(&ast.Source{Name: ds.Name, Input: service.graphqlSchema}); err != nil {
+		return
+	}
+
+	soapServices.Store(ds.Name, service)
+	return
+}
+
+func soapServiceUnchanged(service *soapService, wsdlFilepath string) bool {
+	if _, ok := service.files[wsdlFilepath]; !ok {
+		return false
+	}
+
+	for path, modTime := range service.files {
+		fileInfo, err := os.Stat(path)
+		if err != nil || !fileInfo.ModTime().Equal(modTime) {
+			return false
+		}
+	}
+	return true
+}
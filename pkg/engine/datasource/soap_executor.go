@@ -0,0 +1,422 @@
+package datasource
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	json "github.com/json-iterator/go"
+	"golang.org/x/exp/slices"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	soap11EnvelopeNamespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12EnvelopeNamespace = "http://www.w3.org/2003/05/soap-envelope"
+	soapEnvelopePrefix      = "soap"
+)
+
+type (
+	soapExecutor struct {
+		endpoint string
+		timeout  time.Duration
+		service  *soapService
+		headers  map[string]string
+	}
+	// 响应报文的通用xml节点
+	soapXmlNode struct {
+		XMLName  xml.Name
+		Attrs    []xml.Attr     `xml:",any,attr"`
+		Content  string         `xml:",chardata"`
+		Children []*soapXmlNode `xml:",any"`
+	}
+	// 组装请求报文，命名空间统一声明在Envelope上
+	soapEnvelopeWriter struct {
+		body     strings.Builder
+		prefixes map[string]string
+		spaces   []string
+	}
+)
+
+// ExecuteSoapOperation 将引擎rest数据源请求的json参数转换成soap调用，返回转换成json的响应
+// 请求和响应中的字段均为wsdl中的原始名称，与graphql名称的转换由引擎按照重写规则处理
+// 数据源配置的请求头由引擎解析后传入，转发给soap服务
+func ExecuteSoapOperation(ctx context.Context, dsName, operationName string, header http.Header, body []byte) ([]byte, error) {
+	executor, err := newSoapExecutor(dsName, header)
+	if err != nil {
+		return nil, err
+	}
+
+	return executor.execute(ctx, operationName, body)
+}
+
+func newSoapExecutor(dsName string, header http.Header) (executor *soapExecutor, err error) {
+	ds, err := models.DatasourceRoot.GetByDataName(dsName)
+	if err != nil {
+		return
+	}
+
+	service, err := loadSoapService(ds)
+	if err != nil {
+		return
+	}
+
+	endpoint := utils.GetVariableString(ds.CustomSoap.Endpoint)
+	if endpoint == "" {
+		endpoint = service.endpoint
+	}
+	if endpoint == "" {
+		err = i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.StructParamEmtpyError, "endpoint")
+		return
+	}
+
+	headers := make(map[string]string)
+	for name := range ds.CustomSoap.Headers {
+		if value := header.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+	executor = &soapExecutor{
+		endpoint: endpoint,
+		timeout:  time.Duration(ds.CustomSoap.Timeout) * time.Second,
+		service:  service,
+		headers:  headers,
+	}
+	return
+}
+
+// 调用操作名称对应的soap操作，请求体为空时视为无参数
+func (e *soapExecutor) execute(ctx context.Context, operationName string, body []byte) ([]byte, error) {
+	operation, ok := e.service.operations[operationName]
+	if !ok {
+		return nil, i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.DatasourceSoapOperationNotFoundError, operationName)
+	}
+
+	var arguments map[string]any
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &arguments); err != nil {
+			return nil, err
+		}
+	}
+
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+	responseBody, err := e.invoke(ctx, operation, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := e.service.parseResponse(operation, responseBody)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(value)
+}
+
+func (e *soapExecutor) invoke(ctx context.Context, operation *soapOperation, arguments map[string]any) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, strings.NewReader(e.service.buildEnvelope(operation, arguments)))
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range e.headers {
+		request.Header.Set(name, value)
+	}
+	if e.service.soap12 {
+		request.Header.Set("Content-Type", fmt.Sprintf(`application/soap+xml; charset=utf-8; action="%s"`, operation.action))
+	} else {
+		request.Header.Set("Content-Type", "text/xml; charset=utf-8")
+		request.Header.Set("SOAPAction", strconv.Quote(operation.action))
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// soap错误使用500状态码返回fault，由响应解析处理
+	if response.StatusCode >= http.StatusBadRequest && !bytes.Contains(responseBody, []byte("Fault")) {
+		return nil, fmt.Errorf("soap http status %d: %s", response.StatusCode, strings.TrimSpace(string(responseBody)))
+	}
+	return responseBody, nil
+}
+
+// 组装请求报文，document风格直接写入part元素，rpc风格使用操作名称作为包装元素
+func (s *soapService) buildEnvelope(operation *soapOperation, arguments map[string]any) string {
+	writer := &soapEnvelopeWriter{prefixes: make(map[string]string)}
+	switch {
+	case operation.flattenInput:
+		part := operation.inputParts[0]
+		writer.writeElement(part.xmlName, arguments, part.typ)
+	case operation.rpc:
+		wrapperName := writer.qualifiedName(xml.Name{Space: operation.rpcNamespace, Local: operation.name})
+		writer.body.WriteString("<" + wrapperName + ">")
+		for _, part := range operation.inputParts {
+			writer.writeElement(part.xmlName, arguments[part.originName], part.typ)
+		}
+		writer.body.WriteString("</" + wrapperName + ">")
+	default:
+		for _, part := range operation.inputParts {
+			writer.writeElement(part.xmlName, arguments[part.originName], part.typ)
+		}
+	}
+
+	envelopeNamespace := soap11EnvelopeNamespace
+	if s.soap12 {
+		envelopeNamespace = soap12EnvelopeNamespace
+	}
+	var builder strings.Builder
+	builder.WriteString(xml.Header)
+	builder.WriteString(fmt.Sprintf(`<%s:Envelope xmlns:%s="%s"`, soapEnvelopePrefix, soapEnvelopePrefix, envelopeNamespace))
+	for _, space := range writer.spaces {
+		builder.WriteString(fmt.Sprintf(` xmlns:%s="%s"`, writer.prefixes[space], escapeXmlText(space)))
+	}
+	builder.WriteString(fmt.Sprintf("><%s:Body>%s</%s:Body></%s:Envelope>", soapEnvelopePrefix, writer.body.String(), soapEnvelopePrefix, soapEnvelopePrefix))
+	return builder.String()
+}
+
+func (w *soapEnvelopeWriter) qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	prefix, ok := w.prefixes[name.Space]
+	if !ok {
+		prefix = fmt.Sprintf("ns%d", len(w.spaces))
+		w.prefixes[name.Space] = prefix
+		w.spaces = append(w.spaces, name.Space)
+	}
+	return prefix + ":" + name.Local
+}
+
+// 空值不写入元素，数组按照元素重复写入
+func (w *soapEnvelopeWriter) writeElement(name xml.Name, value any, typ *soapType) {
+	if items, ok := value.([]any); ok {
+		for _, item := range items {
+			w.writeElement(name, item, typ)
+		}
+		return
+	}
+	if value == nil {
+		return
+	}
+
+	tagName := w.qualifiedName(name)
+	object, ok := value.(map[string]any)
+	if !ok || !typ.isObject() {
+		w.body.WriteString(fmt.Sprintf("<%s>%s</%s>", tagName, escapeXmlText(formatSoapScalar(value)), tagName))
+		return
+	}
+
+	w.body.WriteString("<" + tagName)
+	for _, field := range typ.fields {
+		if fieldValue, exists := object[field.xmlName.Local]; exists && fieldValue != nil && field.attribute {
+			w.body.WriteString(fmt.Sprintf(` %s="%s"`, w.qualifiedName(field.xmlName), escapeXmlText(formatSoapScalar(fieldValue))))
+		}
+	}
+	w.body.WriteString(">")
+	for _, field := range typ.fields {
+		fieldValue := object[field.xmlName.Local]
+		switch {
+		case field.attribute || fieldValue == nil:
+		case field.text:
+			w.body.WriteString(escapeXmlText(formatSoapScalar(fieldValue)))
+		default:
+			w.writeElement(field.xmlName, fieldValue, field.typ)
+		}
+	}
+	w.body.WriteString("</" + tagName + ">")
+}
+
+func formatSoapScalar(value any) string {
+	switch item := value.(type) {
+	case string:
+		return item
+	case bool:
+		return strconv.FormatBool(item)
+	case float64:
+		return strconv.FormatFloat(item, 'f', -1, 64)
+	default:
+		return fmt.Sprint(item)
+	}
+}
+
+func escapeXmlText(text string) string {
+	var buffer bytes.Buffer
+	_ = xml.EscapeText(&buffer, []byte(text))
+	return buffer.String()
+}
+
+// 解析响应报文，fault转换成错误，其他内容按照输出类型转换成使用原始名称的json
+func (s *soapService) parseResponse(operation *soapOperation, responseBody []byte) (any, error) {
+	envelope := &soapXmlNode{}
+	if err := xml.Unmarshal(responseBody, envelope); err != nil {
+		return nil, err
+	}
+
+	body := envelope.child("Body")
+	if body == nil {
+		return nil, errors.New("soap body not found in response")
+	}
+
+	if fault := body.child("Fault"); fault != nil {
+		return nil, fault.faultError()
+	}
+
+	container := body
+	if operation.rpc && len(body.Children) > 0 {
+		container = body.Children[0]
+	}
+	switch len(operation.outputParts) {
+	case 0:
+		return true, nil
+	case 1:
+		part := operation.outputParts[0]
+		return convertSoapNode(container.child(part.xmlName.Local), part.typ), nil
+	default:
+		return convertSoapNode(container, operation.outputType), nil
+	}
+}
+
+func (n *soapXmlNode) child(local string) *soapXmlNode {
+	if n == nil {
+		return nil
+	}
+
+	index := slices.IndexFunc(n.Children, func(item *soapXmlNode) bool { return item.XMLName.Local == local })
+	if index == -1 {
+		return nil
+	}
+	return n.Children[index]
+}
+
+func (n *soapXmlNode) attr(local string) (string, bool) {
+	index := slices.IndexFunc(n.Attrs, func(item xml.Attr) bool { return item.Name.Local == local && item.Name.Space != xmlnsAttrSpace })
+	if index == -1 {
+		return "", false
+	}
+	return n.Attrs[index].Value, true
+}
+
+// soap1.1使用faultcode/faultstring，soap1.2使用Code/Value和Reason/Text
+func (n *soapXmlNode) faultError() error {
+	code, reason := n.child("faultcode"), n.child("faultstring")
+	if code == nil {
+		code = n.child("Code").child("Value")
+	}
+	if reason == nil {
+		reason = n.child("Reason").child("Text")
+	}
+
+	var codeText, reasonText string
+	if code != nil {
+		codeText = strings.TrimSpace(code.Content)
+	}
+	if reason != nil {
+		reasonText = strings.TrimSpace(reason.Content)
+	}
+	return fmt.Errorf("soap fault %s: %s", codeText, reasonText)
+}
+
+// 按照soapType转换xml节点，xsi:nil和缺失的元素转换为null，数组字段缺失时为空数组
+func convertSoapNode(node *soapXmlNode, typ *soapType) any {
+	if node == nil {
+		return nil
+	}
+	if slices.ContainsFunc(node.Attrs, func(item xml.Attr) bool {
+		return item.Name.Space == xsiNamespace && item.Name.Local == "nil" && item.Value == "true"
+	}) {
+		return nil
+	}
+
+	switch {
+	case typ.scalar == soapTypeJson:
+		return convertSoapNodeJson(node)
+	case !typ.isObject():
+		return convertSoapScalar(strings.TrimSpace(node.Content), typ.scalar)
+	}
+
+	object := make(map[string]any, len(typ.fields))
+	for _, field := range typ.fields {
+		switch {
+		case field.attribute:
+			if attrValue, ok := node.attr(field.xmlName.Local); ok {
+				object[field.xmlName.Local] = convertSoapScalar(attrValue, field.typ.scalar)
+			} else {
+				object[field.xmlName.Local] = nil
+			}
+		case field.text:
+			object[field.xmlName.Local] = convertSoapScalar(strings.TrimSpace(node.Content), field.typ.scalar)
+		case field.list:
+			items := make([]any, 0)
+			for _, child := range node.Children {
+				if child.XMLName.Local == field.xmlName.Local {
+					items = append(items, convertSoapNode(child, field.typ))
+				}
+			}
+			object[field.xmlName.Local] = items
+		default:
+			object[field.xmlName.Local] = convertSoapNode(node.child(field.xmlName.Local), field.typ)
+		}
+	}
+	return object
+}
+
+// 无法确定类型的节点，无子元素时返回文本，子元素重复时转换成数组
+func convertSoapNodeJson(node *soapXmlNode) any {
+	if len(node.Children) == 0 {
+		return strings.TrimSpace(node.Content)
+	}
+
+	object := make(map[string]any)
+	for _, child := range node.Children {
+		value := convertSoapNodeJson(child)
+		switch existed := object[child.XMLName.Local].(type) {
+		case nil:
+			object[child.XMLName.Local] = value
+		case []any:
+			object[child.XMLName.Local] = append(existed, value)
+		default:
+			object[child.XMLName.Local] = []any{existed, value}
+		}
+	}
+	return object
+}
+
+// 枚举和字符串保持原文，数值和布尔值解析失败时返回null
+func convertSoapScalar(text, scalar string) any {
+	switch scalar {
+	case soapTypeInt:
+		if value, err := strconv.ParseInt(text, 10, 32); err == nil {
+			return value
+		}
+	case soapTypeFloat:
+		if value, err := strconv.ParseFloat(text, 64); err == nil {
+			return value
+		}
+	case soapTypeBoolean:
+		if value, err := strconv.ParseBool(text); err == nil {
+			return value
+		}
+	default:
+		return text
+	}
+	return nil
+}
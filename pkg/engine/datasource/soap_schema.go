@@ -0,0 +1,638 @@
+package datasource
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/exp/slices"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	soapStyleRpc     = "rpc"
+	soapTypeString   = "String"
+	soapTypeInt      = "Int"
+	soapTypeFloat    = "Float"
+	soapTypeBoolean  = "Boolean"
+	soapTypeJson     = "JSON"
+	soapTextField    = "value"
+	soapOutputSuffix = "Output"
+	soapInputSuffix  = "Input"
+)
+
+var (
+	soapInvalidNameRegexp = regexp.MustCompile(`[^A-Za-z0-9_]`)
+	soapGraphqlNameRegexp = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+	soapReservedTypeNames = []string{soapTypeString, soapTypeInt, soapTypeFloat, soapTypeBoolean, soapTypeJson, "ID", "Query", "Mutation", "Subscription"}
+	soapScalarTypes       = map[string]*soapType{
+		soapTypeString:  {name: soapTypeString, scalar: soapTypeString},
+		soapTypeInt:     {name: soapTypeInt, scalar: soapTypeInt},
+		soapTypeFloat:   {name: soapTypeFloat, scalar: soapTypeFloat},
+		soapTypeBoolean: {name: soapTypeBoolean, scalar: soapTypeBoolean},
+	}
+)
+
+type (
+	soapService struct {
+		files          map[string]time.Time
+		operationKinds map[string]string
+		endpoint       string // wsdl中声明的服务地址
+		soap12         bool
+		operations     map[string]*soapOperation // key为graphql字段名称
+		graphqlSchema  string
+	}
+	soapOperation struct {
+		name         string
+		fieldName    string
+		operation    ast.Operation
+		action       string
+		rpc          bool
+		rpcNamespace string
+		flattenInput bool // document风格且仅有一个复杂类型的part时，将其字段平铺为参数
+		inputParts   []*soapPart
+		outputParts  []*soapPart
+		outputType   *soapType
+	}
+	soapPart struct {
+		name       string
+		originName string
+		xmlName    xml.Name
+		typ        *soapType
+	}
+	soapType struct {
+		name       string
+		scalar     string
+		enumValues []string
+		fields     []*soapField
+		outputUsed bool
+		inputUsed  bool
+	}
+	// xmlName的本地名称同时作为json中的字段名称
+	soapField struct {
+		name      string
+		xmlName   xml.Name
+		attribute bool
+		text      bool // simpleContent的文本内容
+		list      bool
+		required  bool
+		typ       *soapType
+	}
+	xsdDefinition struct {
+		schema      *xsdSchema
+		element     *xsdElement
+		complexType *xsdComplexType
+		simpleType  *xsdSimpleType
+	}
+	// 将xsd类型转换成soapType，同一个定义仅转换一次以支持递归引用
+	soapTypeResolver struct {
+		elements     map[xml.Name]*xsdDefinition
+		complexTypes map[xml.Name]*xsdDefinition
+		simpleTypes  map[xml.Name]*xsdDefinition
+		resolved     map[any]*soapType
+		typeNames    map[string]bool
+		types        []*soapType
+	}
+)
+
+func (t *soapType) isObject() bool {
+	return t.scalar == "" && t.enumValues == nil
+}
+
+func (t *soapType) graphqlName(input bool) string {
+	switch {
+	case t.scalar != "":
+		return t.scalar
+	case input && t.enumValues == nil:
+		return t.name + soapInputSuffix
+	default:
+		return t.name
+	}
+}
+
+func (t *soapType) markUsed(input bool) {
+	if t.scalar != "" || (input && t.inputUsed) || (!input && t.outputUsed) {
+		return
+	}
+
+	if input {
+		t.inputUsed = true
+	} else {
+		t.outputUsed = true
+	}
+	for _, field := range t.fields {
+		field.typ.markUsed(input)
+	}
+}
+
+func (f *soapField) graphqlType(input bool) string {
+	typeName := f.typ.graphqlName(input)
+	switch {
+	case f.list && input:
+		return fmt.Sprintf("[%s!]", typeName)
+	case f.list:
+		return fmt.Sprintf("[%s!]!", typeName)
+	case f.required && input:
+		return typeName + "!"
+	default:
+		return typeName
+	}
+}
+
+func newSoapTypeResolver(schemas []*xsdSchema) *soapTypeResolver {
+	resolver := &soapTypeResolver{
+		elements:     make(map[xml.Name]*xsdDefinition),
+		complexTypes: make(map[xml.Name]*xsdDefinition),
+		simpleTypes:  make(map[xml.Name]*xsdDefinition),
+		resolved:     make(map[any]*soapType),
+		typeNames:    make(map[string]bool),
+	}
+	for _, schema := range schemas {
+		for _, item := range schema.Elements {
+			resolver.elements[xml.Name{Space: schema.TargetNamespace, Local: item.Name}] = &xsdDefinition{schema: schema, element: item}
+		}
+		for _, item := range schema.ComplexTypes {
+			resolver.complexTypes[xml.Name{Space: schema.TargetNamespace, Local: item.Name}] = &xsdDefinition{schema: schema, complexType: item}
+		}
+		for _, item := range schema.SimpleTypes {
+			resolver.simpleTypes[xml.Name{Space: schema.TargetNamespace, Local: item.Name}] = &xsdDefinition{schema: schema, simpleType: item}
+		}
+	}
+	return resolver
+}
+
+// 优先按照完整名称查找，命名空间不一致时按照本地名称查找
+func lookupXsdDefinition(definitions map[xml.Name]*xsdDefinition, name xml.Name) *xsdDefinition {
+	if definition, ok := definitions[name]; ok {
+		return definition
+	}
+
+	for itemName, definition := range definitions {
+		if itemName.Local == name.Local {
+			return definition
+		}
+	}
+	return nil
+}
+
+func (r *soapTypeResolver) uniqueTypeName(name string) string {
+	name = normalizeSoapName(name)
+	for r.typeNames[name] || slices.Contains(soapReservedTypeNames, name) {
+		name += "_"
+	}
+	r.typeNames[name] = true
+	return name
+}
+
+func (r *soapTypeResolver) resolveTypeRef(name xml.Name) *soapType {
+	if name.Space == xsdNamespace {
+		return soapScalarTypes[xsdBuiltinScalar(name.Local)]
+	}
+	if definition := lookupXsdDefinition(r.complexTypes, name); definition != nil {
+		return r.resolveComplexType(definition.complexType, definition.schema, name.Local)
+	}
+	if definition := lookupXsdDefinition(r.simpleTypes, name); definition != nil {
+		return r.resolveSimpleType(definition.simpleType, definition.schema, name.Local)
+	}
+	return soapScalarTypes[xsdBuiltinScalar(name.Local)]
+}
+
+func (r *soapTypeResolver) resolveComplexType(complexType *xsdComplexType, schema *xsdSchema, name string) *soapType {
+	if typ, ok := r.resolved[complexType]; ok {
+		return typ
+	}
+
+	typ := &soapType{name: r.uniqueTypeName(name)}
+	r.resolved[complexType] = typ
+	r.types = append(r.types, typ)
+	switch {
+	case complexType.ComplexContent != nil:
+		extension := complexType.ComplexContent.Extension
+		if extension != nil && extension.Base != "" {
+			baseType := r.resolveTypeRef(resolveXmlQName(schema.namespaces, schema.TargetNamespace, extension.Base))
+			typ.fields = append(typ.fields, baseType.fields...)
+		} else {
+			extension = complexType.ComplexContent.Restriction
+		}
+		if extension != nil {
+			typ.fields = r.appendContentFields(typ.fields, schema, typ.name, extension.Attributes, extension.Sequence, extension.All, extension.Choice)
+		}
+	case complexType.SimpleContent != nil && complexType.SimpleContent.Extension != nil:
+		extension := complexType.SimpleContent.Extension
+		baseType := r.resolveTypeRef(resolveXmlQName(schema.namespaces, schema.TargetNamespace, extension.Base))
+		typ.fields = append(typ.fields, &soapField{name: soapTextField, xmlName: xml.Name{Local: soapTextField}, text: true, typ: baseType})
+		typ.fields = r.appendContentFields(typ.fields, schema, typ.name, extension.Attributes)
+	default:
+		typ.fields = r.appendContentFields(typ.fields, schema, typ.name, complexType.Attributes, complexType.Sequence, complexType.All, complexType.Choice)
+	}
+	if len(typ.fields) == 0 {
+		// graphql类型至少需要一个字段，无字段的类型映射为JSON
+		typ.scalar = soapTypeJson
+	}
+	return typ
+}
+
+func (r *soapTypeResolver) appendContentFields(fields []*soapField, schema *xsdSchema, ownerName string, attributes []*xsdAttribute, groups ...*xsdGroup) []*soapField {
+	for _, group := range groups {
+		if group != nil {
+			fields = r.appendGroupFields(fields, group, schema, ownerName, false, false)
+		}
+	}
+	for _, attribute := range attributes {
+		if attribute.Name == "" {
+			continue
+		}
+
+		attributeType := soapScalarTypes[soapTypeString]
+		if attribute.Type != "" {
+			attributeType = r.resolveTypeRef(resolveXmlQName(schema.namespaces, schema.TargetNamespace, attribute.Type))
+		}
+		fields = appendSoapField(fields, &soapField{
+			name:      normalizeSoapName(attribute.Name),
+			xmlName:   xml.Name{Local: attribute.Name},
+			attribute: true,
+			required:  attribute.Use == "required",
+			typ:       attributeType,
+		})
+	}
+	return fields
+}
+
+// choice中的元素均可为空，可重复的分组中的元素均为数组
+func (r *soapTypeResolver) appendGroupFields(fields []*soapField, group *xsdGroup, schema *xsdSchema, ownerName string, optional, list bool) []*soapField {
+	optional = optional || group.choice || group.minOccurs == "0"
+	list = list || group.multiple()
+	for _, particle := range group.particles {
+		switch item := particle.(type) {
+		case *xsdElement:
+			if field := r.resolveElementField(item, schema, ownerName, optional, list); field != nil {
+				fields = appendSoapField(fields, field)
+			}
+		case *xsdGroup:
+			fields = r.appendGroupFields(fields, item, schema, ownerName, optional, list)
+		}
+	}
+	return fields
+}
+
+func (r *soapTypeResolver) resolveElementField(element *xsdElement, schema *xsdSchema, ownerName string, optional, list bool) *soapField {
+	target, targetSchema := element, schema
+	qualified := element.Form == xsdFormQualified || (element.Form == "" && schema.ElementFormDefault == xsdFormQualified)
+	if element.Ref != "" {
+		definition := lookupXsdDefinition(r.elements, resolveXmlQName(schema.namespaces, schema.TargetNamespace, element.Ref))
+		if definition == nil {
+			return nil
+		}
+		// 引用的全局元素总是带有命名空间
+		target, targetSchema, qualified = definition.element, definition.schema, true
+	}
+	if target.Name == "" {
+		return nil
+	}
+
+	xmlName := xml.Name{Local: target.Name}
+	if qualified {
+		xmlName.Space = targetSchema.TargetNamespace
+	}
+	return &soapField{
+		name:     normalizeSoapName(target.Name),
+		xmlName:  xmlName,
+		list:     list || element.multiple(),
+		required: !optional && element.MinOccurs != "0" && !element.Nillable,
+		typ:      r.resolveElementType(target, targetSchema, ownerName),
+	}
+}
+
+// 匿名类型使用所属类型名称和元素名称组合命名
+func (r *soapTypeResolver) resolveElementType(element *xsdElement, schema *xsdSchema, ownerName string) *soapType {
+	anonymousName := element.Name
+	if ownerName != "" {
+		anonymousName = ownerName + "_" + element.Name
+	}
+	switch {
+	case element.Type != "":
+		return r.resolveTypeRef(resolveXmlQName(schema.namespaces, schema.TargetNamespace, element.Type))
+	case element.ComplexType != nil:
+		return r.resolveComplexType(element.ComplexType, schema, anonymousName)
+	case element.SimpleType != nil:
+		return r.resolveSimpleType(element.SimpleType, schema, anonymousName)
+	default:
+		return soapScalarTypes[soapTypeString]
+	}
+}
+
+// 枚举值均为合法的graphql名称时转换成枚举，其他简单类型使用基础类型
+func (r *soapTypeResolver) resolveSimpleType(simpleType *xsdSimpleType, schema *xsdSchema, name string) *soapType {
+	if typ, ok := r.resolved[simpleType]; ok {
+		return typ
+	}
+
+	restriction := simpleType.Restriction
+	if restriction == nil {
+		return soapScalarTypes[soapTypeString]
+	}
+
+	var enumValues []string
+	for _, item := range restriction.Enumerations {
+		if !soapGraphqlNameRegexp.MatchString(item.Value) || slices.Contains([]string{"true", "false", "null"}, item.Value) {
+			enumValues = nil
+			break
+		}
+		enumValues = append(enumValues, item.Value)
+	}
+	if len(enumValues) > 0 {
+		typ := &soapType{name: r.uniqueTypeName(name), enumValues: enumValues}
+		r.resolved[simpleType] = typ
+		r.types = append(r.types, typ)
+		return typ
+	}
+
+	r.resolved[simpleType] = soapScalarTypes[soapTypeString]
+	typ := r.resolveTypeRef(resolveXmlQName(schema.namespaces, schema.TargetNamespace, restriction.Base))
+	r.resolved[simpleType] = typ
+	return typ
+}
+
+func (r *soapTypeResolver) resolvePart(part *wsdlPart, definitions *wsdlDefinitions) (*soapPart, error) {
+	if part.Element != "" {
+		elementName := resolveXmlQName(definitions.namespaces, definitions.TargetNamespace, part.Element)
+		definition := lookupXsdDefinition(r.elements, elementName)
+		if definition == nil {
+			return nil, fmt.Errorf("element [%s] of part [%s] not found", part.Element, part.Name)
+		}
+
+		return &soapPart{
+			name:       normalizeSoapName(part.Name),
+			originName: part.Name,
+			xmlName:    xml.Name{Space: definition.schema.TargetNamespace, Local: definition.element.Name},
+			typ:        r.resolveElementType(definition.element, definition.schema, ""),
+		}, nil
+	}
+
+	return &soapPart{
+		name:       normalizeSoapName(part.Name),
+		originName: part.Name,
+		xmlName:    xml.Name{Local: part.Name},
+		typ:        r.resolveTypeRef(resolveXmlQName(definitions.namespaces, definitions.TargetNamespace, part.Type)),
+	}, nil
+}
+
+// 根据加载的wsdl生成服务，优先使用soap1.1的端口
+func buildSoapService(loader *wsdlLoader, operationKinds map[string]string) (service *soapService, err error) {
+	resolver := newSoapTypeResolver(loader.schemas)
+	definitions, binding, address, soap12 := findSoapBinding(loader.definitions)
+	if binding == nil {
+		err = fmt.Errorf("soap binding not found")
+		return
+	}
+
+	portType := findWsdlItem(loader.definitions, binding.Type, func(item *wsdlDefinitions) []*wsdlPortType { return item.PortTypes },
+		func(item *wsdlPortType) string { return item.Name })
+	if portType == nil {
+		err = fmt.Errorf("portType [%s] not found", binding.Type)
+		return
+	}
+
+	service = &soapService{
+		files:          loader.files,
+		operationKinds: operationKinds,
+		endpoint:       address,
+		soap12:         soap12,
+		operations:     make(map[string]*soapOperation),
+	}
+	for _, portTypeOperation := range portType.Operations {
+		bindingOperationIndex := slices.IndexFunc(binding.Operations, func(item *wsdlBindingOperation) bool { return item.Name == portTypeOperation.Name })
+		if bindingOperationIndex == -1 {
+			continue
+		}
+
+		bindingOperation := binding.Operations[bindingOperationIndex]
+		style := bindingOperation.SoapOperation.Style
+		if style == "" {
+			style = binding.SoapBinding.Style
+		}
+		operation := &soapOperation{
+			name:         portTypeOperation.Name,
+			fieldName:    normalizeSoapName(portTypeOperation.Name),
+			operation:    resolveOperationByName(portTypeOperation.Name, operationKinds[portTypeOperation.Name]),
+			action:       bindingOperation.SoapOperation.SoapAction,
+			rpc:          style == soapStyleRpc,
+			rpcNamespace: bindingOperation.InputBody.Namespace,
+		}
+		if operation.rpcNamespace == "" {
+			operation.rpcNamespace = definitions.TargetNamespace
+		}
+		if operation.inputParts, err = resolver.resolveMessageParts(loader.definitions, portTypeOperation.Input.Message); err != nil {
+			return
+		}
+		if operation.outputParts, err = resolver.resolveMessageParts(loader.definitions, portTypeOperation.Output.Message); err != nil {
+			return
+		}
+
+		operation.flattenInput = !operation.rpc && len(operation.inputParts) == 1 && operation.inputParts[0].typ.isObject()
+		switch len(operation.outputParts) {
+		case 0:
+			// 单向操作没有响应内容，调用成功返回true
+			operation.outputType = soapScalarTypes[soapTypeBoolean]
+		case 1:
+			operation.outputType = operation.outputParts[0].typ
+		default:
+			operation.outputType = &soapType{name: resolver.uniqueTypeName(operation.name + soapOutputSuffix)}
+			for _, part := range operation.outputParts {
+				operation.outputType.fields = append(operation.outputType.fields, &soapField{name: part.name, xmlName: part.xmlName, typ: part.typ})
+			}
+			resolver.types = append(resolver.types, operation.outputType)
+		}
+		service.operations[operation.fieldName] = operation
+	}
+
+	service.graphqlSchema = service.buildGraphqlSchema(resolver, portType)
+	return
+}
+
+func findSoapBinding(definitionsList []*wsdlDefinitions) (definitions *wsdlDefinitions, binding *wsdlBinding, address string, soap12 bool) {
+	for _, soapNamespace := range []string{wsdlSoap11, wsdlSoap12} {
+		for _, item := range definitionsList {
+			for _, service := range item.Services {
+				for _, port := range service.Ports {
+					if port.Address.XMLName.Space != soapNamespace {
+						continue
+					}
+
+					binding = findWsdlItem(definitionsList, port.Binding, func(item *wsdlDefinitions) []*wsdlBinding { return item.Bindings },
+						func(item *wsdlBinding) string { return item.Name })
+					if binding != nil && binding.SoapBinding.XMLName.Space == soapNamespace {
+						return item, binding, port.Address.Location, soapNamespace == wsdlSoap12
+					}
+				}
+			}
+		}
+	}
+	return nil, nil, "", false
+}
+
+// 按照本地名称在所有wsdl中查找定义
+func findWsdlItem[T any](definitionsList []*wsdlDefinitions, qname string, itemsFunc func(*wsdlDefinitions) []T, nameFunc func(T) string) (result T) {
+	_, local, ok := strings.Cut(qname, ":")
+	if !ok {
+		local = qname
+	}
+	for _, definitions := range definitionsList {
+		for _, item := range itemsFunc(definitions) {
+			if nameFunc(item) == local {
+				return item
+			}
+		}
+	}
+	return
+}
+
+func (r *soapTypeResolver) resolveMessageParts(definitionsList []*wsdlDefinitions, messageName string) (parts []*soapPart, err error) {
+	if messageName == "" {
+		return
+	}
+
+	message := findWsdlItem(definitionsList, messageName, func(item *wsdlDefinitions) []*wsdlMessage { return item.Messages },
+		func(item *wsdlMessage) string { return item.Name })
+	if message == nil {
+		err = fmt.Errorf("message [%s] not found", messageName)
+		return
+	}
+
+	definitions := findWsdlDefinitionsOf(definitionsList, message)
+	for _, item := range message.Parts {
+		var part *soapPart
+		if part, err = r.resolvePart(item, definitions); err != nil {
+			return
+		}
+		parts = append(parts, part)
+	}
+	return
+}
+
+func findWsdlDefinitionsOf(definitionsList []*wsdlDefinitions, message *wsdlMessage) *wsdlDefinitions {
+	for _, definitions := range definitionsList {
+		if slices.Contains(definitions.Messages, message) {
+			return definitions
+		}
+	}
+	return definitionsList[0]
+}
+
+// 操作的graphql参数，平铺时为part类型的字段，否则为各个part
+func (o *soapOperation) arguments() []*soapField {
+	if o.flattenInput {
+		return o.inputParts[0].typ.fields
+	}
+
+	fields := make([]*soapField, 0, len(o.inputParts))
+	for _, part := range o.inputParts {
+		fields = append(fields, &soapField{name: part.name, xmlName: xml.Name{Local: part.originName}, typ: part.typ})
+	}
+	return fields
+}
+
+// 生成graphql定义，按照portType中声明的顺序输出操作
+func (s *soapService) buildGraphqlSchema(resolver *soapTypeResolver, portType *wsdlPortType) string {
+	operationFields := make(map[ast.Operation][]string)
+	var jsonRequired bool
+	useType := func(typ *soapType, input bool) string {
+		typ.markUsed(input)
+		jsonRequired = jsonRequired || typ.scalar == soapTypeJson
+		return typ.graphqlName(input)
+	}
+	for _, portTypeOperation := range portType.Operations {
+		operation, ok := s.operations[normalizeSoapName(portTypeOperation.Name)]
+		if !ok {
+			continue
+		}
+
+		var arguments []string
+		for _, field := range operation.arguments() {
+			useType(field.typ, true)
+			arguments = append(arguments, fmt.Sprintf("%s: %s", field.name, field.graphqlType(true)))
+		}
+
+		fieldDefinition := "  " + operation.fieldName
+		if len(arguments) > 0 {
+			fieldDefinition += "(" + strings.Join(arguments, ", ") + ")"
+		}
+		fieldDefinition += ": " + useType(operation.outputType, false)
+		operationFields[operation.operation] = append(operationFields[operation.operation], fieldDefinition)
+	}
+
+	var builder strings.Builder
+	for _, typ := range resolver.types {
+		jsonRequired = jsonRequired || ((typ.outputUsed || typ.inputUsed) && slices.ContainsFunc(typ.fields, func(field *soapField) bool { return field.typ.scalar == soapTypeJson }))
+	}
+	if jsonRequired {
+		builder.WriteString(fmt.Sprintf("scalar %s\n\n", soapTypeJson))
+	}
+	for _, typ := range resolver.types {
+		if typ.enumValues != nil {
+			if typ.outputUsed || typ.inputUsed {
+				builder.WriteString(fmt.Sprintf("enum %s {\n  %s\n}\n\n", typ.name, strings.Join(typ.enumValues, "\n  ")))
+			}
+			continue
+		}
+		if !typ.isObject() {
+			continue
+		}
+
+		for _, input := range []bool{false, true} {
+			if (input && !typ.inputUsed) || (!input && !typ.outputUsed) {
+				continue
+			}
+
+			definitionKind := "type"
+			if input {
+				definitionKind = "input"
+			}
+			builder.WriteString(fmt.Sprintf("%s %s {\n", definitionKind, typ.graphqlName(input)))
+			for _, field := range typ.fields {
+				builder.WriteString(fmt.Sprintf("  %s: %s\n", field.name, field.graphqlType(input)))
+			}
+			builder.WriteString("}\n\n")
+		}
+	}
+	if len(operationFields[ast.Query]) == 0 {
+		operationFields[ast.Query] = append(operationFields[ast.Query], "  "+graphqlPlaceholderField+": String")
+	}
+	for _, operation := range []ast.Operation{ast.Query, ast.Mutation} {
+		if fields := operationFields[operation]; len(fields) > 0 {
+			builder.WriteString(fmt.Sprintf("type %s {\n%s\n}\n\n", rootOperationTypeName(operation), strings.Join(fields, "\n")))
+		}
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// xsd内置类型中仅布尔、32位以内整数和浮点数映射为对应的graphql标量，其他均为字符串
+func xsdBuiltinScalar(local string) string {
+	switch local {
+	case "boolean":
+		return soapTypeBoolean
+	case "int", "short", "byte", "unsignedShort", "unsignedByte":
+		return soapTypeInt
+	case "float", "double":
+		return soapTypeFloat
+	default:
+		return soapTypeString
+	}
+}
+
+func appendSoapField(fields []*soapField, field *soapField) []*soapField {
+	if slices.ContainsFunc(fields, func(item *soapField) bool { return item.name == field.name }) {
+		return fields
+	}
+	return append(fields, field)
+}
+
+// 将名称中不合法的字符替换为下划线
+func normalizeSoapName(name string) string {
+	name = soapInvalidNameRegexp.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
@@ -0,0 +1,122 @@
+package datasource
+
+import (
+	"context"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func loadSoapTestService(t *testing.T) *soapService {
+	loader := newWsdlLoader("testdata/soap")
+	if err := loader.loadDefinitions("testdata/soap/weather.wsdl"); err != nil {
+		t.Fatal(err)
+	}
+
+	service, err := buildSoapService(loader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = gqlparser.LoadSchema(&ast.Source{Input: service.graphqlSchema}); err != nil {
+		t.Fatal(err, service.graphqlSchema)
+	}
+	return service
+}
+
+func TestSoapGraphqlSchema(t *testing.T) {
+	service := loadSoapTestService(t)
+	for _, expected := range []string{
+		"enum Unit {\n  CELSIUS\n  FAHRENHEIT\n}",
+		"type Forecast {\n  date: String\n  temperature: Float\n  unit: Unit\n  summary: String\n  wind_speed: Float\n}",
+		"input StationInput {\n  code: String!\n  station_name: String\n  tags: [String!]\n}",
+		"type Query {\n  GetForecast(city: String!, days: Int, unit: Unit): GetForecastResponse\n}",
+		"type Mutation {\n  UpdateStation(station: StationInput!): UpdateStationResponse\n}",
+	} {
+		if !strings.Contains(service.graphqlSchema, expected) {
+			t.Fatalf("schema missing %q:\n%s", expected, service.graphqlSchema)
+		}
+	}
+	if service.endpoint != "http://localhost:8080/weather" || service.soap12 {
+		t.Fatalf("unexpected endpoint %s", service.endpoint)
+	}
+}
+
+func TestSoapRewriters(t *testing.T) {
+	service := loadSoapTestService(t)
+	formatRewriters := func(rewriters []*wgpb.DataSourceRESTRewriter) (result []string) {
+		for _, item := range rewriters {
+			result = append(result, strings.Join(item.PathComponents, ".")+"->"+item.FieldRewriteTo)
+		}
+		return
+	}
+
+	operation := service.operations["GetForecast"]
+	if body := operation.requestBodyTemplate(); body != `{"city":{{ .arguments.city }},"days":{{ .arguments.days }},"unit":{{ .arguments.unit }}}` {
+		t.Fatalf("unexpected body %s", body)
+	}
+	requestRewriters, responseRewriters := operation.buildRewriters()
+	if len(requestRewriters) != 0 || strings.Join(formatRewriters(responseRewriters), ",") != "forecast.[].wind_speed->wind-speed" {
+		t.Fatalf("unexpected rewriters %v %v", formatRewriters(requestRewriters), formatRewriters(responseRewriters))
+	}
+
+	requestRewriters, responseRewriters = service.operations["UpdateStation"].buildRewriters()
+	if strings.Join(formatRewriters(requestRewriters), ",") != "body.station.station_name->station-name" || len(responseRewriters) != 0 {
+		t.Fatalf("unexpected rewriters %v %v", formatRewriters(requestRewriters), formatRewriters(responseRewriters))
+	}
+}
+
+func TestSoapExecutor(t *testing.T) {
+	service := loadSoapTestService(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		switch r.Header.Get("SOAPAction") {
+		case `"http://example.com/weather/GetForecast"`:
+			if !strings.Contains(string(body), `<ns0:GetForecast><ns0:city>Hangzhou &amp; Suzhou</ns0:city><ns0:unit>CELSIUS</ns0:unit></ns0:GetForecast>`) {
+				t.Errorf("unexpected request %s", body)
+			}
+			_, _ = io.WriteString(w, `<?xml version="1.0"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <s:Body>
+    <GetForecastResponse xmlns="http://example.com/weather">
+      <forecast unit="CELSIUS"><date>2024-01-02</date><temperature>21.5</temperature><summary>sunny</summary><wind-speed>3</wind-speed></forecast>
+      <forecast unit="CELSIUS"><date>2024-01-03</date><temperature>18</temperature><summary xsi:nil="true"/></forecast>
+    </GetForecastResponse>
+  </s:Body>
+</s:Envelope>`)
+		default:
+			if !strings.Contains(string(body), `<ns0:station><ns0:code>x</ns0:code><ns0:station-name>west</ns0:station-name></ns0:station>`) {
+				t.Errorf("unexpected request %s", body)
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = io.WriteString(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault>
+  <faultcode>s:Client</faultcode><faultstring>station not found</faultstring>
+</s:Fault></s:Body></s:Envelope>`)
+		}
+	}))
+	defer server.Close()
+
+	executor := &soapExecutor{endpoint: server.URL, service: service}
+	data, err := executor.execute(context.Background(), "GetForecast", []byte(`{"city":"Hangzhou & Suzhou","days":null,"unit":"CELSIUS"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`"date":"2024-01-02"`, `"temperature":21.5`, `"unit":"CELSIUS"`, `"summary":"sunny"`, `"wind-speed":3`,
+		`"summary":null`, `"wind-speed":null`,
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Fatalf("response missing %s: %s", expected, data)
+		}
+	}
+
+	_, err = executor.execute(context.Background(), "UpdateStation", []byte(`{"station":{"code":"x","station-name":"west"}}`))
+	if err == nil || err.Error() != "soap fault s:Client: station not found" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
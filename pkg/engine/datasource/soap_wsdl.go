@@ -0,0 +1,353 @@
+package datasource
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	xsdNamespace     = "http://www.w3.org/2001/XMLSchema"
+	xsiNamespace     = "http://www.w3.org/2001/XMLSchema-instance"
+	wsdlSoap11       = "http://schemas.xmlsoap.org/wsdl/soap/"
+	wsdlSoap12       = "http://schemas.xmlsoap.org/wsdl/soap12/"
+	xmlnsAttrSpace   = "xmlns"
+	xsdFormQualified = "qualified"
+	xsdUnbounded     = "unbounded"
+)
+
+type (
+	wsdlDefinitions struct {
+		TargetNamespace string            `xml:"targetNamespace,attr"`
+		Attrs           []xml.Attr        `xml:",any,attr"`
+		Imports         []*wsdlImport     `xml:"import"`
+		Schemas         []*xsdSchema      `xml:"types>schema"`
+		Messages        []*wsdlMessage    `xml:"message"`
+		PortTypes       []*wsdlPortType   `xml:"portType"`
+		Bindings        []*wsdlBinding    `xml:"binding"`
+		Services        []*wsdlService    `xml:"service"`
+		namespaces      map[string]string // 前缀与命名空间的映射
+	}
+	wsdlImport struct {
+		Namespace string `xml:"namespace,attr"`
+		Location  string `xml:"location,attr"`
+	}
+	wsdlMessage struct {
+		Name  string      `xml:"name,attr"`
+		Parts []*wsdlPart `xml:"part"`
+	}
+	wsdlPart struct {
+		Name    string `xml:"name,attr"`
+		Element string `xml:"element,attr"`
+		Type    string `xml:"type,attr"`
+	}
+	wsdlPortType struct {
+		Name       string                   `xml:"name,attr"`
+		Operations []*wsdlPortTypeOperation `xml:"operation"`
+	}
+	wsdlPortTypeOperation struct {
+		Name          string `xml:"name,attr"`
+		Documentation string `xml:"documentation"`
+		Input         struct {
+			Message string `xml:"message,attr"`
+		} `xml:"input"`
+		Output struct {
+			Message string `xml:"message,attr"`
+		} `xml:"output"`
+	}
+	wsdlBinding struct {
+		Name        string `xml:"name,attr"`
+		Type        string `xml:"type,attr"`
+		SoapBinding struct {
+			XMLName xml.Name
+			Style   string `xml:"style,attr"`
+		} `xml:"binding"`
+		Operations []*wsdlBindingOperation `xml:"operation"`
+	}
+	wsdlBindingOperation struct {
+		Name          string `xml:"name,attr"`
+		SoapOperation struct {
+			SoapAction string `xml:"soapAction,attr"`
+			Style      string `xml:"style,attr"`
+		} `xml:"operation"`
+		InputBody struct {
+			Namespace string `xml:"namespace,attr"`
+		} `xml:"input>body"`
+	}
+	wsdlService struct {
+		Name  string `xml:"name,attr"`
+		Ports []*struct {
+			Name    string `xml:"name,attr"`
+			Binding string `xml:"binding,attr"`
+			Address struct {
+				XMLName  xml.Name
+				Location string `xml:"location,attr"`
+			} `xml:"address"`
+		} `xml:"port"`
+	}
+
+	xsdSchema struct {
+		TargetNamespace    string            `xml:"targetNamespace,attr"`
+		ElementFormDefault string            `xml:"elementFormDefault,attr"`
+		Attrs              []xml.Attr        `xml:",any,attr"`
+		Imports            []*xsdImport      `xml:"import"`
+		Includes           []*xsdImport      `xml:"include"`
+		Elements           []*xsdElement     `xml:"element"`
+		ComplexTypes       []*xsdComplexType `xml:"complexType"`
+		SimpleTypes        []*xsdSimpleType  `xml:"simpleType"`
+		namespaces         map[string]string
+	}
+	xsdImport struct {
+		Namespace      string `xml:"namespace,attr"`
+		SchemaLocation string `xml:"schemaLocation,attr"`
+	}
+	xsdElement struct {
+		Name        string          `xml:"name,attr"`
+		Type        string          `xml:"type,attr"`
+		Ref         string          `xml:"ref,attr"`
+		Form        string          `xml:"form,attr"`
+		MinOccurs   string          `xml:"minOccurs,attr"`
+		MaxOccurs   string          `xml:"maxOccurs,attr"`
+		Nillable    bool            `xml:"nillable,attr"`
+		ComplexType *xsdComplexType `xml:"complexType"`
+		SimpleType  *xsdSimpleType  `xml:"simpleType"`
+	}
+	xsdAttribute struct {
+		Name string `xml:"name,attr"`
+		Type string `xml:"type,attr"`
+		Use  string `xml:"use,attr"`
+	}
+	xsdComplexType struct {
+		Name           string          `xml:"name,attr"`
+		Sequence       *xsdGroup       `xml:"sequence"`
+		All            *xsdGroup       `xml:"all"`
+		Choice         *xsdGroup       `xml:"choice"`
+		Attributes     []*xsdAttribute `xml:"attribute"`
+		ComplexContent *struct {
+			Extension   *xsdExtension `xml:"extension"`
+			Restriction *xsdExtension `xml:"restriction"`
+		} `xml:"complexContent"`
+		SimpleContent *struct {
+			Extension *xsdExtension `xml:"extension"`
+		} `xml:"simpleContent"`
+	}
+	xsdExtension struct {
+		Base       string          `xml:"base,attr"`
+		Sequence   *xsdGroup       `xml:"sequence"`
+		All        *xsdGroup       `xml:"all"`
+		Choice     *xsdGroup       `xml:"choice"`
+		Attributes []*xsdAttribute `xml:"attribute"`
+	}
+	xsdSimpleType struct {
+		Name        string `xml:"name,attr"`
+		Restriction *struct {
+			Base         string `xml:"base,attr"`
+			Enumerations []*struct {
+				Value string `xml:"value,attr"`
+			} `xml:"enumeration"`
+		} `xml:"restriction"`
+	}
+	// xsdGroup sequence/all/choice，按照声明顺序保存元素和嵌套的分组
+	xsdGroup struct {
+		choice    bool
+		minOccurs string
+		maxOccurs string
+		particles []any // *xsdElement或*xsdGroup
+	}
+	// 加载wsdl及其导入的wsdl和xsd文件，导入路径必须是上传目录内的相对路径
+	wsdlLoader struct {
+		rootDir       string
+		files         map[string]time.Time
+		definitions   []*wsdlDefinitions
+		schemas       []*xsdSchema
+		loadedSchemas map[string]bool
+	}
+)
+
+func (g *xsdGroup) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) error {
+	g.choice = start.Name.Local == "choice"
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "minOccurs":
+			g.minOccurs = attr.Value
+		case "maxOccurs":
+			g.maxOccurs = attr.Value
+		}
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		switch item := token.(type) {
+		case xml.StartElement:
+			switch item.Name.Local {
+			case "element":
+				element := &xsdElement{}
+				if err = decoder.DecodeElement(element, &item); err != nil {
+					return err
+				}
+				g.particles = append(g.particles, element)
+			case "sequence", "choice", "all":
+				group := &xsdGroup{}
+				if err = decoder.DecodeElement(group, &item); err != nil {
+					return err
+				}
+				g.particles = append(g.particles, group)
+			default:
+				if err = decoder.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func (g *xsdGroup) multiple() bool {
+	return g.maxOccurs == xsdUnbounded || (g.maxOccurs != "" && g.maxOccurs != "0" && g.maxOccurs != "1")
+}
+
+func (e *xsdElement) multiple() bool {
+	return e.MaxOccurs == xsdUnbounded || (e.MaxOccurs != "" && e.MaxOccurs != "0" && e.MaxOccurs != "1")
+}
+
+// 解析带前缀的名称，无前缀时使用默认命名空间，未声明默认命名空间时使用目标命名空间
+func resolveXmlQName(namespaces map[string]string, targetNamespace, qname string) xml.Name {
+	prefix, local, ok := strings.Cut(qname, ":")
+	if !ok {
+		local, prefix = prefix, ""
+	}
+	if namespace, found := namespaces[prefix]; found {
+		return xml.Name{Space: namespace, Local: local}
+	}
+	if prefix == "" {
+		return xml.Name{Space: targetNamespace, Local: local}
+	}
+	return xml.Name{Local: local}
+}
+
+// 收集xmlns声明，子元素未声明的前缀从父元素继承
+func collectXmlNamespaces(attrs []xml.Attr, parent map[string]string) map[string]string {
+	namespaces := make(map[string]string, len(parent))
+	for prefix, namespace := range parent {
+		namespaces[prefix] = namespace
+	}
+	for _, attr := range attrs {
+		switch {
+		case attr.Name.Space == xmlnsAttrSpace:
+			namespaces[attr.Name.Local] = attr.Value
+		case attr.Name.Space == "" && attr.Name.Local == xmlnsAttrSpace:
+			namespaces[""] = attr.Value
+		}
+	}
+	return namespaces
+}
+
+func newWsdlLoader(rootDir string) *wsdlLoader {
+	return &wsdlLoader{rootDir: rootDir, files: make(map[string]time.Time), loadedSchemas: make(map[string]bool)}
+}
+
+func (l *wsdlLoader) readFile(path string) ([]byte, error) {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	l.files[path] = fileInfo.ModTime()
+	return os.ReadFile(path)
+}
+
+// 导入路径相对于当前文件所在目录，且不能超出上传目录
+func (l *wsdlLoader) resolveLocation(baseDir, location string) (string, error) {
+	if strings.Contains(location, "://") {
+		return "", fmt.Errorf("remote location [%s] not supported, upload it alongside the wsdl", location)
+	}
+
+	path := filepath.Join(baseDir, location)
+	if relPath, err := filepath.Rel(l.rootDir, path); err != nil || strings.HasPrefix(relPath, "..") {
+		return "", fmt.Errorf("location [%s] outside upload directory", location)
+	}
+	return path, nil
+}
+
+func (l *wsdlLoader) loadDefinitions(path string) error {
+	if _, ok := l.files[path]; ok {
+		return nil
+	}
+
+	content, err := l.readFile(path)
+	if err != nil {
+		return err
+	}
+
+	definitions := &wsdlDefinitions{}
+	if err = xml.Unmarshal(content, definitions); err != nil {
+		return err
+	}
+
+	l.definitions = append(l.definitions, definitions)
+	definitions.namespaces = collectXmlNamespaces(definitions.Attrs, nil)
+	baseDir := filepath.Dir(path)
+	for _, schema := range definitions.Schemas {
+		if err = l.addSchema(schema, definitions.namespaces, baseDir); err != nil {
+			return err
+		}
+	}
+	for _, item := range definitions.Imports {
+		if item.Location == "" {
+			continue
+		}
+
+		importPath, err := l.resolveLocation(baseDir, item.Location)
+		if err != nil {
+			return err
+		}
+		if err = l.loadDefinitions(importPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *wsdlLoader) addSchema(schema *xsdSchema, parentNamespaces map[string]string, baseDir string) error {
+	schema.namespaces = collectXmlNamespaces(schema.Attrs, parentNamespaces)
+	l.schemas = append(l.schemas, schema)
+	for _, item := range append(schema.Imports, schema.Includes...) {
+		if item.SchemaLocation == "" {
+			continue
+		}
+
+		schemaPath, err := l.resolveLocation(baseDir, item.SchemaLocation)
+		if err != nil {
+			return err
+		}
+		if l.loadedSchemas[schemaPath] {
+			continue
+		}
+
+		l.loadedSchemas[schemaPath] = true
+		content, err := l.readFile(schemaPath)
+		if err != nil {
+			return err
+		}
+
+		importedSchema := &xsdSchema{}
+		if err = xml.Unmarshal(content, importedSchema); err != nil {
+			return err
+		}
+		// include的文件没有目标命名空间时使用当前的目标命名空间
+		if importedSchema.TargetNamespace == "" && item.Namespace == "" {
+			importedSchema.TargetNamespace = schema.TargetNamespace
+		}
+		if err = l.addSchema(importedSchema, nil, filepath.Dir(schemaPath)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
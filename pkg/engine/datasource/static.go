@@ -0,0 +1,588 @@
+// Package datasource
+/*
+ 静态文件类型数据源的实现
+ 读取上传的json或csv文件，json为对象数组时作为单表，为值是对象数组的对象时每个key作为一张表，csv首行为列名
+ 按照列值推断类型(Int/Float/Boolean/String/JSON)，为每张表生成findMany/findUnique/count查询
+ 运行时作为graphql数据源请求飞布服务上的静态数据查询接口，文件变更后自动重新加载数据
+*/
+package datasource
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/plugins/fileloader"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	json "github.com/json-iterator/go"
+	"github.com/tidwall/gjson"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/wundergraph/wundergraph/pkg/eventbus"
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	actionMap[wgpb.DataSourceKind_STATIC] = func(ds *models.Datasource, _ string) Action { return &actionStatic{ds: ds} }
+	utils.RegisterInitMethod(40, func() {
+		models.DatasourceRoot.AddRemoveAction(func(dataName string) error {
+			stopStaticFileWatcher(dataName)
+			return nil
+		})
+		models.DatasourceRoot.AddRenameAction(func(srcDataName, _ string) error {
+			stopStaticFileWatcher(srcDataName)
+			return nil
+		})
+	})
+}
+
+const (
+	staticGraphqlPathFormat = "/static/%s/graphql"
+	staticFileWatchDebounce = 300 * time.Millisecond
+
+	staticTypeInt     = "Int"
+	staticTypeFloat   = "Float"
+	staticTypeBoolean = "Boolean"
+	staticTypeString  = "String"
+	staticTypeJson    = "JSON"
+
+	staticFindManyPrefix   = "findMany"
+	staticFindUniquePrefix = "findUnique"
+	staticCountPrefix      = "count"
+)
+
+// StaticGraphqlRoutePath 飞布服务上静态数据查询接口的路由
+var StaticGraphqlRoutePath = fmt.Sprintf(staticGraphqlPathFormat, ":"+consts.PathParamDataName)
+
+var (
+	staticDatasets          utils.SyncMap[string, *staticDataset]
+	staticFileWatchers      utils.SyncMap[string, *staticFileWatcher]
+	staticInvalidNameRegexp = regexp.MustCompile(`[^A-Za-z0-9_]`)
+	staticReservedTypeNames = []string{consts.TypeQuery, consts.TypeMutation, consts.TypeSubscription,
+		staticTypeInt, staticTypeFloat, staticTypeBoolean, staticTypeString, staticTypeJson, "ID", "SortOrder"}
+	staticFilterOperators = map[string][]string{
+		staticTypeInt:     {"", "not", "in", "gt", "gte", "lt", "lte"},
+		staticTypeFloat:   {"", "not", "in", "gt", "gte", "lt", "lte"},
+		staticTypeString:  {"", "not", "in", "contains", "startsWith", "endsWith"},
+		staticTypeBoolean: {"", "not"},
+	}
+)
+
+type (
+	actionStatic struct {
+		ds *models.Datasource
+	}
+	staticFileWatcher struct {
+		filepath string
+		watcher  *fsnotify.Watcher
+	}
+	staticDataset struct {
+		filepath      string
+		modTime       time.Time
+		tables        []*staticTable
+		graphqlSchema string
+		schema        *ast.Schema
+	}
+	staticTable struct {
+		name      string
+		typeName  string
+		columns   []*staticColumn
+		keyColumn *staticColumn
+		filters   []*staticFilter
+		rows      []map[string]any // key为列的字段名称
+	}
+	staticColumn struct {
+		name      string
+		fieldName string
+		typeName  string
+		required  bool
+	}
+	staticFilter struct {
+		name     string
+		column   *staticColumn
+		operator string
+	}
+)
+
+func (a *actionStatic) Introspect() (graphqlSchema string, err error) {
+	dataset, err := loadStaticDataset(a.ds)
+	if err != nil {
+		return
+	}
+
+	graphqlSchema = dataset.graphqlSchema
+	cacheGraphqlSchema(a.ds.Name, graphqlSchema)
+	return
+}
+
+// BuildDataSourceConfiguration 服务地址在运行时确定，编译时无需额外配置
+func (a *actionStatic) BuildDataSourceConfiguration(*ast.SchemaDocument) (*wgpb.DataSourceConfiguration, error) {
+	return &wgpb.DataSourceConfiguration{}, nil
+}
+
+// RuntimeDataSourceConfiguration 转换成请求飞布服务静态数据查询接口的graphql数据源
+func (a *actionStatic) RuntimeDataSourceConfiguration(config *wgpb.DataSourceConfiguration) (configs []*wgpb.DataSourceConfiguration, fields []*wgpb.FieldConfiguration, err error) {
+	graphqlSchema, err := CacheGraphqlSchemaText.Read(config.Id)
+	if err != nil {
+		return
+	}
+
+	customGraphql := &wgpb.DataSourceCustom_GraphQL{
+		Fetch:          buildInternalFetch(wgpb.HTTPMethod_POST, nil, staticGraphqlPathFormat, config.Id),
+		Subscription:   &wgpb.GraphQLSubscriptionConfiguration{},
+		Federation:     &wgpb.GraphQLFederationConfiguration{},
+		UpstreamSchema: graphqlSchema,
+	}
+	configs, fields = copyDatasourceWithRootNodes(config, func(_ *wgpb.TypeField, configItem *wgpb.DataSourceConfiguration) bool {
+		configItem.Kind = wgpb.DataSourceKind_GRAPHQL
+		configItem.CustomGraphql = customGraphql
+		return true
+	})
+	return
+}
+
+// 读取静态数据，文件未变更时使用缓存
+func loadStaticDataset(ds *models.Datasource) (dataset *staticDataset, err error) {
+	if ds.CustomStatic == nil {
+		err = i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.StructParamEmtpyError, "customStatic")
+		return
+	}
+
+	staticFilepath := models.DatasourceUploadStatic.GetPath(ds.Name)
+	fileInfo, err := os.Stat(staticFilepath)
+	if err != nil {
+		return
+	}
+
+	dataset, ok := staticDatasets.Load(ds.Name)
+	if ok && dataset.filepath == staticFilepath && dataset.modTime.Equal(fileInfo.ModTime()) {
+		return
+	}
+
+	content, err := utils.ReadFileAsUTF8(staticFilepath)
+	if err == nil {
+		dataset, err = buildStaticDataset(ds, staticFilepath, content)
+	}
+	if err != nil {
+		err = i18n.NewCustomErrorWithMode(datasourceModelName, err, i18n.DatasourceStaticParseError, filepath.Base(staticFilepath))
+		return
+	}
+
+	dataset.modTime = fileInfo.ModTime()
+	staticDatasets.Store(ds.Name, dataset)
+	watchStaticFile(ds.Name, staticFilepath)
+	return
+}
+
+// 解析静态文件内容，推断表结构并生成graphql schema
+func buildStaticDataset(ds *models.Datasource, staticFilepath string, content []byte) (dataset *staticDataset, err error) {
+	dataset = &staticDataset{filepath: staticFilepath}
+	tableName := strings.TrimSuffix(filepath.Base(staticFilepath), filepath.Ext(staticFilepath))
+	if fileloader.Extension(filepath.Ext(staticFilepath)) == fileloader.ExtCsv {
+		var table *staticTable
+		if table, err = parseStaticCsvTable(tableName, content); err != nil {
+			return
+		}
+		dataset.tables = append(dataset.tables, table)
+	} else if dataset.tables, err = parseStaticJsonTables(tableName, content); err != nil {
+		return
+	}
+
+	typeNames := make(map[string]bool)
+	for _, table := range dataset.tables {
+		table.typeName = normalizeStaticName(table.name, true)
+		for typeNames[table.typeName] || slices.Contains(staticReservedTypeNames, table.typeName) {
+			table.typeName += "_"
+		}
+		typeNames[table.typeName] = true
+		table.resolveKeyColumn(ds.CustomStatic.KeyFields[table.name])
+		table.resolveFilters()
+	}
+
+	dataset.graphqlSchema = buildStaticGraphqlSchema(dataset.tables)
+	dataset.schema, err = gqlparser.LoadSchema(&ast.Source{Name: ds.Name, Input: dataset.graphqlSchema})
+	return
+}
+
+// json对象数组作为单表，值为对象数组的对象按照key生成多张表，列按照首次出现的顺序
+func parseStaticJsonTables(tableName string, content []byte) (tables []*staticTable, err error) {
+	if !gjson.ValidBytes(content) {
+		err = errors.New("invalid json content")
+		return
+	}
+
+	parseTable := func(name string, result gjson.Result) {
+		table := &staticTable{name: name}
+		columnIndexes := make(map[string]int)
+		var rawRows []map[string]gjson.Result
+		result.ForEach(func(_, item gjson.Result) bool {
+			if !item.IsObject() {
+				return true
+			}
+			rawRow := make(map[string]gjson.Result)
+			item.ForEach(func(key, value gjson.Result) bool {
+				if _, ok := columnIndexes[key.String()]; !ok {
+					columnIndexes[key.String()] = len(table.columns)
+					table.columns = append(table.columns, &staticColumn{name: key.String()})
+				}
+				rawRow[key.String()] = value
+				return true
+			})
+			rawRows = append(rawRows, rawRow)
+			return true
+		})
+		table.resolveColumns(len(rawRows), func(column *staticColumn, yield func(any)) {
+			for _, rawRow := range rawRows {
+				value, ok := rawRow[column.name]
+				if !ok || value.Type == gjson.Null {
+					yield(nil)
+					continue
+				}
+				switch value.Type {
+				case gjson.Number:
+					yield(json.Number(value.Raw))
+				case gjson.String:
+					yield(value.Str)
+				case gjson.True, gjson.False:
+					yield(value.Bool())
+				default:
+					yield(value.Value())
+				}
+			}
+		})
+		tables = append(tables, table)
+	}
+
+	result := gjson.ParseBytes(content)
+	switch {
+	case result.IsArray():
+		parseTable(tableName, result)
+	case result.IsObject():
+		result.ForEach(func(key, value gjson.Result) bool {
+			if value.IsArray() {
+				parseTable(key.String(), value)
+			}
+			return true
+		})
+	}
+	if len(tables) == 0 {
+		err = errors.New("json content must be array of objects or object with array values")
+	}
+	return
+}
+
+func parseStaticCsvTable(tableName string, content []byte) (table *staticTable, err error) {
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return
+	}
+	if len(records) == 0 {
+		err = errors.New("csv header required")
+		return
+	}
+
+	table = &staticTable{name: tableName}
+	for _, name := range records[0] {
+		table.columns = append(table.columns, &staticColumn{name: strings.TrimSpace(name)})
+	}
+	records = records[1:]
+	table.resolveColumns(len(records), func(column *staticColumn, yield func(any)) {
+		index := slices.Index(table.columns, column)
+		for _, record := range records {
+			if index >= len(record) || record[index] == "" {
+				yield(nil)
+				continue
+			}
+			yield(record[index])
+		}
+	})
+	return
+}
+
+// 推断列类型并将值转换成对应类型写入行数据，iterate按照行的顺序返回列的原始值
+func (t *staticTable) resolveColumns(rowCount int, iterate func(*staticColumn, func(any))) {
+	t.rows = make([]map[string]any, rowCount)
+	for i := range t.rows {
+		t.rows[i] = make(map[string]any)
+	}
+
+	fieldNames := make(map[string]bool)
+	for _, column := range t.columns {
+		column.fieldName = normalizeStaticName(column.name, false)
+		for fieldNames[column.fieldName] {
+			column.fieldName += "_"
+		}
+		fieldNames[column.fieldName] = true
+
+		var values []any
+		iterate(column, func(value any) { values = append(values, value) })
+		column.typeName = inferStaticColumnType(values)
+		column.required = rowCount > 0 && !slices.Contains(values, nil)
+		for i, value := range values {
+			t.rows[i][column.fieldName] = convertStaticValue(column.typeName, value)
+		}
+	}
+}
+
+// 所有值类型一致时使用该类型，整数与浮点数混合时为Float，包含对象或数组时为JSON，其他混合情况为String
+func inferStaticColumnType(values []any) string {
+	var typeName string
+	for _, value := range values {
+		if value == nil {
+			continue
+		}
+
+		var valueType string
+		switch v := value.(type) {
+		case bool:
+			valueType = staticTypeBoolean
+		case json.Number:
+			valueType = inferStaticNumberType(string(v))
+		case string:
+			if valueType = inferStaticNumberType(v); valueType == "" {
+				valueType = staticTypeString
+				if strings.EqualFold(v, "true") || strings.EqualFold(v, "false") {
+					valueType = staticTypeBoolean
+				}
+			}
+		default:
+			return staticTypeJson
+		}
+
+		switch {
+		case typeName == "" || typeName == valueType:
+			typeName = valueType
+		case slices.Contains([]string{staticTypeInt, staticTypeFloat}, typeName) && slices.Contains([]string{staticTypeInt, staticTypeFloat}, valueType):
+			typeName = staticTypeFloat
+		default:
+			typeName = staticTypeString
+		}
+	}
+	if typeName == "" {
+		typeName = staticTypeString
+	}
+	return typeName
+}
+
+// graphql中Int为32位整数，超出范围时使用Float
+func inferStaticNumberType(text string) string {
+	if intValue, err := strconv.ParseInt(text, 10, 64); err == nil {
+		if intValue >= math.MinInt32 && intValue <= math.MaxInt32 {
+			return staticTypeInt
+		}
+		return staticTypeFloat
+	}
+	if _, err := strconv.ParseFloat(text, 64); err == nil {
+		return staticTypeFloat
+	}
+	return ""
+}
+
+func convertStaticValue(typeName string, value any) any {
+	if value == nil {
+		return nil
+	}
+
+	text := fmt.Sprintf("%v", value)
+	switch typeName {
+	case staticTypeInt:
+		intValue, _ := strconv.ParseInt(text, 10, 64)
+		return intValue
+	case staticTypeFloat:
+		floatValue, _ := strconv.ParseFloat(text, 64)
+		return floatValue
+	case staticTypeBoolean:
+		boolValue, _ := strconv.ParseBool(strings.ToLower(text))
+		return boolValue
+	case staticTypeJson:
+		return value
+	default:
+		if jsonValue, ok := value.(json.Number); ok {
+			return string(jsonValue)
+		}
+		return text
+	}
+}
+
+// 按键查询的列必须所有行都有值，未配置时使用id列
+func (t *staticTable) resolveKeyColumn(keyField string) {
+	if keyField == "" {
+		keyField = "id"
+	}
+	index := slices.IndexFunc(t.columns, func(item *staticColumn) bool {
+		return (item.name == keyField || item.fieldName == keyField) && item.required && item.typeName != staticTypeJson
+	})
+	if index != -1 {
+		t.keyColumn = t.columns[index]
+	}
+}
+
+func (t *staticTable) resolveFilters() {
+	for _, column := range t.columns {
+		for _, operator := range staticFilterOperators[column.typeName] {
+			filter := &staticFilter{name: column.fieldName, column: column, operator: operator}
+			if operator != "" {
+				filter.name += "_" + operator
+			}
+			t.filters = append(t.filters, filter)
+		}
+	}
+}
+
+func buildStaticGraphqlSchema(tables []*staticTable) string {
+	var builder strings.Builder
+	if slices.ContainsFunc(tables, func(table *staticTable) bool {
+		return slices.ContainsFunc(table.columns, func(column *staticColumn) bool { return column.typeName == staticTypeJson })
+	}) {
+		builder.WriteString(fmt.Sprintf("scalar %s\n\n", staticTypeJson))
+	}
+	builder.WriteString("enum SortOrder {\n  asc\n  desc\n}\n\n")
+
+	var queryFields []string
+	for _, table := range tables {
+		builder.WriteString(fmt.Sprintf("type %s {\n", table.typeName))
+		for _, column := range table.columns {
+			builder.WriteString(fmt.Sprintf("  %s: %s", column.fieldName, column.typeName))
+			if column.required {
+				builder.WriteString("!")
+			}
+			builder.WriteString("\n")
+		}
+		builder.WriteString("}\n\n")
+
+		whereName, orderByName := table.typeName+"WhereInput", table.typeName+"OrderByInput"
+		builder.WriteString(fmt.Sprintf("input %s {\n", whereName))
+		for _, filter := range table.filters {
+			filterType := filter.column.typeName
+			if filter.operator == "in" {
+				filterType = fmt.Sprintf("[%s!]", filterType)
+			}
+			builder.WriteString(fmt.Sprintf("  %s: %s\n", filter.name, filterType))
+		}
+		builder.WriteString("}\n\n")
+
+		builder.WriteString(fmt.Sprintf("input %s {\n", orderByName))
+		for _, column := range table.columns {
+			if column.typeName != staticTypeJson {
+				builder.WriteString(fmt.Sprintf("  %s: SortOrder\n", column.fieldName))
+			}
+		}
+		builder.WriteString("}\n\n")
+
+		queryFields = append(queryFields, fmt.Sprintf("  %s%s(where: %s, orderBy: [%s!], skip: Int, take: Int): [%s!]!",
+			staticFindManyPrefix, table.typeName, whereName, orderByName, table.typeName))
+		if table.keyColumn != nil {
+			queryFields = append(queryFields, fmt.Sprintf("  %s%s(%s: %s!): %s",
+				staticFindUniquePrefix, table.typeName, table.keyColumn.fieldName, table.keyColumn.typeName, table.typeName))
+		}
+		queryFields = append(queryFields, fmt.Sprintf("  %s%s(where: %s): Int!", staticCountPrefix, table.typeName, whereName))
+	}
+	builder.WriteString(fmt.Sprintf("type %s {\n%s\n}\n", consts.TypeQuery, strings.Join(queryFields, "\n")))
+	return builder.String()
+}
+
+// 将名称中不合法的字符替换为下划线，类型名称首字母大写
+func normalizeStaticName(name string, typeName bool) string {
+	name = strings.TrimLeft(staticInvalidNameRegexp.ReplaceAllString(name, "_"), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	if typeName {
+		name = strings.ToUpper(name[:1]) + name[1:]
+	}
+	return name
+}
+
+// 开发模式下监听静态文件所在目录，文件变更(包括编辑器以重命名方式保存)后重新加载
+// 数据源被删除、重命名或不再是静态数据源时停止监听
+func watchStaticFile(dsName, staticFilepath string) {
+	if !utils.GetBoolWithLockViper(consts.DevMode) {
+		return
+	}
+
+	staticFilepath = filepath.Clean(staticFilepath)
+	if existed, ok := staticFileWatchers.Load(dsName); ok {
+		if existed.filepath == staticFilepath {
+			return
+		}
+		stopStaticFileWatcher(dsName)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logger.Warn("watch static file failed", zap.Error(err), zap.String(datasourceModelName, dsName))
+		return
+	}
+	if err = watcher.Add(filepath.Dir(staticFilepath)); err != nil {
+		_ = watcher.Close()
+		logger.Warn("watch static file failed", zap.Error(err), zap.String(datasourceModelName, dsName))
+		return
+	}
+	item := &staticFileWatcher{filepath: staticFilepath, watcher: watcher}
+	// 并发加载时已由其他协程开始监听
+	if _, loaded := staticFileWatchers.LoadOrStore(dsName, item); loaded {
+		_ = watcher.Close()
+		return
+	}
+
+	go func() {
+		var debounceTimer *time.Timer
+		for event := range watcher.Events {
+			if filepath.Clean(event.Name) != staticFilepath || event.Has(fsnotify.Chmod) {
+				continue
+			}
+
+			if debounceTimer == nil {
+				debounceTimer = time.AfterFunc(staticFileWatchDebounce, func() { reloadStaticDataset(dsName) })
+			} else {
+				debounceTimer.Reset(staticFileWatchDebounce)
+			}
+		}
+		if debounceTimer != nil {
+			debounceTimer.Stop()
+		}
+	}()
+}
+
+func stopStaticFileWatcher(dsName string) {
+	staticDatasets.Delete(dsName)
+	if item, ok := staticFileWatchers.LoadAndDelete(dsName); ok {
+		_ = item.(*staticFileWatcher).watcher.Close()
+	}
+}
+
+// 重新加载静态数据，生成的schema变化时触发增量编译
+func reloadStaticDataset(dsName string) {
+	ds, err := models.DatasourceRoot.GetByDataName(dsName)
+	if err != nil || !ds.Enabled || ds.Kind != wgpb.DataSourceKind_STATIC {
+		stopStaticFileWatcher(dsName)
+		return
+	}
+	if !utils.EngineStarted() {
+		return
+	}
+
+	reloaded, err := loadStaticDataset(ds)
+	if err != nil {
+		logger.Warn("reload static datasource failed", zap.Error(err), zap.String(datasourceModelName, dsName))
+		return
+	}
+	if cachedSchema, _ := CacheGraphqlSchemaText.Read(dsName); cachedSchema != reloaded.graphqlSchema {
+		if !eventbus.Publish(eventbus.ChannelDatasource, eventbus.EventUpdate, ds) && utils.BuildAndStart != nil {
+			utils.BuildAndStart()
+		}
+	}
+}
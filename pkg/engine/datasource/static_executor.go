@@ -0,0 +1,283 @@
+package datasource
+
+import (
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	json "github.com/json-iterator/go"
+	"github.com/spf13/cast"
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/exp/slices"
+	"strings"
+)
+
+const (
+	staticArgumentWhere   = "where"
+	staticArgumentOrderBy = "orderBy"
+	staticArgumentSkip    = "skip"
+	staticArgumentTake    = "take"
+	staticSortDesc        = "desc"
+)
+
+type staticExecutor struct {
+	dataset   *staticDataset
+	variables map[string]any
+}
+
+// ExecuteStaticGraphql 在静态数据源上执行graphql查询，返回标准的graphql响应
+func ExecuteStaticGraphql(dsName string, body []byte) []byte {
+	data, err := executeStaticGraphql(dsName, body)
+	return marshalGraphqlResponse(data, err)
+}
+
+func executeStaticGraphql(dsName string, body []byte) (data graphqlObject, err error) {
+	ds, err := models.DatasourceRoot.GetByDataName(dsName)
+	if err != nil {
+		return
+	}
+
+	dataset, err := loadStaticDataset(ds)
+	if err != nil {
+		return
+	}
+
+	return executeStaticDataset(dataset, body)
+}
+
+func executeStaticDataset(dataset *staticDataset, body []byte) (data graphqlObject, err error) {
+	var request graphqlExecuteRequest
+	if err = json.Unmarshal(body, &request); err != nil {
+		return
+	}
+
+	operation, variables, err := parseGraphqlOperation(dataset.schema, &request)
+	if err != nil {
+		return
+	}
+	if operation.Operation != ast.Query {
+		err = i18n.NewCustomErrorWithMode(datasourceModelName, nil, i18n.DatasourceStaticOperationNotSupportedError, operation.Operation)
+		return
+	}
+
+	executor := &staticExecutor{dataset: dataset, variables: variables}
+	return executor.resolveQuery(operation.SelectionSet)
+}
+
+func (e *staticExecutor) resolveQuery(selectionSet ast.SelectionSet) (result graphqlObject, err error) {
+	for _, field := range collectGraphqlFields(selectionSet, e.variables) {
+		var value any = consts.TypeQuery
+		if field.Name != graphqlTypenameField {
+			if value, err = e.resolveRootField(field); err != nil {
+				return
+			}
+		}
+		result = append(result, graphqlObjectField{key: field.Alias, value: value})
+	}
+	return
+}
+
+func (e *staticExecutor) resolveRootField(field *ast.Field) (any, error) {
+	arguments := field.ArgumentMap(e.variables)
+	for _, table := range e.dataset.tables {
+		switch field.Name {
+		case staticFindManyPrefix + table.typeName:
+			rows := table.filterRows(arguments[staticArgumentWhere])
+			sortStaticRows(rows, arguments[staticArgumentOrderBy])
+			rows = paginateStaticRows(rows, arguments[staticArgumentSkip], arguments[staticArgumentTake])
+			items := make([]any, 0, len(rows))
+			for _, row := range rows {
+				items = append(items, e.resolveRow(table, row, field.SelectionSet))
+			}
+			return items, nil
+		case staticFindUniquePrefix + table.typeName:
+			if table.keyColumn == nil {
+				continue
+			}
+			keyValue := arguments[table.keyColumn.fieldName]
+			for _, row := range table.rows {
+				if result, ok := compareStaticValue(row[table.keyColumn.fieldName], keyValue); ok && result == 0 {
+					return e.resolveRow(table, row, field.SelectionSet), nil
+				}
+			}
+			return nil, nil
+		case staticCountPrefix + table.typeName:
+			return len(table.filterRows(arguments[staticArgumentWhere])), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown field [%s]", field.Name)
+}
+
+func (e *staticExecutor) resolveRow(table *staticTable, row map[string]any, selectionSet ast.SelectionSet) (result graphqlObject) {
+	for _, field := range collectGraphqlFields(selectionSet, e.variables) {
+		var value any = table.typeName
+		if field.Name != graphqlTypenameField {
+			value = row[field.Name]
+		}
+		result = append(result, graphqlObjectField{key: field.Alias, value: value})
+	}
+	return
+}
+
+func (t *staticTable) filterRows(where any) (rows []map[string]any) {
+	conditions, _ := where.(map[string]any)
+	for _, row := range t.rows {
+		if t.matchRow(row, conditions) {
+			rows = append(rows, row)
+		}
+	}
+	return
+}
+
+func (t *staticTable) matchRow(row map[string]any, conditions map[string]any) bool {
+	for name, expected := range conditions {
+		index := slices.IndexFunc(t.filters, func(item *staticFilter) bool { return item.name == name })
+		if index == -1 {
+			continue
+		}
+		filter := t.filters[index]
+		if !filter.match(row[filter.column.fieldName], expected) {
+			return false
+		}
+	}
+	return true
+}
+
+// 条件值为null时等于/不等于判断是否为空，其他操作符忽略该条件
+func (f *staticFilter) match(value, expected any) bool {
+	if expected == nil {
+		switch f.operator {
+		case "":
+			return value == nil
+		case "not":
+			return value != nil
+		default:
+			return true
+		}
+	}
+
+	switch f.operator {
+	case "in":
+		items, _ := expected.([]any)
+		return slices.ContainsFunc(items, func(item any) bool {
+			result, ok := compareStaticValue(value, item)
+			return ok && result == 0
+		})
+	case "contains", "startsWith", "endsWith":
+		text, ok := value.(string)
+		if !ok {
+			return false
+		}
+		expectedText := fmt.Sprintf("%v", expected)
+		switch f.operator {
+		case "contains":
+			return strings.Contains(text, expectedText)
+		case "startsWith":
+			return strings.HasPrefix(text, expectedText)
+		default:
+			return strings.HasSuffix(text, expectedText)
+		}
+	}
+
+	result, ok := compareStaticValue(value, expected)
+	switch f.operator {
+	case "":
+		return ok && result == 0
+	case "not":
+		return !ok || result != 0
+	case "gt":
+		return ok && result > 0
+	case "gte":
+		return ok && result >= 0
+	case "lt":
+		return ok && result < 0
+	case "lte":
+		return ok && result <= 0
+	}
+	return true
+}
+
+// 比较两个值，类型不一致或为null时返回false，数字统一按浮点数比较
+func compareStaticValue(value, expected any) (int, bool) {
+	if value == nil || expected == nil {
+		return 0, false
+	}
+
+	switch v := value.(type) {
+	case string:
+		expectedText, ok := expected.(string)
+		return strings.Compare(v, expectedText), ok
+	case bool:
+		expectedBool, ok := expected.(bool)
+		if !ok || v == expectedBool {
+			return 0, ok
+		}
+		if v {
+			return 1, true
+		}
+		return -1, true
+	}
+
+	valueFloat, valueErr := cast.ToFloat64E(value)
+	expectedFloat, expectedErr := cast.ToFloat64E(expected)
+	if valueErr != nil || expectedErr != nil {
+		return 0, false
+	}
+	switch {
+	case valueFloat > expectedFloat:
+		return 1, true
+	case valueFloat < expectedFloat:
+		return -1, true
+	default:
+		return 0, true
+	}
+}
+
+// 按照orderBy中的顺序依次比较，null排在最前
+func sortStaticRows(rows []map[string]any, orderBy any) {
+	orders, _ := orderBy.([]any)
+	if len(orders) == 0 {
+		return
+	}
+
+	slices.SortStableFunc(rows, func(a, b map[string]any) bool {
+		for _, order := range orders {
+			orderFields, _ := order.(map[string]any)
+			for fieldName, direction := range orderFields {
+				var result int
+				switch aValue, bValue := a[fieldName], b[fieldName]; {
+				case aValue == nil && bValue == nil:
+				case aValue == nil:
+					result = -1
+				case bValue == nil:
+					result = 1
+				default:
+					result, _ = compareStaticValue(aValue, bValue)
+				}
+				if result == 0 {
+					continue
+				}
+				if direction == staticSortDesc {
+					result = -result
+				}
+				return result < 0
+			}
+		}
+		return false
+	})
+}
+
+func paginateStaticRows(rows []map[string]any, skip, take any) []map[string]any {
+	if skipCount := cast.ToInt(skip); skipCount > 0 {
+		if skipCount >= len(rows) {
+			return nil
+		}
+		rows = rows[skipCount:]
+	}
+	if take != nil {
+		if takeCount := cast.ToInt(take); takeCount >= 0 && takeCount < len(rows) {
+			rows = rows[:takeCount]
+		}
+	}
+	return rows
+}
@@ -0,0 +1,142 @@
+package datasource
+
+import (
+	"fireboom-server/pkg/common/models"
+	json "github.com/json-iterator/go"
+	"reflect"
+	"testing"
+)
+
+func TestInferStaticColumnType(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []any
+		expected string
+	}{
+		{"empty", nil, staticTypeString},
+		{"all null", []any{nil, nil}, staticTypeString},
+		{"json int", []any{json.Number("1"), nil, json.Number("-2")}, staticTypeInt},
+		{"json int out of int32", []any{json.Number("1"), json.Number("4294967296")}, staticTypeFloat},
+		{"json int and float", []any{json.Number("1"), json.Number("1.5")}, staticTypeFloat},
+		{"json bool", []any{true, false}, staticTypeBoolean},
+		{"json mixed", []any{json.Number("1"), "a"}, staticTypeString},
+		{"json object", []any{"a", map[string]any{"b": 1}}, staticTypeJson},
+		{"csv int", []any{"1", "20"}, staticTypeInt},
+		{"csv float", []any{"1", "2.5e3"}, staticTypeFloat},
+		{"csv bool", []any{"true", "FALSE"}, staticTypeBoolean},
+		{"csv bool and int", []any{"true", "1"}, staticTypeString},
+		{"csv text", []any{"cat", "42"}, staticTypeString},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if typeName := inferStaticColumnType(tt.values); typeName != tt.expected {
+				t.Errorf("inferStaticColumnType() = %s, want %s", typeName, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuildStaticDataset(t *testing.T) {
+	tests := []struct {
+		name, filepath, content string
+		expected                map[string][]string // 表类型名称 -> 列定义
+	}{
+		{
+			name:     "json array",
+			filepath: "pets.json",
+			content:  `[{"id":1,"name":"kitty","weight":3.5,"tags":["a"]},{"id":2,"name":"puppy","weight":4,"vaccinated":true}]`,
+			expected: map[string][]string{"Pets": {"id: Int!", "name: String!", "weight: Float!", "tags: JSON", "vaccinated: Boolean"}},
+		},
+		{
+			name:     "json object with tables",
+			filepath: "shop.json",
+			content:  `{"order items":[{"order-id":"A1","count":2}],"Query":[{"id":1}],"meta":{"version":1}}`,
+			expected: map[string][]string{"Order_items": {"order_id: String!", "count: Int!"}, "Query_": {"id: Int!"}},
+		},
+		{
+			name:     "csv",
+			filepath: "users.csv",
+			content:  "id,name,age,active\n1,alice,30,true\n2,bob,,false\n",
+			expected: map[string][]string{"Users": {"id: Int!", "name: String!", "age: Int", "active: Boolean!"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := &models.Datasource{Name: "static", CustomStatic: &models.CustomStatic{}}
+			dataset, err := buildStaticDataset(ds, tt.filepath, []byte(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := make(map[string][]string)
+			for _, table := range dataset.tables {
+				for _, column := range table.columns {
+					definition := column.fieldName + ": " + column.typeName
+					if column.required {
+						definition += "!"
+					}
+					actual[table.typeName] = append(actual[table.typeName], definition)
+				}
+			}
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("buildStaticDataset() tables = %v, want %v", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExecuteStaticDataset(t *testing.T) {
+	ds := &models.Datasource{Name: "static", CustomStatic: &models.CustomStatic{KeyFields: map[string]string{"pets": "code"}}}
+	content := `[
+		{"code":"c1","name":"kitty","age":3,"weight":3.5,"vaccinated":true},
+		{"code":"c2","name":"puppy","age":1,"weight":null,"vaccinated":false},
+		{"code":"c3","name":"bunny","age":5,"weight":1.2,"vaccinated":true}
+	]`
+	dataset, err := buildStaticDataset(ds, "pets.json", []byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, request, expected string
+	}{
+		{
+			name:     "find many with filter, order and pagination",
+			request:  `{"query":"{ pets: findManyPets(where: {age_gte: 2, name_not: \"x\"}, orderBy: [{age: desc}], take: 1) { __typename name age } }"}`,
+			expected: `{"data":{"pets":[{"__typename":"Pets","name":"bunny","age":5}]}}`,
+		},
+		{
+			name:     "find many with variables and in filter",
+			request:  `{"query":"query($codes: [String!]) { findManyPets(where: {code_in: $codes}, orderBy: [{weight: asc}]) { code weight } }","variables":{"codes":["c1","c2"]}}`,
+			expected: `{"data":{"findManyPets":[{"code":"c2","weight":null},{"code":"c1","weight":3.5}]}}`,
+		},
+		{
+			name:     "find unique by key field",
+			request:  `{"query":"{ findUniquePets(code: \"c2\") { name vaccinated } missing: findUniquePets(code: \"c9\") { name } }"}`,
+			expected: `{"data":{"findUniquePets":{"name":"puppy","vaccinated":false},"missing":null}}`,
+		},
+		{
+			name:     "count with null condition",
+			request:  `{"query":"{ total: countPets(where: {vaccinated: true}) withoutWeight: countPets(where: {weight: null}) }"}`,
+			expected: `{"data":{"total":2,"withoutWeight":1}}`,
+		},
+		{
+			name:    "unknown field",
+			request: `{"query":"{ findManyUsers { id } }"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := executeStaticDataset(dataset, []byte(tt.request))
+			if tt.expected == "" {
+				if err == nil {
+					t.Errorf("executeStaticDataset() expected error")
+				}
+				return
+			}
+			if actual := string(marshalGraphqlResponse(data, err)); actual != tt.expected {
+				t.Errorf("executeStaticDataset() = %s, want %s", actual, tt.expected)
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@
 package directives
 
 import (
+	"fireboom-server/pkg/common/models"
 	"fireboom-server/pkg/common/utils"
 	"fireboom-server/pkg/plugins/embed"
 	"fireboom-server/pkg/plugins/fileloader"
@@ -26,8 +27,14 @@ import (
 
 type (
 	OperationResolver struct {
-		Operation *wgpb.Operation
-		Arguments map[string]string
+		Operation       *wgpb.Operation
+		Arguments       map[string]string
+		RateLimitTiers  *[]*models.OperationRateLimitTier
+		MaskFields      *[]*models.OperationMaskField
+		Variables       *openapi3.SchemaRef
+		ValidationRules *[]*models.OperationValidationRule
+		ResponseCache   *models.OperationResponseCache
+		Paginations     *[]*models.OperationPagination
 	}
 	SelectionResolver struct {
 		OperationResolver
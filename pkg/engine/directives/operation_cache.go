@@ -0,0 +1,90 @@
+// Package directives
+/*
+ 实现OperationDirective接口，只能定义在LocationQuery上
+ Resolve 记录响应缓存配置，编译后保存在接口编译配置中，飞布服务转发GET请求时缓存响应，缓存键由声明的参数和用户claim值计算
+ tags用于@invalidateCache声明的mutation执行后删除缓存，与cacheConfig的http缓存互不影响，命中缓存时不会执行钩子
+*/
+package directives
+
+import (
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	json "github.com/json-iterator/go"
+	"github.com/spf13/cast"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const (
+	cacheName                    = "cache"
+	cacheArgTtlSecondsName       = "ttlSeconds"
+	cacheArgVariablesName        = "variables"
+	cacheArgClaimsName           = "claims"
+	cacheArgTagsName             = "tags"
+	cacheTtlSecondsInvalidFormat = "argument [%s] must be positive"
+	cacheVariableNotFoundFormat  = "variable [%s] not found in variables"
+)
+
+type cache struct{}
+
+func (o *cache) Directive() *ast.DirectiveDefinition {
+	return &ast.DirectiveDefinition{
+		Description: appendIfExistExampleGraphql(i18n.CacheDesc.String()),
+		Name:        cacheName,
+		Locations:   []ast.DirectiveLocation{ast.LocationQuery},
+		Arguments: ast.ArgumentDefinitionList{{
+			Description: i18n.CacheArgTtlSecondsDesc.String(),
+			Name:        cacheArgTtlSecondsName,
+			Type:        ast.NonNullNamedType(consts.ScalarInt, nil),
+		}, {
+			Description: i18n.CacheArgVariablesDesc.String(),
+			Name:        cacheArgVariablesName,
+			Type:        ast.ListType(ast.NamedType(consts.ScalarString, nil), nil),
+		}, {
+			Description: i18n.CacheArgClaimsDesc.String(),
+			Name:        cacheArgClaimsName,
+			Type:        ast.ListType(ast.NamedType(consts.ScalarString, nil), nil),
+		}, {
+			Description: i18n.CacheArgTagsDesc.String(),
+			Name:        cacheArgTagsName,
+			Type:        ast.ListType(ast.NamedType(consts.ScalarString, nil), nil),
+		}},
+	}
+}
+
+func (o *cache) Definitions() ast.DefinitionList {
+	return nil
+}
+
+func (o *cache) Resolve(resolver *OperationResolver) (err error) {
+	ttlSeconds, ok := resolver.Arguments[cacheArgTtlSecondsName]
+	if !ok {
+		return fmt.Errorf(argumentRequiredFormat, cacheArgTtlSecondsName)
+	}
+
+	cacheConfig := resolver.ResponseCache
+	if cacheConfig.TtlSeconds = cast.ToInt64(ttlSeconds); cacheConfig.TtlSeconds <= 0 {
+		return fmt.Errorf(cacheTtlSecondsInvalidFormat, cacheArgTtlSecondsName)
+	}
+	for name, target := range map[string]*[]string{
+		cacheArgVariablesName: &cacheConfig.Variables,
+		cacheArgClaimsName:    &cacheConfig.Claims,
+		cacheArgTagsName:      &cacheConfig.Tags,
+	} {
+		if value, ok := resolver.Arguments[name]; ok {
+			if err = json.Unmarshal([]byte(value), target); err != nil {
+				return
+			}
+		}
+	}
+	for _, variable := range cacheConfig.Variables {
+		if !validateFieldExisted(resolver.Variables, variable) {
+			return fmt.Errorf(cacheVariableNotFoundFormat, variable)
+		}
+	}
+	return
+}
+
+func init() {
+	registerDirective(cacheName, &cache{})
+}
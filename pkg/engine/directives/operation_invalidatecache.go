@@ -0,0 +1,57 @@
+// Package directives
+/*
+ 实现OperationDirective接口，只能定义在LocationMutation上
+ Resolve 记录需要删除的缓存标签，飞布服务转发的mutation执行成功后删除包含任意标签的@cache响应缓存
+*/
+package directives
+
+import (
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	json "github.com/json-iterator/go"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const (
+	invalidateCacheName        = "invalidateCache"
+	invalidateCacheArgTagsName = "tags"
+)
+
+type invalidateCache struct{}
+
+func (o *invalidateCache) Directive() *ast.DirectiveDefinition {
+	return &ast.DirectiveDefinition{
+		Description: appendIfExistExampleGraphql(i18n.InvalidateCacheDesc.String()),
+		Name:        invalidateCacheName,
+		Locations:   []ast.DirectiveLocation{ast.LocationMutation},
+		Arguments: ast.ArgumentDefinitionList{{
+			Description: i18n.InvalidateCacheArgTagsDesc.String(),
+			Name:        invalidateCacheArgTagsName,
+			Type:        ast.NonNullListType(ast.NonNullNamedType(consts.ScalarString, nil), nil),
+		}},
+	}
+}
+
+func (o *invalidateCache) Definitions() ast.DefinitionList {
+	return nil
+}
+
+func (o *invalidateCache) Resolve(resolver *OperationResolver) (err error) {
+	value, ok := resolver.Arguments[invalidateCacheArgTagsName]
+	if !ok {
+		return fmt.Errorf(argumentRequiredFormat, invalidateCacheArgTagsName)
+	}
+
+	if err = json.Unmarshal([]byte(value), &resolver.ResponseCache.InvalidateTags); err != nil {
+		return
+	}
+	if len(resolver.ResponseCache.InvalidateTags) == 0 {
+		return fmt.Errorf(argumentRequiredFormat, invalidateCacheArgTagsName)
+	}
+	return
+}
+
+func init() {
+	registerDirective(invalidateCacheName, &invalidateCache{})
+}
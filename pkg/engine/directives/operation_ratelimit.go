@@ -0,0 +1,163 @@
+// Package directives
+/*
+ 实现OperationDirective接口，只能定义在LocationQuery, LocationMutation, LocationSubscription上，可重复定义
+ Resolve 记录限流层级，编译后保存在接口编译配置中，与接口配置中的rateLimitTiers合并生效
+ 每个限流层级按claim/header/IP计算调用方标识，飞布服务转发接口请求时使用滑动窗口计数，超出限制时返回429并设置Retry-After
+*/
+package directives
+
+import (
+	"errors"
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	"github.com/spf13/cast"
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	rateLimitName                  = "rateLimit"
+	rateLimitArgKeyByName          = "keyBy"
+	rateLimitArgKeyName            = "key"
+	rateLimitArgRequestsName       = "requests"
+	rateLimitArgWindowSecondsName  = "windowSeconds"
+	rateLimitArgAppliesToName      = "appliesTo"
+	rateLimitArgKeyByType          = "RateLimitKey"
+	rateLimitArgAppliesToType      = "RateLimitScope"
+	rateLimitTierRepeatFormat      = "rate limit tier [%s] defined repeatedly"
+	rateLimitArgNotPositiveFormat  = "argument [%s] must be positive in rate limit tier [%s]"
+	rateLimitKeyRequiredFormat     = "argument [%s] required when [%s] is [%s] in rate limit tier [%s]"
+	rateLimitTierNameRequiredError = "rate limit tier name required"
+)
+
+var (
+	rateLimitKeyBys    = []string{models.RateLimitKeyByClaim, models.RateLimitKeyByHeader, models.RateLimitKeyByIp}
+	rateLimitAppliesTo = []string{models.RateLimitAppliesToAll, models.RateLimitAppliesToAuthenticated, models.RateLimitAppliesToAnonymous}
+)
+
+type rateLimit struct{}
+
+func (o *rateLimit) Directive() *ast.DirectiveDefinition {
+	return &ast.DirectiveDefinition{
+		Description:  appendIfExistExampleGraphql(i18n.RateLimitDesc.String()),
+		Name:         rateLimitName,
+		Locations:    []ast.DirectiveLocation{ast.LocationQuery, ast.LocationMutation, ast.LocationSubscription},
+		IsRepeatable: true,
+		Arguments: ast.ArgumentDefinitionList{{
+			Description: i18n.RateLimitArgNameDesc.String(),
+			Name:        commonArgName,
+			Type:        ast.NonNullNamedType(consts.ScalarString, nil),
+		}, {
+			Description: i18n.RateLimitArgKeyByDesc.String(),
+			Name:        rateLimitArgKeyByName,
+			Type:        ast.NonNullNamedType(rateLimitArgKeyByType, nil),
+		}, {
+			Description: i18n.RateLimitArgKeyDesc.String(),
+			Name:        rateLimitArgKeyName,
+			Type:        ast.NamedType(consts.ScalarString, nil),
+		}, {
+			Description: i18n.RateLimitArgRequestsDesc.String(),
+			Name:        rateLimitArgRequestsName,
+			Type:        ast.NonNullNamedType(consts.ScalarInt, nil),
+		}, {
+			Description: i18n.RateLimitArgWindowSecondsDesc.String(),
+			Name:        rateLimitArgWindowSecondsName,
+			Type:        ast.NonNullNamedType(consts.ScalarInt, nil),
+		}, {
+			Description:  i18n.RateLimitArgAppliesToDesc.String(),
+			Name:         rateLimitArgAppliesToName,
+			Type:         ast.NamedType(rateLimitArgAppliesToType, nil),
+			DefaultValue: &ast.Value{Kind: ast.EnumValue, Raw: models.RateLimitAppliesToAll},
+		}},
+	}
+}
+
+func (o *rateLimit) Definitions() ast.DefinitionList {
+	var keyByEnumValues, appliesToEnumValues ast.EnumValueList
+	for _, item := range rateLimitKeyBys {
+		keyByEnumValues = append(keyByEnumValues, &ast.EnumValueDefinition{Name: item})
+	}
+	for _, item := range rateLimitAppliesTo {
+		appliesToEnumValues = append(appliesToEnumValues, &ast.EnumValueDefinition{Name: item})
+	}
+
+	return ast.DefinitionList{{
+		Kind:       ast.Enum,
+		Name:       rateLimitArgKeyByType,
+		EnumValues: keyByEnumValues,
+	}, {
+		Kind:       ast.Enum,
+		Name:       rateLimitArgAppliesToType,
+		EnumValues: appliesToEnumValues,
+	}}
+}
+
+func (o *rateLimit) Resolve(resolver *OperationResolver) (err error) {
+	for _, name := range []string{commonArgName, rateLimitArgKeyByName, rateLimitArgRequestsName, rateLimitArgWindowSecondsName} {
+		if _, ok := resolver.Arguments[name]; !ok {
+			return fmt.Errorf(argumentRequiredFormat, name)
+		}
+	}
+
+	tier := &models.OperationRateLimitTier{
+		Name:          resolver.Arguments[commonArgName],
+		KeyBy:         resolver.Arguments[rateLimitArgKeyByName],
+		Key:           resolver.Arguments[rateLimitArgKeyName],
+		Requests:      cast.ToInt64(resolver.Arguments[rateLimitArgRequestsName]),
+		WindowSeconds: cast.ToInt64(resolver.Arguments[rateLimitArgWindowSecondsName]),
+		AppliesTo:     resolver.Arguments[rateLimitArgAppliesToName],
+	}
+	if slices.ContainsFunc(*resolver.RateLimitTiers, func(item *models.OperationRateLimitTier) bool { return item.Name == tier.Name }) {
+		return fmt.Errorf(rateLimitTierRepeatFormat, tier.Name)
+	}
+	if err = validateRateLimitTier(tier); err != nil {
+		return
+	}
+
+	*resolver.RateLimitTiers = append(*resolver.RateLimitTiers, tier)
+	return
+}
+
+// ValidateRateLimitTiers 校验接口配置和指令声明合并后的限流层级，层级名称不可重复
+func ValidateRateLimitTiers(tiers []*models.OperationRateLimitTier) error {
+	names := make(map[string]bool, len(tiers))
+	for _, tier := range tiers {
+		if names[tier.Name] {
+			return fmt.Errorf(rateLimitTierRepeatFormat, tier.Name)
+		}
+		if err := validateRateLimitTier(tier); err != nil {
+			return err
+		}
+		names[tier.Name] = true
+	}
+	return nil
+}
+
+func validateRateLimitTier(tier *models.OperationRateLimitTier) error {
+	if tier.Name == "" {
+		return errors.New(rateLimitTierNameRequiredError)
+	}
+	if !slices.Contains(rateLimitKeyBys, tier.KeyBy) {
+		return fmt.Errorf(argumentValueNotSupportedFormat, tier.KeyBy, rateLimitArgKeyByName)
+	}
+	// IP作为标识时无需指定名称，claim/header须指定名称，如 userId，X-Tenant-Id
+	if tier.KeyBy != models.RateLimitKeyByIp && tier.Key == "" {
+		return fmt.Errorf(rateLimitKeyRequiredFormat, rateLimitArgKeyName, rateLimitArgKeyByName, tier.KeyBy, tier.Name)
+	}
+	if tier.Requests <= 0 {
+		return fmt.Errorf(rateLimitArgNotPositiveFormat, rateLimitArgRequestsName, tier.Name)
+	}
+	if tier.WindowSeconds <= 0 {
+		return fmt.Errorf(rateLimitArgNotPositiveFormat, rateLimitArgWindowSecondsName, tier.Name)
+	}
+	if tier.AppliesTo != "" && !slices.Contains(rateLimitAppliesTo, tier.AppliesTo) {
+		return fmt.Errorf(argumentValueNotSupportedFormat, tier.AppliesTo, rateLimitArgAppliesToName)
+	}
+	return nil
+}
+
+func init() {
+	registerDirective(rateLimitName, &rateLimit{})
+}
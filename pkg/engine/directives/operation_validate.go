@@ -0,0 +1,122 @@
+// Package directives
+/*
+ 实现OperationDirective接口，只能定义在LocationQuery, LocationMutation, LocationSubscription上，可重复定义
+ Resolve 记录入参校验规则，编译后保存在接口编译配置中，飞布服务转发接口请求前计算表达式，结果为false时返回400和字段错误
+ 表达式可以从arguments，request.header, environment, user获取参数，fields声明的参数须存在于入参定义中
+*/
+package directives
+
+import (
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	json "github.com/json-iterator/go"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/wundergraph/wundergraph/pkg/apihandler"
+	"golang.org/x/exp/slices"
+	"strings"
+)
+
+const (
+	validateName                = "validate"
+	validateArgExpressionName   = "expression"
+	validateArgFieldsName       = "fields"
+	validateArgCodeName         = "code"
+	validateArgMessageName      = "message"
+	validateDefaultCode         = "VALIDATION_FAILED"
+	validateRuleRepeatFormat    = "rule [%s] defined repeatedly"
+	validateFieldNotFoundFormat = "field [%s] not found in variables"
+)
+
+type validate struct{}
+
+func (o *validate) Directive() *ast.DirectiveDefinition {
+	return &ast.DirectiveDefinition{
+		Description:  appendIfExistExampleGraphql(i18n.ValidateDesc.String()),
+		Name:         validateName,
+		Locations:    []ast.DirectiveLocation{ast.LocationQuery, ast.LocationMutation, ast.LocationSubscription},
+		IsRepeatable: true,
+		Arguments: ast.ArgumentDefinitionList{{
+			Description: i18n.ValidateArgNameDesc.String(),
+			Name:        commonArgName,
+			Type:        ast.NonNullNamedType(consts.ScalarString, nil),
+		}, {
+			Description:  i18n.ValidateArgExpressionDesc.String(),
+			Name:         validateArgExpressionName,
+			Type:         ast.NonNullNamedType(consts.ScalarString, nil),
+			DefaultValue: &ast.Value{Kind: ast.StringValue, Raw: "arguments.endDate > arguments.startDate"},
+		}, {
+			Description: i18n.ValidateArgFieldsDesc.String(),
+			Name:        validateArgFieldsName,
+			Type:        ast.ListType(ast.NamedType(consts.ScalarString, nil), nil),
+		}, {
+			Description:  i18n.ValidateArgCodeDesc.String(),
+			Name:         validateArgCodeName,
+			Type:         ast.NamedType(consts.ScalarString, nil),
+			DefaultValue: &ast.Value{Kind: ast.StringValue, Raw: validateDefaultCode},
+		}, {
+			Description: i18n.ValidateArgMessageDesc.String(),
+			Name:        validateArgMessageName,
+			Type:        ast.NonNullNamedType(consts.ScalarString, nil),
+		}},
+	}
+}
+
+func (o *validate) Definitions() ast.DefinitionList {
+	return nil
+}
+
+func (o *validate) Resolve(resolver *OperationResolver) (err error) {
+	for _, name := range []string{commonArgName, validateArgExpressionName, validateArgMessageName} {
+		if _, ok := resolver.Arguments[name]; !ok {
+			return fmt.Errorf(argumentRequiredFormat, name)
+		}
+	}
+	rule := &models.OperationValidationRule{
+		Name:    resolver.Arguments[commonArgName],
+		Code:    validateDefaultCode,
+		Message: resolver.Arguments[validateArgMessageName],
+	}
+	if slices.ContainsFunc(*resolver.ValidationRules, func(item *models.OperationValidationRule) bool { return item.Name == rule.Name }) {
+		return fmt.Errorf(validateRuleRepeatFormat, rule.Name)
+	}
+
+	rule.Expression = strings.ReplaceAll(resolver.Arguments[validateArgExpressionName], "'", "`")
+	if _, err = apihandler.GvalFullLanguage.NewEvaluable(rule.Expression); err != nil {
+		return
+	}
+	if code, ok := resolver.Arguments[validateArgCodeName]; ok && code != "" {
+		rule.Code = code
+	}
+	if fields, ok := resolver.Arguments[validateArgFieldsName]; ok {
+		if err = json.Unmarshal([]byte(fields), &rule.Fields); err != nil {
+			return
+		}
+		for _, field := range rule.Fields {
+			if !validateFieldExisted(resolver.Variables, field) {
+				return fmt.Errorf(validateFieldNotFoundFormat, field)
+			}
+		}
+	}
+
+	*resolver.ValidationRules = append(*resolver.ValidationRules, rule)
+	return
+}
+
+// 校验字段路径的首段参数存在于入参定义中，嵌套的对象参数为引用定义不再向下校验
+func validateFieldExisted(variables *openapi3.SchemaRef, field string) bool {
+	if variables == nil || variables.Value == nil {
+		return false
+	}
+
+	variable, _, _ := strings.Cut(field, utils.StringDot)
+	_, ok := variables.Value.Properties[variable]
+	return ok
+}
+
+func init() {
+	registerDirective(validateName, &validate{})
+}
@@ -0,0 +1,172 @@
+// Package directives
+/*
+ 实现SelectionDirective接口，只能定义在LocationField上
+ Resolve 记录脱敏字段，编译后保存在接口编译配置中，飞布服务转发接口请求时按照策略改写响应中的字段
+ 仅允许作用于字符串或字符串数组字段，用户拥有exceptRoles中任意角色或rule表达式结果为false时不脱敏
+*/
+package directives
+
+import (
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	json "github.com/json-iterator/go"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/wundergraph/wundergraph/pkg/apihandler"
+	"golang.org/x/exp/slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	maskName                 = "mask"
+	maskArgStrategyName      = "strategy"
+	maskArgKeepFirstName     = "keepFirst"
+	maskArgKeepLastName      = "keepLast"
+	maskArgMaskCharName      = "maskChar"
+	maskArgReplacementName   = "replacement"
+	maskArgExceptRolesName   = "exceptRoles"
+	maskArgRuleName          = "rule"
+	maskArgStrategyType      = "MaskStrategy"
+	maskDefaultMaskChar      = "*"
+	maskDefaultReplacement   = "******"
+	maskNotAllowedFormat     = "expected string or string array, but found [%s]"
+	maskArgNotNegativeFormat = "argument [%s] must not be negative"
+)
+
+var maskStrategies = []string{models.MaskStrategyKeep, models.MaskStrategyFixed, models.MaskStrategyHash, models.MaskStrategyNull}
+
+type mask struct{}
+
+func (s *mask) Directive() *ast.DirectiveDefinition {
+	return &ast.DirectiveDefinition{
+		Description: appendIfExistExampleGraphql(i18n.MaskDesc.String()),
+		Name:        maskName,
+		Locations:   []ast.DirectiveLocation{ast.LocationField},
+		Arguments: ast.ArgumentDefinitionList{{
+			Description: i18n.MaskArgStrategyDesc.String(),
+			Name:        maskArgStrategyName,
+			Type:        ast.NonNullNamedType(maskArgStrategyType, nil),
+		}, {
+			Description:  i18n.MaskArgKeepFirstDesc.String(),
+			Name:         maskArgKeepFirstName,
+			Type:         ast.NamedType(consts.ScalarInt, nil),
+			DefaultValue: &ast.Value{Kind: ast.IntValue, Raw: "0"},
+		}, {
+			Description:  i18n.MaskArgKeepLastDesc.String(),
+			Name:         maskArgKeepLastName,
+			Type:         ast.NamedType(consts.ScalarInt, nil),
+			DefaultValue: &ast.Value{Kind: ast.IntValue, Raw: "0"},
+		}, {
+			Description:  i18n.MaskArgMaskCharDesc.String(),
+			Name:         maskArgMaskCharName,
+			Type:         ast.NamedType(consts.ScalarString, nil),
+			DefaultValue: &ast.Value{Kind: ast.StringValue, Raw: maskDefaultMaskChar},
+		}, {
+			Description:  i18n.MaskArgReplacementDesc.String(),
+			Name:         maskArgReplacementName,
+			Type:         ast.NamedType(consts.ScalarString, nil),
+			DefaultValue: &ast.Value{Kind: ast.StringValue, Raw: maskDefaultReplacement},
+		}, {
+			Description: i18n.MaskArgExceptRolesDesc.String(),
+			Name:        maskArgExceptRolesName,
+			Type:        ast.ListType(ast.NamedType(rbacRoleEnumName, nil), nil),
+		}, {
+			Description: i18n.MaskArgRuleDesc.String(),
+			Name:        maskArgRuleName,
+			Type:        ast.NamedType(consts.ScalarString, nil),
+		}},
+	}
+}
+
+func (s *mask) Definitions() ast.DefinitionList {
+	var strategyEnumValues ast.EnumValueList
+	for _, item := range maskStrategies {
+		strategyEnumValues = append(strategyEnumValues, &ast.EnumValueDefinition{Name: item})
+	}
+
+	return ast.DefinitionList{{
+		Kind:       ast.Enum,
+		Name:       maskArgStrategyType,
+		EnumValues: strategyEnumValues,
+	}}
+}
+
+func (s *mask) Resolve(resolver *SelectionResolver) (err error) {
+	stringSchema := resolver.Schema.Value
+	for stringSchema != nil && stringSchema.Type == openapi3.TypeArray && stringSchema.Items != nil {
+		stringSchema = stringSchema.Items.Value
+	}
+	if stringSchema == nil || stringSchema.Type != openapi3.TypeString {
+		return fmt.Errorf(maskNotAllowedFormat, resolver.Schema.Value.Type)
+	}
+
+	strategy, ok := resolver.Arguments[maskArgStrategyName]
+	if !ok {
+		return fmt.Errorf(argumentRequiredFormat, maskArgStrategyName)
+	}
+	if !slices.Contains(maskStrategies, strategy) {
+		return fmt.Errorf(argumentValueNotSupportedFormat, strategy, maskArgStrategyName)
+	}
+
+	maskField := &models.OperationMaskField{Path: slices.Clone(resolver.Path), Strategy: strategy}
+	switch strategy {
+	case models.MaskStrategyKeep:
+		if maskField.KeepFirst, err = resolveMaskIntArgument(resolver.Arguments, maskArgKeepFirstName); err != nil {
+			return
+		}
+		if maskField.KeepLast, err = resolveMaskIntArgument(resolver.Arguments, maskArgKeepLastName); err != nil {
+			return
+		}
+		maskField.MaskChar = maskDefaultMaskChar
+		if maskChar, ok := resolver.Arguments[maskArgMaskCharName]; ok && maskChar != "" {
+			maskField.MaskChar = maskChar
+		}
+	case models.MaskStrategyFixed:
+		maskField.Replacement = maskDefaultReplacement
+		if replacement, ok := resolver.Arguments[maskArgReplacementName]; ok {
+			maskField.Replacement = replacement
+		}
+	case models.MaskStrategyNull:
+		stringSchema.Nullable = true
+	}
+	// 脱敏后的值不再满足原有的格式，如email/uuid
+	stringSchema.Format = ""
+
+	if exceptRoles, ok := resolver.Arguments[maskArgExceptRolesName]; ok {
+		if err = json.Unmarshal([]byte(exceptRoles), &maskField.ExceptRoles); err != nil {
+			return
+		}
+	}
+	if rule, ok := resolver.Arguments[maskArgRuleName]; ok && rule != "" {
+		rule = strings.ReplaceAll(rule, "'", "`")
+		if _, err = apihandler.GvalFullLanguage.NewEvaluable(rule); err != nil {
+			return
+		}
+		maskField.Rule = rule
+	}
+
+	*resolver.MaskFields = append(*resolver.MaskFields, maskField)
+	return
+}
+
+func resolveMaskIntArgument(arguments map[string]string, name string) (result int, err error) {
+	value, ok := arguments[name]
+	if !ok {
+		return
+	}
+
+	if result, err = strconv.Atoi(value); err != nil {
+		return
+	}
+	if result < 0 {
+		err = fmt.Errorf(maskArgNotNegativeFormat, name)
+	}
+	return
+}
+
+func init() {
+	registerDirective(maskName, &mask{})
+}
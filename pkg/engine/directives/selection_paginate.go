@@ -0,0 +1,301 @@
+// Package directives
+/*
+ 实现SelectionDirective接口，只能定义在LocationField上
+ Resolve 记录分页配置，编译后保存在接口编译配置中，将findMany字段的响应定义改为connection结构(edges/items, pageInfo, totalCount)
+ 编译时为字段生成take/skip/cursor内部参数，对外公开first/after参数，飞布服务转发请求时根据first/after计算内部参数并在响应时转换结构
+ 游标为cursorFields字段值的base64编码，totalCount通过同级的aggregate查询获取
+*/
+package directives
+
+import (
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	json "github.com/json-iterator/go"
+	"github.com/spf13/cast"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	paginateName                   = "paginate"
+	paginateArgCursorFieldsName    = "cursorFields"
+	paginateArgShapeName           = "shape"
+	paginateArgTotalCountName      = "totalCount"
+	paginateArgDefaultFirstName    = "defaultFirst"
+	paginateArgMaxFirstName        = "maxFirst"
+	paginateArgFirstVariableName   = "firstVariable"
+	paginateArgAfterVariableName   = "afterVariable"
+	paginateArgShapeType           = "PaginateShape"
+	paginateDefaultCursorField     = "id"
+	paginateDefaultFirst           = 10
+	paginateDefaultMaxFirst        = 100
+	paginateDefaultFirstVariable   = "first"
+	paginateDefaultAfterVariable   = "after"
+	paginateFieldArgumentTake      = "take"
+	paginateFieldArgumentSkip      = "skip"
+	paginateFieldArgumentCursor    = "cursor"
+	paginateFieldArgumentWhere     = "where"
+	paginateTotalCountSuffix       = "TotalCount"
+	paginateAggregateCountField    = "_count"
+	paginateAggregateCountAllField = "_all"
+	paginateQueryRequiredFormat    = "only supported on query operation, but found [%s]"
+	paginateRootFieldFormat        = "only supported on root field, but found [%s]"
+	paginateNotAllowedFormat       = "expected array of object, but found [%s]"
+	paginateCursorFieldMissFormat  = "cursor field [%s] must be selected on path [%s]"
+	paginateArgNotPositiveFormat   = "argument [%s] must be positive"
+	paginateMaxFirstInvalidFormat  = "argument [%s] must not be less than [%s]"
+	paginateArgumentMissFormat     = "argument [%s] not found on field [%s], expected findMany field"
+	paginateArgumentDefinedFormat  = "argument [%s] on field [%s] conflicts with @paginate"
+)
+
+var (
+	paginateShapes         = []string{models.PaginateShapeEdges, models.PaginateShapeItems}
+	paginateFieldArguments = []string{paginateFieldArgumentTake, paginateFieldArgumentSkip, paginateFieldArgumentCursor}
+)
+
+type paginate struct{}
+
+func (s *paginate) Directive() *ast.DirectiveDefinition {
+	return &ast.DirectiveDefinition{
+		Description: appendIfExistExampleGraphql(i18n.PaginateDesc.String()),
+		Name:        paginateName,
+		Locations:   []ast.DirectiveLocation{ast.LocationField},
+		Arguments: ast.ArgumentDefinitionList{{
+			Description: i18n.PaginateArgCursorFieldsDesc.String(),
+			Name:        paginateArgCursorFieldsName,
+			Type:        ast.ListType(ast.NonNullNamedType(consts.ScalarString, nil), nil),
+			DefaultValue: &ast.Value{Kind: ast.ListValue, Children: ast.ChildValueList{{
+				Value: &ast.Value{Kind: ast.StringValue, Raw: paginateDefaultCursorField},
+			}}},
+		}, {
+			Description:  i18n.PaginateArgShapeDesc.String(),
+			Name:         paginateArgShapeName,
+			Type:         ast.NamedType(paginateArgShapeType, nil),
+			DefaultValue: &ast.Value{Kind: ast.EnumValue, Raw: models.PaginateShapeEdges},
+		}, {
+			Description:  i18n.PaginateArgTotalCountDesc.String(),
+			Name:         paginateArgTotalCountName,
+			Type:         ast.NamedType(consts.ScalarBoolean, nil),
+			DefaultValue: &ast.Value{Kind: ast.BooleanValue, Raw: "false"},
+		}, {
+			Description:  i18n.PaginateArgDefaultFirstDesc.String(),
+			Name:         paginateArgDefaultFirstName,
+			Type:         ast.NamedType(consts.ScalarInt, nil),
+			DefaultValue: &ast.Value{Kind: ast.IntValue, Raw: cast.ToString(paginateDefaultFirst)},
+		}, {
+			Description:  i18n.PaginateArgMaxFirstDesc.String(),
+			Name:         paginateArgMaxFirstName,
+			Type:         ast.NamedType(consts.ScalarInt, nil),
+			DefaultValue: &ast.Value{Kind: ast.IntValue, Raw: cast.ToString(paginateDefaultMaxFirst)},
+		}, {
+			Description:  i18n.PaginateArgFirstVariableDesc.String(),
+			Name:         paginateArgFirstVariableName,
+			Type:         ast.NamedType(consts.ScalarString, nil),
+			DefaultValue: &ast.Value{Kind: ast.StringValue, Raw: paginateDefaultFirstVariable},
+		}, {
+			Description:  i18n.PaginateArgAfterVariableDesc.String(),
+			Name:         paginateArgAfterVariableName,
+			Type:         ast.NamedType(consts.ScalarString, nil),
+			DefaultValue: &ast.Value{Kind: ast.StringValue, Raw: paginateDefaultAfterVariable},
+		}},
+	}
+}
+
+func (s *paginate) Definitions() ast.DefinitionList {
+	var shapeEnumValues ast.EnumValueList
+	for _, item := range paginateShapes {
+		shapeEnumValues = append(shapeEnumValues, &ast.EnumValueDefinition{Name: item})
+	}
+
+	return ast.DefinitionList{{
+		Kind:       ast.Enum,
+		Name:       paginateArgShapeType,
+		EnumValues: shapeEnumValues,
+	}}
+}
+
+func (s *paginate) Resolve(resolver *SelectionResolver) (err error) {
+	if operationType := resolver.Operation.OperationType; operationType != wgpb.OperationType_QUERY {
+		return fmt.Errorf(paginateQueryRequiredFormat, operationType)
+	}
+
+	// 路径首段为data，根字段路径为[data, 字段名, []]
+	path := resolver.Path
+	if length := len(path); length > 0 && path[length-1] == utils.ArrayPath {
+		path = path[:length-1]
+	}
+	if len(path) != 2 {
+		return fmt.Errorf(paginateRootFieldFormat, utils.JoinStringWithDot(resolver.Path...))
+	}
+
+	listSchema := resolver.Schema.Value
+	if listSchema.Type != openapi3.TypeArray || listSchema.Items == nil || listSchema.Items.Value == nil || listSchema.Items.Value.Type != openapi3.TypeObject {
+		return fmt.Errorf(paginateNotAllowedFormat, listSchema.Type)
+	}
+
+	itemName := path[1]
+	pagination := &models.OperationPagination{
+		Path:           slices.Clone(path),
+		Shape:          models.PaginateShapeEdges,
+		CursorFields:   []string{paginateDefaultCursorField},
+		FirstVariable:  paginateDefaultFirstVariable,
+		AfterVariable:  paginateDefaultAfterVariable,
+		TakeVariable:   itemName + utils.UppercaseFirst(paginateFieldArgumentTake),
+		SkipVariable:   itemName + utils.UppercaseFirst(paginateFieldArgumentSkip),
+		CursorVariable: itemName + utils.UppercaseFirst(paginateFieldArgumentCursor),
+		DefaultFirst:   paginateDefaultFirst,
+		MaxFirst:       paginateDefaultMaxFirst,
+	}
+	if shape, ok := resolver.Arguments[paginateArgShapeName]; ok {
+		switch shape {
+		case models.PaginateShapeEdges, models.PaginateShapeItems:
+			pagination.Shape = shape
+		default:
+			return fmt.Errorf(argumentValueNotSupportedFormat, shape, paginateArgShapeName)
+		}
+	}
+	if cursorFields, ok := resolver.Arguments[paginateArgCursorFieldsName]; ok {
+		if err = json.Unmarshal([]byte(cursorFields), &pagination.CursorFields); err != nil {
+			return
+		}
+		if len(pagination.CursorFields) == 0 {
+			return fmt.Errorf(argumentRequiredFormat, paginateArgCursorFieldsName)
+		}
+	}
+	// 游标由每一项的cursorFields字段值计算，所以须在查询字段中选择
+	for _, field := range pagination.CursorFields {
+		if _, ok := listSchema.Items.Value.Properties[field]; !ok {
+			return fmt.Errorf(paginateCursorFieldMissFormat, field, utils.JoinStringWithDot(path...))
+		}
+	}
+	for name, target := range map[string]*string{
+		paginateArgFirstVariableName: &pagination.FirstVariable,
+		paginateArgAfterVariableName: &pagination.AfterVariable,
+	} {
+		if value, ok := resolver.Arguments[name]; ok && value != "" {
+			*target = value
+		}
+	}
+	if value, ok := resolver.Arguments[paginateArgDefaultFirstName]; ok {
+		pagination.DefaultFirst = cast.ToInt64(value)
+	}
+	if value, ok := resolver.Arguments[paginateArgMaxFirstName]; ok {
+		pagination.MaxFirst = cast.ToInt64(value)
+	}
+	if pagination.DefaultFirst <= 0 {
+		return fmt.Errorf(paginateArgNotPositiveFormat, paginateArgDefaultFirstName)
+	}
+	if pagination.MaxFirst < pagination.DefaultFirst {
+		return fmt.Errorf(paginateMaxFirstInvalidFormat, paginateArgMaxFirstName, paginateArgDefaultFirstName)
+	}
+
+	totalCount := cast.ToBool(resolver.Arguments[paginateArgTotalCountName])
+	if totalCount {
+		pagination.TotalCountPath = []string{path[0], itemName + paginateTotalCountSuffix, paginateAggregateCountField, paginateAggregateCountAllField}
+	}
+	resolver.Schema.Value = buildPaginateConnectionSchema(pagination.Shape, listSchema.Items, totalCount)
+	*resolver.Paginations = append(*resolver.Paginations, pagination)
+	return
+}
+
+// 构建connection结构的响应定义
+func buildPaginateConnectionSchema(shape string, itemSchema *openapi3.SchemaRef, totalCount bool) *openapi3.Schema {
+	pageInfoSchema := openapi3.NewObjectSchema()
+	pageInfoSchema.Properties["hasNextPage"] = openapi3.NewBoolSchema().NewRef()
+	pageInfoSchema.Properties["endCursor"] = openapi3.NewStringSchema().WithNullable().NewRef()
+	pageInfoSchema.Required = []string{"hasNextPage", "endCursor"}
+
+	connectionSchema := openapi3.NewObjectSchema()
+	connectionSchema.Properties["pageInfo"] = pageInfoSchema.NewRef()
+	switch shape {
+	case models.PaginateShapeItems:
+		itemsSchema := openapi3.NewArraySchema()
+		itemsSchema.Items = itemSchema
+		connectionSchema.Properties["items"] = itemsSchema.NewRef()
+		connectionSchema.Required = []string{"items", "pageInfo"}
+	default:
+		edgeSchema := openapi3.NewObjectSchema()
+		edgeSchema.Properties["cursor"] = openapi3.NewStringSchema().NewRef()
+		edgeSchema.Properties["node"] = itemSchema
+		edgeSchema.Required = []string{"cursor", "node"}
+		connectionSchema.Properties["edges"] = openapi3.NewArraySchema().WithItems(edgeSchema).NewRef()
+		connectionSchema.Required = []string{"edges", "pageInfo"}
+	}
+	if totalCount {
+		connectionSchema.Properties["totalCount"] = openapi3.NewIntegerSchema().NewRef()
+		connectionSchema.Required = append(connectionSchema.Required, "totalCount")
+	}
+	return connectionSchema
+}
+
+// PaginateFieldDirective 返回查询字段上声明的@paginate，同时返回是否需要totalCount
+func PaginateFieldDirective(directiveList ast.DirectiveList) (directive *ast.Directive, totalCount bool) {
+	if directive = directiveList.ForName(paginateName); directive != nil {
+		totalCount = cast.ToBool(ResolveDirectiveArguments(directive.Arguments)[paginateArgTotalCountName])
+	}
+	return
+}
+
+// PaginateFieldArguments 构建@paginate需要的内部参数定义和字段入参，类型使用字段定义中take/skip/cursor参数的类型
+func PaginateFieldArguments(itemName string, field *ast.Field, fieldDefinition *ast.FieldDefinition) (variableDefinitions ast.VariableDefinitionList, err error) {
+	for _, name := range paginateFieldArguments {
+		if field.Arguments.ForName(name) != nil {
+			err = fmt.Errorf(paginateArgumentDefinedFormat, name, field.Name)
+			return
+		}
+		argumentDefinition := fieldDefinition.Arguments.ForName(name)
+		if argumentDefinition == nil {
+			err = fmt.Errorf(paginateArgumentMissFormat, name, field.Name)
+			return
+		}
+
+		variableType := *argumentDefinition.Type
+		variableType.NonNull = false
+		variableName := itemName + utils.UppercaseFirst(name)
+		variableDefinitions = append(variableDefinitions, &ast.VariableDefinition{
+			Variable:   variableName,
+			Type:       &variableType,
+			Directives: ast.DirectiveList{{Name: internalName}},
+		})
+		field.Arguments = append(field.Arguments, &ast.Argument{
+			Name:  name,
+			Value: &ast.Value{Kind: ast.Variable, Raw: variableName},
+		})
+	}
+	return
+}
+
+// PaginateTotalCountField 构建@paginate(totalCount: true)需要的同级计数字段，复用字段的where参数
+func PaginateTotalCountField(itemName string, field *ast.Field, aggregateFieldName string) *ast.Field {
+	totalCountField := &ast.Field{
+		Alias: itemName + paginateTotalCountSuffix,
+		Name:  aggregateFieldName,
+		SelectionSet: ast.SelectionSet{&ast.Field{
+			Name:         paginateAggregateCountField,
+			SelectionSet: ast.SelectionSet{&ast.Field{Name: paginateAggregateCountAllField}},
+		}},
+	}
+	if where := field.Arguments.ForName(paginateFieldArgumentWhere); where != nil {
+		totalCountField.Arguments = ast.ArgumentList{{Name: where.Name, Value: where.Value}}
+	}
+	return totalCountField
+}
+
+// PaginateVariableSchemas 构建@paginate对外公开的first/after参数定义
+func PaginateVariableSchemas(pagination *models.OperationPagination) openapi3.Schemas {
+	firstSchema := openapi3.NewIntegerSchema().WithMin(1).WithMax(float64(pagination.MaxFirst))
+	firstSchema.Default = pagination.DefaultFirst
+	return openapi3.Schemas{
+		pagination.FirstVariable: firstSchema.NewRef(),
+		pagination.AfterVariable: openapi3.NewStringSchema().WithNullable().NewRef(),
+	}
+}
+
+func init() {
+	registerDirective(paginateName, &paginate{})
+}
@@ -241,7 +241,7 @@ func (o *reflectObjectFactory) reflectHookEndpoint() {
 	graphqlEndpoint := "/gqls/{name}/graphql"
 	endpointSchema.Enum = append(endpointSchema.Enum, graphqlEndpoint)
 	endpointEnumVarnames = append(endpointEnumVarnames, consts.HookCustomizeParent)
-	o.endpoints[wsConnectionInitEndpoint] = &endpointMetadata{
+	o.endpoints[graphqlEndpoint] = &endpointMetadata{
 		title:       consts.HookCustomizeParent,
 		requestBody: customizeHookPayload{},
 		response:    customizeHookResponse{},
@@ -436,10 +436,11 @@ var (
 	serverEnumFieldArray   []*enumField
 	serverTypeFormatArray  []string
 	endpointIgnoreNames    = []string{utils.GetTypeName(s3uploadclient.UploadedFiles{}), utils.GetTypeName(wgpb.WunderGraphConfiguration{})}
+	serverReflectFactory   *reflectObjectFactory
 )
 
 func init() {
-	serverReflectFactory := reflectObjectFactory{
+	serverReflectFactory = &reflectObjectFactory{
 		objectInfoFactory: newObjectInfoFactory(make(map[string]int)),
 		definitions:       make(openapi3.Schemas),
 		endpoints:         make(map[string]*endpointMetadata),
@@ -0,0 +1,197 @@
+// Package sdk
+/*
+ 内置钩子桩服务，按照hook.swagger约定的路径实现所有钩子接口，供仅开发graphql接口时使用
+ 前置和后置钩子原样放行，mutating钩子返回输入的数据，httpTransport的on钩子跳过修改
+ function和proxy接口优先返回store/stub下以接口路径命名的json文件，否则按照接口定义的响应schema构造数据
+ mockResolve钩子同样读取store/stub下的文件，不存在时返回空数据
+*/
+package sdk
+
+import (
+	"bytes"
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/plugins/fileloader"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	json "github.com/json-iterator/go"
+	"github.com/wundergraph/wundergraph/pkg/interpolate"
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	hookStubResponseSchemaName    = "__response"
+	hookStubCustomizeNotSupported = "customize datasource is not supported by hook stub"
+)
+
+var (
+	hookStubStartTime  = time.Now()
+	hookStubFixtureDir = utils.NormalizePath(consts.RootStore, consts.StoreStubParent)
+)
+
+// ServeHookStub 根据钩子地址返回桩数据，endpoint为去掉路由前缀后的地址
+func ServeHookStub(w http.ResponseWriter, r *http.Request, endpoint string) {
+	var body map[string]any
+	if bodyBytes, _ := io.ReadAll(r.Body); len(bodyBytes) > 0 {
+		_ = json.Unmarshal(bodyBytes, &body)
+	}
+	if body == nil {
+		body = make(map[string]any)
+	}
+
+	result, ok := resolveHookStubResponse(strings.Trim(endpoint, "/"), body)
+	w.Header().Set("Content-Type", hookVerifyContentType)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	resultBytes, _ := json.Marshal(result)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(resultBytes)
+}
+
+func resolveHookStubResponse(endpoint string, body map[string]any) (result map[string]any, ok bool) {
+	parent, rest, _ := strings.Cut(endpoint, "/")
+	ok = true
+	switch parent {
+	case "health":
+		result = buildHookStubHealth()
+	case "global":
+		transport, hook, _ := strings.Cut(rest, "/")
+		result = map[string]any{"hook": hook}
+		switch consts.MiddlewareHook(hook) {
+		case consts.HttpTransportOnRequest, consts.HttpTransportOnResponse:
+			result["response"] = map[string]any{"skip": true}
+		case consts.WsTransportOnConnectionInit:
+			result["response"] = map[string]any{"payload": body["payload"]}
+		}
+		ok = transport == "httpTransport" || transport == "wsTransport"
+	case consts.HookAuthenticationParent:
+		result = map[string]any{"hook": rest}
+		switch consts.MiddlewareHook(rest) {
+		case consts.MutatingPostAuthentication, consts.RevalidateAuthentication:
+			var user any
+			if wg, _ := body["__wg"].(map[string]any); wg != nil {
+				user = wg["user"]
+			}
+			result["response"] = map[string]any{"status": "ok", "user": user}
+		}
+	case consts.HookOperationParent:
+		index := strings.LastIndex(rest, "/")
+		if index == -1 {
+			return nil, false
+		}
+
+		path, hook := rest[:index], consts.MiddlewareHook(rest[index+1:])
+		result = map[string]any{"op": path, "hook": hook}
+		switch hook {
+		case consts.MutatingPreResolve:
+			result["input"] = body["input"]
+		case consts.MutatingPostResolve:
+			result["response"] = body["response"]
+		case consts.MockResolve:
+			data, _ := readHookStubFixture(path)
+			result["response"] = map[string]any{"data": data}
+		}
+	case consts.HookStorageProfileParent:
+		result = make(map[string]any)
+	case consts.HookFunctionParent, consts.HookProxyParent:
+		operationPath := utils.NormalizePath(parent, rest)
+		result = map[string]any{"op": operationPath, "hook": parent}
+		data, existed := readHookStubFixture(operationPath)
+		if !existed {
+			extensionText := models.OperationFunction
+			if parent == consts.HookProxyParent {
+				extensionText = models.OperationProxy
+			}
+			data = synthesizeHookStubResponse(extensionText, operationPath)
+		}
+		result["response"] = map[string]any{"data": data}
+	case "gqls":
+		result = map[string]any{"data": nil, "errors": []map[string]any{{"message": hookStubCustomizeNotSupported}}}
+	default:
+		ok = false
+	}
+	return
+}
+
+// 健康检查返回当前开启的function/proxy/自定义数据源，避免钩子报告修改已有配置
+func buildHookStubHealth() map[string]any {
+	functions, proxys := make([]string, 0), make([]string, 0)
+	for _, item := range models.OperationRoot.ListByCondition(func(item *models.Operation) bool { return item.Enabled }) {
+		switch item.Engine {
+		case wgpb.OperationExecutionEngine_ENGINE_FUNCTION:
+			functions = append(functions, strings.TrimPrefix(item.Path, consts.HookFunctionParent+"/"))
+		case wgpb.OperationExecutionEngine_ENGINE_PROXY:
+			proxys = append(proxys, strings.TrimPrefix(item.Path, consts.HookProxyParent+"/"))
+		}
+	}
+	customizes := make([]string, 0)
+	for _, item := range models.DatasourceRoot.ListByCondition(func(item *models.Datasource) bool {
+		return item.Enabled && item.CustomGraphql != nil && item.CustomGraphql.Customized
+	}) {
+		customizes = append(customizes, item.Name)
+	}
+	return map[string]any{
+		"status": "ok",
+		"report": map[string]any{
+			"customizes": customizes,
+			"functions":  functions,
+			"proxys":     proxys,
+			"time":       hookStubStartTime,
+		},
+	}
+}
+
+// 读取store/stub下以接口路径命名的json文件
+func readHookStubFixture(operationPath string) (data any, existed bool) {
+	fixtureBytes, err := os.ReadFile(utils.NormalizePath(hookStubFixtureDir, operationPath+string(fileloader.ExtJson)))
+	if err != nil {
+		return
+	}
+
+	existed = json.Unmarshal(fixtureBytes, &data) == nil
+	return
+}
+
+// 按照function/proxy定义的响应schema构造数据，schema中definitions的引用转换成openapi的引用后解析
+func synthesizeHookStubResponse(extensionText *fileloader.ModelText[models.Operation], operationPath string) any {
+	content, err := extensionText.Read(operationPath)
+	if err != nil {
+		return nil
+	}
+
+	var operation wgpb.Operation
+	if err = json.Unmarshal([]byte(content), &operation); err != nil || operation.ResponseSchema == "" {
+		return nil
+	}
+
+	var responseSchema map[string]json.RawMessage
+	if err = json.Unmarshal([]byte(operation.ResponseSchema), &responseSchema); err != nil {
+		return nil
+	}
+
+	schemas := make(map[string]json.RawMessage)
+	if definitionsBytes, ok := responseSchema["definitions"]; ok {
+		_ = json.Unmarshal(definitionsBytes, &schemas)
+	}
+	delete(responseSchema, "definitions")
+	delete(responseSchema, "$schema")
+	schemas[hookStubResponseSchemaName], _ = json.Marshal(responseSchema)
+	schemasBytes, _ := json.Marshal(schemas)
+	schemasBytes = bytes.ReplaceAll(schemasBytes, []byte(`"#/definitions/`), []byte(`"`+interpolate.Openapi3SchemaRefPrefix))
+	docBytes := fmt.Sprintf(`{"openapi":"3.0.1","info":{"title":"stub","version":"1"},"paths":{},"components":{"schemas":%s}}`, schemasBytes)
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(docBytes))
+	if err != nil {
+		return nil
+	}
+
+	return synthesizeHookSchemaValue(doc.Components.Schemas[hookStubResponseSchemaName].Value, 0)
+}
@@ -18,6 +18,11 @@ import (
 )
 
 func (o *reflectObjectFactory) generateHookSwagger() {
+	docBytes, _ := json.Marshal(o.buildHookSwagger())
+	_ = build.GeneratedHookSwaggerText.Write(build.GeneratedHookSwaggerText.Title, fileloader.SystemUser, docBytes)
+}
+
+func (o *reflectObjectFactory) buildHookSwagger() *openapi3.T {
 	doc := &openapi3.T{
 		OpenAPI:  "3.0.1",
 		Security: make(openapi3.SecurityRequirements, 0),
@@ -48,10 +53,12 @@ func (o *reflectObjectFactory) generateHookSwagger() {
 			responseName := utils.GetTypeName(metadata.response)
 			if responseSchema, responseOk = o.definitions[responseName]; responseOk {
 				if metadata.middlewareResponseRewrite != nil {
+					// 复制schema后再替换response属性，避免修改共用的定义
 					rewriteName := utils.GetTypeName(metadata.middlewareResponseRewrite)
-					copySchema := *responseSchema
-					copySchema.Value.Properties["response"] = o.definitions[rewriteName]
-					responseSchema = &copySchema
+					copySchema := *responseSchema.Value
+					copySchema.Properties = maps.Clone(copySchema.Properties)
+					copySchema.Properties["response"] = o.definitions[rewriteName]
+					responseSchema = &openapi3.SchemaRef{Value: &copySchema}
 				} else {
 					responseSchema = &openapi3.SchemaRef{Ref: interpolate.Openapi3SchemaRefPrefix + responseName}
 				}
@@ -70,7 +77,5 @@ func (o *reflectObjectFactory) generateHookSwagger() {
 		}
 		doc.Paths[endpoint] = &openapi3.PathItem{Post: pathItem}
 	}
-
-	docBytes, _ := json.Marshal(&doc)
-	_ = build.GeneratedHookSwaggerText.Write(build.GeneratedHookSwaggerText.Title, fileloader.SystemUser, docBytes)
+	return doc
 }
@@ -0,0 +1,486 @@
+// Package sdk
+/*
+ 按照hook.swagger校验钩子服务是否符合约定
+ 收集开启的全局、认证、operation、function/proxy接口、上传和自定义数据源钩子，只收集开启的operation，根据请求定义构造模拟请求并发送到钩子服务
+ 校验返回的状态码和响应体，并统计耗时，报告缺失、异常或慢的钩子
+ 响应体校验只检查已返回字段的类型和枚举，不检查必填，null值视为未返回
+*/
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/plugins/i18n"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	json "github.com/json-iterator/go"
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type HookVerifyStatus string
+
+const (
+	HookVerifyPassed      HookVerifyStatus = "passed"
+	HookVerifySlow        HookVerifyStatus = "slow"
+	HookVerifyMissing     HookVerifyStatus = "missing"
+	HookVerifyMisbehaving HookVerifyStatus = "misbehaving"
+	HookVerifyUnreachable HookVerifyStatus = "unreachable"
+
+	hookVerifyHealthEndpoint       = "/health"
+	hookVerifyDefaultSlowThreshold = time.Second
+	hookVerifyDefaultTimeout       = 10 * time.Second
+	hookVerifyMaxSchemaDepth       = 8
+	hookVerifyRootPath             = "$"
+	hookVerifyCustomizeQuery       = "query { __typename }"
+	hookVerifyContentType          = "application/json"
+)
+
+type (
+	HookVerifyOption struct {
+		SlowThreshold time.Duration
+		Timeout       time.Duration
+	}
+	HookVerifyReport struct {
+		ServerUrl string              `json:"serverUrl"`
+		Passed    bool                `json:"passed"`
+		Results   []*HookVerifyResult `json:"results"`
+	}
+	HookVerifyResult struct {
+		Endpoint   string           `json:"endpoint"`
+		Hook       string           `json:"hook"`
+		Target     string           `json:"target,omitempty"`
+		Status     HookVerifyStatus `json:"status"`
+		StatusCode int              `json:"statusCode,omitempty"`
+		Latency    int64            `json:"latency"`
+		Errors     []string         `json:"errors,omitempty"`
+	}
+	// 待校验的钩子，pattern为hook.swagger中的路径
+	hookVerifyTarget struct {
+		pattern  string
+		endpoint string
+		hook     string
+		target   string
+		method   string
+		rewrite  func(map[string]any)
+	}
+)
+
+// VerifyHooks 向钩子服务发送模拟请求并按照hook.swagger校验响应
+func VerifyHooks(ctx context.Context, option *HookVerifyOption) (report *HookVerifyReport, err error) {
+	serverUrl := strings.TrimSuffix(models.GetHookServerUrl(), "/")
+	if serverUrl == "" {
+		err = i18n.NewCustomErrorWithMode(models.SdkRoot.GetModelName(), nil, i18n.SettingServerUrlEmptyError)
+		return
+	}
+
+	docBytes, _ := json.Marshal(serverReflectFactory.buildHookSwagger())
+	doc, err := openapi3.NewLoader().LoadFromData(docBytes)
+	if err != nil {
+		return
+	}
+
+	if option == nil {
+		option = &HookVerifyOption{}
+	}
+	if option.SlowThreshold <= 0 {
+		option.SlowThreshold = hookVerifyDefaultSlowThreshold
+	}
+	if option.Timeout <= 0 {
+		option.Timeout = hookVerifyDefaultTimeout
+	}
+	client := &http.Client{Timeout: option.Timeout}
+	report = &HookVerifyReport{ServerUrl: serverUrl, Passed: true}
+	var healthResult *HookVerifyResult
+	for _, target := range collectHookVerifyTargets() {
+		var result *HookVerifyResult
+		// 健康检查不可达时不再请求其他钩子
+		if healthResult != nil && healthResult.Status == HookVerifyUnreachable {
+			result = &HookVerifyResult{Status: HookVerifyUnreachable, Errors: healthResult.Errors}
+		} else {
+			result = target.verify(ctx, client, serverUrl, doc.Paths[target.pattern], option.SlowThreshold)
+		}
+		result.Endpoint, result.Hook, result.Target = target.endpoint, target.hook, target.target
+		if target.pattern == hookVerifyHealthEndpoint {
+			healthResult = result
+		}
+		if result.Status != HookVerifyPassed {
+			report.Passed = false
+		}
+		report.Results = append(report.Results, result)
+	}
+	return
+}
+
+// 收集开启的钩子，同一个地址只校验一次
+func collectHookVerifyTargets() (targets []*hookVerifyTarget) {
+	existedEndpoints := make(map[string]bool)
+	appendTarget := func(target *hookVerifyTarget) {
+		if existedEndpoints[target.endpoint] {
+			return
+		}
+
+		existedEndpoints[target.endpoint] = true
+		if target.method == "" {
+			target.method = http.MethodPost
+		}
+		targets = append(targets, target)
+	}
+	forEachEnabledHook := func(options models.HookOptions, callback func(consts.MiddlewareHook)) {
+		hooks := maps.Keys(options)
+		slices.Sort(hooks)
+		for _, hook := range hooks {
+			if options[hook].Enabled {
+				callback(hook)
+			}
+		}
+	}
+	appendHttpTransportTarget := func(hook consts.MiddlewareHook, target string) {
+		pattern := fmt.Sprintf("/global/httpTransport/%s", hook)
+		appendTarget(&hookVerifyTarget{pattern: pattern, endpoint: pattern, hook: string(hook), target: target})
+	}
+
+	appendTarget(&hookVerifyTarget{pattern: hookVerifyHealthEndpoint, endpoint: hookVerifyHealthEndpoint, hook: "health", method: http.MethodGet})
+	forEachEnabledHook(models.GetHttpTransportHookOptions(), func(hook consts.MiddlewareHook) {
+		appendHttpTransportTarget(hook, consts.GlobalOperation)
+	})
+	if globalOperation := models.GlobalOperationRoot.FirstData(); globalOperation != nil && globalOperation.GlobalHttpTransportHooks[consts.WsTransportOnConnectionInit] {
+		pattern := fmt.Sprintf("/global/wsTransport/%s", consts.WsTransportOnConnectionInit)
+		appendTarget(&hookVerifyTarget{pattern: pattern, endpoint: pattern, hook: string(consts.WsTransportOnConnectionInit), target: consts.GlobalOperation})
+	}
+	forEachEnabledHook(models.GetAuthenticationHookOptions(), func(hook consts.MiddlewareHook) {
+		pattern := fmt.Sprintf("/authentication/%s", hook)
+		appendTarget(&hookVerifyTarget{pattern: pattern, endpoint: pattern, hook: string(hook), target: consts.GlobalOperation})
+	})
+
+	operations := models.OperationRoot.ListByCondition(func(item *models.Operation) bool { return item.Enabled })
+	slices.SortFunc(operations, func(a, b *models.Operation) bool { return a.Path < b.Path })
+	for _, operation := range operations {
+		path := operation.Path
+		// function/proxy接口由钩子服务实现，地址即接口路径
+		switch operation.Engine {
+		case wgpb.OperationExecutionEngine_ENGINE_FUNCTION:
+			appendTarget(&hookVerifyTarget{
+				pattern:  fmt.Sprintf("/%s/{path}", consts.HookFunctionParent),
+				endpoint: "/" + path,
+				hook:     consts.HookFunctionParent,
+				target:   path,
+				rewrite: func(body map[string]any) {
+					body["op"], body["hook"] = path, consts.HookFunctionParent
+				},
+			})
+		case wgpb.OperationExecutionEngine_ENGINE_PROXY:
+			appendTarget(&hookVerifyTarget{
+				pattern:  fmt.Sprintf("/%s/{path}", consts.HookProxyParent),
+				endpoint: "/" + path,
+				hook:     consts.HookProxyParent,
+				target:   path,
+			})
+		}
+		forEachEnabledHook(models.GetOperationHookOptions(path), func(hook consts.MiddlewareHook) {
+			// operation开启的httpTransport钩子调用的是全局钩子地址
+			if _, ok := models.HttpTransportHookAliasMap[hook]; ok {
+				appendHttpTransportTarget(hook, path)
+				return
+			}
+
+			appendTarget(&hookVerifyTarget{
+				pattern:  fmt.Sprintf("/operation/{path}/%s", hook),
+				endpoint: fmt.Sprintf("/operation/%s/%s", path, hook),
+				hook:     string(hook),
+				target:   path,
+				rewrite: func(body map[string]any) {
+					body["op"], body["hook"] = path, hook
+				},
+			})
+		})
+	}
+
+	storages := models.StorageRoot.List()
+	slices.SortFunc(storages, func(a, b *models.Storage) bool { return a.Name < b.Name })
+	for _, storage := range storages {
+		profiles := maps.Keys(storage.UploadProfiles)
+		slices.Sort(profiles)
+		for _, profile := range profiles {
+			forEachEnabledHook(models.GetStorageProfileHookOptions(storage.Name, profile), func(hook consts.MiddlewareHook) {
+				appendTarget(&hookVerifyTarget{
+					pattern:  fmt.Sprintf("/upload/{provider}/{profile}/%s", hook),
+					endpoint: fmt.Sprintf("/upload/%s/%s/%s", storage.Name, profile, hook),
+					hook:     string(hook),
+					target:   storage.Name + "/" + profile,
+				})
+			})
+		}
+	}
+
+	datasources := models.DatasourceRoot.List()
+	slices.SortFunc(datasources, func(a, b *models.Datasource) bool { return a.Name < b.Name })
+	for _, ds := range datasources {
+		if !ds.Enabled || ds.CustomGraphql == nil || !ds.CustomGraphql.Customized {
+			continue
+		}
+
+		appendTarget(&hookVerifyTarget{
+			pattern:  "/gqls/{name}/graphql",
+			endpoint: fmt.Sprintf("/gqls/%s/graphql", ds.Name),
+			hook:     consts.HookCustomizeParent,
+			target:   ds.Name,
+			rewrite: func(body map[string]any) {
+				body["operationName"], body["query"] = "", hookVerifyCustomizeQuery
+			},
+		})
+	}
+	return
+}
+
+// 发送模拟请求并校验状态码、响应体和耗时
+func (t *hookVerifyTarget) verify(ctx context.Context, client *http.Client, serverUrl string, pathItem *openapi3.PathItem, slowThreshold time.Duration) (result *HookVerifyResult) {
+	result = &HookVerifyResult{}
+	if pathItem == nil || pathItem.Post == nil {
+		result.Status, result.Errors = HookVerifyMisbehaving, []string{fmt.Sprintf("endpoint %s not defined in hook swagger", t.pattern)}
+		return
+	}
+
+	operation := pathItem.Post
+	var body io.Reader
+	if requestSchema := getHookVerifyJsonSchema(operation.RequestBody); requestSchema != nil && t.method != http.MethodGet {
+		requestBody, _ := synthesizeHookSchemaValue(requestSchema, 0).(map[string]any)
+		if requestBody == nil {
+			requestBody = make(map[string]any)
+		}
+		setHookVerifyValue(requestBody, t.method, "__wg", "clientRequest", "method")
+		setHookVerifyValue(requestBody, t.endpoint, "__wg", "clientRequest", "requestURI")
+		if t.rewrite != nil {
+			t.rewrite(requestBody)
+		}
+		requestBytes, _ := json.Marshal(requestBody)
+		body = bytes.NewReader(requestBytes)
+	}
+	request, err := http.NewRequestWithContext(ctx, t.method, serverUrl+t.endpoint, body)
+	if err != nil {
+		result.Status, result.Errors = HookVerifyMisbehaving, []string{err.Error()}
+		return
+	}
+	request.Header.Set("Content-Type", hookVerifyContentType)
+
+	startTime := time.Now()
+	response, err := client.Do(request)
+	if err != nil {
+		result.Latency = time.Since(startTime).Milliseconds()
+		result.Status, result.Errors = HookVerifyUnreachable, []string{err.Error()}
+		return
+	}
+	defer func() { _ = response.Body.Close() }()
+	responseBytes, err := io.ReadAll(response.Body)
+	latency := time.Since(startTime)
+	result.StatusCode, result.Latency = response.StatusCode, latency.Milliseconds()
+	switch {
+	case err != nil:
+		result.Status, result.Errors = HookVerifyMisbehaving, []string{err.Error()}
+	case response.StatusCode == http.StatusNotFound:
+		result.Status = HookVerifyMissing
+	case response.StatusCode != http.StatusOK:
+		result.Status, result.Errors = HookVerifyMisbehaving, []string{fmt.Sprintf("unexpected status code %d: %s", response.StatusCode, responseBytes)}
+	default:
+		if responseSchema := getHookVerifyResponseSchema(operation.Responses); responseSchema != nil {
+			var responseValue any
+			if err = json.Unmarshal(responseBytes, &responseValue); err != nil {
+				result.Status, result.Errors = HookVerifyMisbehaving, []string{fmt.Sprintf("invalid json response: %s", err.Error())}
+				return
+			}
+			if result.Errors = validateHookSchemaValue(responseSchema, responseValue, hookVerifyRootPath, 0); len(result.Errors) > 0 {
+				result.Status = HookVerifyMisbehaving
+				return
+			}
+		}
+		if result.Status = HookVerifyPassed; latency > slowThreshold {
+			result.Status = HookVerifySlow
+		}
+	}
+	return
+}
+
+func getHookVerifyJsonSchema(requestBody *openapi3.RequestBodyRef) *openapi3.Schema {
+	if requestBody == nil || requestBody.Value == nil {
+		return nil
+	}
+
+	return getHookVerifyContentSchema(requestBody.Value.Content)
+}
+
+func getHookVerifyResponseSchema(responses openapi3.Responses) *openapi3.Schema {
+	response := responses.Get(http.StatusOK)
+	if response == nil || response.Value == nil {
+		return nil
+	}
+
+	return getHookVerifyContentSchema(response.Value.Content)
+}
+
+func getHookVerifyContentSchema(content openapi3.Content) *openapi3.Schema {
+	mediaType := content.Get(hookVerifyContentType)
+	if mediaType == nil || mediaType.Schema == nil {
+		return nil
+	}
+
+	return mediaType.Schema.Value
+}
+
+// 在多层对象中设置值，中间层不存在或不是对象时忽略
+func setHookVerifyValue(body map[string]any, value any, path ...string) {
+	for _, key := range path[:len(path)-1] {
+		child, ok := body[key].(map[string]any)
+		if !ok {
+			return
+		}
+		body = child
+	}
+	body[path[len(path)-1]] = value
+}
+
+// 根据schema构造模拟值，优先使用示例值、默认值和第一个枚举值
+func synthesizeHookSchemaValue(schema *openapi3.Schema, depth int) any {
+	if schema == nil || depth > hookVerifyMaxSchemaDepth {
+		return nil
+	}
+	if schema.Example != nil {
+		return schema.Example
+	}
+	if schema.Default != nil {
+		return schema.Default
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[0]
+	}
+	if candidates := append(slices.Clone(schema.OneOf), schema.AnyOf...); len(candidates) > 0 {
+		return synthesizeHookSchemaValue(candidates[0].Value, depth+1)
+	}
+	if len(schema.AllOf) > 0 {
+		merged := make(map[string]any)
+		for _, item := range schema.AllOf {
+			if value, ok := synthesizeHookSchemaValue(item.Value, depth+1).(map[string]any); ok {
+				maps.Copy(merged, value)
+			}
+		}
+		return merged
+	}
+
+	switch schema.Type {
+	case openapi3.TypeObject:
+		value := make(map[string]any, len(schema.Properties))
+		for name, property := range schema.Properties {
+			value[name] = synthesizeHookSchemaValue(property.Value, depth+1)
+		}
+		return value
+	case openapi3.TypeArray:
+		return []any{}
+	case openapi3.TypeString:
+		if schema.Format == "date-time" {
+			return time.Now().Format(time.RFC3339)
+		}
+		return ""
+	case openapi3.TypeInteger, openapi3.TypeNumber:
+		return 0
+	case openapi3.TypeBoolean:
+		return false
+	default:
+		return nil
+	}
+}
+
+// 校验值的类型和枚举，返回所有不匹配的字段
+func validateHookSchemaValue(schema *openapi3.Schema, value any, path string, depth int) (errs []string) {
+	if schema == nil || value == nil || depth > hookVerifyMaxSchemaDepth {
+		return
+	}
+	if candidates := append(slices.Clone(schema.OneOf), schema.AnyOf...); len(candidates) > 0 {
+		for _, item := range candidates {
+			if len(validateHookSchemaValue(item.Value, value, path, depth+1)) == 0 {
+				return
+			}
+		}
+		return []string{fmt.Sprintf("%s: not match any of schemas", path)}
+	}
+	for _, item := range schema.AllOf {
+		errs = append(errs, validateHookSchemaValue(item.Value, value, path, depth+1)...)
+	}
+	if len(schema.Enum) > 0 && !slices.ContainsFunc(schema.Enum, func(item any) bool { return fmt.Sprint(item) == fmt.Sprint(value) }) {
+		errs = append(errs, fmt.Sprintf("%s: %v not in enum %v", path, value, schema.Enum))
+	}
+
+	typeMismatched := func() []string {
+		return append(errs, fmt.Sprintf("%s: expected %s, got %s", path, schema.Type, getHookVerifyJsonType(value)))
+	}
+	switch schema.Type {
+	case openapi3.TypeObject:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return typeMismatched()
+		}
+
+		names := maps.Keys(object)
+		slices.Sort(names)
+		for _, name := range names {
+			propertySchema := schema.AdditionalProperties.Schema
+			if property, ok := schema.Properties[name]; ok {
+				propertySchema = property
+			}
+			if propertySchema != nil {
+				errs = append(errs, validateHookSchemaValue(propertySchema.Value, object[name], path+"."+name, depth+1)...)
+			}
+		}
+	case openapi3.TypeArray:
+		array, ok := value.([]any)
+		if !ok {
+			return typeMismatched()
+		}
+
+		if schema.Items != nil {
+			for index, item := range array {
+				errs = append(errs, validateHookSchemaValue(schema.Items.Value, item, fmt.Sprintf("%s[%d]", path, index), depth+1)...)
+			}
+		}
+	case openapi3.TypeString:
+		if _, ok := value.(string); !ok {
+			return typeMismatched()
+		}
+	case openapi3.TypeNumber:
+		if _, ok := value.(float64); !ok {
+			return typeMismatched()
+		}
+	case openapi3.TypeInteger:
+		if number, ok := value.(float64); !ok || number != math.Trunc(number) {
+			return typeMismatched()
+		}
+	case openapi3.TypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return typeMismatched()
+		}
+	}
+	return
+}
+
+func getHookVerifyJsonType(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return openapi3.TypeObject
+	case []any:
+		return openapi3.TypeArray
+	case string:
+		return openapi3.TypeString
+	case float64:
+		return openapi3.TypeNumber
+	case bool:
+		return openapi3.TypeBoolean
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
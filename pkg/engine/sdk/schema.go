@@ -88,6 +88,9 @@ type (
 		IsLiveQuery      bool
 		IsMutation       bool
 		IsSubscription   bool
+		RateLimitTiers   []*models.OperationRateLimitTier
+		IsDeprecated     bool
+		Deprecation      *models.OperationDeprecation
 	}
 )
 
@@ -205,6 +208,11 @@ func (t *templateContext) buildFromDefinedApiSchema(api, sdkRequiredApi *wgpb.Us
 			maxLength = itemLen
 		}
 		t.buildOperationHooks(item)
+		var rateLimitTiers []*models.OperationRateLimitTier
+		var deprecation *models.OperationDeprecation
+		if itemData, _ := models.OperationRoot.GetByDataName(item.Path); itemData != nil {
+			rateLimitTiers, deprecation = operationsConfigData.GetRateLimitTiers(itemData), itemData.Deprecation
+		}
 		itemOperationInfo := &operationInfo{
 			Name: item.Name, Path: item.Path, IsInternal: item.Internal,
 			Engine:         item.Engine,
@@ -214,6 +222,9 @@ func (t *templateContext) buildFromDefinedApiSchema(api, sdkRequiredApi *wgpb.Us
 			IsQuery:        item.OperationType == wgpb.OperationType_QUERY,
 			IsMutation:     item.OperationType == wgpb.OperationType_MUTATION,
 			IsSubscription: item.OperationType == wgpb.OperationType_SUBSCRIPTION,
+			RateLimitTiers: rateLimitTiers,
+			IsDeprecated:   deprecation != nil,
+			Deprecation:    deprecation,
 		}
 		t.Operations = append(t.Operations, itemOperationInfo)
 
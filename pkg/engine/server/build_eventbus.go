@@ -12,6 +12,7 @@ import (
 func (b *EngineBuild) Subscribe() {
 	b.eventbusSubscribeStorage()
 	b.eventbusSubscribeOperation()
+	b.eventbusSubscribeDatasource()
 }
 
 func (b *EngineBuild) printIncrementBuild(event eventbus.Event, field zap.Field) {
@@ -147,3 +148,22 @@ func (b *EngineBuild) eventbusSubscribeStorage() {
 		return storage
 	})
 }
+
+// 数据源增量编译后引擎读取配置缓存生成新的运行时配置，需同步写入配置缓存
+func (b *EngineBuild) eventbusSubscribeDatasource() {
+	datasourceHandler := func(data any) any {
+		increment := data.(*build.DatasourceIncrement)
+		if err := b.emitGraphqlConfigCache(); err != nil {
+			b.logger.Error("increment build failed", zap.Error(err), zap.Strings(string(eventbus.ChannelDatasource), increment.Names))
+			return nil
+		}
+
+		build.CallAsyncGenerates(b.builder)
+		b.logger.Info(string(increment.Event), zap.String(consts.EngineStatusField, consts.EngineIncrementBuild),
+			zap.Strings(string(eventbus.ChannelDatasource), increment.Names))
+		return increment
+	}
+	for _, event := range build.DatasourceIncrementEvents {
+		eventbus.Subscribe(eventbus.ChannelDatasource, event, datasourceHandler)
+	}
+}
@@ -0,0 +1,330 @@
+// Package server
+/*
+ @cache声明的接口响应缓存，飞布服务转发GET请求时读取和写入，引擎启动和增量变更时按照声明更新缓存配置
+ 引擎重启时保留缓存，仅删除缓存配置或接口内容变更及已移除接口的缓存
+ 缓存键由接口路径、声明的参数值和用户claim值计算，需要登录的接口额外按照userId隔离，claim缺失时不缓存
+ 使用LRU淘汰，总大小超过response-cache-max-size时淘汰最久未使用的缓存，过期的缓存在读取时删除
+ @invalidateCache声明的mutation执行成功后按标签删除缓存，命中缓存时不会请求引擎和执行钩子
+*/
+package server
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	json "github.com/json-iterator/go"
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	responseCacheSizeUnit     = 1 << 20
+	responseCacheUserIdClaim  = "userId"
+	responseCacheKeyVariables = "variables"
+	responseCacheKeyClaims    = "claims"
+)
+
+type (
+	ResponseCacheEntry struct {
+		Key         string    `json:"key"`
+		Operation   string    `json:"operation"`
+		Tags        []string  `json:"tags"`
+		Size        int       `json:"size"`
+		Hits        int64     `json:"hits"`
+		CreatedAt   time.Time `json:"createdAt"`
+		ExpiresAt   time.Time `json:"expiresAt"`
+		data        []byte
+		contentType string
+	}
+	ResponseCacheStatistics struct {
+		Entries   int   `json:"entries"`
+		Size      int64 `json:"size"`
+		MaxSize   int64 `json:"maxSize"`
+		Hits      int64 `json:"hits"`
+		Misses    int64 `json:"misses"`
+		Evictions int64 `json:"evictions"`
+	}
+	ResponseCacheSnapshot struct {
+		Statistics *ResponseCacheStatistics `json:"statistics"`
+		Entries    []*ResponseCacheEntry    `json:"entries"`
+	}
+	responseCache struct {
+		elements   map[string]*list.Element
+		lru        *list.List
+		statistics ResponseCacheStatistics
+		mutex      sync.Mutex
+	}
+	// 接口的缓存配置，userIsolated为接口需要登录时按照userId隔离缓存，content用于判断接口内容是否变更
+	operationResponseCache struct {
+		*models.OperationResponseCache
+		userIsolated bool
+		content      string
+	}
+)
+
+var (
+	// ResponseCacheStore 飞布服务转发接口请求时使用的响应缓存
+	ResponseCacheStore = &responseCache{elements: make(map[string]*list.Element), lru: list.New()}
+
+	operationResponseCaches      atomic.Pointer[map[string]*operationResponseCache]
+	operationResponseCachesMutex sync.Mutex
+)
+
+// OperationCacheRequired 判断接口是否声明了@cache或@invalidateCache
+func OperationCacheRequired(operationPath string) bool {
+	return loadOperationResponseCache(operationPath) != nil
+}
+
+// OperationCacheUserRequired 判断计算缓存键是否需要登录用户信息，声明claims或接口需要登录时需要
+func OperationCacheUserRequired(operationPath string) bool {
+	cacheConfig := loadOperationResponseCache(operationPath)
+	return cacheConfig != nil && cacheConfig.TtlSeconds > 0 && (len(cacheConfig.Claims) > 0 || cacheConfig.userIsolated)
+}
+
+// OperationResponseCacheKey 计算接口的缓存键，未声明@cache或声明的claim/userId缺失时返回false
+func OperationResponseCacheKey(operationPath string, caller *OperationRuleCaller) (string, bool) {
+	cacheConfig := loadOperationResponseCache(operationPath)
+	if cacheConfig == nil || cacheConfig.TtlSeconds <= 0 {
+		return "", false
+	}
+
+	keyParts := make(map[string]any)
+	if len(cacheConfig.Variables) > 0 {
+		variables := make(map[string]any, len(cacheConfig.Variables))
+		for _, variable := range cacheConfig.Variables {
+			variables[variable] = searchArgumentValue(caller.Arguments, variable)
+		}
+		keyParts[responseCacheKeyVariables] = variables
+	}
+	if len(cacheConfig.Claims) > 0 {
+		claims := make(map[string]any, len(cacheConfig.Claims))
+		for _, claim := range cacheConfig.Claims {
+			value, ok := rateLimitUserClaim(caller.User, claim)
+			if !ok {
+				return "", false
+			}
+			claims[claim] = value
+		}
+		keyParts[responseCacheKeyClaims] = claims
+	}
+	if cacheConfig.userIsolated {
+		userId, ok := rateLimitUserClaim(caller.User, responseCacheUserIdClaim)
+		if !ok {
+			return "", false
+		}
+		keyParts[responseCacheUserIdClaim] = userId
+	}
+	return buildResponseCacheKey(operationPath, keyParts), true
+}
+
+// StoreOperationResponse 按照接口的@cache声明写入响应缓存
+func StoreOperationResponse(operationPath, key string, data []byte, contentType string) {
+	if cacheConfig := loadOperationResponseCache(operationPath); cacheConfig != nil && cacheConfig.TtlSeconds > 0 {
+		ResponseCacheStore.Set(operationPath, key, data, contentType, cacheConfig.OperationResponseCache)
+	}
+}
+
+// InvalidateOperationResponse 按照接口的@invalidateCache声明删除缓存，返回删除的数量
+func InvalidateOperationResponse(operationPath string) int {
+	cacheConfig := loadOperationResponseCache(operationPath)
+	if cacheConfig == nil {
+		return 0
+	}
+	return ResponseCacheStore.Invalidate(cacheConfig.InvalidateTags)
+}
+
+// Get 读取缓存，过期时删除并返回未命中
+func (c *responseCache) Get(key string) ([]byte, string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	element, ok := c.elements[key]
+	if !ok {
+		c.statistics.Misses++
+		return nil, "", false
+	}
+
+	entry := element.Value.(*ResponseCacheEntry)
+	if time.Now().After(entry.ExpiresAt) {
+		c.removeElement(element)
+		c.statistics.Misses++
+		return nil, "", false
+	}
+
+	entry.Hits++
+	c.statistics.Hits++
+	c.lru.MoveToFront(element)
+	return entry.data, entry.contentType, true
+}
+
+// Set 写入缓存，超过最大容量时按LRU淘汰
+func (c *responseCache) Set(operationPath, key string, data []byte, contentType string, config *models.OperationResponseCache) {
+	maxSize := c.maxSize()
+	if int64(len(data)) > maxSize {
+		return
+	}
+
+	now := time.Now()
+	entry := &ResponseCacheEntry{
+		Key:         key,
+		Operation:   operationPath,
+		Tags:        config.Tags,
+		Size:        len(data),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Duration(config.TtlSeconds) * time.Second),
+		data:        data,
+		contentType: contentType,
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, ok := c.elements[entry.Key]; ok {
+		c.removeElement(element)
+	}
+	c.elements[entry.Key] = c.lru.PushFront(entry)
+	c.statistics.Size += int64(entry.Size)
+	for c.statistics.Size > maxSize {
+		c.removeElement(c.lru.Back())
+		c.statistics.Evictions++
+	}
+}
+
+// Invalidate 删除包含任意标签的缓存，返回删除的数量
+func (c *responseCache) Invalidate(tags []string) int {
+	if len(tags) == 0 {
+		return 0
+	}
+
+	return c.purge(func(entry *ResponseCacheEntry) bool {
+		return slices.ContainsFunc(entry.Tags, func(tag string) bool { return slices.Contains(tags, tag) })
+	})
+}
+
+// Purge 按照接口路径和标签删除缓存，均为空时清空所有缓存
+func (c *responseCache) Purge(operationPath string, tags []string) int {
+	return c.purge(func(entry *ResponseCacheEntry) bool {
+		return (operationPath == "" || entry.Operation == operationPath) &&
+			(len(tags) == 0 || slices.ContainsFunc(entry.Tags, func(tag string) bool { return slices.Contains(tags, tag) }))
+	})
+}
+
+// Snapshot 获取缓存统计和未过期的缓存列表，按最近使用排序
+func (c *responseCache) Snapshot() *ResponseCacheSnapshot {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := time.Now()
+	entries := make([]*ResponseCacheEntry, 0, c.lru.Len())
+	for element := c.lru.Front(); element != nil; element = element.Next() {
+		if entry := element.Value.(*ResponseCacheEntry); now.Before(entry.ExpiresAt) {
+			entryCopy := *entry
+			entries = append(entries, &entryCopy)
+		}
+	}
+	statistics := c.statistics
+	statistics.Entries, statistics.MaxSize = c.lru.Len(), c.maxSize()
+	return &ResponseCacheSnapshot{Statistics: &statistics, Entries: entries}
+}
+
+func (c *responseCache) purge(match func(*ResponseCacheEntry) bool) (count int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for element := c.lru.Front(); element != nil; {
+		next := element.Next()
+		if match(element.Value.(*ResponseCacheEntry)) {
+			c.removeElement(element)
+			count++
+		}
+		element = next
+	}
+	return
+}
+
+func (c *responseCache) removeElement(element *list.Element) {
+	entry := c.lru.Remove(element).(*ResponseCacheEntry)
+	delete(c.elements, entry.Key)
+	c.statistics.Size -= int64(entry.Size)
+}
+
+func (c *responseCache) maxSize() int64 {
+	return int64(utils.GetInt32WithLockViper(consts.ResponseCacheMaxSize)) * responseCacheSizeUnit
+}
+
+func newOperationResponseCache(cache *models.OperationResponseCache, operation *wgpb.Operation) *operationResponseCache {
+	if cache == nil || operation == nil {
+		return nil
+	}
+
+	userIsolated := operation.AuthenticationConfig != nil && operation.AuthenticationConfig.AuthRequired
+	return &operationResponseCache{OperationResponseCache: cache, userIsolated: userIsolated, content: operation.Content}
+}
+
+// 引擎启动时整体替换缓存配置，仅删除缓存配置或接口内容变更及已移除接口的缓存
+func replaceOperationResponseCaches(caches map[string]*operationResponseCache) {
+	operationResponseCachesMutex.Lock()
+	defer operationResponseCachesMutex.Unlock()
+
+	if existed := operationResponseCaches.Load(); existed != nil {
+		for operationPath, cache := range *existed {
+			if !reflect.DeepEqual(cache, caches[operationPath]) {
+				ResponseCacheStore.Purge(operationPath, nil)
+			}
+		}
+	}
+	operationResponseCaches.Store(&caches)
+}
+
+// 增量变更时更新单个接口的缓存配置并删除该接口已有的缓存，cache或operation为nil时移除
+func storeOperationResponseCache(operationPath string, cache *models.OperationResponseCache, operation *wgpb.Operation) {
+	operationResponseCachesMutex.Lock()
+	defer operationResponseCachesMutex.Unlock()
+
+	storedCaches := make(map[string]*operationResponseCache)
+	if existed := operationResponseCaches.Load(); existed != nil {
+		maps.Copy(storedCaches, *existed)
+	}
+	if responseCache := newOperationResponseCache(cache, operation); responseCache != nil {
+		storedCaches[operationPath] = responseCache
+	} else {
+		delete(storedCaches, operationPath)
+	}
+	operationResponseCaches.Store(&storedCaches)
+	ResponseCacheStore.Purge(operationPath, nil)
+}
+
+func loadOperationResponseCache(operationPath string) *operationResponseCache {
+	if caches := operationResponseCaches.Load(); caches != nil {
+		return (*caches)[operationPath]
+	}
+	return nil
+}
+
+// 按照'.'分隔的路径读取参数值，参数不存在时返回nil
+func searchArgumentValue(arguments map[string]any, variable string) (value any) {
+	value = arguments
+	for _, name := range strings.Split(variable, utils.StringDot) {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	return
+}
+
+// 缓存键为接口路径加上参数/claim值的摘要，map序列化时按键排序保证稳定
+func buildResponseCacheKey(operationPath string, keyParts map[string]any) string {
+	if len(keyParts) == 0 {
+		return operationPath
+	}
+
+	keyBytes, _ := json.ConfigCompatibleWithStandardLibrary.Marshal(keyParts)
+	sum := sha256.Sum256(keyBytes)
+	return operationPath + ":" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"golang.org/x/exp/maps"
+	"testing"
+)
+
+// 缓存键按照声明的参数和claim计算，需要登录的接口按照userId隔离，claim缺失时不缓存，mutation按标签删除缓存
+func TestOperationResponseCache(t *testing.T) {
+	utils.SetWithLockViper(consts.ResponseCacheMaxSize, 1)
+	queryPath, mutationPath := "Product/GetMany", "Product/UpdateOne"
+	queryOperation := &wgpb.Operation{Content: "query GetMany", AuthenticationConfig: &wgpb.OperationAuthenticationConfig{AuthRequired: true}}
+	storeOperationResponseCache(queryPath, &models.OperationResponseCache{TtlSeconds: 60, Variables: []string{"where.categoryId"}, Claims: []string{"tenantId"}, Tags: []string{"product"}}, queryOperation)
+	storeOperationResponseCache(mutationPath, &models.OperationResponseCache{InvalidateTags: []string{"product"}}, &wgpb.Operation{})
+	t.Cleanup(func() {
+		storeOperationResponseCache(queryPath, nil, nil)
+		storeOperationResponseCache(mutationPath, nil, nil)
+	})
+
+	user := map[string]any{"userId": "1", "customClaims": map[string]any{"tenantId": "t1"}}
+	caller := &OperationRuleCaller{User: user, Arguments: map[string]any{"where": map[string]any{"categoryId": 1}, "page": 1}}
+	key, ok := OperationResponseCacheKey(queryPath, caller)
+	if !ok {
+		t.Fatal("expected cache key for caller with claims")
+	}
+	if _, ok = OperationResponseCacheKey(queryPath, &OperationRuleCaller{Arguments: caller.Arguments}); ok {
+		t.Error("expected no cache key for anonymous caller")
+	}
+	otherPage, _ := OperationResponseCacheKey(queryPath, &OperationRuleCaller{User: user, Arguments: map[string]any{"where": map[string]any{"categoryId": 1}, "page": 2}})
+	if otherPage != key {
+		t.Error("expected undeclared variables ignored in cache key")
+	}
+	otherUser, _ := OperationResponseCacheKey(queryPath, &OperationRuleCaller{User: map[string]any{"userId": "2", "customClaims": map[string]any{"tenantId": "t1"}}, Arguments: caller.Arguments})
+	if otherUser == key {
+		t.Error("expected cache key isolated by userId")
+	}
+
+	StoreOperationResponse(queryPath, key, []byte(`{"data":{}}`), "application/json")
+	if data, contentType, hit := ResponseCacheStore.Get(key); !hit || string(data) != `{"data":{}}` || contentType != "application/json" {
+		t.Fatalf("expected cache hit, got %s %s %v", data, contentType, hit)
+	}
+	// 引擎重启时接口未变更的缓存保留，接口内容变更时删除
+	caches := maps.Clone(*operationResponseCaches.Load())
+	replaceOperationResponseCaches(caches)
+	if _, _, hit := ResponseCacheStore.Get(key); !hit {
+		t.Fatal("expected cache kept across engine restart")
+	}
+	changedCaches := maps.Clone(caches)
+	changedCaches[queryPath] = newOperationResponseCache(caches[queryPath].OperationResponseCache, &wgpb.Operation{Content: "query GetManyChanged", AuthenticationConfig: queryOperation.AuthenticationConfig})
+	replaceOperationResponseCaches(changedCaches)
+	if _, _, hit := ResponseCacheStore.Get(key); hit {
+		t.Fatal("expected cache purged after operation changed")
+	}
+
+	StoreOperationResponse(queryPath, key, []byte(`{"data":{}}`), "application/json")
+	if count := InvalidateOperationResponse(mutationPath); count != 1 {
+		t.Errorf("expected 1 entry invalidated, got %d", count)
+	}
+	if _, _, hit := ResponseCacheStore.Get(key); hit {
+		t.Error("expected cache miss after invalidation")
+	}
+}
@@ -0,0 +1,128 @@
+// Package server
+/*
+ 已弃用接口的响应头和调用统计，飞布服务转发接口请求时调用，引擎启动和接口增量变更时按照接口的deprecation配置更新响应头
+ 仅统计经过飞布服务转发的请求，直接请求引擎端口(如PublicNodeUrl指向引擎时)不会设置响应头和计数
+ 响应头包括Deprecation，设置下线时间时添加Sunset，设置替代接口时添加rel="successor-version"的Link
+ 统计数据在服务运行期间保留，引擎重启不会清空
+*/
+package server
+
+import (
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	"fmt"
+	"github.com/wundergraph/wundergraph/pkg/apihandler"
+	"golang.org/x/exp/maps"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	DeprecatedOperationReport struct {
+		Path         string     `json:"path"`
+		Title        string     `json:"title"`
+		Enabled      bool       `json:"enabled"`
+		Reason       string     `json:"reason"`
+		Replacement  string     `json:"replacement"`
+		SunsetDate   string     `json:"sunsetDate"`
+		SunsetPassed bool       `json:"sunsetPassed"`
+		Calls        int64      `json:"calls"`
+		LastCalledAt *time.Time `json:"lastCalledAt,omitempty"`
+	}
+	deprecatedOperationCall struct {
+		calls        atomic.Int64
+		lastCalledAt atomic.Pointer[time.Time]
+	}
+)
+
+const (
+	deprecationHeaderValue = "true"
+	successorLinkFormat    = `<%s>; rel="successor-version"`
+)
+
+var (
+	deprecatedOperationCalls        utils.SyncMap[string, *deprecatedOperationCall]
+	deprecatedOperationHeaders      atomic.Pointer[map[string]http.Header]
+	deprecatedOperationHeadersMutex sync.Mutex
+)
+
+// GetDeprecatedOperationReports 获取所有声明deprecation的接口及其调用次数
+func GetDeprecatedOperationReports() []*DeprecatedOperationReport {
+	deprecatedOperations := models.OperationRoot.ListByCondition(func(item *models.Operation) bool { return item.Deprecation != nil })
+	reports := make([]*DeprecatedOperationReport, 0, len(deprecatedOperations))
+	for _, item := range deprecatedOperations {
+		report := &DeprecatedOperationReport{
+			Path:         item.Path,
+			Title:        item.Title,
+			Enabled:      item.Enabled,
+			Reason:       item.Deprecation.Reason,
+			Replacement:  item.Deprecation.Replacement,
+			SunsetDate:   item.Deprecation.SunsetDate,
+			SunsetPassed: item.SunsetPassed(),
+		}
+		if call, ok := deprecatedOperationCalls.Load(item.Path); ok {
+			report.Calls, report.LastCalledAt = call.calls.Load(), call.lastCalledAt.Load()
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// CallDeprecatedOperation 记录已弃用接口的调用并返回需要设置的响应头，未弃用的接口返回nil
+func CallDeprecatedOperation(operationPath string) http.Header {
+	headersMap := deprecatedOperationHeaders.Load()
+	if headersMap == nil {
+		return nil
+	}
+
+	headers, ok := (*headersMap)[operationPath]
+	if !ok {
+		return nil
+	}
+
+	recordDeprecatedOperationCall(operationPath)
+	return headers
+}
+
+// 增量变更时更新单个接口的弃用响应头，接口删除或未声明deprecation时移除
+func storeDeprecatedOperationHeaders(operationPath string, operation *models.Operation) {
+	deprecatedOperationHeadersMutex.Lock()
+	defer deprecatedOperationHeadersMutex.Unlock()
+
+	storedHeaders := make(map[string]http.Header)
+	if existed := deprecatedOperationHeaders.Load(); existed != nil {
+		maps.Copy(storedHeaders, *existed)
+	}
+	if operation != nil && operation.Deprecation != nil {
+		storedHeaders[operationPath] = buildDeprecatedOperationHeaders(operation)
+	} else {
+		delete(storedHeaders, operationPath)
+	}
+	deprecatedOperationHeaders.Store(&storedHeaders)
+}
+
+func recordDeprecatedOperationCall(operationPath string) {
+	call, ok := deprecatedOperationCalls.Load(operationPath)
+	if !ok {
+		call, _ = deprecatedOperationCalls.LoadOrStore(operationPath, &deprecatedOperationCall{})
+	}
+	now := time.Now()
+	call.calls.Add(1)
+	call.lastCalledAt.Store(&now)
+}
+
+// 构建已弃用接口的响应头，下线时间按照HTTP-date格式输出
+func buildDeprecatedOperationHeaders(operation *models.Operation) http.Header {
+	headers := http.Header{}
+	headers.Set(consts.HeaderParamDeprecation, deprecationHeaderValue)
+	if sunset, err := operation.SunsetTime(); err == nil && !sunset.IsZero() {
+		headers.Set(consts.HeaderParamSunset, sunset.UTC().Format(http.TimeFormat))
+	}
+	if replacement := operation.Deprecation.Replacement; replacement != "" {
+		headers.Set(consts.HeaderParamLink, fmt.Sprintf(successorLinkFormat, apihandler.OperationApiPath(replacement)))
+	}
+	return headers
+}
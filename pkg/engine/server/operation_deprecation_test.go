@@ -0,0 +1,48 @@
+package server
+
+import (
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"github.com/wundergraph/wundergraph/pkg/apihandler"
+	"net/http"
+	"testing"
+)
+
+// 已弃用接口返回Deprecation/Sunset/Link响应头并计数，未弃用或取消弃用的接口不设置响应头
+func TestCallDeprecatedOperation(t *testing.T) {
+	defer deprecatedOperationHeaders.Store(deprecatedOperationHeaders.Load())
+	operation := &models.Operation{
+		Path:        "user/getV1",
+		Deprecation: &models.OperationDeprecation{Replacement: "user/getV2", SunsetDate: "2030-01-01T00:00:00Z"},
+	}
+	deprecatedOperationHeaders.Store(&map[string]http.Header{operation.Path: buildDeprecatedOperationHeaders(operation)})
+	deprecatedOperationCalls.Store(operation.Path, &deprecatedOperationCall{})
+
+	headers := CallDeprecatedOperation(operation.Path)
+	expected := map[string]string{
+		consts.HeaderParamDeprecation: "true",
+		consts.HeaderParamSunset:      "Tue, 01 Jan 2030 00:00:00 GMT",
+		consts.HeaderParamLink:        "<" + apihandler.OperationApiPath("user/getV2") + `>; rel="successor-version"`,
+	}
+	for name, value := range expected {
+		if actual := headers.Get(name); actual != value {
+			t.Errorf("expected header %s=%s, got %s", name, value, actual)
+		}
+	}
+	if call, ok := deprecatedOperationCalls.Load(operation.Path); !ok || call.calls.Load() != 1 {
+		t.Errorf("expected deprecated call recorded once")
+	}
+
+	if headers = CallDeprecatedOperation("user/getV2"); headers != nil {
+		t.Errorf("expected no headers for active operation, got %v", headers)
+	}
+	if deprecatedOperationCalls.Contains("user/getV2") {
+		t.Errorf("expected active operation not recorded")
+	}
+
+	// 增量变更取消弃用后不再设置响应头
+	storeDeprecatedOperationHeaders(operation.Path, &models.Operation{Path: operation.Path})
+	if headers = CallDeprecatedOperation(operation.Path); headers != nil {
+		t.Errorf("expected no headers after deprecation removed, got %v", headers)
+	}
+}
@@ -0,0 +1,237 @@
+// Package server
+/*
+ 接口响应脱敏，飞布服务转发接口请求时调用，引擎启动和增量变更时按照@mask声明更新脱敏字段
+ 按照字段路径改写响应中的字符串，用户拥有exceptRoles中任意角色或rule表达式结果为false时不脱敏
+ rule表达式计算失败时按照需要脱敏处理，直接请求引擎端口的响应不会脱敏
+*/
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	"github.com/PaesslerAG/gval"
+	"github.com/buger/jsonparser"
+	json "github.com/json-iterator/go"
+	"github.com/wundergraph/wundergraph/pkg/apihandler"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// OperationRuleCaller rule表达式的调用方信息，User为nil时视为匿名用户
+type OperationRuleCaller struct {
+	Header    http.Header
+	Arguments map[string]any
+	User      map[string]any
+}
+
+const (
+	maskUserRoles          = "roles"
+	ruleArguments          = "arguments"
+	ruleRequest            = "request"
+	ruleRequestHeader      = "header"
+	ruleEnvironment        = "environment"
+	ruleUser               = "user"
+	ruleEnvironmentItemSep = "="
+)
+
+var (
+	operationMaskFields      atomic.Pointer[map[string][]*models.OperationMaskField]
+	operationMaskFieldsMutex sync.Mutex
+	ruleEvaluables           utils.SyncMap[string, gval.Evaluable]
+)
+
+// OperationMaskRequired 判断接口是否声明了脱敏字段
+func OperationMaskRequired(operationPath string) bool {
+	return len(loadOperationMaskFields(operationPath)) > 0
+}
+
+// OperationMaskUserRequired 判断接口脱敏是否需要登录用户信息，声明exceptRoles或rule时需要
+func OperationMaskUserRequired(operationPath string) bool {
+	return slices.ContainsFunc(loadOperationMaskFields(operationPath), func(field *models.OperationMaskField) bool {
+		return len(field.ExceptRoles) > 0 || field.Rule != ""
+	})
+}
+
+// OperationMaskArgumentsRequired 判断接口脱敏是否需要请求参数，声明rule时需要
+func OperationMaskArgumentsRequired(operationPath string) bool {
+	return slices.ContainsFunc(loadOperationMaskFields(operationPath), func(field *models.OperationMaskField) bool {
+		return field.Rule != ""
+	})
+}
+
+// MaskOperationResponse 按照接口的脱敏字段改写单条响应数据，数据无法解析时原样返回
+func MaskOperationResponse(operationPath string, data []byte, caller *OperationRuleCaller) []byte {
+	var ruleParameters map[string]any
+	for _, field := range loadOperationMaskFields(operationPath) {
+		if slices.ContainsFunc(field.ExceptRoles, func(role string) bool { return maskUserHasRole(caller.User, role) }) {
+			continue
+		}
+		if field.Rule != "" {
+			if ruleParameters == nil {
+				ruleParameters = buildRuleParameters(caller)
+			}
+			if masked, err := evaluateRule(field.Rule, ruleParameters); err == nil && !masked {
+				continue
+			}
+		}
+
+		data = maskJsonPath(data, jsonparser.Object, field.Path, field)
+	}
+	return data
+}
+
+// 增量变更时更新单个接口的脱敏字段，fields为空时移除，复制后整体替换避免影响正在读取的请求
+func storeOperationMaskFields(operationPath string, fields []*models.OperationMaskField) {
+	operationMaskFieldsMutex.Lock()
+	defer operationMaskFieldsMutex.Unlock()
+
+	storedFields := make(map[string][]*models.OperationMaskField)
+	if existed := operationMaskFields.Load(); existed != nil {
+		maps.Copy(storedFields, *existed)
+	}
+	if len(fields) > 0 {
+		storedFields[operationPath] = fields
+	} else {
+		delete(storedFields, operationPath)
+	}
+	operationMaskFields.Store(&storedFields)
+}
+
+func loadOperationMaskFields(operationPath string) []*models.OperationMaskField {
+	if fields := operationMaskFields.Load(); fields != nil {
+		return (*fields)[operationPath]
+	}
+	return nil
+}
+
+func maskUserHasRole(user map[string]any, role string) bool {
+	roles, _ := user[maskUserRoles].([]any)
+	return slices.ContainsFunc(roles, func(item any) bool { return item == role })
+}
+
+// 构造rule表达式的参数，与引擎中rule表达式可以读取的参数保持一致
+func buildRuleParameters(caller *OperationRuleCaller) map[string]any {
+	header := make(map[string]any, len(caller.Header))
+	for name := range caller.Header {
+		header[name] = caller.Header.Get(name)
+	}
+	environment := make(map[string]any)
+	for _, item := range os.Environ() {
+		if name, value, ok := strings.Cut(item, ruleEnvironmentItemSep); ok {
+			environment[name] = value
+		}
+	}
+	return map[string]any{
+		ruleArguments:   caller.Arguments,
+		ruleRequest:     map[string]any{ruleRequestHeader: header},
+		ruleEnvironment: environment,
+		ruleUser:        caller.User,
+	}
+}
+
+func evaluateRule(rule string, parameters map[string]any) (bool, error) {
+	evaluable, ok := ruleEvaluables.Load(rule)
+	if !ok {
+		var err error
+		if evaluable, err = apihandler.GvalFullLanguage.NewEvaluable(rule); err != nil {
+			return false, err
+		}
+		ruleEvaluables.Store(rule, evaluable)
+	}
+	return evaluable.EvalBool(context.Background(), parameters)
+}
+
+// 按照路径递归改写json数据，'[]'表示遍历数组元素，字段不存在或类型不匹配时保持原样
+func maskJsonPath(data []byte, dataType jsonparser.ValueType, path []string, field *models.OperationMaskField) []byte {
+	if len(path) == 0 {
+		return maskJsonString(data, dataType, field)
+	}
+
+	if path[0] == utils.ArrayPath {
+		if dataType != jsonparser.Array {
+			return data
+		}
+
+		result, index := []byte{'['}, 0
+		if _, err := jsonparser.ArrayEach(data, func(value []byte, valueType jsonparser.ValueType, _ int, _ error) {
+			if index > 0 {
+				result = append(result, ',')
+			}
+			index++
+			result = append(result, maskJsonPath(rawJsonValue(value, valueType), valueType, path[1:], field)...)
+		}); err != nil {
+			return data
+		}
+		return append(result, ']')
+	}
+
+	if dataType != jsonparser.Object {
+		return data
+	}
+	value, valueType, _, err := jsonparser.Get(data, path[0])
+	if err != nil {
+		return data
+	}
+	result, err := jsonparser.Set(data, maskJsonPath(rawJsonValue(value, valueType), valueType, path[1:], field), path[0])
+	if err != nil {
+		return data
+	}
+	return result
+}
+
+// jsonparser读取的字符串不包含引号，补充引号还原成json数据
+func rawJsonValue(value []byte, valueType jsonparser.ValueType) []byte {
+	if valueType != jsonparser.String {
+		return value
+	}
+
+	raw := make([]byte, 0, len(value)+2)
+	raw = append(raw, '"')
+	raw = append(raw, value...)
+	return append(raw, '"')
+}
+
+func maskJsonString(data []byte, dataType jsonparser.ValueType, field *models.OperationMaskField) []byte {
+	if dataType != jsonparser.String {
+		return data
+	}
+	if field.Strategy == models.MaskStrategyNull {
+		return []byte("null")
+	}
+
+	// 无法反转义时使用原始内容脱敏，避免原样输出
+	value, err := jsonparser.ParseString(data[1 : len(data)-1])
+	if err != nil {
+		value = string(data[1 : len(data)-1])
+	}
+	masked, _ := json.Marshal(maskString(field, value))
+	return masked
+}
+
+// 按照脱敏策略处理字符串
+func maskString(field *models.OperationMaskField, value string) string {
+	switch field.Strategy {
+	case models.MaskStrategyKeep:
+		length := utf8.RuneCountInString(value)
+		if field.KeepFirst+field.KeepLast >= length {
+			// 保留长度覆盖整个字符串时全部脱敏，避免原样输出
+			return strings.Repeat(field.MaskChar, length)
+		}
+		runes := []rune(value)
+		return string(runes[:field.KeepFirst]) + strings.Repeat(field.MaskChar, length-field.KeepFirst-field.KeepLast) + string(runes[length-field.KeepLast:])
+	case models.MaskStrategyFixed:
+		return field.Replacement
+	default:
+		sum := sha256.Sum256([]byte(value))
+		return hex.EncodeToString(sum[:])
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fireboom-server/pkg/common/models"
+	"reflect"
+	"testing"
+
+	json "github.com/json-iterator/go"
+)
+
+// 按照路径改写数组和对象中的字符串，字段不存在或为null时保持原样，拥有exceptRoles角色或rule为false时不脱敏
+func TestMaskOperationResponse(t *testing.T) {
+	operationPath := "User/GetMany"
+	storeOperationMaskFields(operationPath, []*models.OperationMaskField{
+		{Path: []string{"data", "users", "[]", "phone"}, Strategy: models.MaskStrategyKeep, KeepFirst: 3, KeepLast: 4, MaskChar: "*"},
+		{Path: []string{"data", "users", "[]", "emails", "[]"}, Strategy: models.MaskStrategyHash},
+		{Path: []string{"data", "me", "idCard"}, Strategy: models.MaskStrategyNull, ExceptRoles: []string{"admin"}},
+		{Path: []string{"data", "me", "name"}, Strategy: models.MaskStrategyFixed, Replacement: "******", Rule: "user.userId != arguments.id"},
+		{Path: []string{"data", "missing", "name"}, Strategy: models.MaskStrategyFixed, Replacement: "******"},
+	})
+	t.Cleanup(func() { storeOperationMaskFields(operationPath, nil) })
+
+	response := []byte(`{"data":{"users":[{"phone":"13812345678","emails":["a@b.c","张"]},{"phone":null,"emails":[]}],"me":{"idCard":"1101","name":"张三"}}}`)
+	emailHash := sha256.Sum256([]byte("a@b.c"))
+	nameHash := sha256.Sum256([]byte("张"))
+	for _, item := range []struct {
+		name     string
+		caller   *OperationRuleCaller
+		expected string
+	}{{
+		name:   "anonymous",
+		caller: &OperationRuleCaller{Arguments: map[string]any{"id": "1"}},
+		expected: `{"data":{"users":[{"phone":"138****5678","emails":["` + hex.EncodeToString(emailHash[:]) + `","` + hex.EncodeToString(nameHash[:]) + `"]},{"phone":null,"emails":[]}],` +
+			`"me":{"idCard":null,"name":"******"}}}`,
+	}, {
+		name:   "admin self",
+		caller: &OperationRuleCaller{User: map[string]any{"userId": "1", "roles": []any{"admin"}}, Arguments: map[string]any{"id": "1"}},
+		expected: `{"data":{"users":[{"phone":"138****5678","emails":["` + hex.EncodeToString(emailHash[:]) + `","` + hex.EncodeToString(nameHash[:]) + `"]},{"phone":null,"emails":[]}],` +
+			`"me":{"idCard":"1101","name":"张三"}}}`,
+	}} {
+		var actual, expected any
+		if err := json.Unmarshal(MaskOperationResponse(operationPath, response, item.caller), &actual); err != nil {
+			t.Fatalf("%s: %v", item.name, err)
+		}
+		_ = json.Unmarshal([]byte(item.expected), &expected)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%s: expected %v, got %v", item.name, expected, actual)
+		}
+	}
+
+	if !OperationMaskUserRequired(operationPath) || !OperationMaskArgumentsRequired(operationPath) {
+		t.Error("expected user and arguments required for exceptRoles and rule")
+	}
+	if OperationMaskRequired("User/GetOne") {
+		t.Error("expected no mask fields on undeclared operation")
+	}
+}
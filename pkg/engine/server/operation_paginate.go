@@ -0,0 +1,197 @@
+// Package server
+/*
+ @paginate声明的游标分页，飞布服务转发接口请求时调用，引擎启动和增量变更时按照声明更新分页配置
+ 请求时将first/after转换为take/skip/cursor参数，多查询一条用于判断hasNextPage
+ 响应时将列表转换为connection结构，游标为cursorFields字段值的base64编码，totalCount从同级aggregate计数字段读取后移除
+*/
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fireboom-server/pkg/common/models"
+	"fmt"
+	"github.com/buger/jsonparser"
+	json "github.com/json-iterator/go"
+	"github.com/spf13/cast"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	paginateCompoundCursorSep = "_"
+	paginateFirstRangeFormat  = "variable [%s] must be between 1 and %d"
+	paginateCursorFormat      = "variable [%s] is not a valid cursor"
+)
+
+var (
+	operationPaginations      atomic.Pointer[map[string][]*models.OperationPagination]
+	operationPaginationsMutex sync.Mutex
+)
+
+// OperationPaginationRequired 判断接口是否声明了@paginate
+func OperationPaginationRequired(operationPath string) bool {
+	return len(loadOperationPaginations(operationPath)) > 0
+}
+
+// PaginateOperationVariables 将请求参数中的first/after转换为take/skip/cursor，返回每个分页字段的分页大小
+func PaginateOperationVariables(operationPath string, variables map[string]any) (firsts []int64, err error) {
+	for _, pagination := range loadOperationPaginations(operationPath) {
+		first := pagination.DefaultFirst
+		if value, ok := variables[pagination.FirstVariable]; ok && value != nil {
+			if first, err = cast.ToInt64E(value); err != nil || first < 1 || first > pagination.MaxFirst {
+				return nil, fmt.Errorf(paginateFirstRangeFormat, pagination.FirstVariable, pagination.MaxFirst)
+			}
+		}
+		after, _ := variables[pagination.AfterVariable].(string)
+		delete(variables, pagination.FirstVariable)
+		delete(variables, pagination.AfterVariable)
+
+		variables[pagination.TakeVariable] = first + 1
+		delete(variables, pagination.SkipVariable)
+		delete(variables, pagination.CursorVariable)
+		if after != "" {
+			cursor, ok := decodePaginateCursor(pagination.CursorFields, after)
+			if !ok {
+				return nil, fmt.Errorf(paginateCursorFormat, pagination.AfterVariable)
+			}
+			// 游标所在的数据已在上一页返回，跳过一条
+			variables[pagination.SkipVariable], variables[pagination.CursorVariable] = 1, cursor
+		}
+		firsts = append(firsts, first)
+	}
+	return
+}
+
+// PaginateOperationResponse 将单条响应数据中的列表转换为connection结构，数据无法解析时原样返回
+func PaginateOperationResponse(operationPath string, data []byte, firsts []int64) []byte {
+	paginations := loadOperationPaginations(operationPath)
+	if len(paginations) != len(firsts) {
+		return data
+	}
+
+	for index, pagination := range paginations {
+		data = paginateJsonList(data, pagination, firsts[index])
+	}
+	return data
+}
+
+// 增量变更时更新单个接口的分页配置，paginations为空时移除，复制后整体替换避免影响正在读取的请求
+func storeOperationPaginations(operationPath string, paginations []*models.OperationPagination) {
+	operationPaginationsMutex.Lock()
+	defer operationPaginationsMutex.Unlock()
+
+	storedPaginations := make(map[string][]*models.OperationPagination)
+	if existed := operationPaginations.Load(); existed != nil {
+		maps.Copy(storedPaginations, *existed)
+	}
+	if len(paginations) > 0 {
+		storedPaginations[operationPath] = paginations
+	} else {
+		delete(storedPaginations, operationPath)
+	}
+	operationPaginations.Store(&storedPaginations)
+}
+
+func loadOperationPaginations(operationPath string) []*models.OperationPagination {
+	if paginations := operationPaginations.Load(); paginations != nil {
+		return (*paginations)[operationPath]
+	}
+	return nil
+}
+
+func paginateJsonList(data []byte, pagination *models.OperationPagination, first int64) []byte {
+	list, listType, _, err := jsonparser.Get(data, pagination.Path...)
+	if err != nil || listType != jsonparser.Array {
+		return data
+	}
+
+	var items [][]byte
+	if _, err = jsonparser.ArrayEach(list, func(value []byte, valueType jsonparser.ValueType, _ int, _ error) {
+		items = append(items, rawJsonValue(value, valueType))
+	}); err != nil {
+		return data
+	}
+	hasNextPage := int64(len(items)) > first
+	if hasNextPage {
+		items = items[:first]
+	}
+
+	connection := make(map[string]any)
+	var endCursor any
+	switch pagination.Shape {
+	case models.PaginateShapeItems:
+		rawItems := make([]json.RawMessage, 0, len(items))
+		for _, item := range items {
+			rawItems = append(rawItems, item)
+		}
+		connection["items"] = rawItems
+		if length := len(items); length > 0 {
+			endCursor = encodePaginateCursor(pagination.CursorFields, items[length-1])
+		}
+	default:
+		edges := make([]map[string]any, 0, len(items))
+		for _, item := range items {
+			edges = append(edges, map[string]any{"cursor": encodePaginateCursor(pagination.CursorFields, item), "node": json.RawMessage(item)})
+		}
+		connection["edges"] = edges
+		if length := len(edges); length > 0 {
+			endCursor = edges[length-1]["cursor"]
+		}
+	}
+	connection["pageInfo"] = map[string]any{"hasNextPage": hasNextPage, "endCursor": endCursor}
+	if totalCountPath := pagination.TotalCountPath; len(totalCountPath) > 0 {
+		totalCount, _ := jsonparser.GetInt(data, totalCountPath...)
+		connection["totalCount"] = totalCount
+		data = jsonparser.Delete(data, totalCountPath[:2]...)
+	}
+
+	connectionBytes, err := json.Marshal(connection)
+	if err != nil {
+		return data
+	}
+	if result, err := jsonparser.Set(data, connectionBytes, pagination.Path...); err == nil {
+		data = result
+	}
+	return data
+}
+
+// 游标为cursorFields字段值组成的json对象的base64编码
+func encodePaginateCursor(cursorFields []string, item []byte) string {
+	values := make(map[string]json.RawMessage, len(cursorFields))
+	for _, field := range cursorFields {
+		value, valueType, _, err := jsonparser.Get(item, field)
+		if err != nil {
+			continue
+		}
+		values[field] = rawJsonValue(value, valueType)
+	}
+	cursorBytes, _ := json.ConfigCompatibleWithStandardLibrary.Marshal(values)
+	return base64.RawURLEncoding.EncodeToString(cursorBytes)
+}
+
+// 解析游标并构造cursor参数，多个字段时按照prisma联合唯一键的命名(字段名以'_'连接)嵌套
+func decodePaginateCursor(cursorFields []string, after string) (cursor map[string]any, ok bool) {
+	cursorBytes, err := base64.RawURLEncoding.DecodeString(after)
+	if err != nil {
+		return
+	}
+	// 数字按照原样读取，避免大整数丢失精度
+	var values map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(cursorBytes))
+	decoder.UseNumber()
+	if err = decoder.Decode(&values); err != nil || len(values) != len(cursorFields) {
+		return
+	}
+	if slices.ContainsFunc(cursorFields, func(field string) bool { _, existed := values[field]; return !existed }) {
+		return
+	}
+
+	if len(cursorFields) == 1 {
+		return values, true
+	}
+	return map[string]any{strings.Join(cursorFields, paginateCompoundCursorSep): values}, true
+}
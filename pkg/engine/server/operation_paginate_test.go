@@ -0,0 +1,80 @@
+package server
+
+import (
+	"fireboom-server/pkg/common/models"
+	"reflect"
+	"testing"
+
+	json "github.com/json-iterator/go"
+)
+
+// first/after转换为take/skip/cursor，响应多查询的一条用于判断hasNextPage，endCursor可以作为下一页的after
+func TestPaginateOperation(t *testing.T) {
+	operationPath := "Post/GetMany"
+	storeOperationPaginations(operationPath, []*models.OperationPagination{{
+		Path:           []string{"data", "posts"},
+		Shape:          models.PaginateShapeEdges,
+		CursorFields:   []string{"id"},
+		FirstVariable:  "first",
+		AfterVariable:  "after",
+		TakeVariable:   "postsTake",
+		SkipVariable:   "postsSkip",
+		CursorVariable: "postsCursor",
+		DefaultFirst:   2,
+		MaxFirst:       10,
+		TotalCountPath: []string{"data", "postsTotalCount", "_count", "_all"},
+	}})
+	t.Cleanup(func() { storeOperationPaginations(operationPath, nil) })
+
+	if _, err := PaginateOperationVariables(operationPath, map[string]any{"first": float64(11)}); err == nil {
+		t.Error("expected error when first exceeds maxFirst")
+	}
+
+	variables := map[string]any{"title": "go"}
+	firsts, err := PaginateOperationVariables(operationPath, variables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string]any{"title": "go", "postsTake": int64(3)}; !reflect.DeepEqual(variables, expected) {
+		t.Errorf("expected %v, got %v", expected, variables)
+	}
+
+	response := []byte(`{"data":{"posts":[{"id":1,"title":"a"},{"id":2,"title":"b"},{"id":3,"title":"c"}],"postsTotalCount":{"_count":{"_all":5}}}}`)
+	var actual struct {
+		Data struct {
+			Posts struct {
+				Edges []struct {
+					Cursor string         `json:"cursor"`
+					Node   map[string]any `json:"node"`
+				} `json:"edges"`
+				PageInfo struct {
+					HasNextPage bool    `json:"hasNextPage"`
+					EndCursor   *string `json:"endCursor"`
+				} `json:"pageInfo"`
+				TotalCount int `json:"totalCount"`
+			} `json:"posts"`
+			PostsTotalCount any `json:"postsTotalCount"`
+		} `json:"data"`
+	}
+	if err = json.Unmarshal(PaginateOperationResponse(operationPath, response, firsts), &actual); err != nil {
+		t.Fatal(err)
+	}
+	posts := actual.Data.Posts
+	if len(posts.Edges) != 2 || !posts.PageInfo.HasNextPage || posts.TotalCount != 5 || actual.Data.PostsTotalCount != nil {
+		t.Fatalf("unexpected connection %+v", actual.Data)
+	}
+	if posts.PageInfo.EndCursor == nil || *posts.PageInfo.EndCursor != posts.Edges[1].Cursor {
+		t.Fatalf("expected endCursor equal to last edge cursor")
+	}
+
+	variables = map[string]any{"first": float64(2), "after": *posts.PageInfo.EndCursor}
+	if _, err = PaginateOperationVariables(operationPath, variables); err != nil {
+		t.Fatal(err)
+	}
+	if cursor, _ := json.Marshal(variables["postsCursor"]); string(cursor) != `{"id":2}` || variables["postsSkip"] != 1 {
+		t.Errorf("expected cursor of last edge skipped, got %s %v", cursor, variables["postsSkip"])
+	}
+	if _, err = PaginateOperationVariables(operationPath, map[string]any{"after": "invalid"}); err == nil {
+		t.Error("expected error on invalid cursor")
+	}
+}
@@ -0,0 +1,223 @@
+// Package server
+/*
+ 接口分层限流，飞布服务转发接口请求时调用，引擎启动时按照接口配置和@rateLimit声明更新限流层级
+ 每个层级按claim/header/IP计算调用方标识，使用滑动窗口记录窗口期内的请求时间，任意层级超出限制时拒绝请求
+ 拒绝的请求不计入其他层级，计数在服务运行期间保留，引擎重启不会清空
+*/
+package server
+
+import (
+	"fireboom-server/pkg/common/models"
+	"fmt"
+	"github.com/spf13/cast"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// OperationRateLimitCaller 调用方信息，User为nil时视为匿名用户
+	OperationRateLimitCaller struct {
+		Ip     string
+		Header http.Header
+		User   map[string]any
+	}
+	// OperationRateLimitResult 限流结果，Limit/Remaining/Reset取剩余次数最少的层级
+	OperationRateLimitResult struct {
+		Allowed    bool
+		Limit      int64
+		Remaining  int64
+		Reset      time.Duration
+		RetryAfter time.Duration
+	}
+	operationRateLimiter struct {
+		mutex   sync.Mutex
+		windows map[string]*rateLimitWindow
+		sweptAt time.Time
+	}
+	rateLimitWindow struct {
+		duration   time.Duration
+		timestamps []time.Time
+	}
+)
+
+const (
+	rateLimitWindowKeyFormat  = "%s\x00%s\x00%s"
+	rateLimitUserCustomClaims = "customClaims"
+	rateLimitSweepInterval    = time.Minute
+)
+
+var (
+	operationRateLimitTiers      atomic.Pointer[map[string][]*models.OperationRateLimitTier]
+	operationRateLimitTiersMutex sync.Mutex
+	defaultOperationRateLimiter  = newOperationRateLimiter()
+)
+
+func newOperationRateLimiter() *operationRateLimiter {
+	return &operationRateLimiter{windows: make(map[string]*rateLimitWindow)}
+}
+
+// OperationRateLimitUserRequired 判断接口限流是否需要登录用户信息，按claim计算标识或区分登录/匿名用户时需要
+func OperationRateLimitUserRequired(operationPath string) bool {
+	return slices.ContainsFunc(loadOperationRateLimitTiers(operationPath), func(tier *models.OperationRateLimitTier) bool {
+		return tier.KeyBy == models.RateLimitKeyByClaim ||
+			tier.AppliesTo == models.RateLimitAppliesToAuthenticated || tier.AppliesTo == models.RateLimitAppliesToAnonymous
+	})
+}
+
+// AllowOperationRequest 按照接口的限流层级判断是否允许请求，未声明限流层级时返回nil
+func AllowOperationRequest(operationPath string, caller *OperationRateLimitCaller) *OperationRateLimitResult {
+	tiers := loadOperationRateLimitTiers(operationPath)
+	if len(tiers) == 0 {
+		return nil
+	}
+
+	return defaultOperationRateLimiter.allow(operationPath, tiers, caller, time.Now())
+}
+
+// 增量变更时更新单个接口的限流层级，tiers为空时移除，复制后整体替换避免影响正在读取的请求
+func storeOperationRateLimitTiers(operationPath string, tiers []*models.OperationRateLimitTier) {
+	operationRateLimitTiersMutex.Lock()
+	defer operationRateLimitTiersMutex.Unlock()
+
+	storedTiers := make(map[string][]*models.OperationRateLimitTier)
+	if existed := operationRateLimitTiers.Load(); existed != nil {
+		maps.Copy(storedTiers, *existed)
+	}
+	if len(tiers) > 0 {
+		storedTiers[operationPath] = tiers
+	} else {
+		delete(storedTiers, operationPath)
+	}
+	operationRateLimitTiers.Store(&storedTiers)
+}
+
+func loadOperationRateLimitTiers(operationPath string) []*models.OperationRateLimitTier {
+	if tiers := operationRateLimitTiers.Load(); tiers != nil {
+		return (*tiers)[operationPath]
+	}
+	return nil
+}
+
+func (l *operationRateLimiter) allow(operationPath string, tiers []*models.OperationRateLimitTier, caller *OperationRateLimitCaller, now time.Time) *OperationRateLimitResult {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.sweep(now)
+	result := &OperationRateLimitResult{Allowed: true, Remaining: -1}
+	var matchedWindows []*rateLimitWindow
+	for _, tier := range tiers {
+		callerKey, ok := rateLimitCallerKey(tier, caller)
+		if !ok {
+			continue
+		}
+
+		windowKey := fmt.Sprintf(rateLimitWindowKeyFormat, operationPath, tier.Name, callerKey)
+		window, ok := l.windows[windowKey]
+		if !ok {
+			window = &rateLimitWindow{}
+			l.windows[windowKey] = window
+		}
+		window.duration = time.Duration(tier.WindowSeconds) * time.Second
+		window.prune(now)
+		matchedWindows = append(matchedWindows, window)
+
+		used := int64(len(window.timestamps))
+		remaining := max(tier.Requests-used-1, 0)
+		// 窗口期内最早的请求过期后才会释放额度，超出限制时需等待超出部分全部过期
+		reset := window.duration
+		if used >= tier.Requests {
+			reset = window.timestamps[used-tier.Requests].Add(window.duration).Sub(now)
+			result.Allowed = false
+			result.RetryAfter = max(result.RetryAfter, reset)
+		} else if used > 0 {
+			reset = window.timestamps[0].Add(window.duration).Sub(now)
+		}
+		if result.Remaining < 0 || remaining < result.Remaining {
+			result.Limit, result.Remaining, result.Reset = tier.Requests, remaining, reset
+		}
+	}
+	if len(matchedWindows) == 0 {
+		return nil
+	}
+
+	if result.Allowed {
+		for _, window := range matchedWindows {
+			window.timestamps = append(window.timestamps, now)
+		}
+	}
+	return result
+}
+
+// 定期删除窗口期内已无请求的计数，防止调用方标识过多时内存持续增长
+func (l *operationRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.sweptAt) < rateLimitSweepInterval {
+		return
+	}
+
+	l.sweptAt = now
+	for key, window := range l.windows {
+		if window.prune(now); len(window.timestamps) == 0 {
+			delete(l.windows, key)
+		}
+	}
+}
+
+func (w *rateLimitWindow) prune(now time.Time) {
+	expiredAt := now.Add(-w.duration)
+	index, _ := slices.BinarySearchFunc(w.timestamps, expiredAt, func(item, target time.Time) int {
+		if item.After(target) {
+			return 1
+		}
+		return -1
+	})
+	w.timestamps = w.timestamps[index:]
+}
+
+// 计算层级的调用方标识，返回false时该层级不适用于当前调用方
+// 按claim计算时匿名用户或用户缺少该claim不计数，按请求头计算时未携带请求头的调用方按IP计数
+func rateLimitCallerKey(tier *models.OperationRateLimitTier, caller *OperationRateLimitCaller) (string, bool) {
+	switch tier.AppliesTo {
+	case models.RateLimitAppliesToAuthenticated:
+		if caller.User == nil {
+			return "", false
+		}
+	case models.RateLimitAppliesToAnonymous:
+		if caller.User != nil {
+			return "", false
+		}
+	}
+
+	switch tier.KeyBy {
+	case models.RateLimitKeyByClaim:
+		return rateLimitUserClaim(caller.User, tier.Key)
+	case models.RateLimitKeyByHeader:
+		if value := caller.Header.Get(tier.Key); value != "" {
+			return value, true
+		}
+		return caller.Ip, true
+	default:
+		return caller.Ip, true
+	}
+}
+
+// 优先读取用户信息中的字段，如 userId，其次读取customClaims中的字段，如 tenantId
+func rateLimitUserClaim(user map[string]any, claim string) (string, bool) {
+	if user == nil {
+		return "", false
+	}
+
+	value, ok := user[claim]
+	if !ok {
+		if customClaims, isMap := user[rateLimitUserCustomClaims].(map[string]any); isMap {
+			value, ok = customClaims[claim]
+		}
+	}
+	if !ok || value == nil {
+		return "", false
+	}
+	return cast.ToString(value), true
+}
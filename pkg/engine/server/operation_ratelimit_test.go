@@ -0,0 +1,81 @@
+package server
+
+import (
+	"fireboom-server/pkg/common/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// 滑动窗口按调用方标识独立计数，超出限制时返回Retry-After，窗口滑过最早的请求后释放额度
+func TestOperationRateLimiterSlidingWindow(t *testing.T) {
+	limiter := newOperationRateLimiter()
+	tiers := []*models.OperationRateLimitTier{{Name: "perIp", KeyBy: models.RateLimitKeyByIp, Requests: 2, WindowSeconds: 10}}
+	alice, bob := &OperationRateLimitCaller{Ip: "10.0.0.1"}, &OperationRateLimitCaller{Ip: "10.0.0.2"}
+
+	now := time.Now()
+	for i, remaining := range []int64{1, 0} {
+		result := limiter.allow("user/get", tiers, alice, now.Add(time.Duration(i)*4*time.Second))
+		if !result.Allowed || result.Limit != 2 || result.Remaining != remaining {
+			t.Fatalf("request %d: unexpected result %+v", i, result)
+		}
+	}
+
+	result := limiter.allow("user/get", tiers, alice, now.Add(5*time.Second))
+	if result.Allowed || result.RetryAfter != 5*time.Second {
+		t.Fatalf("expected rejected with retry after 5s, got %+v", result)
+	}
+	if result = limiter.allow("user/get", tiers, bob, now.Add(5*time.Second)); !result.Allowed {
+		t.Fatalf("expected other caller allowed, got %+v", result)
+	}
+	if result = limiter.allow("user/list", tiers, alice, now.Add(5*time.Second)); !result.Allowed {
+		t.Fatalf("expected other operation allowed, got %+v", result)
+	}
+	if result = limiter.allow("user/get", tiers, alice, now.Add(10*time.Second+time.Millisecond)); !result.Allowed {
+		t.Fatalf("expected allowed after window slides, got %+v", result)
+	}
+}
+
+// 任意层级超出限制时拒绝请求且不计入其他层级，不适用于调用方的层级不计数
+func TestOperationRateLimiterTiers(t *testing.T) {
+	limiter := newOperationRateLimiter()
+	tiers := []*models.OperationRateLimitTier{
+		{Name: "perUser", KeyBy: models.RateLimitKeyByClaim, Key: "userId", Requests: 1, WindowSeconds: 60},
+		{Name: "perTenant", KeyBy: models.RateLimitKeyByClaim, Key: "tenantId", Requests: 3, WindowSeconds: 60},
+		{Name: "anonymous", KeyBy: models.RateLimitKeyByHeader, Key: "X-Api-Key", Requests: 1, WindowSeconds: 60, AppliesTo: models.RateLimitAppliesToAnonymous},
+	}
+	tenantUser := func(userId string) *OperationRateLimitCaller {
+		return &OperationRateLimitCaller{User: map[string]any{"userId": userId, "customClaims": map[string]any{"tenantId": "t1"}}}
+	}
+
+	now := time.Now()
+	if result := limiter.allow("order/list", tiers, tenantUser("u1"), now); !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("expected first request allowed, got %+v", result)
+	}
+	if result := limiter.allow("order/list", tiers, tenantUser("u1"), now); result.Allowed {
+		t.Fatalf("expected user limit exceeded, got %+v", result)
+	}
+	// 被拒绝的请求不计入租户层级，租户内其余用户仍有2次额度
+	for _, userId := range []string{"u2", "u3"} {
+		if result := limiter.allow("order/list", tiers, tenantUser(userId), now); !result.Allowed {
+			t.Fatalf("expected %s allowed, got %+v", userId, result)
+		}
+	}
+	if result := limiter.allow("order/list", tiers, tenantUser("u4"), now); result.Allowed || result.RetryAfter != time.Minute {
+		t.Fatalf("expected tenant limit exceeded, got %+v", result)
+	}
+
+	anonymous := &OperationRateLimitCaller{Header: http.Header{"X-Api-Key": {"k1"}}}
+	if result := limiter.allow("order/list", tiers, anonymous, now); !result.Allowed {
+		t.Fatalf("expected anonymous allowed, got %+v", result)
+	}
+	if result := limiter.allow("order/list", tiers, anonymous, now); result.Allowed {
+		t.Fatalf("expected anonymous limit exceeded, got %+v", result)
+	}
+	// 未携带请求头的匿名调用方按IP计数，不共用同一计数
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		if result := limiter.allow("order/list", tiers, &OperationRateLimitCaller{Ip: ip, Header: http.Header{}}, now); !result.Allowed {
+			t.Fatalf("expected anonymous without header %s allowed, got %+v", ip, result)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Package server
+/*
+ 接口入参校验，飞布服务转发接口请求前调用，引擎启动和增量变更时按照@validate声明更新校验规则
+ 按照声明顺序计算所有规则，结果为false或计算失败的规则均视为校验失败，直接请求引擎端口的请求不会校验
+*/
+package server
+
+import (
+	"fireboom-server/pkg/common/models"
+	"golang.org/x/exp/maps"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	// OperationValidationError 校验失败的规则，按照graphql错误格式返回，extensions中包含错误码、规则名称和参数路径
+	OperationValidationError struct {
+		Message    string                              `json:"message"`
+		Extensions *OperationValidationErrorExtensions `json:"extensions"`
+	}
+	OperationValidationErrorExtensions struct {
+		Code   string   `json:"code"`
+		Rule   string   `json:"rule"`
+		Fields []string `json:"fields,omitempty"`
+	}
+)
+
+var (
+	operationValidationRules      atomic.Pointer[map[string][]*models.OperationValidationRule]
+	operationValidationRulesMutex sync.Mutex
+)
+
+// OperationValidationRequired 判断接口是否声明了校验规则
+func OperationValidationRequired(operationPath string) bool {
+	return len(loadOperationValidationRules(operationPath)) > 0
+}
+
+// ValidateOperationRequest 按照接口的校验规则校验请求，返回所有校验失败的规则
+func ValidateOperationRequest(operationPath string, caller *OperationRuleCaller) (validationErrors []*OperationValidationError) {
+	rules := loadOperationValidationRules(operationPath)
+	if len(rules) == 0 {
+		return
+	}
+
+	ruleParameters := buildRuleParameters(caller)
+	for _, rule := range rules {
+		if passed, err := evaluateRule(rule.Expression, ruleParameters); err == nil && passed {
+			continue
+		}
+
+		validationErrors = append(validationErrors, &OperationValidationError{
+			Message:    rule.Message,
+			Extensions: &OperationValidationErrorExtensions{Code: rule.Code, Rule: rule.Name, Fields: rule.Fields},
+		})
+	}
+	return
+}
+
+// 增量变更时更新单个接口的校验规则，rules为空时移除，复制后整体替换避免影响正在读取的请求
+func storeOperationValidationRules(operationPath string, rules []*models.OperationValidationRule) {
+	operationValidationRulesMutex.Lock()
+	defer operationValidationRulesMutex.Unlock()
+
+	storedRules := make(map[string][]*models.OperationValidationRule)
+	if existed := operationValidationRules.Load(); existed != nil {
+		maps.Copy(storedRules, *existed)
+	}
+	if len(rules) > 0 {
+		storedRules[operationPath] = rules
+	} else {
+		delete(storedRules, operationPath)
+	}
+	operationValidationRules.Store(&storedRules)
+}
+
+func loadOperationValidationRules(operationPath string) []*models.OperationValidationRule {
+	if rules := operationValidationRules.Load(); rules != nil {
+		return (*rules)[operationPath]
+	}
+	return nil
+}
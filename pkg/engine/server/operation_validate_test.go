@@ -0,0 +1,33 @@
+package server
+
+import (
+	"fireboom-server/pkg/common/models"
+	"testing"
+)
+
+// 返回所有校验失败的规则，表达式计算失败时视为校验失败
+func TestValidateOperationRequest(t *testing.T) {
+	operationPath := "Order/GetMany"
+	storeOperationValidationRules(operationPath, []*models.OperationValidationRule{
+		{Name: "dateRange", Expression: "arguments.endDate > arguments.startDate", Fields: []string{"endDate"}, Code: "INVALID_DATE_RANGE", Message: "endDate must be after startDate"},
+		{Name: "tenant", Expression: `request.header["X-Tenant"] == "t1"`, Code: "VALIDATION_FAILED", Message: "tenant required"},
+		{Name: "broken", Expression: "arguments.startDate.year > 2000", Code: "VALIDATION_FAILED", Message: "broken"},
+	})
+	t.Cleanup(func() { storeOperationValidationRules(operationPath, nil) })
+
+	caller := &OperationRuleCaller{
+		Header:    map[string][]string{"X-Tenant": {"t1"}},
+		Arguments: map[string]any{"startDate": "2024-01-02", "endDate": "2024-01-01"},
+	}
+	validationErrors := ValidateOperationRequest(operationPath, caller)
+	if len(validationErrors) != 2 || validationErrors[0].Extensions.Rule != "dateRange" || validationErrors[1].Extensions.Rule != "broken" {
+		t.Fatalf("expected dateRange and broken failed, got %v", validationErrors)
+	}
+	if extensions := validationErrors[0].Extensions; extensions.Code != "INVALID_DATE_RANGE" || len(extensions.Fields) != 1 || extensions.Fields[0] != "endDate" {
+		t.Errorf("unexpected extensions %+v", extensions)
+	}
+
+	if validationErrors = ValidateOperationRequest("Order/GetOne", caller); validationErrors != nil {
+		t.Errorf("expected no validation on undeclared operation, got %v", validationErrors)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/wundergraph/wundergraph/pkg/node"
 	"github.com/wundergraph/wundergraph/pkg/wgpb"
 	"go.uber.org/zap"
+	"net/http"
 	"runtime/debug"
 	"strings"
 	"sync"
@@ -36,9 +37,12 @@ import (
 // 7. globalSetting配置变更启动
 // 8. globalOperation配置变更启动
 
+const engineLoopbackHost = "127.0.0.1"
+
 var (
 	EngineStarter      *EngineStart
 	engineStarterMutex = &sync.RWMutex{}
+	nodeConfigFetcher  = (*EngineStart).fetchNodeConfig
 )
 
 func init() {
@@ -50,6 +54,12 @@ func init() {
 			datasourceModelName: models.DatasourceRoot.GetModelName(),
 			nodeServer:          node.New(ctx, node.BuildInfo{}, consts.RootExported, logger),
 		}
+		utils.RestartEngine = func() {
+			engineStarterMutex.Lock()
+
+			EngineStarter.release()
+			EngineStarter.StartNodeServer(engineStarterMutex)
+		}
 		if !utils.GetBoolWithLockViper(consts.DevMode) {
 			return
 		}
@@ -98,10 +108,13 @@ func (s *EngineStart) StartNodeServer(mutex ...*sync.RWMutex) {
 
 	startingTime := time.Now()
 	s.logger.Info("start begin", zap.String(consts.EngineStatusField, consts.EngineStarting))
-	if err = s.fetchNodeConfig(); err != nil {
+	nodeConfig, err := nodeConfigFetcher(s)
+	if err != nil {
 		return
 	}
 
+	s.nodeConfig = nodeConfig
+
 	setting := configs.GlobalSettingRoot.FirstData()
 	if setting.BuildInfo != nil {
 		s.nodeServer.UpdateBuildInfo(*setting.BuildInfo)
@@ -144,7 +157,8 @@ func (s *EngineStart) StartNodeServer(mutex ...*sync.RWMutex) {
 	return
 }
 
-func (s *EngineStart) fetchNodeConfig() (err error) {
+// 生成引擎运行时配置，全部步骤完成后才返回，由调用方决定何时替换当前配置
+func (s *EngineStart) fetchNodeConfig() (runtimeConfig *node.WunderNodeConfig, err error) {
 	schemaText := build.GeneratedGraphqlSchemaText
 	graphqlSchema, err := schemaText.Read(schemaText.Title)
 	if err != nil {
@@ -167,25 +181,29 @@ func (s *EngineStart) fetchNodeConfig() (err error) {
 		FieldConfigurations:  make([]*wgpb.FieldConfiguration, len(generateEngineConfig.FieldConfigurations)),
 	}
 	copy(nodeConfig.Api.EngineConfiguration.FieldConfigurations, generateEngineConfig.FieldConfigurations)
-	s.nodeConfig = &nodeConfig
-
-	s.runtimeDataSourceConfigurations(generateEngineConfig.DatasourceConfigurations)
-	s.runtimeOperations()
-	s.runtimeInvalidOperations()
+	// 使用内置钩子桩服务或记录钩子调用时替换钩子服务地址，不修改编译生成的配置
+	if models.HookServerUrlRewritten() {
+		nodeConfig.Api.Options.ServerUrl = models.GetEngineHookServerUrl()
+	}
+	s.runtimeDataSourceConfigurations(&nodeConfig, generateEngineConfig.DatasourceConfigurations)
+	s.runtimeOperations(&nodeConfig)
+	s.runtimeInvalidOperations(&nodeConfig)
+	s.restrictEngineListen(&nodeConfig)
 
 	s.logger.Debug("fetch node configuration succeed")
+	runtimeConfig = &nodeConfig
 	return
 }
 
 // 构建运行时数据源配置
 // 在编译过程中通过下标记录来每个查询所依赖的子字段
 // 引擎所需的数据源需要将每个查询都拆成一个数据源
-func (s *EngineStart) runtimeDataSourceConfigurations(datasourceConfigurations []*wgpb.DataSourceConfiguration) {
+func (s *EngineStart) runtimeDataSourceConfigurations(nodeConfig *node.WunderNodeConfig, datasourceConfigurations []*wgpb.DataSourceConfiguration) {
 	var err error
 	var itemDsAction datasource.Action
 	var itemRuntimeConfigs []*wgpb.DataSourceConfiguration
 	var itemRuntimeFields []*wgpb.FieldConfiguration
-	runtimeEngineConfig := s.nodeConfig.Api.EngineConfiguration
+	runtimeEngineConfig := nodeConfig.Api.EngineConfiguration
 	for _, dsConfig := range datasourceConfigurations {
 		if itemDsAction, err = datasource.GetDatasourceAction(&models.Datasource{Name: dsConfig.Id, Kind: dsConfig.Kind}); err != nil {
 			s.printSetSchemaError(err, dsConfig.Id)
@@ -219,17 +237,53 @@ func (s *EngineStart) setStoreOperationByRuntimeData(path string, operation *wgp
 
 // 编译生成的文件有大量的下标引用来缩减配置文件大小
 // 启动引擎时需要将所有引用还原成真实配置
-func (s *EngineStart) runtimeOperations() {
-	s.nodeConfig.Api.OperationSchemas = make(map[string]*apihandler.OperationSchema, len(s.nodeConfig.Api.Operations))
+func (s *EngineStart) runtimeOperations(nodeConfig *node.WunderNodeConfig) {
+	nodeConfig.Api.OperationSchemas = make(map[string]*apihandler.OperationSchema, len(nodeConfig.Api.Operations))
 	operationsConfig := build.GeneratedOperationsConfigRoot.FirstData()
-	for _, operation := range s.nodeConfig.Api.Operations {
-		s.runtimeOperationItem(operationsConfig, operation)
+	rateLimitTiers := make(map[string][]*models.OperationRateLimitTier)
+	deprecatedHeaders := make(map[string]http.Header)
+	maskFields := make(map[string][]*models.OperationMaskField)
+	validationRules := make(map[string][]*models.OperationValidationRule)
+	responseCaches := make(map[string]*operationResponseCache)
+	paginations := make(map[string][]*models.OperationPagination)
+	for _, operation := range nodeConfig.Api.Operations {
+		s.runtimeOperationItem(nodeConfig, operationsConfig, operation)
+		if graphqlFile, ok := operationsConfig.GraphqlOperationFiles[operation.Path]; ok {
+			if len(graphqlFile.MaskFields) > 0 {
+				maskFields[operation.Path] = graphqlFile.MaskFields
+			}
+			if len(graphqlFile.ValidationRules) > 0 {
+				validationRules[operation.Path] = graphqlFile.ValidationRules
+			}
+			if responseCache := newOperationResponseCache(graphqlFile.ResponseCache, operation); responseCache != nil {
+				responseCaches[operation.Path] = responseCache
+			}
+			if len(graphqlFile.Paginations) > 0 {
+				paginations[operation.Path] = graphqlFile.Paginations
+			}
+		}
+		itemData, _ := models.OperationRoot.GetByDataName(operation.Path)
+		if itemData == nil {
+			continue
+		}
+		if tiers := operationsConfig.GetRateLimitTiers(itemData); len(tiers) > 0 {
+			rateLimitTiers[operation.Path] = tiers
+		}
+		if itemData.Deprecation != nil {
+			deprecatedHeaders[operation.Path] = buildDeprecatedOperationHeaders(itemData)
+		}
 	}
+	operationRateLimitTiers.Store(&rateLimitTiers)
+	deprecatedOperationHeaders.Store(&deprecatedHeaders)
+	operationMaskFields.Store(&maskFields)
+	operationValidationRules.Store(&validationRules)
+	operationPaginations.Store(&paginations)
+	replaceOperationResponseCaches(responseCaches)
 
 	s.logger.Debug("build runtime operations succeed")
 }
 
-func (s *EngineStart) runtimeOperationItem(operationsConfig *build.OperationsConfig, operation *wgpb.Operation) {
+func (s *EngineStart) runtimeOperationItem(nodeConfig *node.WunderNodeConfig, operationsConfig *build.OperationsConfig, operation *wgpb.Operation) {
 	var baseFile build.BaseOperationFile
 	var optional []func(*models.Operation)
 	operationPath := operation.Path
@@ -258,7 +312,7 @@ func (s *EngineStart) runtimeOperationItem(operationsConfig *build.OperationsCon
 
 	variablesDefs := &utils.SyncMap[string, *openapi3.SchemaRef]{}
 	build.SearchRefDefinitions(nil, operationsConfig.Definitions, variablesDefs, baseFile.VariablesRefs...)
-	s.nodeConfig.Api.OperationSchemas[operationPath] = &apihandler.OperationSchema{
+	nodeConfig.Api.OperationSchemas[operationPath] = &apihandler.OperationSchema{
 		Variables:         baseFile.Variables,
 		InternalVariables: baseFile.InternalVariables,
 		Response:          baseFile.Response,
@@ -266,15 +320,32 @@ func (s *EngineStart) runtimeOperationItem(operationsConfig *build.OperationsCon
 	}
 }
 
+// 接口声明限流层级、脱敏字段、校验规则或分页时引擎仅监听本机地址，外部请求需经过飞布服务转发，防止直接请求引擎绕过限流、脱敏和校验或得到未转换的分页结构
+// 监听地址在引擎启动时生效，增量变更新增的声明需重启引擎后才会限制监听地址
+func (s *EngineStart) restrictEngineListen(nodeConfig *node.WunderNodeConfig) {
+	listener := nodeConfig.Api.Options.Listen
+	if listener == nil || listener.Host == engineLoopbackHost {
+		return
+	}
+	tiers, maskFields, validationRules, paginations := operationRateLimitTiers.Load(), operationMaskFields.Load(), operationValidationRules.Load(), operationPaginations.Load()
+	if (tiers == nil || len(*tiers) == 0) && (maskFields == nil || len(*maskFields) == 0) &&
+		(validationRules == nil || len(*validationRules) == 0) && (paginations == nil || len(*paginations) == 0) {
+		return
+	}
+
+	s.logger.Warn("operation rate limit, mask, validation or pagination declared, engine listen on loopback only", zap.String("listenHost", listener.Host))
+	listener.Host = engineLoopbackHost
+}
+
 // 设置失效的operation
-func (s *EngineStart) runtimeInvalidOperations() {
+func (s *EngineStart) runtimeInvalidOperations(nodeConfig *node.WunderNodeConfig) {
 	invalidOperationNames := build.GeneratedOperationsConfigRoot.FirstData().Invalids
 	for _, itemPath := range invalidOperationNames {
 		if itemData, _ := models.OperationRoot.GetByDataName(itemPath); itemData != nil {
 			itemData.Invalid = true
 		}
 	}
-	s.nodeConfig.Api.InvalidOperationNames = invalidOperationNames
+	nodeConfig.Api.InvalidOperationNames = invalidOperationNames
 	s.logger.Debug("build runtime invalid operations succeed")
 }
 
@@ -13,6 +13,7 @@ import (
 
 func (s *EngineStart) Subscribe() {
 	s.eventbusSubscribeOperation()
+	s.eventbusSubscribeDatasource()
 }
 
 func (s *EngineStart) BreakData() {
@@ -29,6 +30,10 @@ func (s *EngineStart) BreakData() {
 		s.printBreakStart(eventbus.ChannelStorage, data)
 		return nil
 	})
+	eventbus.Subscribe(eventbus.ChannelDatasource, eventbus.EventBreak, func(data any) any {
+		s.printBreakStart(eventbus.ChannelDatasource, data)
+		return nil
+	})
 	eventbus.Subscribe(eventbus.ChannelSdk, eventbus.EventBreak, func(data any) any {
 		return nil
 	})
@@ -73,6 +78,12 @@ func (s *EngineStart) eventbusSubscribeOperation() {
 			}
 		}()
 		if operation.Name == "" {
+			storeOperationRateLimitTiers(operation.Path, nil)
+			storeDeprecatedOperationHeaders(operation.Path, nil)
+			storeOperationMaskFields(operation.Path, nil)
+			storeOperationValidationRules(operation.Path, nil)
+			storeOperationResponseCache(operation.Path, nil, nil)
+			storeOperationPaginations(operation.Path, nil)
 			next = operation
 			return
 		}
@@ -101,11 +112,25 @@ func (s *EngineStart) eventbusSubscribeOperation() {
 	})
 	eventbus.Subscribe(eventbus.ChannelOperation, eventbus.EventDelete, func(data any) any {
 		operationPath := data.(string)
+		storeOperationRateLimitTiers(operationPath, nil)
+		storeDeprecatedOperationHeaders(operationPath, nil)
+		storeOperationMaskFields(operationPath, nil)
+		storeOperationValidationRules(operationPath, nil)
+		storeOperationResponseCache(operationPath, nil, nil)
+		storeOperationPaginations(operationPath, nil)
 		s.printIncrementStart(eventbus.EventDelete, zap.String(string(eventbus.ChannelOperation), operationPath))
 		return operationPath
 	})
 	eventbus.Subscribe(eventbus.ChannelOperation, eventbus.EventBatchDelete, func(data any) any {
 		operationPaths := data.([]string)
+		for _, operationPath := range operationPaths {
+			storeOperationRateLimitTiers(operationPath, nil)
+			storeDeprecatedOperationHeaders(operationPath, nil)
+			storeOperationMaskFields(operationPath, nil)
+			storeOperationValidationRules(operationPath, nil)
+			storeOperationResponseCache(operationPath, nil, nil)
+			storeOperationPaginations(operationPath, nil)
+		}
 		s.printIncrementStart(eventbus.EventBatchDelete, zap.Strings(string(eventbus.ChannelOperation), operationPaths))
 		return operationPaths
 	})
@@ -145,7 +170,58 @@ func (s *EngineStart) eventbusSubscribeOperation() {
 	})
 	eventbus.Subscribe(eventbus.ChannelOperation, eventbus.EventRuntime, func(data any) any {
 		operation := data.(*wgpb.Operation)
-		s.runtimeOperationItem(operationsConfig, operation)
+		s.runtimeOperationItem(s.nodeConfig, operationsConfig, operation)
+		itemData, _ := models.OperationRoot.GetByDataName(operation.Path)
+		if itemData != nil {
+			storeOperationRateLimitTiers(operation.Path, operationsConfig.GetRateLimitTiers(itemData))
+		}
+		storeDeprecatedOperationHeaders(operation.Path, itemData)
+		var maskFields []*models.OperationMaskField
+		var validationRules []*models.OperationValidationRule
+		var responseCache *models.OperationResponseCache
+		var paginations []*models.OperationPagination
+		if graphqlFile, ok := operationsConfig.GraphqlOperationFiles[operation.Path]; ok {
+			maskFields, validationRules = graphqlFile.MaskFields, graphqlFile.ValidationRules
+			responseCache, paginations = graphqlFile.ResponseCache, graphqlFile.Paginations
+		}
+		storeOperationMaskFields(operation.Path, maskFields)
+		storeOperationValidationRules(operation.Path, validationRules)
+		storeOperationResponseCache(operation.Path, responseCache, operation)
+		storeOperationPaginations(operation.Path, paginations)
 		return nil
 	})
 }
+
+// 数据源变更会影响引擎的查询规划，使用增量编译后的配置重新生成引擎配置
+func (s *EngineStart) eventbusSubscribeDatasource() {
+	for _, event := range build.DatasourceIncrementEvents {
+		eventbus.Subscribe(eventbus.ChannelDatasource, event, s.reloadDatasourceIncrement)
+	}
+	// 数据源运行时认证信息变更(如oauth2令牌刷新)时无需编译，仅重新生成运行时配置
+	eventbus.Subscribe(eventbus.ChannelDatasource, eventbus.EventRuntime, func(data any) any {
+		return s.reloadNodeConfig(eventbus.EventRuntime, zap.String(string(eventbus.ChannelDatasource), data.(string)))
+	})
+}
+
+func (s *EngineStart) reloadDatasourceIncrement(data any) any {
+	increment := data.(*build.DatasourceIncrement)
+	return s.reloadNodeConfig(increment.Event, zap.Strings(string(eventbus.ChannelDatasource), increment.Names))
+}
+
+// 引擎未启动或生成配置失败时返回nil，由发布方退回到全量编译
+// 引擎没有替换数据源配置的订阅方，生成配置成功后使用增量编译的配置重启引擎(不重新编译)，保证变更生效
+func (s *EngineStart) reloadNodeConfig(event eventbus.Event, field zap.Field) any {
+	if s.nodeConfig == nil {
+		return nil
+	}
+
+	nodeConfig, err := nodeConfigFetcher(s)
+	if err != nil {
+		s.logger.Error("increment start failed", zap.Error(err), field)
+		return nil
+	}
+
+	s.printIncrementStart(event, field)
+	go utils.RestartEngine()
+	return nodeConfig
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/engine/build"
+	"github.com/wundergraph/wundergraph/pkg/node"
+	"go.uber.org/zap"
+	"os"
+	"testing"
+	"time"
+)
+
+// 数据源增量变更无法生成新配置时不重启引擎，返回nil交由发布方全量编译，且保留引擎当前配置
+func TestReloadDatasourceIncrement(t *testing.T) {
+	restartEngine := utils.RestartEngine
+	defer func() { utils.RestartEngine = restartEngine }()
+	utils.RestartEngine = func() { t.Error("engine restarted on datasource increment") }
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(workDir) }()
+	// 空目录中没有编译生成的文件，模拟增量编译后配置缓存读取失败
+	if err = os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	startedNodeConfig := &node.WunderNodeConfig{}
+	for _, started := range []bool{false, true} {
+		starter := &EngineStart{logger: zap.NewNop()}
+		if started {
+			starter.nodeConfig = startedNodeConfig
+		}
+		for _, event := range build.DatasourceIncrementEvents {
+			increment := &build.DatasourceIncrement{Event: event, Names: []string{"ds"}}
+			if next := starter.reloadDatasourceIncrement(increment); next != nil {
+				t.Errorf("expected nil on %s (started=%v), got %v", event, started, next)
+			}
+		}
+		if started && starter.nodeConfig != startedNodeConfig {
+			t.Error("node config replaced after failed reload")
+		}
+		if !started && starter.nodeConfig != nil {
+			t.Error("node config created before engine started")
+		}
+	}
+}
+
+// 数据源增量变更生成新配置成功时使用新配置重启引擎，返回非nil阻止发布方全量编译
+func TestReloadDatasourceIncrementApplied(t *testing.T) {
+	restartEngine, fetcher := utils.RestartEngine, nodeConfigFetcher
+	defer func() { utils.RestartEngine, nodeConfigFetcher = restartEngine, fetcher }()
+	restarted := make(chan struct{}, len(build.DatasourceIncrementEvents))
+	utils.RestartEngine = func() { restarted <- struct{}{} }
+	reloadedNodeConfig := &node.WunderNodeConfig{}
+	nodeConfigFetcher = func(*EngineStart) (*node.WunderNodeConfig, error) { return reloadedNodeConfig, nil }
+
+	startedNodeConfig := &node.WunderNodeConfig{}
+	starter := &EngineStart{logger: zap.NewNop(), nodeConfig: startedNodeConfig}
+	for _, event := range build.DatasourceIncrementEvents {
+		increment := &build.DatasourceIncrement{Event: event, Names: []string{"ds"}}
+		if next := starter.reloadDatasourceIncrement(increment); next != reloadedNodeConfig {
+			t.Errorf("expected reloaded node config on %s, got %v", event, next)
+		}
+		select {
+		case <-restarted:
+		case <-time.After(time.Second):
+			t.Fatalf("engine not restarted on %s", event)
+		}
+	}
+	// 当前配置由重启时替换，重启前保持引擎正在使用的配置
+	if starter.nodeConfig != startedNodeConfig {
+		t.Error("node config replaced before engine restarted")
+	}
+}
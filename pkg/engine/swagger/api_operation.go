@@ -5,6 +5,7 @@
 package swagger
 
 import (
+	"fireboom-server/pkg/common/consts"
 	"fireboom-server/pkg/common/models"
 	"fireboom-server/pkg/common/utils"
 	"fireboom-server/pkg/engine/build"
@@ -14,13 +15,24 @@ import (
 	"github.com/wundergraph/wundergraph/pkg/wgpb"
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
+	"net/http"
+	"strconv"
 	"strings"
 )
 
+const (
+	operationRateLimitsTitle          = "\n#### Rate limits"
+	operationRateLimitsExtension      = "x-rate-limits"
+	operationDeprecationTitle         = "\n#### Deprecated"
+	operationDeprecationExtension     = "x-deprecation"
+	operationValidationRulesTitle     = "\n#### Validation rules"
+	operationValidationRulesExtension = "x-validation-rules"
+)
+
 func (s *document) buildApiOperation() {
 	operationsConfigData := build.GeneratedOperationsConfigRoot.FirstData()
 	for _, item := range s.api.Operations {
-		if item.Internal {
+		if item.Internal || !s.filterOperation(item) {
 			continue
 		}
 
@@ -33,11 +45,12 @@ func (s *document) buildApiOperation() {
 		operation := &openapi3.Operation{
 			OperationID: uri,
 			Summary:     item.Path,
-			Tags:        s.makeApiOperationTags(item.Path),
+			Tags:        makeApiOperationTags(item.Path),
 			Security:    openapi3.NewSecurityRequirements(),
 		}
 
 		var requestSchema, responseSchema *openapi3.SchemaRef
+		var validationRules []*models.OperationValidationRule
 		switch item.Engine {
 		case wgpb.OperationExecutionEngine_ENGINE_GRAPHQL:
 			graphqlFile, ok := operationsConfigData.GraphqlOperationFiles[item.Path]
@@ -45,7 +58,7 @@ func (s *document) buildApiOperation() {
 				return
 			}
 
-			requestSchema, responseSchema = graphqlFile.Variables, graphqlFile.Response
+			requestSchema, responseSchema, validationRules = graphqlFile.Variables, graphqlFile.Response, graphqlFile.ValidationRules
 			originContent, _ := models.OperationGraphql.Read(item.Path)
 			operation.Description = fmt.Sprintf("```graphql\n%s\n```", originContent)
 			if remark := apiData.Remark; remark != "" {
@@ -59,7 +72,11 @@ func (s *document) buildApiOperation() {
 
 			requestSchema, responseSchema = functionFile.Variables, functionFile.Response
 		}
+		s.addFilterSchemas(requestSchema, responseSchema)
 		operation.Responses = utils.MakeApiOperationResponse(responseSchema)
+		makeApiOperationRateLimits(operation, operationsConfigData.GetRateLimitTiers(apiData))
+		makeApiOperationDeprecation(operation, apiData.Deprecation)
+		makeApiOperationValidationRules(operation, validationRules)
 		if title := apiData.Title; title != "" {
 			operation.Summary = title
 		}
@@ -80,7 +97,87 @@ func (s *document) buildApiOperation() {
 	return
 }
 
-func (s *document) makeApiOperationTags(path string) []string {
+// 将限流层级写入接口描述和扩展字段，并添加X-RateLimit-*响应头和429响应
+func makeApiOperationRateLimits(operation *openapi3.Operation, tiers []*models.OperationRateLimitTier) {
+	if len(tiers) == 0 {
+		return
+	}
+
+	lines := []string{operationRateLimitsTitle, "| name | keyBy | key | requests | windowSeconds | appliesTo |", "| --- | --- | --- | --- | --- | --- |"}
+	for _, tier := range tiers {
+		lines = append(lines, fmt.Sprintf("| %s | %s | %s | %d | %d | %s |", tier.Name, tier.KeyBy, tier.Key, tier.Requests, tier.WindowSeconds, tier.AppliesTo))
+	}
+	operation.Description = utils.JoinString("\n", operation.Description, strings.Join(lines, "\n"))
+	if operation.Extensions == nil {
+		operation.Extensions = make(map[string]any)
+	}
+	operation.Extensions[operationRateLimitsExtension] = tiers
+
+	rateLimitHeaders := openapi3.Headers{
+		consts.HeaderParamRateLimitLimit:     makeApiOperationIntegerHeader(),
+		consts.HeaderParamRateLimitRemaining: makeApiOperationIntegerHeader(),
+		consts.HeaderParamRateLimitReset:     makeApiOperationIntegerHeader(),
+	}
+	if okResponse := operation.Responses.Get(http.StatusOK); okResponse != nil && okResponse.Value != nil {
+		okResponse.Value.Headers = rateLimitHeaders
+	}
+	tooManyRequestsHeaders := maps.Clone(rateLimitHeaders)
+	tooManyRequestsHeaders[consts.HeaderParamRetryAfter] = makeApiOperationIntegerHeader()
+	tooManyRequestsText := http.StatusText(http.StatusTooManyRequests)
+	operation.Responses[strconv.Itoa(http.StatusTooManyRequests)] = &openapi3.ResponseRef{
+		Value: &openapi3.Response{Description: &tooManyRequestsText, Headers: tooManyRequestsHeaders},
+	}
+}
+
+// 标记接口弃用，将弃用原因、替代接口和下线时间写入接口描述和扩展字段
+func makeApiOperationDeprecation(operation *openapi3.Operation, deprecation *models.OperationDeprecation) {
+	if deprecation == nil {
+		return
+	}
+
+	operation.Deprecated = true
+	lines := []string{operationDeprecationTitle}
+	if deprecation.Reason != "" {
+		lines = append(lines, fmt.Sprintf("- reason: %s", deprecation.Reason))
+	}
+	if deprecation.Replacement != "" {
+		lines = append(lines, fmt.Sprintf("- replacement: %s", apihandler.OperationApiPath(deprecation.Replacement)))
+	}
+	if deprecation.SunsetDate != "" {
+		lines = append(lines, fmt.Sprintf("- sunset: %s", deprecation.SunsetDate))
+	}
+	operation.Description = utils.JoinString("\n", operation.Description, strings.Join(lines, "\n"))
+	if operation.Extensions == nil {
+		operation.Extensions = make(map[string]any)
+	}
+	operation.Extensions[operationDeprecationExtension] = deprecation
+}
+
+// 将@validate声明的校验规则写入接口描述和扩展字段，并添加校验失败时的400响应
+func makeApiOperationValidationRules(operation *openapi3.Operation, rules []*models.OperationValidationRule) {
+	if len(rules) == 0 {
+		return
+	}
+
+	lines := []string{operationValidationRulesTitle, "| name | fields | code | message | expression |", "| --- | --- | --- | --- | --- |"}
+	for _, rule := range rules {
+		lines = append(lines, fmt.Sprintf("| %s | %s | %s | %s | %s |", rule.Name, strings.Join(rule.Fields, utils.StringComma), rule.Code, rule.Message, rule.Expression))
+	}
+	operation.Description = utils.JoinString("\n", operation.Description, strings.Join(lines, "\n"))
+	if operation.Extensions == nil {
+		operation.Extensions = make(map[string]any)
+	}
+	operation.Extensions[operationValidationRulesExtension] = rules
+
+	badRequestText := http.StatusText(http.StatusBadRequest)
+	operation.Responses[strconv.Itoa(http.StatusBadRequest)] = &openapi3.ResponseRef{Value: &openapi3.Response{Description: &badRequestText}}
+}
+
+func makeApiOperationIntegerHeader() *openapi3.HeaderRef {
+	return &openapi3.HeaderRef{Value: &openapi3.Header{Parameter: openapi3.Parameter{Schema: openapi3.NewIntegerSchema().NewRef()}}}
+}
+
+func makeApiOperationTags(path string) []string {
 	tag := "Others"
 	if before, _, ok := strings.Cut(path, "/"); ok {
 		tag = before
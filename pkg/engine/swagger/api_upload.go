@@ -15,18 +15,25 @@ import (
 	"strconv"
 )
 
+const uploadTag = "FileUpload"
+
 func (s *document) buildApiUpload() {
 	for _, upload := range s.api.S3UploadConfiguration {
+		profiles, ok := s.filterUpload(upload)
+		if !ok {
+			continue
+		}
+
 		uri := fmt.Sprintf("/s3/%s/upload", upload.Name)
 		operation := &openapi3.Operation{
-			Tags:        []string{"FileUpload"},
+			Tags:        []string{uploadTag},
 			Summary:     fmt.Sprintf("upload file to %s", upload.Name),
 			OperationID: uri,
 			Responses:   s.makeApiUploadResponse(),
 			Security:    openapi3.NewSecurityRequirements(),
 		}
 
-		operation.Parameters = s.makeApiUploadParameters(upload)
+		operation.Parameters = s.makeApiUploadParameters(profiles)
 		operation.RequestBody = s.makeApiUploadRequestBody()
 		s.doc.Paths[uri] = &openapi3.PathItem{
 			Post: operation,
@@ -35,17 +42,17 @@ func (s *document) buildApiUpload() {
 }
 
 // 构建上传的普通参数定义
-func (s *document) makeApiUploadParameters(upload *wgpb.S3UploadConfiguration) (result openapi3.Parameters) {
+func (s *document) makeApiUploadParameters(uploadProfiles map[string]*wgpb.S3UploadProfile) (result openapi3.Parameters) {
 	directoryParam := openapi3.NewQueryParameter("directory")
 	directoryParam.WithSchema(&openapi3.Schema{Type: openapi3.TypeString, Description: "上传文件目录"})
 	result = openapi3.Parameters{{Value: directoryParam}}
-	if len(upload.UploadProfiles) == 0 {
+	if len(uploadProfiles) == 0 {
 		return
 	}
 
 	var anyOfSchemas openapi3.SchemaRefs
 	var profiles []interface{}
-	for name, item := range upload.UploadProfiles {
+	for name, item := range uploadProfiles {
 		// 添加枚举项X-Upload-Profile
 		profiles = append(profiles, name)
 		if item.MetadataJSONSchema == "" {
@@ -0,0 +1,259 @@
+// Package swagger
+/*
+ 生成订阅和实时查询的asyncapi3.0文档
+ swagger文档仅能描述请求/响应接口，订阅和实时查询通过SSE持续推送消息，需要单独描述
+*/
+package swagger
+
+import (
+	"fireboom-server/pkg/common/configs"
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/engine/asyncapi"
+	"fireboom-server/pkg/engine/build"
+	"fireboom-server/pkg/plugins/fileloader"
+	"github.com/getkin/kin-openapi/openapi3"
+	json "github.com/json-iterator/go"
+	"github.com/wundergraph/wundergraph/pkg/apihandler"
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"go.uber.org/zap"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	asyncapiVersion                 = "3.0.0"
+	asyncapiContentType             = "text/event-stream"
+	asyncapiSchemaFormat            = "application/vnd.oai.openapi+json;version=3.0.0"
+	asyncapiServerName              = "fireboom"
+	asyncapiMessageName             = "response"
+	asyncapiJwtSchemeName           = "JWT"
+	asyncapiSecuritySchemeRefPrefix = "#/components/securitySchemes/"
+	asyncapiOidcDiscoveryUri        = "/.well-known/openid-configuration"
+	asyncapiQuerySse                = "wg_sse"
+	asyncapiQueryLive               = "wg_live"
+)
+
+func init() {
+	build.AddAsyncGenerate(func() build.AsyncGenerate { return &asyncapiDocument{} })
+}
+
+type asyncapiDocument struct {
+	doc *asyncapi.Spec
+	api *wgpb.UserDefinedApi
+	// 需要鉴权的operation引用所有安全方案，满足其一即可
+	securityRefs []*asyncapi.SecuritySchemeRef
+}
+
+func (s *asyncapiDocument) Generate(builder *build.Builder) {
+	s.api = builder.DefinedApi
+	s.doc = &asyncapi.Spec{
+		Asyncapi:           asyncapiVersion,
+		DefaultContentType: asyncapiContentType,
+		Servers:            make(asyncapi.Servers),
+		Channels:           make(asyncapi.Channels),
+		Operations:         make(asyncapi.Operations),
+		Components: &asyncapi.Components{
+			Schemas:         make(map[string]*asyncapi.MultiFormatSchemaRef),
+			SecuritySchemes: make(asyncapi.SecuritySchemes),
+		},
+	}
+
+	s.buildInfo()
+	s.buildServers()
+	s.buildSecuritySchemes()
+	s.buildOperations()
+
+	var err error
+	defer func() {
+		if err != nil {
+			logger.Error("generate asyncapi3 failed", zap.Error(err))
+		} else {
+			logger.Debug("generate asyncapi3 succeed")
+		}
+	}()
+	docBytes, err := json.Marshal(&s.doc)
+	if err != nil {
+		return
+	}
+
+	s.api = nil
+	s.doc = nil
+	s.securityRefs = nil
+	err = build.GeneratedAsyncapiText.Write(build.GeneratedAsyncapiText.Title, fileloader.SystemUser, docBytes)
+	return
+}
+
+func (s *asyncapiDocument) buildInfo() {
+	s.doc.Info = openapi3.Info{
+		Title:       "Fireboom asyncapi3.0",
+		Description: "Fireboom subscriptions and live queries over server-sent events",
+		Contact:     &openapi3.Contact{URL: configs.ApplicationData.ContactAddress},
+		Version:     utils.GetStringWithLockViper(consts.FbVersion),
+	}
+}
+
+func (s *asyncapiDocument) buildServers() {
+	nodeUrl, err := url.Parse(utils.GetVariableString(s.api.NodeOptions.PublicNodeUrl))
+	if err != nil {
+		return
+	}
+
+	s.doc.Servers[asyncapiServerName] = &asyncapi.Server{
+		Host:            nodeUrl.Host,
+		Protocol:        asyncapi.BindingKey(nodeUrl.Scheme),
+		ProtocolVersion: "1.1",
+		Pathname:        nodeUrl.Path,
+	}
+}
+
+// 添加JWT及所有开启的OIDC身份认证供应商
+func (s *asyncapiDocument) buildSecuritySchemes() {
+	s.addSecurityScheme(asyncapiJwtSchemeName, &asyncapi.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: asyncapiJwtSchemeName,
+	})
+	if s.api.AuthenticationConfig == nil {
+		return
+	}
+
+	for _, provider := range s.api.AuthenticationConfig.CookieBased.Providers {
+		if provider.OidcConfig == nil {
+			continue
+		}
+
+		issuer := strings.TrimSuffix(utils.GetVariableString(provider.OidcConfig.Issuer), "/")
+		s.addSecurityScheme(provider.Id, &asyncapi.SecurityScheme{
+			Type:             "openIdConnect",
+			OpenIdConnectUrl: issuer + asyncapiOidcDiscoveryUri,
+		})
+	}
+}
+
+func (s *asyncapiDocument) addSecurityScheme(name string, scheme *asyncapi.SecurityScheme) {
+	s.doc.Components.SecuritySchemes[name] = &asyncapi.SecuritySchemeRef{Value: scheme}
+	s.securityRefs = append(s.securityRefs, &asyncapi.SecuritySchemeRef{Ref: asyncapiSecuritySchemeRefPrefix + name})
+}
+
+// 仅处理订阅和开启实时查询的operation，每个operation生成一个channel和一个send操作
+func (s *asyncapiDocument) buildOperations() {
+	operationsConfigData := build.GeneratedOperationsConfigRoot.FirstData()
+	for _, item := range s.api.Operations {
+		liveQueryEnabled := item.LiveQueryConfig != nil && item.LiveQueryConfig.Enabled
+		if item.Internal || item.OperationType != wgpb.OperationType_SUBSCRIPTION && !liveQueryEnabled {
+			continue
+		}
+
+		var operationSchema *apihandler.OperationSchema
+		switch item.Engine {
+		case wgpb.OperationExecutionEngine_ENGINE_GRAPHQL:
+			if graphqlFile, ok := operationsConfigData.GraphqlOperationFiles[item.Path]; ok {
+				operationSchema = &graphqlFile.OperationSchema
+			}
+		case wgpb.OperationExecutionEngine_ENGINE_FUNCTION:
+			if functionFile, ok := operationsConfigData.FunctionOperationFiles[item.Path]; ok {
+				operationSchema = &functionFile.OperationSchema
+			}
+		}
+		if operationSchema == nil {
+			continue
+		}
+
+		s.buildOperationItem(item, operationSchema, liveQueryEnabled)
+	}
+}
+
+func (s *asyncapiDocument) buildOperationItem(item *wgpb.Operation, operationSchema *apihandler.OperationSchema, liveQueryEnabled bool) {
+	channelName := item.Name
+	description := "subscription"
+	if liveQueryEnabled {
+		description = "live query"
+	}
+	var tags asyncapi.Tags
+	for _, tag := range makeApiOperationTags(item.Path) {
+		tags = append(tags, &asyncapi.TagRef{Value: &openapi3.Tag{Name: tag}})
+	}
+
+	s.doc.Channels[channelName] = &asyncapi.ChannelRef{Value: &asyncapi.Channel{
+		Address:     apihandler.OperationApiPath(item.Path),
+		Title:       item.Path,
+		Description: description,
+		Tags:        tags,
+		Messages: map[string]*asyncapi.MessageRef{
+			asyncapiMessageName: {Value: &asyncapi.Message{
+				Name:        asyncapiMessageName,
+				ContentType: asyncapiContentType,
+				Payload:     s.makeMultiFormatSchema(operationSchema.Response),
+			}},
+		},
+	}}
+
+	channelRef := "#/channels/" + channelName
+	operation := &asyncapi.Operation{
+		Action:      asyncapi.OperationAction_send,
+		Channel:     &asyncapi.ChannelRef{Ref: channelRef},
+		Summary:     item.Path,
+		Description: description,
+		Tags:        tags,
+		Messages:    asyncapi.Messages{{Ref: channelRef + "/messages/" + asyncapiMessageName}},
+		Bindings: asyncapi.OperationBindings{
+			asyncapi.BindingKey_http: {Value: &asyncapi.OperationBinding{
+				OperationBindingHttp: asyncapi.OperationBindingHttp{
+					Method: http.MethodGet,
+					Query:  s.makeQuerySchema(operationSchema.Variables, liveQueryEnabled),
+				},
+			}},
+		},
+	}
+	if item.AuthenticationConfig != nil && item.AuthenticationConfig.AuthRequired {
+		operation.Security = s.securityRefs
+	}
+	s.doc.Operations[channelName] = operation
+}
+
+// 将入参定义展开为查询参数，并追加SSE及实时查询开关参数
+func (s *asyncapiDocument) makeQuerySchema(variablesSchema *openapi3.SchemaRef, liveQueryEnabled bool) *openapi3.SchemaRef {
+	querySchema := openapi3.NewObjectSchema()
+	if variablesSchema != nil && variablesSchema.Value != nil {
+		maps.Copy(querySchema.Properties, variablesSchema.Value.Properties)
+		querySchema.Required = slices.Clone(variablesSchema.Value.Required)
+		s.requireDefinitions(variablesSchema)
+	}
+	querySchema.Properties[asyncapiQuerySse] = openapi3.NewBoolSchema().WithDefault(true).NewRef()
+	querySchema.Required = append(querySchema.Required, asyncapiQuerySse)
+	if liveQueryEnabled {
+		querySchema.Properties[asyncapiQueryLive] = openapi3.NewBoolSchema().WithDefault(true).NewRef()
+		querySchema.Required = append(querySchema.Required, asyncapiQueryLive)
+	}
+	return querySchema.NewRef()
+}
+
+func (s *asyncapiDocument) makeMultiFormatSchema(schema *openapi3.SchemaRef) *asyncapi.MultiFormatSchemaRef {
+	if schema == nil {
+		return nil
+	}
+
+	s.requireDefinitions(schema)
+	schemaValue := schema.Value
+	if schema.Ref != "" || schemaValue == nil {
+		schemaValue = &openapi3.Schema{AllOf: openapi3.SchemaRefs{schema}}
+	}
+	return &asyncapi.MultiFormatSchemaRef{Value: &asyncapi.MultiFormatSchema{
+		SchemaFormat: asyncapiSchemaFormat,
+		Schema:       schemaValue,
+	}}
+}
+
+// 仅保留被引用的定义，asyncapi与openapi的components.schemas路径一致，无需改写引用
+func (s *asyncapiDocument) requireDefinitions(schema *openapi3.SchemaRef) {
+	requireRefs := &utils.SyncMap[string, *openapi3.SchemaRef]{}
+	build.SearchRefDefinitions(schema, build.GeneratedOperationsConfigRoot.FirstData().Definitions, requireRefs)
+	requireRefs.Range(func(name string, definition *openapi3.SchemaRef) bool {
+		s.doc.Components.Schemas[name] = &asyncapi.MultiFormatSchemaRef{Value: &asyncapi.MultiFormatSchema{Schema: definition.Value}}
+		return true
+	})
+}
@@ -1,6 +1,7 @@
 // Package swagger
 /*
  生成引擎注册的接口，包括operation，认证和上传
+ 支持按角色、标签及是否公开生成过滤后的文档
 */
 package swagger
 
@@ -11,6 +12,7 @@ import (
 	"fireboom-server/pkg/common/utils"
 	"fireboom-server/pkg/engine/build"
 	"fireboom-server/pkg/plugins/fileloader"
+	"fireboom-server/pkg/plugins/i18n"
 	"github.com/getkin/kin-openapi/openapi3"
 	json "github.com/json-iterator/go"
 	"github.com/wundergraph/wundergraph/pkg/wgpb"
@@ -24,44 +26,87 @@ func init() {
 }
 
 type document struct {
-	doc *openapi3.T
-	api *wgpb.UserDefinedApi
+	doc    *openapi3.T
+	api    *wgpb.UserDefinedApi
+	filter *configs.SwaggerFilter
+	// 过滤文档中被引用的出入参定义，用来裁剪components.schemas
+	filterSchemas []*openapi3.SchemaRef
 }
 
 func (s *document) Generate(builder *build.Builder) {
-	s.api = builder.DefinedApi
+	var err error
+	defer func() {
+		if err != nil {
+			logger.Error("generate swagger3 failed", zap.Error(err))
+		} else {
+			logger.Debug("generate swagger3 succeed")
+		}
+	}()
+	docBytes, err := s.generate(builder.DefinedApi, nil)
+	if err != nil {
+		return
+	}
+
+	if err = build.GeneratedSwaggerText.Write(build.GeneratedSwaggerText.Title, fileloader.SystemUser, docBytes); err != nil {
+		return
+	}
+
+	s.generateExports(builder.DefinedApi)
+	return
+}
+
+// GenerateFiltered 根据过滤条件及最近一次的编译结果生成swagger文档
+func GenerateFiltered(filter *configs.SwaggerFilter) ([]byte, error) {
+	generateConfig := build.GeneratedGraphqlConfigRoot.FirstData()
+	if generateConfig == nil || generateConfig.Api == nil {
+		return nil, i18n.NewCustomError(nil, i18n.EngineCreateConfigError)
+	}
+
+	return (&document{}).generate(generateConfig.Api, filter)
+}
+
+func (s *document) generate(api *wgpb.UserDefinedApi, filter *configs.SwaggerFilter) ([]byte, error) {
+	s.api, s.filter = api, filter
 	s.doc = &openapi3.T{
 		OpenAPI:  "3.0.1",
 		Security: make(openapi3.SecurityRequirements, 0),
 		Paths:    make(openapi3.Paths),
 	}
+	defer func() { s.api, s.doc, s.filter, s.filterSchemas = nil, nil, nil, nil }()
 
 	s.buildInfo()
 	s.buildServers()
 	s.buildSecurityRequirements()
 	s.buildComponents()
 
-	s.buildApiAuthentication()
+	if !s.filterPublicOnly() {
+		s.buildApiAuthentication()
+	}
 	s.buildApiOperation()
 	s.buildApiUpload()
+	s.filterComponents()
 
-	var err error
-	defer func() {
+	return json.Marshal(&s.doc)
+}
+
+// 将globalSetting.swaggerExports中配置的过滤文档写入exported/generated
+func (s *document) generateExports(api *wgpb.UserDefinedApi) {
+	for _, item := range configs.GlobalSettingRoot.FirstData().SwaggerExports {
+		if item.Name == "" {
+			continue
+		}
+
+		docBytes, err := s.generate(api, &item.SwaggerFilter)
 		if err != nil {
-			logger.Error("generate swagger3 failed", zap.Error(err))
-		} else {
-			logger.Debug("generate swagger3 succeed")
+			logger.Error("generate swagger3 export failed", zap.Error(err), zap.String("name", item.Name))
+			continue
 		}
-	}()
-	docBytes, err := json.Marshal(&s.doc)
-	if err != nil {
-		return
-	}
 
-	s.api = nil
-	s.doc = nil
-	err = build.GeneratedSwaggerText.Write(build.GeneratedSwaggerText.Title, fileloader.SystemUser, docBytes)
-	return
+		exportFilename := utils.JoinStringWithDot(consts.ExportedGeneratedSwaggerFilename, utils.NormalizeName(item.Name)) + string(fileloader.ExtJson)
+		if err = utils.WriteFile(utils.NormalizePath(build.GeneratedSwaggerText.Root, exportFilename), docBytes); err != nil {
+			logger.Error("write swagger3 export failed", zap.Error(err), zap.String("name", item.Name))
+		}
+	}
 }
 
 func (s *document) buildInfo() {
@@ -92,8 +137,12 @@ func (s *document) buildSecurityRequirements() {
 	}
 
 	var roles []string
-	for _, item := range models.RoleRoot.List() {
-		roles = append(roles, item.Code)
+	if role := s.filterRole(); role != "" {
+		roles = append(roles, role)
+	} else {
+		for _, item := range models.RoleRoot.List() {
+			roles = append(roles, item.Code)
+		}
 	}
 
 	for _, provider := range s.api.AuthenticationConfig.CookieBased.Providers {
@@ -0,0 +1,119 @@
+// Package swagger
+/*
+ swagger文档过滤，用于生成提供给合作方的文档
+ 角色过滤依据@rbac定义的RoleConfig判断，未定义角色的接口对所有角色可见
+ 标签过滤同时匹配operation的一级目录(即文档标签)和多级目录前缀
+ 上传接口没有rbac配置，按文档标签FileUpload匹配标签，仅公开时只保留无需登录的profile
+*/
+package swagger
+
+import (
+	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/engine/build"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/wundergraph/wundergraph/pkg/wgpb"
+	"golang.org/x/exp/slices"
+	"strings"
+)
+
+func (s *document) filterOperation(item *wgpb.Operation) bool {
+	filter := s.filter
+	if filter == nil {
+		return true
+	}
+
+	authRequired := item.AuthenticationConfig != nil && item.AuthenticationConfig.AuthRequired
+	if filter.PublicOnly && authRequired {
+		return false
+	}
+
+	if len(filter.Tags) > 0 && !slices.ContainsFunc(filter.Tags, func(tag string) bool {
+		return slices.Contains(makeApiOperationTags(item.Path), tag) || strings.HasPrefix(item.Path, strings.TrimSuffix(tag, "/")+"/")
+	}) {
+		return false
+	}
+
+	if filter.Role == "" || item.AuthorizationConfig == nil {
+		return true
+	}
+	return matchRoleConfig(filter.Role, item.AuthorizationConfig.RoleConfig)
+}
+
+// 过滤上传接口，返回false时不生成该上传接口，返回的profiles为文档中可展示的profile
+func (s *document) filterUpload(upload *wgpb.S3UploadConfiguration) (map[string]*wgpb.S3UploadProfile, bool) {
+	filter := s.filter
+	if filter == nil {
+		return upload.UploadProfiles, true
+	}
+
+	if len(filter.Tags) > 0 && !slices.Contains(filter.Tags, uploadTag) {
+		return nil, false
+	}
+
+	if !filter.PublicOnly || len(upload.UploadProfiles) == 0 {
+		return upload.UploadProfiles, true
+	}
+
+	publicProfiles := make(map[string]*wgpb.S3UploadProfile, len(upload.UploadProfiles))
+	for name, profile := range upload.UploadProfiles {
+		if !profile.RequireAuthentication {
+			publicProfiles[name] = profile
+		}
+	}
+	return publicProfiles, len(publicProfiles) > 0
+}
+
+// 判断仅拥有单个角色的用户是否满足rbac配置
+func matchRoleConfig(role string, roleConfig *wgpb.OperationRoleConfig) bool {
+	if roleConfig == nil {
+		return true
+	}
+
+	onlyRole := func(roles []string) bool {
+		return !slices.ContainsFunc(roles, func(item string) bool { return item != role })
+	}
+	if len(roleConfig.RequireMatchAll) > 0 && !onlyRole(roleConfig.RequireMatchAll) {
+		return false
+	}
+	if len(roleConfig.RequireMatchAny) > 0 && !slices.Contains(roleConfig.RequireMatchAny, role) {
+		return false
+	}
+	if len(roleConfig.DenyMatchAll) > 0 && onlyRole(roleConfig.DenyMatchAll) {
+		return false
+	}
+	return !slices.Contains(roleConfig.DenyMatchAny, role)
+}
+
+// 记录过滤文档中使用的出入参定义
+func (s *document) addFilterSchemas(schemas ...*openapi3.SchemaRef) {
+	if s.filter == nil {
+		return
+	}
+
+	s.filterSchemas = append(s.filterSchemas, schemas...)
+}
+
+// 过滤文档仅保留被引用的定义，避免暴露未授权接口的结构
+func (s *document) filterComponents() {
+	if s.filter == nil {
+		return
+	}
+
+	definitions := build.GeneratedOperationsConfigRoot.FirstData().Definitions
+	requireRefs := &utils.SyncMap[string, *openapi3.SchemaRef]{}
+	for _, schema := range s.filterSchemas {
+		build.SearchRefDefinitions(schema, definitions, requireRefs)
+	}
+	s.doc.Components.Schemas = requireRefs.ToMap()
+}
+
+func (s *document) filterPublicOnly() bool {
+	return s.filter != nil && s.filter.PublicOnly
+}
+
+func (s *document) filterRole() (role string) {
+	if s.filter != nil {
+		role = s.filter.Role
+	}
+	return
+}
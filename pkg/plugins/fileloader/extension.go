@@ -15,6 +15,7 @@ const (
 	ExtYaml       Extension = ".yaml"
 	ExtYml        Extension = ".yml"
 	ExtKey        Extension = ".key"
+	ExtCsv        Extension = ".csv"
 )
 
 var rootDirectories map[string]string
@@ -99,3 +99,57 @@ const SkipVariableDesc Directive = iota + 11401
 const AsyncResolveDesc Directive = iota + 11501
 const FirstRawResultDesc Directive = iota + 11601
 const ExportMatchDesc Directive = iota + 11701
+
+const (
+	MaskDesc Directive = iota + 11801
+	MaskArgStrategyDesc
+	MaskArgKeepFirstDesc
+	MaskArgKeepLastDesc
+	MaskArgMaskCharDesc
+	MaskArgReplacementDesc
+	MaskArgExceptRolesDesc
+	MaskArgRuleDesc
+)
+
+const (
+	ValidateDesc Directive = iota + 11901
+	ValidateArgNameDesc
+	ValidateArgExpressionDesc
+	ValidateArgFieldsDesc
+	ValidateArgCodeDesc
+	ValidateArgMessageDesc
+)
+
+const (
+	CacheDesc Directive = iota + 12001
+	CacheArgTtlSecondsDesc
+	CacheArgVariablesDesc
+	CacheArgClaimsDesc
+	CacheArgTagsDesc
+)
+
+const (
+	InvalidateCacheDesc Directive = iota + 12101
+	InvalidateCacheArgTagsDesc
+)
+
+const (
+	RateLimitDesc Directive = iota + 12201
+	RateLimitArgNameDesc
+	RateLimitArgKeyByDesc
+	RateLimitArgKeyDesc
+	RateLimitArgRequestsDesc
+	RateLimitArgWindowSecondsDesc
+	RateLimitArgAppliesToDesc
+)
+
+const (
+	PaginateDesc Directive = iota + 12301
+	PaginateArgCursorFieldsDesc
+	PaginateArgShapeDesc
+	PaginateArgTotalCountDesc
+	PaginateArgDefaultFirstDesc
+	PaginateArgMaxFirstDesc
+	PaginateArgFirstVariableDesc
+	PaginateArgAfterVariableDesc
+)
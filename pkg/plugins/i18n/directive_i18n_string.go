@@ -62,10 +62,46 @@ func _() {
 	_ = x[AsyncResolveDesc-11501]
 	_ = x[FirstRawResultDesc-11601]
 	_ = x[ExportMatchDesc-11701]
+	_ = x[MaskDesc-11801]
+	_ = x[MaskArgStrategyDesc-11802]
+	_ = x[MaskArgKeepFirstDesc-11803]
+	_ = x[MaskArgKeepLastDesc-11804]
+	_ = x[MaskArgMaskCharDesc-11805]
+	_ = x[MaskArgReplacementDesc-11806]
+	_ = x[MaskArgExceptRolesDesc-11807]
+	_ = x[MaskArgRuleDesc-11808]
+	_ = x[ValidateDesc-11901]
+	_ = x[ValidateArgNameDesc-11902]
+	_ = x[ValidateArgExpressionDesc-11903]
+	_ = x[ValidateArgFieldsDesc-11904]
+	_ = x[ValidateArgCodeDesc-11905]
+	_ = x[ValidateArgMessageDesc-11906]
+	_ = x[CacheDesc-12001]
+	_ = x[CacheArgTtlSecondsDesc-12002]
+	_ = x[CacheArgVariablesDesc-12003]
+	_ = x[CacheArgClaimsDesc-12004]
+	_ = x[CacheArgTagsDesc-12005]
+	_ = x[InvalidateCacheDesc-12101]
+	_ = x[InvalidateCacheArgTagsDesc-12102]
+	_ = x[RateLimitDesc-12201]
+	_ = x[RateLimitArgNameDesc-12202]
+	_ = x[RateLimitArgKeyByDesc-12203]
+	_ = x[RateLimitArgKeyDesc-12204]
+	_ = x[RateLimitArgRequestsDesc-12205]
+	_ = x[RateLimitArgWindowSecondsDesc-12206]
+	_ = x[RateLimitArgAppliesToDesc-12207]
+	_ = x[PaginateDesc-12301]
+	_ = x[PaginateArgCursorFieldsDesc-12302]
+	_ = x[PaginateArgShapeDesc-12303]
+	_ = x[PaginateArgTotalCountDesc-12304]
+	_ = x[PaginateArgDefaultFirstDesc-12305]
+	_ = x[PaginateArgMaxFirstDesc-12306]
+	_ = x[PaginateArgFirstVariableDesc-12307]
+	_ = x[PaginateArgAfterVariableDesc-12308]
 }
 
 const (
-	_Directive_ZhCn_name = "作用于String变量上，用于注入当前时间作用于标量选择集上，将字段赋值给@internal声明的变量作用在字段上，用于格式化日期枚举值，系统内置的标准格式，如 ISO8601自定义格式，需遵循Golang规范，例如 2006-01-02 15:04:05作用于变量上，用于注入用户信息用于String变量，注入OIDC Claim对象声明的值，如USERID等用于任意变量，name=CUSTOM时生效，以数组形式指定json path，从CustomClaims中提取数据作用于String变量上，用于注入请求头中的字段作用于变量上，用于声明变量，和_join和export一起使用作用于OPERATION上，将其声明为内部函数，不对外暴露作用于变量上，用于入参校验用于数字类型变量，变量>minimum用于数字类型变量，变量<maximum用于数组变量，len(变量)≥minItems用于数组变量，len(变量)≤maxItems用于数组变量，为true时每项值不能重复用于String变量，len(变量)≤maxLength用于数组变量，len(变量) ≤ maxItems用于String变量，校验字符串是否匹配正则同pattern，声明了几种特殊正则枚举作用于OPERATION上，声明API的RBAC权限任意匹配，用户角色与API角色有交集时，可访问（常用）全部匹配，用户角色包含API角色时，可访问非全部匹配，当任意匹配或互斥匹配时，可访问互斥匹配，用户角色与API角色互斥时，可访问作用于MUTATION OPERATION上，指定当前变更为事务操作等待时间超时时间隔离级别作用于对象/数组类型的选择集上，将其拍扁示例用法：info.name用作将参数动态转换成查询条件反向筛选筛选条件筛选字段普通筛选关联筛选筛选类型忽略大小写嵌套条件作用于变量上，根据表达式注入参数，可以从arguments，request.header, request.body, environment获取参数作用于OPERATION上，禁止graphql并行解析作用于标量选择集上，自定义字段，可以在钩子和返回值中看到作用于标量选择集上，根据条件跳过参数填充作用于标量选择集上，并行解析数组提升响应速度作用于标量选择集上，用于提取首个 QueryRaw/ExecuteRaw 响应作用于标量选择集上，匹配@export的变量用于解决N+1查询问题"
+	_Directive_ZhCn_name = "作用于String变量上，用于注入当前时间作用于标量选择集上，将字段赋值给@internal声明的变量作用在字段上，用于格式化日期枚举值，系统内置的标准格式，如 ISO8601自定义格式，需遵循Golang规范，例如 2006-01-02 15:04:05作用于变量上，用于注入用户信息用于String变量，注入OIDC Claim对象声明的值，如USERID等用于任意变量，name=CUSTOM时生效，以数组形式指定json path，从CustomClaims中提取数据作用于String变量上，用于注入请求头中的字段作用于变量上，用于声明变量，和_join和export一起使用作用于OPERATION上，将其声明为内部函数，不对外暴露作用于变量上，用于入参校验用于数字类型变量，变量>minimum用于数字类型变量，变量<maximum用于数组变量，len(变量)≥minItems用于数组变量，len(变量)≤maxItems用于数组变量，为true时每项值不能重复用于String变量，len(变量)≤maxLength用于数组变量，len(变量) ≤ maxItems用于String变量，校验字符串是否匹配正则同pattern，声明了几种特殊正则枚举作用于OPERATION上，声明API的RBAC权限任意匹配，用户角色与API角色有交集时，可访问（常用）全部匹配，用户角色包含API角色时，可访问非全部匹配，当任意匹配或互斥匹配时，可访问互斥匹配，用户角色与API角色互斥时，可访问作用于MUTATION OPERATION上，指定当前变更为事务操作等待时间超时时间隔离级别作用于对象/数组类型的选择集上，将其拍扁示例用法：info.name用作将参数动态转换成查询条件反向筛选筛选条件筛选字段普通筛选关联筛选筛选类型忽略大小写嵌套条件作用于变量上，根据表达式注入参数，可以从arguments，request.header, request.body, environment获取参数作用于OPERATION上，禁止graphql并行解析作用于标量选择集上，自定义字段，可以在钩子和返回值中看到作用于标量选择集上，根据条件跳过参数填充作用于标量选择集上，并行解析数组提升响应速度作用于标量选择集上，用于提取首个 QueryRaw/ExecuteRaw 响应作用于标量选择集上，匹配@export的变量用于解决N+1查询问题作用于字符串/字符串数组类型的选择集上，按照策略对响应数据脱敏脱敏策略，KEEP保留首尾字符，FIXED替换为固定值，HASH替换为sha256摘要，NULL置为nullKEEP策略时保留开头的字符数KEEP策略时保留结尾的字符数KEEP策略时替换的字符FIXED策略时替换的固定值用户拥有任意角色时不脱敏表达式结果为true时脱敏，可以从arguments，request.header, environment, user获取参数，未设置时始终脱敏作用于OPERATION上，声明跨字段的入参校验规则，可重复定义规则名称，同一OPERATION内唯一校验表达式，结果为true时通过，可以从arguments，request.header, environment, user获取参数校验失败时返回错误的参数路径，如 endDate校验失败时返回的错误码校验失败时返回的错误信息作用于QUERY OPERATION上，在服务端缓存响应，缓存键由声明的参数和用户claim值计算缓存有效期(秒)参与计算缓存键的参数名称参与计算缓存键的用户claim名称，如 userId，tenantId，用于按用户/租户隔离缓存缓存标签，@invalidateCache声明相同标签的MUTATION执行后删除缓存作用于MUTATION OPERATION上，执行成功后删除包含任意标签的@cache响应缓存需要删除的缓存标签作用于OPERATION上，可重复定义，按调用方标识限流，每个层级使用独立的滑动窗口计数，超出任意层级限制时返回429限流层级名称，同一接口内不可重复调用方标识来源，CLAIM为用户claim，HEADER为请求头，IP为客户端地址claim或请求头名称，如 userId，tenantId，X-Api-Key，keyBy为IP时无需填写窗口期内允许的最大请求数滑动窗口时长(秒)生效范围，AUTHENTICATED仅对登录用户生效，ANONYMOUS仅对匿名用户生效作用于QUERY根字段findMany上，将列表转换为游标分页的connection结构，编译时生成分页参数first/after，无需手动定义take/skip/cursor及@export计算游标的字段，须在查询字段中选择且组合唯一，默认为id响应结构，EDGES为edges { cursor node }，ITEMS为items列表是否返回totalCount，开启后使用aggregate查询满足where条件的总数未传递first时的分页大小first允许的最大值对外公开的分页大小参数名称对外公开的游标参数名称，值为上一页pageInfo.endCursor"
 )
 
 var (
@@ -118,6 +154,42 @@ var (
 		11501: _Directive_ZhCn_name[2100:2166],
 		11601: _Directive_ZhCn_name[2166:2241],
 		11701: _Directive_ZhCn_name[2241:2320],
+		11801: _Directive_ZhCn_name[2320:2411],
+		11802: _Directive_ZhCn_name[2411:2519],
+		11803: _Directive_ZhCn_name[2519:2556],
+		11804: _Directive_ZhCn_name[2556:2593],
+		11805: _Directive_ZhCn_name[2593:2621],
+		11806: _Directive_ZhCn_name[2621:2653],
+		11807: _Directive_ZhCn_name[2653:2689],
+		11808: _Directive_ZhCn_name[2689:2816],
+		11901: _Directive_ZhCn_name[2816:2894],
+		11902: _Directive_ZhCn_name[2894:2933],
+		11903: _Directive_ZhCn_name[2933:3042],
+		11904: _Directive_ZhCn_name[3042:3098],
+		11905: _Directive_ZhCn_name[3098:3131],
+		11906: _Directive_ZhCn_name[3131:3167],
+		12001: _Directive_ZhCn_name[3167:3274],
+		12002: _Directive_ZhCn_name[3274:3294],
+		12003: _Directive_ZhCn_name[3294:3330],
+		12004: _Directive_ZhCn_name[3330:3432],
+		12005: _Directive_ZhCn_name[3432:3513],
+		12101: _Directive_ZhCn_name[3513:3606],
+		12102: _Directive_ZhCn_name[3606:3633],
+		12201: _Directive_ZhCn_name[3633:3786],
+		12202: _Directive_ZhCn_name[3786:3834],
+		12203: _Directive_ZhCn_name[3834:3921],
+		12204: _Directive_ZhCn_name[3921:4008],
+		12205: _Directive_ZhCn_name[4008:4044],
+		12206: _Directive_ZhCn_name[4044:4067],
+		12207: _Directive_ZhCn_name[4067:4155],
+		12301: _Directive_ZhCn_name[4155:4329],
+		12302: _Directive_ZhCn_name[4329:4409],
+		12303: _Directive_ZhCn_name[4409:4475],
+		12304: _Directive_ZhCn_name[4475:4556],
+		12305: _Directive_ZhCn_name[4556:4588],
+		12306: _Directive_ZhCn_name[4588:4611],
+		12307: _Directive_ZhCn_name[4611:4650],
+		12308: _Directive_ZhCn_name[4650:4719],
 	}
 )
 
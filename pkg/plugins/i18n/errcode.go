@@ -172,6 +172,28 @@ const (
 	PrismaApplyMigrationError
 	PrismaDiffError
 	PrismaShadowDatabaseUrlEmptyError
+	DatasourceAuthTokenFetchError
+	DatasourceImportFormatError
+	DatasourceImportConvertError
+	DatasourceSchemaDriftError
+	DatasourceSchemaBreakingError
+	PrismaMigrationStatusError
+	PrismaMigrationNotFoundError
+	PrismaMigrationNotFailedError
+	PrismaMigrationRollbackError
+	PrismaMigrationNotSupportedError
+	PrismaDestructivePushForbiddenError
+	DatasourceDbmlParseError
+	DatasourceSeedParseError
+	DatasourceSeedModelNotFoundError
+	DatasourceSeedApplyError
+	DatasourceStaticParseError
+	DatasourceStaticOperationNotSupportedError
+	DatasourceGrpcDescriptorParseError
+	DatasourceGrpcMethodNotFoundError
+	DatasourceSoapWsdlParseError
+	DatasourceSoapOperationNotFoundError
+	DatasourceImportOriginNotFoundError
 )
 
 const (
@@ -83,6 +83,28 @@ func _() {
 	_ = x[PrismaApplyMigrationError-20309]
 	_ = x[PrismaDiffError-20310]
 	_ = x[PrismaShadowDatabaseUrlEmptyError-20311]
+	_ = x[DatasourceAuthTokenFetchError-20312]
+	_ = x[DatasourceImportFormatError-20313]
+	_ = x[DatasourceImportConvertError-20314]
+	_ = x[DatasourceSchemaDriftError-20315]
+	_ = x[DatasourceSchemaBreakingError-20316]
+	_ = x[PrismaMigrationStatusError-20317]
+	_ = x[PrismaMigrationNotFoundError-20318]
+	_ = x[PrismaMigrationNotFailedError-20319]
+	_ = x[PrismaMigrationRollbackError-20320]
+	_ = x[PrismaMigrationNotSupportedError-20321]
+	_ = x[PrismaDestructivePushForbiddenError-20322]
+	_ = x[DatasourceDbmlParseError-20323]
+	_ = x[DatasourceSeedParseError-20324]
+	_ = x[DatasourceSeedModelNotFoundError-20325]
+	_ = x[DatasourceSeedApplyError-20326]
+	_ = x[DatasourceStaticParseError-20327]
+	_ = x[DatasourceStaticOperationNotSupportedError-20328]
+	_ = x[DatasourceGrpcDescriptorParseError-20329]
+	_ = x[DatasourceGrpcMethodNotFoundError-20330]
+	_ = x[DatasourceSoapWsdlParseError-20331]
+	_ = x[DatasourceSoapOperationNotFoundError-20332]
+	_ = x[DatasourceImportOriginNotFoundError-20333]
 	_ = x[StoragePingError-20401]
 	_ = x[StorageDisabledError-20402]
 	_ = x[StorageMkdirError-20403]
@@ -99,7 +121,7 @@ func _() {
 }
 
 const (
-	_Errcode_ZhCn_name = "服务器内部错误引擎重启错误参数非法参数解析错误结构体参数[%s]为空Body参数[%s]为空Path参数[%s]为空Query参数[%s]为空Form参数[%s]为空请勿重复提交参数签名有误请求数据读取错误请求数据为空请求代理错误文件[%s]读取错误文件[%s]写入错误文件压缩错误文件解压错误文件压缩数量为0文件[%s]内容为空目录[%s]读取错误文件[%s]读取失败文件[%s]不存在反序列化文件[%s]失败[%s]正在编辑数据数据[%s]已存在数据[%s]不存在数据锁[%s]未找到watcher[%s]不支持数据[%s]未变更数据操作[%s]不支持数据名称为空basename函数未设置root或extension为空仅允许[MultipleRW]调用内置[EmbedRW]禁止修改未发现删除的KEYS未发现重命名的KEY重命名目标[%s]已存在禁止重命名多个KEY文件写入依赖relyModel文件路径不匹配，预期[%s]，实际[%s]仅目录可被监听目录[%s]已存在文件[%s]已存在文件[%s]不存在来源[%s]不是目录目标目录[%s]已存在创建引擎启动配置错误数据新增错误数据删除错误数据修改错误数据查询错误数据拷贝错误数据重命名错误数据批量新增错误数据批量删除错误数据批量更新错误数据列表为空数据不存在数据源连接错误数据源类型[%d]不支持数据源未开启数据源连接参数为空OAS版本[%s]不支持Prisma Query引擎错误Prisma Migrate引擎错误Prisma 创建迁移文件错误Prisma 应用迁移错误Prisma 创建增量迁移错误Prisma 影子数据库连接参数为空OSS存储连接异常OSS存储未开启OSS存储创建目录错误OSS存储创建文件错误OSS存储删除错误OSS存储重命名错误OSS存储查询列表错误OSS存储查询详情错误OSS存储下载文件错误rbac[%s]已绑定角色[%s]rbacType[%s]不支持钩子服务地址未配置SDK[%s]已是最新版本"
+	_Errcode_ZhCn_name = "服务器内部错误引擎重启错误参数非法参数解析错误结构体参数[%s]为空Body参数[%s]为空Path参数[%s]为空Query参数[%s]为空Form参数[%s]为空请勿重复提交参数签名有误请求数据读取错误请求数据为空请求代理错误文件[%s]读取错误文件[%s]写入错误文件压缩错误文件解压错误文件压缩数量为0文件[%s]内容为空目录[%s]读取错误文件[%s]读取失败文件[%s]不存在反序列化文件[%s]失败[%s]正在编辑数据数据[%s]已存在数据[%s]不存在数据锁[%s]未找到watcher[%s]不支持数据[%s]未变更数据操作[%s]不支持数据名称为空basename函数未设置root或extension为空仅允许[MultipleRW]调用内置[EmbedRW]禁止修改未发现删除的KEYS未发现重命名的KEY重命名目标[%s]已存在禁止重命名多个KEY文件写入依赖relyModel文件路径不匹配，预期[%s]，实际[%s]仅目录可被监听目录[%s]已存在文件[%s]已存在文件[%s]不存在来源[%s]不是目录目标目录[%s]已存在创建引擎启动配置错误数据新增错误数据删除错误数据修改错误数据查询错误数据拷贝错误数据重命名错误数据批量新增错误数据批量删除错误数据批量更新错误数据列表为空数据不存在数据源连接错误数据源类型[%d]不支持数据源未开启数据源连接参数为空OAS版本[%s]不支持Prisma Query引擎错误Prisma Migrate引擎错误Prisma 创建迁移文件错误Prisma 应用迁移错误Prisma 创建增量迁移错误Prisma 影子数据库连接参数为空数据源获取OAuth2令牌失败无法识别的导入文件，仅支持Postman v2.1集合和HAR文件导入文件转换OAS文档失败数据源上游schema发生变更[%s]数据源上游schema存在破坏性变更[%s]，受影响的接口[%s]Prisma 查询迁移状态错误迁移[%s]不存在迁移[%s]不是失败状态，无法标记为已回滚Prisma 标记迁移回滚错误数据源类型[%s]不支持迁移生产环境禁止推送破坏性变更，请使用--enable-destructive-push启动dbml解析错误[%s]种子数据文件[%s]解析错误种子数据文件[%s]对应的模型不存在种子数据[%s]第%d条写入失败静态数据文件[%s]解析错误静态数据源仅支持query操作，不支持[%s]grpc描述文件[%s]解析错误grpc方法[%s]不存在wsdl文件[%s]解析错误soap操作[%s]不存在HAR文件中没有源[%s]的接口请求OSS存储连接异常OSS存储未开启OSS存储创建目录错误OSS存储创建文件错误OSS存储删除错误OSS存储重命名错误OSS存储查询列表错误OSS存储查询详情错误OSS存储下载文件错误rbac[%s]已绑定角色[%s]rbacType[%s]不支持钩子服务地址未配置SDK[%s]已是最新版本"
 )
 
 var (
@@ -175,19 +197,41 @@ var (
 		20309: _Errcode_ZhCn_name[1489:1514],
 		20310: _Errcode_ZhCn_name[1514:1545],
 		20311: _Errcode_ZhCn_name[1545:1585],
-		20401: _Errcode_ZhCn_name[1585:1606],
-		20402: _Errcode_ZhCn_name[1606:1624],
-		20403: _Errcode_ZhCn_name[1624:1651],
-		20404: _Errcode_ZhCn_name[1651:1678],
-		20405: _Errcode_ZhCn_name[1678:1699],
-		20406: _Errcode_ZhCn_name[1699:1723],
-		20407: _Errcode_ZhCn_name[1723:1750],
-		20408: _Errcode_ZhCn_name[1750:1777],
-		20409: _Errcode_ZhCn_name[1777:1804],
-		20501: _Errcode_ZhCn_name[1804:1831],
-		20502: _Errcode_ZhCn_name[1831:1852],
-		20601: _Errcode_ZhCn_name[1852:1879],
-		20701: _Errcode_ZhCn_name[1879:1904],
+		20312: _Errcode_ZhCn_name[1585:1618],
+		20313: _Errcode_ZhCn_name[1618:1687],
+		20314: _Errcode_ZhCn_name[1687:1720],
+		20315: _Errcode_ZhCn_name[1720:1757],
+		20316: _Errcode_ZhCn_name[1757:1828],
+		20317: _Errcode_ZhCn_name[1828:1859],
+		20318: _Errcode_ZhCn_name[1859:1878],
+		20319: _Errcode_ZhCn_name[1878:1933],
+		20320: _Errcode_ZhCn_name[1933:1964],
+		20321: _Errcode_ZhCn_name[1964:1998],
+		20322: _Errcode_ZhCn_name[1998:2080],
+		20323: _Errcode_ZhCn_name[2080:2100],
+		20324: _Errcode_ZhCn_name[2100:2134],
+		20325: _Errcode_ZhCn_name[2134:2180],
+		20326: _Errcode_ZhCn_name[2180:2216],
+		20327: _Errcode_ZhCn_name[2216:2250],
+		20328: _Errcode_ZhCn_name[2250:2301],
+		20329: _Errcode_ZhCn_name[2301:2333],
+		20330: _Errcode_ZhCn_name[2333:2356],
+		20331: _Errcode_ZhCn_name[2356:2382],
+		20332: _Errcode_ZhCn_name[2382:2405],
+		20333: _Errcode_ZhCn_name[2405:2445],
+		20401: _Errcode_ZhCn_name[2445:2466],
+		20402: _Errcode_ZhCn_name[2466:2484],
+		20403: _Errcode_ZhCn_name[2484:2511],
+		20404: _Errcode_ZhCn_name[2511:2538],
+		20405: _Errcode_ZhCn_name[2538:2559],
+		20406: _Errcode_ZhCn_name[2559:2583],
+		20407: _Errcode_ZhCn_name[2583:2610],
+		20408: _Errcode_ZhCn_name[2610:2637],
+		20409: _Errcode_ZhCn_name[2637:2664],
+		20501: _Errcode_ZhCn_name[2664:2691],
+		20502: _Errcode_ZhCn_name[2691:2712],
+		20601: _Errcode_ZhCn_name[2712:2739],
+		20701: _Errcode_ZhCn_name[2739:2764],
 	}
 )
 
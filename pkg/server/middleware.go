@@ -5,18 +5,68 @@
 package server
 
 import (
+	"bytes"
+	"errors"
 	"fireboom-server/pkg/common/configs"
 	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/engine/server"
 	"fmt"
+	"github.com/buger/jsonparser"
+	json "github.com/json-iterator/go"
 	echoMiddleware "github.com/labstack/echo/v4/middleware"
+	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
+type (
+	engineUserCacheItem struct {
+		user      map[string]any
+		expiredAt time.Time
+	}
+	// 按照消息分隔符拆分响应，每条消息改写后写出，普通请求的响应在请求结束后作为一条消息处理
+	operationMessageWriter struct {
+		http.ResponseWriter
+		transform    func([]byte) []byte
+		transforming bool
+		pending      []byte
+	}
+	// 记录转发接口的响应状态和内容，同时原样写出
+	operationResponseRecorder struct {
+		http.ResponseWriter
+		statusCode int
+		body       bytes.Buffer
+	}
+)
+
+const (
+	engineUserPath     = "/auth/cookie/user"
+	engineUserCacheTTL = 10 * time.Second
+	engineErrorsField  = "errors"
+
+	operationArgumentsKey = "operationArguments"
+)
+
+var (
+	engineMessageSeparator = []byte("\n\n")
+	engineSseDataPrefix    = []byte("data: ")
+)
+
+var (
+	engineUserCache          utils.SyncMap[string, *engineUserCacheItem]
+	engineUserCacheClearedAt atomic.Int64
+)
+
 // ProductionAuthentication 生产环境下的9123端口鉴权
 func ProductionAuthentication(productionAuthenticationKey string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -41,6 +91,433 @@ func ProductionAuthentication(productionAuthenticationKey string) echo.Middlewar
 	}
 }
 
+// InternalAuthentication 内部路由鉴权，仅允许携带启动时生成标识码的引擎请求
+func InternalAuthentication(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Request().Header.Get(consts.HeaderParamTag) != utils.RandomIdentifyCode {
+			return echo.NewHTTPError(http.StatusUnauthorized)
+		}
+
+		return next(c)
+	}
+}
+
+// OperationRateLimit 接口分层限流，超出任意层级限制时返回429并设置Retry-After，否则设置X-RateLimit-*响应头
+func OperationRateLimit(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		operationPath := c.Param("*")
+		caller := &server.OperationRateLimitCaller{Ip: c.RealIP(), Header: c.Request().Header}
+		if server.OperationRateLimitUserRequired(operationPath) {
+			caller.User = loadEngineUser(c.Request())
+		}
+		result := server.AllowOperationRequest(operationPath, caller)
+		if result == nil {
+			return next(c)
+		}
+
+		header := c.Response().Header()
+		header.Set(consts.HeaderParamRateLimitLimit, strconv.FormatInt(result.Limit, 10))
+		header.Set(consts.HeaderParamRateLimitRemaining, strconv.FormatInt(result.Remaining, 10))
+		header.Set(consts.HeaderParamRateLimitReset, formatCeilSeconds(result.Reset))
+		if !result.Allowed {
+			header.Set(consts.HeaderParamRetryAfter, formatCeilSeconds(result.RetryAfter))
+			return echo.NewHTTPError(http.StatusTooManyRequests)
+		}
+
+		return next(c)
+	}
+}
+
+// OperationDeprecation 已弃用接口设置Deprecation/Sunset/Link响应头并统计调用次数
+func OperationDeprecation(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		header := c.Response().Header()
+		for name, values := range server.CallDeprecatedOperation(c.Param("*")) {
+			header[name] = slices.Clone(values)
+		}
+
+		return next(c)
+	}
+}
+
+// OperationPaginate 按照@paginate声明将first/after转换为take/skip/cursor后转发，并将响应中的列表转换为connection结构
+// 位于OperationMask之前，脱敏和响应缓存均按照引擎返回的原始结构处理
+func OperationPaginate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		operationPath, request := c.Param("*"), c.Request()
+		if !server.OperationPaginationRequired(operationPath) {
+			return next(c)
+		}
+
+		arguments, err := readOperationArguments(c)
+		if err != nil {
+			return err
+		}
+		variables := make(map[string]any, len(arguments))
+		maps.Copy(variables, arguments)
+		firsts, err := server.PaginateOperationVariables(operationPath, variables)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if err = writeOperationVariables(request, variables); err != nil {
+			return err
+		}
+		// 由转发请求自行处理压缩，且不使用协商缓存，避免按照原始结构生成的ETag判断响应是否变更
+		request.Header.Del(echo.HeaderAcceptEncoding)
+		request.Header.Del(consts.HeaderParamIfNoneMatch)
+
+		response := c.Response()
+		writer := &operationMessageWriter{ResponseWriter: response.Writer, transform: func(data []byte) []byte {
+			return server.PaginateOperationResponse(operationPath, data, firsts)
+		}}
+		response.Writer = writer
+		defer func() { response.Writer = writer.ResponseWriter }()
+		if err = next(c); err != nil {
+			return err
+		}
+
+		return writer.finish()
+	}
+}
+
+// OperationValidate 按照@validate声明在转发前校验接口入参，校验失败时返回400和所有失败规则的错误信息
+func OperationValidate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		operationPath, request := c.Param("*"), c.Request()
+		if !server.OperationValidationRequired(operationPath) {
+			return next(c)
+		}
+
+		arguments, err := readOperationArguments(c)
+		if err != nil {
+			return err
+		}
+
+		caller := &server.OperationRuleCaller{Header: request.Header, Arguments: arguments, User: loadEngineUser(request)}
+		if validationErrors := server.ValidateOperationRequest(operationPath, caller); len(validationErrors) > 0 {
+			return c.JSON(http.StatusBadRequest, map[string]any{engineErrorsField: validationErrors})
+		}
+
+		return next(c)
+	}
+}
+
+// OperationMask 按照@mask声明改写转发接口的响应，订阅和实时查询按照每条推送的消息改写
+func OperationMask(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		operationPath, request := c.Param("*"), c.Request()
+		if !server.OperationMaskRequired(operationPath) {
+			return next(c)
+		}
+
+		caller := &server.OperationRuleCaller{Header: request.Header}
+		if server.OperationMaskUserRequired(operationPath) {
+			caller.User = loadEngineUser(request)
+		}
+		if server.OperationMaskArgumentsRequired(operationPath) {
+			arguments, err := readOperationArguments(c)
+			if err != nil {
+				return err
+			}
+			caller.Arguments = arguments
+		}
+		// 由转发请求自行处理压缩，且不使用协商缓存，避免按照未脱敏内容生成的ETag判断响应是否变更
+		request.Header.Del(echo.HeaderAcceptEncoding)
+		request.Header.Del(consts.HeaderParamIfNoneMatch)
+
+		response := c.Response()
+		writer := &operationMessageWriter{ResponseWriter: response.Writer, transform: func(data []byte) []byte {
+			return server.MaskOperationResponse(operationPath, data, caller)
+		}}
+		response.Writer = writer
+		defer func() { response.Writer = writer.ResponseWriter }()
+		if err := next(c); err != nil {
+			return err
+		}
+
+		return writer.finish()
+	}
+}
+
+// OperationCache 按照@cache声明缓存GET请求的响应，实时查询不缓存，@invalidateCache声明的mutation执行成功后按标签删除缓存
+// 位于OperationMask之后，缓存的是脱敏前的响应，命中时仍按照当前调用方脱敏
+func OperationCache(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		operationPath, request := c.Param("*"), c.Request()
+		if !server.OperationCacheRequired(operationPath) {
+			return next(c)
+		}
+
+		if request.Method != http.MethodGet {
+			recorder, err := recordOperationResponse(c, next)
+			if err == nil && recorder.succeed() {
+				server.InvalidateOperationResponse(operationPath)
+			}
+			return err
+		}
+
+		if request.URL.Query().Has(consts.QueryParamEngineLive) {
+			return next(c)
+		}
+		arguments, err := readOperationArguments(c)
+		if err != nil {
+			return err
+		}
+		caller := &server.OperationRuleCaller{Header: request.Header, Arguments: arguments}
+		if server.OperationCacheUserRequired(operationPath) {
+			caller.User = loadEngineUser(request)
+		}
+		cacheKey, ok := server.OperationResponseCacheKey(operationPath, caller)
+		if !ok {
+			return next(c)
+		}
+		if data, contentType, hit := server.ResponseCacheStore.Get(cacheKey); hit {
+			return c.Blob(http.StatusOK, contentType, data)
+		}
+
+		// 缓存未压缩的完整响应，不使用协商缓存避免引擎返回304
+		request.Header.Del(echo.HeaderAcceptEncoding)
+		request.Header.Del(consts.HeaderParamIfNoneMatch)
+		recorder, err := recordOperationResponse(c, next)
+		if err == nil && recorder.succeed() {
+			server.StoreOperationResponse(operationPath, cacheKey, recorder.body.Bytes(), recorder.Header().Get(echo.HeaderContentType))
+		}
+		return err
+	}
+}
+
+func recordOperationResponse(c echo.Context, next echo.HandlerFunc) (*operationResponseRecorder, error) {
+	response := c.Response()
+	recorder := &operationResponseRecorder{ResponseWriter: response.Writer}
+	response.Writer = recorder
+	defer func() { response.Writer = recorder.ResponseWriter }()
+	return recorder, next(c)
+}
+
+func (w *operationResponseRecorder) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *operationResponseRecorder) Write(data []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *operationResponseRecorder) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// 响应状态为200且不包含graphql错误时视为执行成功
+func (w *operationResponseRecorder) succeed() bool {
+	if w.statusCode != http.StatusOK {
+		return false
+	}
+
+	_, _, _, err := jsonparser.Get(w.body.Bytes(), engineErrorsField)
+	return errors.Is(err, jsonparser.KeyPathNotFoundError)
+}
+
+func (w *operationMessageWriter) WriteHeader(statusCode int) {
+	if w.transforming = statusCode == http.StatusOK; w.transforming {
+		header := w.Header()
+		header.Del(echo.HeaderContentLength)
+		header.Del(consts.HeaderParamETag)
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *operationMessageWriter) Write(data []byte) (int, error) {
+	if !w.transforming {
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.pending = append(w.pending, data...)
+	for {
+		index := bytes.Index(w.pending, engineMessageSeparator)
+		if index < 0 {
+			break
+		}
+
+		if err := w.writeMessage(w.pending[:index+len(engineMessageSeparator)]); err != nil {
+			return 0, err
+		}
+		w.pending = w.pending[index+len(engineMessageSeparator):]
+	}
+	return len(data), nil
+}
+
+func (w *operationMessageWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *operationMessageWriter) finish() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+
+	err := w.writeMessage(w.pending)
+	w.pending = nil
+	return err
+}
+
+// 消息为sse格式时去除data前缀后改写，保留消息末尾的分隔符
+func (w *operationMessageWriter) writeMessage(message []byte) error {
+	content := bytes.TrimSuffix(message, engineMessageSeparator)
+	suffix := message[len(content):]
+	prefix := content[:0]
+	if bytes.HasPrefix(content, engineSseDataPrefix) {
+		prefix, content = engineSseDataPrefix, content[len(engineSseDataPrefix):]
+	}
+
+	transformed := make([]byte, 0, len(message))
+	transformed = append(transformed, prefix...)
+	transformed = append(transformed, w.transform(content)...)
+	transformed = append(transformed, suffix...)
+	_, err := w.ResponseWriter.Write(transformed)
+	return err
+}
+
+// 读取调用方传递的接口请求参数，GET请求从query参数读取，其他请求读取json请求体后重置
+// 读取结果保存在请求上下文中，@paginate改写转发参数后其他中间件仍读取调用方传递的参数
+func readOperationArguments(c echo.Context) (arguments map[string]any, err error) {
+	if existed, ok := c.Get(operationArgumentsKey).(map[string]any); ok {
+		return existed, nil
+	}
+	defer func() {
+		if err == nil {
+			c.Set(operationArgumentsKey, arguments)
+		}
+	}()
+
+	request := c.Request()
+	if request.Method == http.MethodGet {
+		query := request.URL.Query()
+		if variables := query.Get(consts.QueryParamEngineVariables); variables != "" {
+			_ = json.Unmarshal([]byte(variables), &arguments)
+			return
+		}
+
+		// 参数值可以解析成json时按照json读取，否则作为字符串
+		arguments = make(map[string]any, len(query))
+		for name := range query {
+			if strings.HasPrefix(name, consts.QueryParamEnginePrefix) {
+				continue
+			}
+
+			var value any
+			if json.Unmarshal([]byte(query.Get(name)), &value) != nil {
+				value = query.Get(name)
+			}
+			arguments[name] = value
+		}
+		return
+	}
+
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return
+	}
+
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	_ = json.Unmarshal(body, &arguments)
+	return
+}
+
+// 改写转发给引擎的请求参数，GET请求替换query参数为wg_variables，其他请求替换json请求体
+func writeOperationVariables(request *http.Request, variables map[string]any) error {
+	variablesBytes, err := json.Marshal(variables)
+	if err != nil {
+		return err
+	}
+
+	if request.Method == http.MethodGet {
+		query := request.URL.Query()
+		for name := range query {
+			if !strings.HasPrefix(name, consts.QueryParamEnginePrefix) {
+				query.Del(name)
+			}
+		}
+		query.Set(consts.QueryParamEngineVariables, string(variablesBytes))
+		request.URL.RawQuery = query.Encode()
+		return nil
+	}
+
+	request.Body = io.NopCloser(bytes.NewReader(variablesBytes))
+	request.ContentLength = int64(len(variablesBytes))
+	request.Header.Set(echo.HeaderContentLength, strconv.Itoa(len(variablesBytes)))
+	return nil
+}
+
+// 按请求的Authorization和cookie缓存登录用户信息，缓存期内同一凭证不再请求引擎，未携带凭证时直接视为匿名用户
+func loadEngineUser(request *http.Request) map[string]any {
+	authorization, cookies := request.Header.Values(echo.HeaderAuthorization), request.Header.Values(echo.HeaderCookie)
+	if len(authorization) == 0 && len(cookies) == 0 {
+		return nil
+	}
+
+	credential := strings.Join(authorization, "\n") + "\x00" + strings.Join(cookies, "\n")
+	now := time.Now()
+	if item, ok := engineUserCache.Load(credential); ok && now.Before(item.expiredAt) {
+		return item.user
+	}
+
+	user, err := fetchEngineUser(request)
+	if err != nil {
+		return nil
+	}
+
+	// 每个缓存周期整体清空一次，防止凭证过多时内存持续增长
+	if clearedAt := engineUserCacheClearedAt.Load(); now.UnixNano()-clearedAt > int64(engineUserCacheTTL) &&
+		engineUserCacheClearedAt.CompareAndSwap(clearedAt, now.UnixNano()) {
+		engineUserCache.Clear()
+	}
+	engineUserCache.Store(credential, &engineUserCacheItem{user: user, expiredAt: now.Add(engineUserCacheTTL)})
+	return user
+}
+
+// 携带请求的cookie和Authorization向引擎获取登录用户信息，未登录时返回nil
+func fetchEngineUser(request *http.Request) (user map[string]any, err error) {
+	nodeUrl, err := engineNodeUrl()
+	if err != nil {
+		return
+	}
+
+	userRequest, err := http.NewRequestWithContext(request.Context(), http.MethodGet, nodeUrl.JoinPath(engineUserPath).String(), nil)
+	if err != nil {
+		return
+	}
+	for _, name := range []string{echo.HeaderAuthorization, echo.HeaderCookie} {
+		if values := request.Header.Values(name); len(values) > 0 {
+			userRequest.Header[name] = values
+		}
+	}
+	resp, err := http.DefaultClient.Do(userRequest)
+	if err != nil {
+		return
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&user)
+	return
+}
+
+// 秒数向上取整，不足1秒按1秒计算
+func formatCeilSeconds(duration time.Duration) string {
+	return strconv.FormatInt(max(int64(math.Ceil(duration.Seconds())), 1), 10)
+}
+
 // CORS will handle the CORS middleware
 func CORS(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -12,9 +12,14 @@ import (
 	"fireboom-server/pkg/common/consts"
 	"fireboom-server/pkg/common/models"
 	"fireboom-server/pkg/common/utils"
+	"fireboom-server/pkg/engine/datasource"
+	"fireboom-server/pkg/engine/sdk"
 	"fireboom-server/pkg/plugins/i18n"
+	"fireboom-server/pkg/websocket"
 	"github.com/labstack/echo/v4"
 	echoSwagger "github.com/swaggo/echo-swagger"
+	"github.com/wundergraph/wundergraph/pkg/apihandler"
+	"io"
 	"net/http"
 	"net/http/httputil"
 	"net/http/pprof"
@@ -64,18 +69,91 @@ func registerWebConsoleRouters(baseRouter *echo.Echo) {
 func registerEngineForwardRequests(baseRouter *echo.Echo) {
 	for _, request := range configs.ApplicationData.EngineForwardRequests {
 		baseRouter.Any(request, func(c echo.Context) error {
-			nodeOptions := configs.GlobalSettingRoot.FirstData().NodeOptions
-			if nodeOptions == nil {
-				return i18n.NewCustomError(nil, i18n.RequestProxyError)
-			}
+			c.Request().Header.Add(consts.HeaderParamTag, utils.RandomIdentifyCode)
+			return forwardEngineRequest(c)
+		})
+	}
+}
 
-			forward, err := url.Parse(utils.GetVariableString(nodeOptions.NodeUrl))
-			if err != nil {
-				return err
-			}
+// 转发接口请求到引擎，经过此路由的请求按照接口配置和@rateLimit声明的限流层级限流，已弃用接口设置弃用响应头，按照@validate声明校验入参，按照@mask声明脱敏响应
+// 弃用响应头、调用统计、校验和脱敏仅对经过此路由的请求生效，直接请求引擎端口不会处理，被限流拒绝的请求不计入调用次数
+func registerOperationForwardRouter(baseRouter *echo.Echo) {
+	baseRouter.Any(apihandler.OperationApiPath("*"), forwardEngineRequest, OperationRateLimit, OperationDeprecation, OperationPaginate, OperationValidate, OperationMask, OperationCache)
+}
 
-			c.Request().Header.Add(consts.HeaderParamTag, utils.RandomIdentifyCode)
-			httputil.NewSingleHostReverseProxy(forward).ServeHTTP(c.Response(), c.Request())
+func forwardEngineRequest(c echo.Context) error {
+	forward, err := engineNodeUrl()
+	if err != nil {
+		return err
+	}
+
+	httputil.NewSingleHostReverseProxy(forward).ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+func engineNodeUrl() (*url.URL, error) {
+	nodeOptions := configs.GlobalSettingRoot.FirstData().NodeOptions
+	if nodeOptions == nil {
+		return nil, i18n.NewCustomError(nil, i18n.RequestProxyError)
+	}
+
+	return url.Parse(utils.GetVariableString(nodeOptions.NodeUrl))
+}
+
+// 静态数据源graphql路由，供引擎作为graphql数据源请求
+func registerStaticDatasourceRouter(baseRouter *echo.Echo) {
+	baseRouter.POST(datasource.StaticGraphqlRoutePath, func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+
+		return c.JSONBlob(http.StatusOK, datasource.ExecuteStaticGraphql(c.Param(consts.PathParamDataName), body))
+	}, InternalAuthentication)
+}
+
+// grpc数据源graphql路由，订阅使用sse请求
+func registerGrpcDatasourceRouter(baseRouter *echo.Echo) {
+	baseRouter.Any(datasource.GrpcGraphqlRoutePath, func(c echo.Context) error {
+		datasource.ServeGrpcGraphql(c.Response(), c.Request(), c.Param(consts.PathParamDataName))
+		return nil
+	}, InternalAuthentication)
+}
+
+// soap数据源操作路由，供引擎作为rest数据源请求，请求和响应均为json
+func registerSoapDatasourceRouter(baseRouter *echo.Echo) {
+	baseRouter.POST(datasource.SoapOperationRoutePath, func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+
+		data, err := datasource.ExecuteSoapOperation(c.Request().Context(), c.Param(consts.PathParamDataName),
+			c.Param(consts.PathParamOperation), c.Request().Header, body)
+		if err != nil {
+			return err
+		}
+
+		return c.JSONBlob(http.StatusOK, data)
+	}, InternalAuthentication)
+}
+
+// 内置钩子桩服务路由，dev模式开启enable-hook-stub时注册
+func registerHookStubRouter(baseRouter *echo.Echo) {
+	if utils.GetBoolWithLockViper(consts.DevMode) && utils.GetBoolWithLockViper(consts.EnableHookStub) {
+		baseRouter.Any(models.HookStubRoutePrefix+"/*", func(c echo.Context) error {
+			sdk.ServeHookStub(c.Response(), c.Request(), c.Param("*"))
+			return nil
+		})
+	}
+}
+
+// 钩子调用记录路由，开启enable-hook-trace时注册，引擎调用钩子经过此路由转发到钩子服务
+// 引擎调用钩子时无法添加请求头，路由中携带启动时生成的标识码用于鉴权
+func registerHookTraceRouter(baseRouter *echo.Echo) {
+	if utils.GetBoolWithLockViper(consts.EnableHookTrace) {
+		baseRouter.Any(models.HookTraceRoutePrefix+"/"+utils.RandomIdentifyCode+"/*", func(c echo.Context) error {
+			websocket.ServeHookTrace(c.Response(), c.Request(), c.Param("*"))
 			return nil
 		})
 	}
@@ -108,12 +108,14 @@ func initHttpServer(beforeStarted func()) {
 	go beforeStarted()
 	// 启动服务器
 	go func() { _ = e.Start("") }()
+	websocket.StartHookSupervisor()
 
 	// 等待终止信号
 	stop := make(chan os.Signal)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	<-stop
 
+	websocket.StopHookSupervisor()
 	server.Shutdown()
 }
 
@@ -137,11 +139,19 @@ func initEchoRoot() *echo.Echo {
 
 	e := echo.New()
 	e.Listener = listener
+	// 仅信任本机和内网代理设置的X-Forwarded-For，防止客户端伪造请求头绕过按IP限流
+	e.IPExtractor = echo.ExtractIPFromXFFHeader()
 	websocket.InitRouter(e)
 	registerProfRouters(e)
 	registerWebConsoleRouters(e)
 	registerGeneratedStaticRouter(e)
 	registerEngineForwardRequests(e)
+	registerOperationForwardRouter(e)
+	registerStaticDatasourceRouter(e)
+	registerGrpcDatasourceRouter(e)
+	registerSoapDatasourceRouter(e)
+	registerHookStubRouter(e)
+	registerHookTraceRouter(e)
 
 	contextRouter := e.Group(configs.ApplicationData.ContextPath)
 	registerContextBaseRouters(contextRouter)
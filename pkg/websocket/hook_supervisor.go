@@ -0,0 +1,375 @@
+// Package websocket
+/*
+ 钩子服务进程托管实现
+ dev模式或start模式开启enable-hook-supervisor时，按照启用的服务端sdk的supervisor配置启动钩子服务
+ 进程退出或健康检查连续失败时按照退避间隔重启，dev模式下钩子文件变更或配置变更时立即重启
+ 标准输出和标准错误按行推送到hookServerLog频道，并保留最近的日志供pull事件读取
+ generated目录在每次编译时重新生成，不监听其变更以避免与钩子健康报告触发的编译形成循环
+*/
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"fireboom-server/pkg/common/configs"
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"fireboom-server/pkg/common/utils"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	json "github.com/json-iterator/go"
+	"github.com/wundergraph/wundergraph/pkg/hooks"
+	"github.com/wundergraph/wundergraph/pkg/pool"
+	"go.uber.org/zap"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	hookServerLogChannel configs.WsChannel = "hookServerLog"
+
+	hookServerLogLimit           = 500
+	hookSupervisorMinBackoff     = time.Second
+	hookSupervisorMaxBackoff     = 30 * time.Second
+	hookSupervisorStableDuration = time.Minute
+	hookSupervisorHealthInterval = 5 * time.Second
+	hookSupervisorHealthFailures = 3
+	hookSupervisorStartupTimeout = 60 * time.Second
+	hookSupervisorStopTimeout    = 10 * time.Second
+	hookSupervisorWatchDebounce  = 500 * time.Millisecond
+)
+
+const (
+	hookRestartStopped       = ""
+	hookRestartExited        = "exited"
+	hookRestartUnhealthy     = "unhealthy"
+	hookRestartFilesChanged  = "filesChanged"
+	hookRestartConfigChanged = "configChanged"
+)
+
+type (
+	hookServerLog struct {
+		Time   time.Time `json:"time"`
+		Stream string    `json:"stream"`
+		Line   string    `json:"line"`
+	}
+	// 按行切分进程输出，不完整的行等待后续输出
+	hookServerLogWriter struct {
+		stream  string
+		pending []byte
+		mutex   sync.Mutex
+	}
+	hookServerProcess struct {
+		cmd       *exec.Cmd
+		signature string
+		startTime time.Time
+		exited    chan struct{}
+	}
+	hookSupervisorInfo struct {
+		stopped chan struct{}
+		done    chan struct{}
+		logs    []*hookServerLog
+		mutex   sync.Mutex
+	}
+)
+
+var hookSupervisor = &hookSupervisorInfo{}
+
+func init() {
+	configs.WsMsgHandlerMap[hookServerLogChannel] = func(msg *configs.WsMsgBody) any {
+		switch msg.Event {
+		case configs.PullEvent:
+			hookSupervisor.mutex.Lock()
+			defer hookSupervisor.mutex.Unlock()
+			return slices.Clone(hookSupervisor.logs)
+		}
+		return nil
+	}
+}
+
+// StartHookSupervisor dev模式或开启enable-hook-supervisor时启动钩子服务托管
+func StartHookSupervisor() {
+	if !utils.GetBoolWithLockViper(consts.DevMode) && !utils.GetBoolWithLockViper(consts.EnableHookSupervisor) {
+		return
+	}
+
+	hookSupervisor.stopped, hookSupervisor.done = make(chan struct{}), make(chan struct{})
+	go hookSupervisor.run()
+}
+
+// StopHookSupervisor 停止托管并等待钩子服务进程退出
+func StopHookSupervisor() {
+	if hookSupervisor.stopped == nil {
+		return
+	}
+
+	close(hookSupervisor.stopped)
+	<-hookSupervisor.done
+}
+
+// 获取托管配置和配置签名，签名变更时重启进程
+func fetchHookSupervisorConfig() (sdk *models.Sdk, signature string) {
+	if sdk = models.GetEnabledServerSdk(); sdk == nil || sdk.Supervisor == nil || len(sdk.Supervisor.Command) == 0 {
+		return nil, ""
+	}
+
+	signatureBytes, _ := json.Marshal(sdk.Supervisor)
+	signature = sdk.Name + sdk.OutputPath + string(signatureBytes)
+	return
+}
+
+func (s *hookSupervisorInfo) run() {
+	defer close(s.done)
+	backoff := hookSupervisorMinBackoff
+	for {
+		sdk, signature := fetchHookSupervisorConfig()
+		if sdk == nil {
+			if s.sleep(hookSupervisorHealthInterval) {
+				return
+			}
+			continue
+		}
+
+		process, err := s.startProcess(sdk, signature)
+		if err != nil {
+			logger.Error("start hook server failed", zap.String("sdk", sdk.Name), zap.Error(err))
+			if s.sleep(backoff) {
+				return
+			}
+			backoff = min(backoff*2, hookSupervisorMaxBackoff)
+			continue
+		}
+
+		reason := s.waitProcess(process, sdk)
+		s.stopProcess(process)
+		switch reason {
+		case hookRestartStopped:
+			return
+		case hookRestartExited, hookRestartUnhealthy:
+			if time.Since(process.startTime) > hookSupervisorStableDuration {
+				backoff = hookSupervisorMinBackoff
+			}
+			logger.Warn("hook server will restart", zap.String("reason", reason), zap.Duration("backoff", backoff))
+			if s.sleep(backoff) {
+				return
+			}
+			backoff = min(backoff*2, hookSupervisorMaxBackoff)
+		default:
+			logger.Info("hook server will restart", zap.String("reason", reason))
+			backoff = hookSupervisorMinBackoff
+		}
+	}
+}
+
+// 等待指定时间，返回是否已停止托管
+func (s *hookSupervisorInfo) sleep(duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-s.stopped:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (s *hookSupervisorInfo) startProcess(sdk *models.Sdk, signature string) (process *hookServerProcess, err error) {
+	supervisor := sdk.Supervisor
+	cmd := exec.Command(supervisor.Command[0], supervisor.Command[1:]...)
+	cmd.Dir = supervisor.Workdir
+	if cmd.Dir == "" {
+		cmd.Dir = sdk.OutputPath
+	}
+	cmd.Env = os.Environ()
+	envNames := maps.Keys(supervisor.Env)
+	slices.Sort(envNames)
+	for _, name := range envNames {
+		cmd.Env = append(cmd.Env, utils.JoinString("=", name, supervisor.Env[name]))
+	}
+	cmd.Stdout = &hookServerLogWriter{stream: "stdout"}
+	cmd.Stderr = &hookServerLogWriter{stream: "stderr"}
+	cmd.WaitDelay = hookSupervisorStopTimeout
+	prepareHookServerCommand(cmd)
+	if err = cmd.Start(); err != nil {
+		return
+	}
+
+	process = &hookServerProcess{cmd: cmd, signature: signature, startTime: time.Now(), exited: make(chan struct{})}
+	go func() {
+		waitErr := cmd.Wait()
+		logger.Info("hook server exited", zap.Int("pid", cmd.Process.Pid), zap.Error(waitErr))
+		close(process.exited)
+	}()
+	logger.Info("hook server started", zap.String("sdk", sdk.Name), zap.Int("pid", cmd.Process.Pid), zap.Strings("command", supervisor.Command))
+	return
+}
+
+// 等待进程需要重启的原因，启动超时前不进行健康检查
+func (s *hookSupervisorInfo) waitProcess(process *hookServerProcess, sdk *models.Sdk) string {
+	var filesChanged <-chan struct{}
+	if utils.GetBoolWithLockViper(consts.DevMode) && !sdk.Supervisor.WatchDisabled {
+		changed, closeWatcher, err := watchHookFiles(process.cmd.Dir, sdk.Extension)
+		if err != nil {
+			logger.Warn("watch hook files failed", zap.String("workdir", process.cmd.Dir), zap.Error(err))
+		} else {
+			filesChanged = changed
+			defer closeWatcher()
+		}
+	}
+
+	startupTimeout := hookSupervisorStartupTimeout
+	if sdk.Supervisor.StartupTimeout > 0 {
+		startupTimeout = time.Duration(sdk.Supervisor.StartupTimeout) * time.Second
+	}
+	healthClient := hooks.NewHealthClient(logger)
+	healthTicker := time.NewTicker(hookSupervisorHealthInterval)
+	defer healthTicker.Stop()
+	var healthFailures int
+	for {
+		select {
+		case <-s.stopped:
+			return hookRestartStopped
+		case <-process.exited:
+			return hookRestartExited
+		case <-filesChanged:
+			return hookRestartFilesChanged
+		case <-healthTicker.C:
+			if _, signature := fetchHookSupervisorConfig(); signature != process.signature {
+				return hookRestartConfigChanged
+			}
+			if time.Since(process.startTime) < startupTimeout {
+				continue
+			}
+
+			buf := pool.GetBytesBuffer()
+			healthClient.ResetServerUrl(models.GetHookServerUrl())
+			healthy := healthClient.DoHealthCheckRequest(context.Background(), buf)
+			pool.PutBytesBuffer(buf)
+			if healthy {
+				healthFailures = 0
+			} else if healthFailures++; healthFailures >= hookSupervisorHealthFailures {
+				return hookRestartUnhealthy
+			}
+		}
+	}
+}
+
+// 先发送终止信号，超时后强制结束进程组
+func (s *hookSupervisorInfo) stopProcess(process *hookServerProcess) {
+	select {
+	case <-process.exited:
+		return
+	default:
+	}
+
+	signalHookServerProcess(process.cmd, false)
+	timer := time.NewTimer(hookSupervisorStopTimeout)
+	defer timer.Stop()
+	select {
+	case <-process.exited:
+	case <-timer.C:
+		signalHookServerProcess(process.cmd, true)
+		<-process.exited
+	}
+}
+
+func (s *hookSupervisorInfo) appendLog(item *hookServerLog) {
+	s.mutex.Lock()
+	s.logs = append(s.logs, item)
+	if len(s.logs) > hookServerLogLimit {
+		s.logs = slices.Delete(s.logs, 0, len(s.logs)-hookServerLogLimit)
+	}
+	s.mutex.Unlock()
+
+	if configs.WebsocketInstance != nil {
+		configs.WebsocketInstance.WriteWsMsgBodyForAll(&configs.WsMsgBody{
+			Channel: hookServerLogChannel,
+			Event:   configs.PushEvent,
+			Data:    item,
+		})
+	}
+}
+
+func (w *hookServerLogWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.pending = append(w.pending, p...)
+	for {
+		index := bytes.IndexByte(w.pending, '\n')
+		if index == -1 {
+			break
+		}
+
+		line := strings.TrimRight(string(w.pending[:index]), "\r")
+		w.pending = w.pending[index+1:]
+		hookSupervisor.appendLog(&hookServerLog{Time: time.Now(), Stream: w.stream, Line: line})
+	}
+	return len(p), nil
+}
+
+// 监听钩子工作目录下指定扩展名的文件变更，短时间内的多次变更合并为一次通知
+func watchHookFiles(workdir, extension string) (<-chan struct{}, func(), error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addDirFunc := func(dir string) error {
+		return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return err
+			}
+			if path != dir && ignoredHookWatchDir(d.Name()) {
+				return filepath.SkipDir
+			}
+
+			return watcher.Add(path)
+		})
+	}
+	if err = addDirFunc(workdir); err != nil {
+		_ = watcher.Close()
+		return nil, nil, fmt.Errorf("watch %s: %w", workdir, err)
+	}
+
+	changed := make(chan struct{}, 1)
+	go func() {
+		var debounceTimer *time.Timer
+		for event := range watcher.Events {
+			if event.Has(fsnotify.Create) {
+				if info, statErr := os.Stat(event.Name); statErr == nil && info.IsDir() && !ignoredHookWatchDir(info.Name()) {
+					_ = addDirFunc(event.Name)
+					continue
+				}
+			}
+			if event.Has(fsnotify.Chmod) || (extension != "" && filepath.Ext(event.Name) != extension) {
+				continue
+			}
+
+			if debounceTimer == nil {
+				debounceTimer = time.AfterFunc(hookSupervisorWatchDebounce, func() {
+					select {
+					case changed <- struct{}{}:
+					default:
+					}
+				})
+			} else {
+				debounceTimer.Reset(hookSupervisorWatchDebounce)
+			}
+		}
+		if debounceTimer != nil {
+			debounceTimer.Stop()
+		}
+	}()
+	return changed, func() { _ = watcher.Close() }, nil
+}
+
+func ignoredHookWatchDir(name string) bool {
+	return strings.HasPrefix(name, ".") || slices.Contains([]string{consts.HookGeneratedParent, "node_modules", "vendor", "dist"}, name)
+}
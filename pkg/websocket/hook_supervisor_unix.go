@@ -0,0 +1,21 @@
+//go:build !windows
+
+package websocket
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// 钩子服务使用独立的进程组，停止时一并结束其派生的子进程
+func prepareHookServerCommand(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func signalHookServerProcess(cmd *exec.Cmd, force bool) {
+	signal := syscall.SIGTERM
+	if force {
+		signal = syscall.SIGKILL
+	}
+	_ = syscall.Kill(-cmd.Process.Pid, signal)
+}
@@ -0,0 +1,12 @@
+//go:build windows
+
+package websocket
+
+import "os/exec"
+
+func prepareHookServerCommand(*exec.Cmd) {}
+
+// windows不支持发送终止信号，直接结束进程
+func signalHookServerProcess(cmd *exec.Cmd, _ bool) {
+	_ = cmd.Process.Kill()
+}
@@ -0,0 +1,395 @@
+// Package websocket
+/*
+ 钩子调用记录实现
+ 开启enable-hook-trace时引擎调用钩子经过飞布服务转发，记录每次调用的接口路径、钩子、耗时、状态码和数据大小
+ 按分钟分桶保存最近10分钟的计数和耗时直方图，过期的桶自动丢弃，百分位耗时按直方图区间上限估算
+ 每次调用推送到hookTrace频道，pull事件返回窗口内的统计和最近的调用记录
+ 转发时创建jaeger子span并注入请求头，钩子服务可以继续传递链路
+*/
+package websocket
+
+import (
+	"bytes"
+	"fireboom-server/pkg/common/configs"
+	"fireboom-server/pkg/common/consts"
+	"fireboom-server/pkg/common/models"
+	"fmt"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	hookTraceChannel configs.WsChannel = "hookTrace"
+
+	hookTraceWindow         = 10 * time.Minute
+	hookTraceBucketDuration = time.Minute
+	hookTraceRecentLimit    = 200
+	hookTraceHealthEndpoint = "health"
+)
+
+// 耗时直方图的区间上限(毫秒)，超过最后一个上限的计入溢出区间
+var hookTraceLatencyBounds = []int64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000}
+
+type (
+	HookInvocation struct {
+		Time         time.Time `json:"time"`
+		Operation    string    `json:"operation"`
+		Hook         string    `json:"hook"`
+		Latency      int64     `json:"latency"`
+		StatusCode   int       `json:"statusCode"`
+		Error        string    `json:"error,omitempty"`
+		RequestSize  int64     `json:"requestSize"`
+		ResponseSize int64     `json:"responseSize"`
+	}
+	HookStatistic struct {
+		Operation     string  `json:"operation"`
+		Hook          string  `json:"hook"`
+		Count         int64   `json:"count"`
+		ErrorCount    int64   `json:"errorCount"`
+		AvgLatency    float64 `json:"avgLatency"`
+		MaxLatency    int64   `json:"maxLatency"`
+		P50Latency    int64   `json:"p50Latency"`
+		P95Latency    int64   `json:"p95Latency"`
+		P99Latency    int64   `json:"p99Latency"`
+		RequestBytes  int64   `json:"requestBytes"`
+		ResponseBytes int64   `json:"responseBytes"`
+		Histogram     []int64 `json:"histogram"`
+	}
+	HookTraceStatistics struct {
+		Window        int64             `json:"window"`
+		LatencyBounds []int64           `json:"latencyBounds"`
+		Statistics    []*HookStatistic  `json:"statistics"`
+		Recent        []*HookInvocation `json:"recent"`
+	}
+	hookTraceKey struct {
+		operation string
+		hook      string
+	}
+	// 耗时记录总和与次数，读取统计时计算平均耗时
+	hookTraceCounter struct {
+		count         int64
+		errorCount    int64
+		latencySum    int64
+		maxLatency    int64
+		requestBytes  int64
+		responseBytes int64
+		histogram     []int64
+	}
+	hookTraceBucket struct {
+		start    time.Time
+		counters map[hookTraceKey]*hookTraceCounter
+	}
+	// 统计响应体大小，引擎读取完响应关闭时记录调用
+	hookTraceResponseBody struct {
+		io.ReadCloser
+		size    int64
+		onClose func(int64)
+		once    sync.Once
+	}
+	hookTraceInfo struct {
+		buckets []*hookTraceBucket
+		recent  []*HookInvocation
+		mutex   sync.Mutex
+	}
+)
+
+var hookTrace = &hookTraceInfo{}
+
+func init() {
+	configs.WsMsgHandlerMap[hookTraceChannel] = func(msg *configs.WsMsgBody) any {
+		switch msg.Event {
+		case configs.PullEvent:
+			return GetHookTraceStatistics()
+		}
+		return nil
+	}
+}
+
+// GetHookTraceStatistics 获取窗口内的钩子调用统计，按照p95耗时倒序
+func GetHookTraceStatistics() *HookTraceStatistics {
+	return &HookTraceStatistics{
+		Window:        int64(hookTraceWindow.Seconds()),
+		LatencyBounds: hookTraceLatencyBounds,
+		Statistics:    hookTrace.statistics(),
+		Recent:        hookTrace.recentInvocations(),
+	}
+}
+
+// GetSlowestHooks 获取p95耗时最高的钩子
+func GetSlowestHooks(limit int) []*HookStatistic {
+	statistics := hookTrace.statistics()
+	if len(statistics) > limit {
+		statistics = statistics[:limit]
+	}
+	return statistics
+}
+
+// ServeHookTrace 转发引擎的钩子请求到钩子服务并记录调用，endpoint为去掉路由前缀后的地址
+func ServeHookTrace(w http.ResponseWriter, r *http.Request, endpoint string) {
+	target, err := url.Parse(models.GetHookServerUrl())
+	if err != nil || target.Host == "" {
+		http.Error(w, fmt.Sprintf("invalid hook server url: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	var requestBody []byte
+	if r.Body != nil {
+		requestBody, _ = io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+	endpoint = "/" + strings.TrimPrefix(endpoint, "/")
+	operation, hook := parseHookTraceEndpoint(endpoint, requestBody)
+	recorded := hook != hookTraceHealthEndpoint
+
+	var span opentracing.Span
+	tracer := opentracing.GlobalTracer()
+	if parentContext, extractErr := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(r.Header)); extractErr == nil {
+		span = tracer.StartSpan("hook "+hook, opentracing.ChildOf(parentContext))
+	} else {
+		span = tracer.StartSpan("hook " + hook)
+	}
+	ext.SpanKindRPCClient.Set(span)
+	span.SetTag("hook.operation", operation)
+	span.SetTag("hook.name", hook)
+
+	invocation := &HookInvocation{Time: time.Now(), Operation: operation, Hook: hook, RequestSize: int64(len(requestBody))}
+	finish := func(statusCode int, responseSize int64, finishErr error) {
+		invocation.Latency = time.Since(invocation.Time).Milliseconds()
+		invocation.StatusCode, invocation.ResponseSize = statusCode, responseSize
+		ext.HTTPStatusCode.Set(span, uint16(statusCode))
+		if finishErr != nil {
+			invocation.Error = finishErr.Error()
+			ext.LogError(span, finishErr)
+		} else if statusCode >= http.StatusBadRequest {
+			ext.Error.Set(span, true)
+		}
+		span.Finish()
+		if recorded {
+			hookTrace.record(invocation)
+		}
+	}
+	proxy := &httputil.ReverseProxy{
+		FlushInterval: -1,
+		Director: func(request *http.Request) {
+			request.URL.Scheme, request.URL.Host = target.Scheme, target.Host
+			request.URL.Path = strings.TrimSuffix(target.Path, "/") + endpoint
+			request.URL.RawPath = ""
+			request.Host = target.Host
+			_ = tracer.Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(request.Header))
+		},
+		ModifyResponse: func(response *http.Response) error {
+			statusCode := response.StatusCode
+			response.Body = &hookTraceResponseBody{
+				ReadCloser: response.Body,
+				onClose:    func(size int64) { finish(statusCode, size, nil) },
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, _ *http.Request, proxyErr error) {
+			finish(http.StatusBadGateway, 0, proxyErr)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// 从钩子地址解析接口路径和钩子名称，httpTransport钩子的接口路径从请求体中获取
+func parseHookTraceEndpoint(endpoint string, requestBody []byte) (operation, hook string) {
+	parts := strings.Split(strings.Trim(endpoint, "/"), "/")
+	lastIndex := len(parts) - 1
+	switch parts[0] {
+	case "global":
+		hook = parts[lastIndex]
+		operation = gjson.GetBytes(requestBody, "operationName").String()
+	case consts.HookAuthenticationParent:
+		hook = parts[lastIndex]
+	case consts.HookOperationParent, consts.HookStorageProfileParent:
+		if lastIndex > 0 {
+			operation, hook = strings.Join(parts[1:lastIndex], "/"), parts[lastIndex]
+		}
+	case consts.HookFunctionParent, consts.HookProxyParent:
+		operation, hook = strings.Join(parts, "/"), parts[0]
+	case "gqls":
+		if lastIndex > 0 {
+			operation = parts[1]
+		}
+		hook = consts.HookCustomizeParent
+	default:
+		hook = parts[0]
+	}
+	return
+}
+
+func (b *hookTraceResponseBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	b.size += int64(n)
+	return
+}
+
+func (b *hookTraceResponseBody) Close() error {
+	b.once.Do(func() { b.onClose(b.size) })
+	return b.ReadCloser.Close()
+}
+
+func (t *hookTraceInfo) record(invocation *HookInvocation) {
+	// 按记录时间分桶，保证桶按时间有序
+	now := time.Now()
+	t.mutex.Lock()
+	bucketStart := now.Truncate(hookTraceBucketDuration)
+	t.expireBuckets(now)
+	var bucket *hookTraceBucket
+	if size := len(t.buckets); size > 0 && t.buckets[size-1].start.Equal(bucketStart) {
+		bucket = t.buckets[size-1]
+	} else {
+		bucket = &hookTraceBucket{start: bucketStart, counters: make(map[hookTraceKey]*hookTraceCounter)}
+		t.buckets = append(t.buckets, bucket)
+	}
+	key := hookTraceKey{operation: invocation.Operation, hook: invocation.Hook}
+	counter, ok := bucket.counters[key]
+	if !ok {
+		counter = newHookTraceCounter()
+		bucket.counters[key] = counter
+	}
+	counter.count++
+	if invocation.Error != "" || invocation.StatusCode >= http.StatusBadRequest {
+		counter.errorCount++
+	}
+	counter.latencySum += invocation.Latency
+	counter.maxLatency = max(counter.maxLatency, invocation.Latency)
+	counter.requestBytes += invocation.RequestSize
+	counter.responseBytes += invocation.ResponseSize
+	counter.histogram[hookTraceHistogramIndex(invocation.Latency)]++
+
+	t.recent = append(t.recent, invocation)
+	if len(t.recent) > hookTraceRecentLimit {
+		t.recent = slices.Delete(t.recent, 0, len(t.recent)-hookTraceRecentLimit)
+	}
+	t.mutex.Unlock()
+
+	if invocation.Error != "" {
+		logger.Warn("hook invocation failed", zap.String("operation", invocation.Operation), zap.String("hook", invocation.Hook), zap.String("error", invocation.Error))
+	}
+	if configs.WebsocketInstance != nil {
+		configs.WebsocketInstance.WriteWsMsgBodyForAll(&configs.WsMsgBody{
+			Channel: hookTraceChannel,
+			Event:   configs.PushEvent,
+			Data:    invocation,
+		})
+	}
+}
+
+// 丢弃窗口外的桶
+func (t *hookTraceInfo) expireBuckets(now time.Time) {
+	expiredTime := now.Add(-hookTraceWindow)
+	index := slices.IndexFunc(t.buckets, func(item *hookTraceBucket) bool { return item.start.Add(hookTraceBucketDuration).After(expiredTime) })
+	if index == -1 {
+		index = len(t.buckets)
+	}
+	t.buckets = slices.Delete(t.buckets, 0, index)
+}
+
+// 合并窗口内所有桶的计数，由耗时总和与次数计算平均耗时
+func (t *hookTraceInfo) statistics() (result []*HookStatistic) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.expireBuckets(time.Now())
+	merged := make(map[hookTraceKey]*hookTraceCounter)
+	var keys []hookTraceKey
+	for _, bucket := range t.buckets {
+		for key, counter := range bucket.counters {
+			item, ok := merged[key]
+			if !ok {
+				item = newHookTraceCounter()
+				merged[key] = item
+				keys = append(keys, key)
+			}
+			item.merge(counter)
+		}
+	}
+	for _, key := range keys {
+		result = append(result, merged[key].statistic(key))
+	}
+	slices.SortFunc(result, func(a, b *HookStatistic) bool {
+		if a.P95Latency != b.P95Latency {
+			return a.P95Latency > b.P95Latency
+		}
+		return a.AvgLatency > b.AvgLatency
+	})
+	return
+}
+
+func newHookTraceCounter() *hookTraceCounter {
+	return &hookTraceCounter{histogram: make([]int64, len(hookTraceLatencyBounds)+1)}
+}
+
+func (c *hookTraceCounter) merge(other *hookTraceCounter) {
+	c.count += other.count
+	c.errorCount += other.errorCount
+	c.latencySum += other.latencySum
+	c.maxLatency = max(c.maxLatency, other.maxLatency)
+	c.requestBytes += other.requestBytes
+	c.responseBytes += other.responseBytes
+	for index, count := range other.histogram {
+		c.histogram[index] += count
+	}
+}
+
+func (c *hookTraceCounter) statistic(key hookTraceKey) *HookStatistic {
+	item := &HookStatistic{
+		Operation:     key.operation,
+		Hook:          key.hook,
+		Count:         c.count,
+		ErrorCount:    c.errorCount,
+		MaxLatency:    c.maxLatency,
+		RequestBytes:  c.requestBytes,
+		ResponseBytes: c.responseBytes,
+		Histogram:     c.histogram,
+	}
+	if c.count > 0 {
+		item.AvgLatency = math.Round(float64(c.latencySum)/float64(c.count)*100) / 100
+	}
+	item.P50Latency = hookTracePercentile(item, 0.5)
+	item.P95Latency = hookTracePercentile(item, 0.95)
+	item.P99Latency = hookTracePercentile(item, 0.99)
+	return item
+}
+
+func (t *hookTraceInfo) recentInvocations() []*HookInvocation {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return slices.Clone(t.recent)
+}
+
+func hookTraceHistogramIndex(latency int64) int {
+	index := slices.IndexFunc(hookTraceLatencyBounds, func(bound int64) bool { return latency <= bound })
+	if index == -1 {
+		index = len(hookTraceLatencyBounds)
+	}
+	return index
+}
+
+// 按直方图估算百分位耗时，落在溢出区间时返回最大耗时
+func hookTracePercentile(item *HookStatistic, percent float64) int64 {
+	threshold := int64(math.Ceil(float64(item.Count) * percent))
+	var accumulated int64
+	for index, count := range item.Histogram {
+		if accumulated += count; accumulated >= threshold {
+			if index < len(hookTraceLatencyBounds) {
+				return min(hookTraceLatencyBounds[index], item.MaxLatency)
+			}
+			break
+		}
+	}
+	return item.MaxLatency
+}